@@ -2,6 +2,8 @@ package cache
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"time"
 )
@@ -18,6 +20,8 @@ const (
 	ChatroomsAllKey         = "chatrooms:all"
 	ChatroomsVersionKey     = "chatrooms:version"
 	MessageHistoryPrefix    = "room:%d:messages"
+	LinkPreviewKeyPrefix    = "link_preview:%s"
+	WikiDiffKeyPrefix       = "wiki_page:%d:diff:%d:%d"
 )
 
 // Cache TTLs
@@ -27,6 +31,8 @@ const (
 	MessageHistoryTTL = 2 * time.Minute
 	PostTTL           = 30 * time.Minute
 	ListTTL           = 2 * time.Minute
+	LinkPreviewTTL    = 6 * time.Hour
+	WikiDiffTTL       = 24 * time.Hour
 )
 
 // UserKey returns the cache key for a user.
@@ -71,6 +77,19 @@ func MessageHistoryKey(roomID uint) string {
 	return fmt.Sprintf(MessageHistoryPrefix, roomID)
 }
 
+// LinkPreviewKey returns the cache key for an unfurled URL. The URL is hashed
+// to keep keys short and free of delimiter characters.
+func LinkPreviewKey(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return fmt.Sprintf(LinkPreviewKeyPrefix, hex.EncodeToString(sum[:]))
+}
+
+// WikiDiffKey returns the cache key for the diff between two revisions of a
+// wiki page. Revisions never change, so the entry needs no invalidation.
+func WikiDiffKey(pageID uint, from, to int) string {
+	return fmt.Sprintf(WikiDiffKeyPrefix, pageID, from, to)
+}
+
 // GetVersion returns the current version number for a versioned cache key.
 func GetVersion(ctx context.Context, key string) int64 {
 	if client == nil {
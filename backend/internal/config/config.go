@@ -49,6 +49,8 @@ type Config struct {
 	OTELServiceName               string  `mapstructure:"OTEL_SERVICE_NAME"`
 	OTELTracesSamplerRatio        float64 `mapstructure:"OTEL_TRACES_SAMPLER_RATIO"`
 	EnableProxyHeader             bool    `mapstructure:"ENABLE_PROXY_HEADER"`
+	RegistrationMode              string  `mapstructure:"REGISTRATION_MODE"`
+	SignupInviteQuota             int     `mapstructure:"SIGNUP_INVITE_QUOTA"`
 }
 
 // LoadConfig loads application configuration from file and environment variables.
@@ -123,6 +125,8 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("OTEL_SERVICE_NAME", "sanctum-api")
 	viper.SetDefault("OTEL_TRACES_SAMPLER_RATIO", 1.0)
 	viper.SetDefault("ENABLE_PROXY_HEADER", false)
+	viper.SetDefault("REGISTRATION_MODE", "open")
+	viper.SetDefault("SIGNUP_INVITE_QUOTA", 5)
 
 	var config Config
 	if err := viper.Unmarshal(&config); err != nil {
@@ -161,6 +165,19 @@ func (c *Config) Validate() error {
 		return errors.New("IMAGE_MAX_UPLOAD_SIZE_MB must be greater than 0")
 	}
 
+	registration := strings.ToLower(strings.TrimSpace(c.RegistrationMode))
+	switch registration {
+	case "":
+		registration = "open"
+	case "open", "invite_only", "waitlist":
+	default:
+		return fmt.Errorf("REGISTRATION_MODE must be one of open|invite_only|waitlist, got %q", c.RegistrationMode)
+	}
+	c.RegistrationMode = registration
+	if c.SignupInviteQuota < 0 {
+		return errors.New("SIGNUP_INVITE_QUOTA must be >= 0")
+	}
+
 	if c.DBMaxOpenConns < 0 {
 		return errors.New("DB_MAX_OPEN_CONNS must be >= 0")
 	}
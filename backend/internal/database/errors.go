@@ -0,0 +1,15 @@
+package database
+
+import "strings"
+
+// IsUniqueConstraintError reports whether err is a unique constraint
+// violation (Postgres SQLSTATE 23505 or its SQLite equivalent).
+func IsUniqueConstraintError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "duplicate key") ||
+		strings.Contains(msg, "unique constraint") ||
+		strings.Contains(msg, "23505")
+}
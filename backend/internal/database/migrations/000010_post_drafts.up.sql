@@ -0,0 +1,23 @@
+-- Posts: drafts and scheduled publishing.
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'published',
+ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'chk_posts_status'
+    ) THEN
+        ALTER TABLE posts
+        ADD CONSTRAINT chk_posts_status
+        CHECK (status IN ('published', 'draft', 'scheduled'));
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_posts_status ON posts (status);
+-- Scheduler scan: only scheduled rows are indexed.
+CREATE INDEX IF NOT EXISTS idx_posts_scheduled_publish_at
+    ON posts (publish_at)
+    WHERE status = 'scheduled' AND deleted_at IS NULL;
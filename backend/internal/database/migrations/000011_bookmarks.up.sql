@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS bookmark_collections (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_bookmark_collections_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT uq_bookmark_collections_user_name UNIQUE (user_id, name)
+);
+
+-- target_id is polymorphic (posts or comments), so it carries no foreign key;
+-- bookmarks outlive their targets and are rendered as tombstones.
+CREATE TABLE IF NOT EXISTS bookmarks (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    collection_id BIGINT,
+    target_type VARCHAR(20) NOT NULL,
+    target_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_bookmarks_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_bookmarks_collection FOREIGN KEY (collection_id) REFERENCES bookmark_collections(id) ON DELETE SET NULL,
+    CONSTRAINT chk_bookmarks_target_type CHECK (target_type IN ('post', 'comment')),
+    CONSTRAINT uq_bookmarks_user_target UNIQUE (user_id, target_type, target_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bookmarks_user_id ON bookmarks (user_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_bookmarks_collection_id ON bookmarks (collection_id, id DESC);
@@ -0,0 +1,5 @@
+ALTER TABLE messages
+DROP COLUMN IF EXISTS link_preview;
+
+ALTER TABLE posts
+DROP COLUMN IF EXISTS link_preview;
@@ -0,0 +1,6 @@
+-- Unfurled link metadata for link posts and chat messages.
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS link_preview JSONB;
+
+ALTER TABLE messages
+ADD COLUMN IF NOT EXISTS link_preview JSONB;
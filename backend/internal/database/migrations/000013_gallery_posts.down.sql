@@ -0,0 +1,11 @@
+ALTER TABLE images DROP COLUMN IF EXISTS ref_count;
+
+-- Gallery posts fall back to plain media posts (their cover image is kept).
+UPDATE posts SET post_type = 'media' WHERE post_type = 'gallery';
+
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS chk_posts_post_type;
+ALTER TABLE posts
+ADD CONSTRAINT chk_posts_post_type
+CHECK (post_type IN ('text', 'media', 'video', 'link', 'poll'));
+
+DROP TABLE IF EXISTS post_images;
@@ -0,0 +1,36 @@
+-- Gallery posts: ordered images with per-item caption and alt text.
+-- image_hash carries no foreign key so that unreferenced images can be purged
+-- while soft-deleted posts still hold their item rows.
+CREATE TABLE IF NOT EXISTS post_images (
+    id BIGSERIAL PRIMARY KEY,
+    post_id BIGINT NOT NULL,
+    image_hash VARCHAR(64) NOT NULL,
+    position INT NOT NULL,
+    caption VARCHAR(300) NOT NULL DEFAULT '',
+    alt_text VARCHAR(500) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_post_images_post FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    CONSTRAINT uq_post_images_post_position UNIQUE (post_id, position),
+    CONSTRAINT chk_post_images_position CHECK (position >= 0 AND position < 20)
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_images_image_hash ON post_images (image_hash);
+
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS chk_posts_post_type;
+ALTER TABLE posts
+ADD CONSTRAINT chk_posts_post_type
+CHECK (post_type IN ('text', 'media', 'video', 'link', 'poll', 'gallery'));
+
+-- Number of live posts (single-image or gallery) referencing each image.
+ALTER TABLE images
+ADD COLUMN IF NOT EXISTS ref_count INT NOT NULL DEFAULT 0;
+
+UPDATE images i
+SET ref_count = refs.n
+FROM (
+    SELECT image_hash, COUNT(*) AS n
+    FROM posts
+    WHERE image_hash <> '' AND deleted_at IS NULL
+    GROUP BY image_hash
+) refs
+WHERE i.hash = refs.image_hash;
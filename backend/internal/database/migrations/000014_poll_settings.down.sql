@@ -0,0 +1,18 @@
+DROP INDEX IF EXISTS idx_poll_votes_poll_user;
+
+-- Keep only each user's earliest vote per poll so the single-vote constraint can return.
+DELETE FROM poll_votes v
+USING poll_votes keep
+WHERE v.poll_id = keep.poll_id
+  AND v.user_id = keep.user_id
+  AND v.id > keep.id;
+
+ALTER TABLE poll_votes DROP CONSTRAINT IF EXISTS uq_poll_votes_user_option;
+ALTER TABLE poll_votes
+ADD CONSTRAINT uq_poll_votes_user_poll UNIQUE (user_id, poll_id);
+
+ALTER TABLE polls DROP CONSTRAINT IF EXISTS chk_polls_max_selections;
+ALTER TABLE polls
+DROP COLUMN IF EXISTS hide_results,
+DROP COLUMN IF EXISTS closes_at,
+DROP COLUMN IF EXISTS max_selections;
@@ -0,0 +1,33 @@
+ALTER TABLE polls
+ADD COLUMN IF NOT EXISTS max_selections INT NOT NULL DEFAULT 1,
+ADD COLUMN IF NOT EXISTS closes_at TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS hide_results BOOLEAN NOT NULL DEFAULT FALSE;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'chk_polls_max_selections'
+    ) THEN
+        ALTER TABLE polls
+        ADD CONSTRAINT chk_polls_max_selections CHECK (max_selections >= 1);
+    END IF;
+END $$;
+
+-- Multi-select: one row per (user, option) instead of one per (user, poll).
+ALTER TABLE poll_votes DROP CONSTRAINT IF EXISTS uq_poll_votes_user_poll;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'uq_poll_votes_user_option'
+    ) THEN
+        ALTER TABLE poll_votes
+        ADD CONSTRAINT uq_poll_votes_user_option UNIQUE (user_id, poll_option_id);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_poll_votes_poll_user ON poll_votes (poll_id, user_id);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS post_tags;
+
+DROP INDEX IF EXISTS idx_posts_flair_id;
+ALTER TABLE posts DROP CONSTRAINT IF EXISTS fk_posts_flair;
+ALTER TABLE posts DROP COLUMN IF EXISTS flair_id;
+
+DROP TABLE IF EXISTS sanctum_flairs;
@@ -0,0 +1,41 @@
+-- Sanctum-defined post flairs.
+CREATE TABLE IF NOT EXISTS sanctum_flairs (
+    id BIGSERIAL PRIMARY KEY,
+    sanctum_id BIGINT NOT NULL,
+    name VARCHAR(40) NOT NULL,
+    color VARCHAR(7) NOT NULL,
+    mod_only BOOLEAN NOT NULL DEFAULT FALSE,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_sanctum_flairs_sanctum FOREIGN KEY (sanctum_id) REFERENCES sanctums(id) ON DELETE CASCADE,
+    CONSTRAINT uq_sanctum_flairs_sanctum_name UNIQUE (sanctum_id, name),
+    CONSTRAINT chk_sanctum_flairs_color CHECK (color ~ '^#[0-9a-fA-F]{6}$')
+);
+
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS flair_id BIGINT;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1
+        FROM pg_constraint
+        WHERE conname = 'fk_posts_flair'
+    ) THEN
+        ALTER TABLE posts
+        ADD CONSTRAINT fk_posts_flair FOREIGN KEY (flair_id) REFERENCES sanctum_flairs(id) ON DELETE SET NULL;
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_posts_flair_id ON posts (flair_id);
+
+-- Free-form, lowercase post tags.
+CREATE TABLE IF NOT EXISTS post_tags (
+    post_id BIGINT NOT NULL,
+    tag VARCHAR(32) NOT NULL,
+    CONSTRAINT pk_post_tags PRIMARY KEY (post_id, tag),
+    CONSTRAINT fk_post_tags_post FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_tags_tag ON post_tags (tag);
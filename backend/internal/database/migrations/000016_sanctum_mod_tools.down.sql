@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS sanctum_mod_log;
+
+ALTER TABLE comments
+DROP COLUMN IF EXISTS distinguished,
+DROP COLUMN IF EXISTS removal_reason,
+DROP COLUMN IF EXISTS removed_by_user_id,
+DROP COLUMN IF EXISTS removed_at;
+
+DROP INDEX IF EXISTS idx_posts_sanctum_pinned;
+DROP INDEX IF EXISTS idx_posts_removed_at;
+
+ALTER TABLE posts
+DROP COLUMN IF EXISTS locked,
+DROP COLUMN IF EXISTS pinned_at,
+DROP COLUMN IF EXISTS removal_reason,
+DROP COLUMN IF EXISTS removed_by_user_id,
+DROP COLUMN IF EXISTS removed_at;
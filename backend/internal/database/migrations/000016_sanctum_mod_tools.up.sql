@@ -0,0 +1,31 @@
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS removed_at TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS removed_by_user_id BIGINT,
+ADD COLUMN IF NOT EXISTS removal_reason TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS pinned_at TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS locked BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_posts_removed_at ON posts (removed_at);
+CREATE INDEX IF NOT EXISTS idx_posts_sanctum_pinned ON posts (sanctum_id, pinned_at) WHERE pinned_at IS NOT NULL;
+
+ALTER TABLE comments
+ADD COLUMN IF NOT EXISTS removed_at TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS removed_by_user_id BIGINT,
+ADD COLUMN IF NOT EXISTS removal_reason TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS distinguished BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS sanctum_mod_log (
+    id BIGSERIAL PRIMARY KEY,
+    sanctum_id BIGINT NOT NULL,
+    moderator_id BIGINT NOT NULL,
+    action VARCHAR(40) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,
+    target_id BIGINT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_sanctum_mod_log_sanctum FOREIGN KEY (sanctum_id) REFERENCES sanctums(id) ON DELETE CASCADE,
+    CONSTRAINT fk_sanctum_mod_log_moderator FOREIGN KEY (moderator_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sanctum_mod_log_sanctum_created ON sanctum_mod_log (sanctum_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_sanctum_mod_log_moderator_id ON sanctum_mod_log (moderator_id);
@@ -0,0 +1,19 @@
+-- Sanctum-scoped bans and temporary mutes.
+CREATE TABLE IF NOT EXISTS sanctum_bans (
+    id BIGSERIAL PRIMARY KEY,
+    sanctum_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    kind VARCHAR(10) NOT NULL DEFAULT 'ban',
+    reason VARCHAR(500) NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ,
+    created_by_user_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_sanctum_bans_sanctum FOREIGN KEY (sanctum_id) REFERENCES sanctums(id) ON DELETE CASCADE,
+    CONSTRAINT fk_sanctum_bans_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT uq_sanctum_bans_sanctum_user UNIQUE (sanctum_id, user_id),
+    CONSTRAINT chk_sanctum_bans_kind CHECK (kind IN ('ban', 'mute')),
+    CONSTRAINT chk_sanctum_bans_mute_expires CHECK (kind <> 'mute' OR expires_at IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sanctum_bans_user_id ON sanctum_bans (user_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS sanctum_join_requests;
+
+ALTER TABLE sanctums DROP CONSTRAINT IF EXISTS chk_sanctums_visibility;
+ALTER TABLE sanctums DROP COLUMN IF EXISTS visibility;
@@ -0,0 +1,32 @@
+ALTER TABLE sanctums
+ADD COLUMN IF NOT EXISTS visibility VARCHAR(20) NOT NULL DEFAULT 'public';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'chk_sanctums_visibility'
+    ) THEN
+        ALTER TABLE sanctums
+        ADD CONSTRAINT chk_sanctums_visibility CHECK (visibility IN ('public', 'restricted', 'private'));
+    END IF;
+END $$;
+
+-- Membership requests for restricted and private sanctums.
+CREATE TABLE IF NOT EXISTS sanctum_join_requests (
+    id BIGSERIAL PRIMARY KEY,
+    sanctum_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    message VARCHAR(500) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    reviewed_by_user_id BIGINT,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_sanctum_join_requests_sanctum FOREIGN KEY (sanctum_id) REFERENCES sanctums(id) ON DELETE CASCADE,
+    CONSTRAINT fk_sanctum_join_requests_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_sanctum_join_requests_reviewed_by FOREIGN KEY (reviewed_by_user_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT uq_sanctum_join_requests_sanctum_user UNIQUE (sanctum_id, user_id),
+    CONSTRAINT chk_sanctum_join_requests_status CHECK (status IN ('pending', 'approved', 'rejected'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_sanctum_join_requests_sanctum_status ON sanctum_join_requests (sanctum_id, status);
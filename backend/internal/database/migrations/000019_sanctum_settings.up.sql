@@ -0,0 +1,14 @@
+-- Per-sanctum rules and posting policy.
+CREATE TABLE IF NOT EXISTS sanctum_settings (
+    sanctum_id BIGINT PRIMARY KEY,
+    rules JSONB NOT NULL DEFAULT '[]'::jsonb,
+    allowed_post_types JSONB NOT NULL DEFAULT '[]'::jsonb,
+    min_account_age_days INTEGER NOT NULL DEFAULT 0,
+    min_karma INTEGER NOT NULL DEFAULT 0,
+    require_image BOOLEAN NOT NULL DEFAULT FALSE,
+    title_pattern VARCHAR(200) NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_sanctum_settings_sanctum FOREIGN KEY (sanctum_id) REFERENCES sanctums(id) ON DELETE CASCADE,
+    CONSTRAINT chk_sanctum_settings_min_account_age CHECK (min_account_age_days >= 0),
+    CONSTRAINT chk_sanctum_settings_min_karma CHECK (min_karma >= 0)
+);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_comments_needs_review;
+DROP INDEX IF EXISTS idx_posts_sanctum_needs_review;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS needs_review;
+ALTER TABLE posts DROP COLUMN IF EXISTS needs_review;
+
+DROP TABLE IF EXISTS sanctum_automod;
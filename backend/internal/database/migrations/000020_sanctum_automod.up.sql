@@ -0,0 +1,19 @@
+-- AutoModerator rule sets and the filtered-content mod queue.
+CREATE TABLE IF NOT EXISTS sanctum_automod (
+    sanctum_id BIGINT PRIMARY KEY,
+    source TEXT NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_by_user_id BIGINT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_sanctum_automod_sanctum FOREIGN KEY (sanctum_id) REFERENCES sanctums(id) ON DELETE CASCADE,
+    CONSTRAINT fk_sanctum_automod_updated_by FOREIGN KEY (updated_by_user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+ALTER TABLE posts
+ADD COLUMN IF NOT EXISTS needs_review BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE comments
+ADD COLUMN IF NOT EXISTS needs_review BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_posts_sanctum_needs_review ON posts (sanctum_id) WHERE needs_review;
+CREATE INDEX IF NOT EXISTS idx_comments_needs_review ON comments (post_id) WHERE needs_review;
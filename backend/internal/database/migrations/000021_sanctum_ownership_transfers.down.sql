@@ -0,0 +1 @@
+DROP TABLE IF EXISTS sanctum_ownership_transfers;
@@ -0,0 +1,21 @@
+-- Ownership transfer offers, accepted or declined by the recipient.
+CREATE TABLE IF NOT EXISTS sanctum_ownership_transfers (
+    id BIGSERIAL PRIMARY KEY,
+    sanctum_id BIGINT NOT NULL,
+    from_user_id BIGINT NOT NULL,
+    to_user_id BIGINT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    responded_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_sanctum_ownership_transfers_sanctum FOREIGN KEY (sanctum_id) REFERENCES sanctums(id) ON DELETE CASCADE,
+    CONSTRAINT fk_sanctum_ownership_transfers_from_user FOREIGN KEY (from_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_sanctum_ownership_transfers_to_user FOREIGN KEY (to_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT chk_sanctum_ownership_transfers_status CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_sanctum_ownership_transfers_sanctum_id ON sanctum_ownership_transfers (sanctum_id);
+CREATE INDEX IF NOT EXISTS idx_sanctum_ownership_transfers_to_user_id ON sanctum_ownership_transfers (to_user_id);
+-- A sanctum has at most one open offer at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_sanctum_ownership_transfers_pending
+    ON sanctum_ownership_transfers (sanctum_id) WHERE status = 'pending';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS modmail_messages;
+DROP TABLE IF EXISTS modmail_threads;
@@ -0,0 +1,33 @@
+-- Modmail: threads between a user and a sanctum's mod team.
+CREATE TABLE IF NOT EXISTS modmail_threads (
+    id BIGSERIAL PRIMARY KEY,
+    sanctum_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    subject VARCHAR(200) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    assigned_to_user_id BIGINT,
+    last_message_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_modmail_threads_sanctum FOREIGN KEY (sanctum_id) REFERENCES sanctums(id) ON DELETE CASCADE,
+    CONSTRAINT fk_modmail_threads_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_modmail_threads_assigned_to FOREIGN KEY (assigned_to_user_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT chk_modmail_threads_status CHECK (status IN ('open', 'archived'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_modmail_threads_sanctum_status ON modmail_threads (sanctum_id, status);
+CREATE INDEX IF NOT EXISTS idx_modmail_threads_user_id ON modmail_threads (user_id);
+
+CREATE TABLE IF NOT EXISTS modmail_messages (
+    id BIGSERIAL PRIMARY KEY,
+    thread_id BIGINT NOT NULL,
+    author_id BIGINT NOT NULL,
+    body TEXT NOT NULL,
+    from_mod BOOLEAN NOT NULL DEFAULT FALSE,
+    internal BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_modmail_messages_thread FOREIGN KEY (thread_id) REFERENCES modmail_threads(id) ON DELETE CASCADE,
+    CONSTRAINT fk_modmail_messages_author FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_modmail_messages_thread_id ON modmail_messages (thread_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS sanctum_wiki_contributors;
+DROP TABLE IF EXISTS sanctum_wiki_revisions;
+DROP TABLE IF EXISTS sanctum_wiki_pages;
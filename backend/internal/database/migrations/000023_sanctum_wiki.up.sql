@@ -0,0 +1,43 @@
+-- Sanctum wiki pages, their revision history and approved contributors.
+CREATE TABLE IF NOT EXISTS sanctum_wiki_pages (
+    id BIGSERIAL PRIMARY KEY,
+    sanctum_id BIGINT NOT NULL,
+    slug VARCHAR(200) NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    content TEXT NOT NULL,
+    edit_permission VARCHAR(20) NOT NULL DEFAULT 'mods',
+    revision INTEGER NOT NULL DEFAULT 1,
+    updated_by_user_id BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_sanctum_wiki_pages_sanctum FOREIGN KEY (sanctum_id) REFERENCES sanctums(id) ON DELETE CASCADE,
+    CONSTRAINT fk_sanctum_wiki_pages_updated_by FOREIGN KEY (updated_by_user_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT uq_sanctum_wiki_pages_sanctum_slug UNIQUE (sanctum_id, slug),
+    CONSTRAINT chk_sanctum_wiki_pages_edit_permission CHECK (edit_permission IN ('mods', 'contributors', 'members'))
+);
+
+CREATE TABLE IF NOT EXISTS sanctum_wiki_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    page_id BIGINT NOT NULL,
+    revision INTEGER NOT NULL,
+    title VARCHAR(200) NOT NULL,
+    content TEXT NOT NULL,
+    reason VARCHAR(300) NOT NULL DEFAULT '',
+    reverted_from INTEGER,
+    author_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_sanctum_wiki_revisions_page FOREIGN KEY (page_id) REFERENCES sanctum_wiki_pages(id) ON DELETE CASCADE,
+    CONSTRAINT fk_sanctum_wiki_revisions_author FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT uq_sanctum_wiki_revisions_page_revision UNIQUE (page_id, revision)
+);
+
+CREATE TABLE IF NOT EXISTS sanctum_wiki_contributors (
+    sanctum_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    added_by_user_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (sanctum_id, user_id),
+    CONSTRAINT fk_sanctum_wiki_contributors_sanctum FOREIGN KEY (sanctum_id) REFERENCES sanctums(id) ON DELETE CASCADE,
+    CONSTRAINT fk_sanctum_wiki_contributors_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_sanctum_wiki_contributors_added_by FOREIGN KEY (added_by_user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+ALTER TABLE sanctums DROP CONSTRAINT IF EXISTS chk_sanctums_accent_color_dark;
+ALTER TABLE sanctums DROP CONSTRAINT IF EXISTS chk_sanctums_accent_color;
+ALTER TABLE sanctums DROP COLUMN IF EXISTS accent_color_dark;
+ALTER TABLE sanctums DROP COLUMN IF EXISTS accent_color;
+ALTER TABLE sanctums DROP COLUMN IF EXISTS banner_hash;
+ALTER TABLE sanctums DROP COLUMN IF EXISTS icon_hash;
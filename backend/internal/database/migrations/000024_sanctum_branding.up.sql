@@ -0,0 +1,22 @@
+-- Sanctum icon and banner images (by hash) and accent colours.
+ALTER TABLE sanctums
+ADD COLUMN IF NOT EXISTS icon_hash VARCHAR(64) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS banner_hash VARCHAR(64) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS accent_color VARCHAR(7) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS accent_color_dark VARCHAR(7) NOT NULL DEFAULT '';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'chk_sanctums_accent_color'
+    ) THEN
+        ALTER TABLE sanctums
+        ADD CONSTRAINT chk_sanctums_accent_color CHECK (accent_color = '' OR accent_color ~ '^#[0-9a-f]{6}$');
+    END IF;
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'chk_sanctums_accent_color_dark'
+    ) THEN
+        ALTER TABLE sanctums
+        ADD CONSTRAINT chk_sanctums_accent_color_dark CHECK (accent_color_dark = '' OR accent_color_dark ~ '^#[0-9a-f]{6}$');
+    END IF;
+END $$;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS sanctum_recommendations;
+DROP TABLE IF EXISTS sanctum_stats;
+
+DROP INDEX IF EXISTS idx_sanctums_category;
+ALTER TABLE sanctums DROP CONSTRAINT IF EXISTS chk_sanctums_category;
+ALTER TABLE sanctums DROP COLUMN IF EXISTS category;
@@ -0,0 +1,46 @@
+-- Directory categories, precomputed activity stats and recommendations.
+ALTER TABLE sanctums
+ADD COLUMN IF NOT EXISTS category VARCHAR(30) NOT NULL DEFAULT 'other';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'chk_sanctums_category'
+    ) THEN
+        ALTER TABLE sanctums
+        ADD CONSTRAINT chk_sanctums_category CHECK (category IN ('community', 'entertainment', 'gaming', 'technology', 'lifestyle', 'other'));
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_sanctums_category ON sanctums (category);
+
+-- Categorise the built-in sanctums.
+UPDATE sanctums SET category = 'community' WHERE slug IN ('atrium', 'general', 'herald', 'support') AND category = 'other';
+UPDATE sanctums SET category = 'entertainment' WHERE slug IN ('movies', 'television', 'books', 'music', 'anime') AND category = 'other';
+UPDATE sanctums SET category = 'gaming' WHERE slug IN ('gaming', 'pcgaming') AND category = 'other';
+UPDATE sanctums SET category = 'technology' WHERE slug IN ('development', 'hardware', 'linux', 'ai') AND category = 'other';
+UPDATE sanctums SET category = 'lifestyle' WHERE slug IN ('fitness', 'food') AND category = 'other';
+
+CREATE TABLE IF NOT EXISTS sanctum_stats (
+    sanctum_id BIGINT PRIMARY KEY,
+    member_count BIGINT NOT NULL DEFAULT 0,
+    new_members_this_week BIGINT NOT NULL DEFAULT 0,
+    posts_this_week BIGINT NOT NULL DEFAULT 0,
+    comments_this_week BIGINT NOT NULL DEFAULT 0,
+    posts_per_day DOUBLE PRECISION NOT NULL DEFAULT 0,
+    trending_score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_sanctum_stats_sanctum FOREIGN KEY (sanctum_id) REFERENCES sanctums(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sanctum_stats_trending_score ON sanctum_stats (trending_score);
+
+CREATE TABLE IF NOT EXISTS sanctum_recommendations (
+    user_id BIGINT NOT NULL,
+    sanctum_id BIGINT NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, sanctum_id),
+    CONSTRAINT fk_sanctum_recommendations_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_sanctum_recommendations_sanctum FOREIGN KEY (sanctum_id) REFERENCES sanctums(id) ON DELETE CASCADE
+);
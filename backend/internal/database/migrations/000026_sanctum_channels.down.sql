@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS uq_conversations_sanctum_channel_name;
+DROP INDEX IF EXISTS uq_conversations_sanctum_default;
+
+-- Keep only the default channel of each sanctum linked so the one-room
+-- index can be restored.
+UPDATE conversations SET sanctum_id = NULL
+WHERE sanctum_id IS NOT NULL AND is_default = FALSE;
+
+ALTER TABLE conversations DROP CONSTRAINT IF EXISTS chk_conversations_post_permission;
+ALTER TABLE conversations DROP COLUMN IF EXISTS is_default;
+ALTER TABLE conversations DROP COLUMN IF EXISTS post_permission;
+ALTER TABLE conversations DROP COLUMN IF EXISTS topic;
+ALTER TABLE conversations DROP COLUMN IF EXISTS channel_position;
+
+DROP INDEX IF EXISTS idx_conversations_sanctum_id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_conversations_sanctum_id_unique ON conversations (sanctum_id) WHERE sanctum_id IS NOT NULL;
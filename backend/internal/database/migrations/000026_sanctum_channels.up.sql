@@ -0,0 +1,40 @@
+-- Sanctums own several ordered chat channels instead of a single room.
+DROP INDEX IF EXISTS idx_conversations_sanctum_id_unique;
+CREATE INDEX IF NOT EXISTS idx_conversations_sanctum_id ON conversations (sanctum_id);
+
+ALTER TABLE conversations
+ADD COLUMN IF NOT EXISTS channel_position INT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS topic VARCHAR(300) NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS post_permission VARCHAR(20) NOT NULL DEFAULT 'members',
+ADD COLUMN IF NOT EXISTS is_default BOOLEAN NOT NULL DEFAULT FALSE;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'chk_conversations_post_permission'
+    ) THEN
+        ALTER TABLE conversations
+        ADD CONSTRAINT chk_conversations_post_permission CHECK (post_permission IN ('members', 'mods'));
+    END IF;
+END $$;
+
+-- The existing room of each sanctum becomes its default channel.
+UPDATE conversations SET is_default = TRUE
+WHERE sanctum_id IS NOT NULL AND deleted_at IS NULL AND is_default = FALSE
+  AND NOT EXISTS (
+      SELECT 1 FROM conversations d WHERE d.sanctum_id = conversations.sanctum_id AND d.is_default
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_conversations_sanctum_default
+ON conversations (sanctum_id) WHERE is_default = TRUE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_conversations_sanctum_channel_name
+ON conversations (sanctum_id, LOWER(name)) WHERE sanctum_id IS NOT NULL AND deleted_at IS NULL;
+
+-- Sanctum members get access to every channel of their sanctums.
+INSERT INTO conversation_participants (conversation_id, user_id)
+SELECT c.id, m.user_id
+FROM conversations c
+JOIN sanctum_memberships m ON m.sanctum_id = c.sanctum_id
+WHERE c.deleted_at IS NULL
+ON CONFLICT DO NOTHING;
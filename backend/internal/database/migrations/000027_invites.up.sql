@@ -0,0 +1,39 @@
+-- Shareable invite codes for sanctums and group conversations.
+CREATE TABLE IF NOT EXISTS invites (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(16) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,
+    sanctum_id BIGINT,
+    conversation_id BIGINT,
+    created_by_user_id BIGINT NOT NULL,
+    max_uses INT NOT NULL DEFAULT 0,
+    uses INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_invites_code UNIQUE (code),
+    CONSTRAINT fk_invites_sanctum FOREIGN KEY (sanctum_id) REFERENCES sanctums(id) ON DELETE CASCADE,
+    CONSTRAINT fk_invites_conversation FOREIGN KEY (conversation_id) REFERENCES conversations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_invites_created_by FOREIGN KEY (created_by_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT chk_invites_target CHECK (
+        (target_type = 'sanctum' AND sanctum_id IS NOT NULL AND conversation_id IS NULL)
+        OR (target_type = 'conversation' AND conversation_id IS NOT NULL AND sanctum_id IS NULL)
+    ),
+    CONSTRAINT chk_invites_uses CHECK (max_uses >= 0 AND uses >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_invites_sanctum_id ON invites (sanctum_id);
+CREATE INDEX IF NOT EXISTS idx_invites_conversation_id ON invites (conversation_id);
+CREATE INDEX IF NOT EXISTS idx_invites_created_by_user_id ON invites (created_by_user_id);
+
+CREATE TABLE IF NOT EXISTS invite_redemptions (
+    invite_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (invite_id, user_id),
+    CONSTRAINT fk_invite_redemptions_invite FOREIGN KEY (invite_id) REFERENCES invites(id) ON DELETE CASCADE,
+    CONSTRAINT fk_invite_redemptions_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_invite_redemptions_user_id ON invite_redemptions (user_id);
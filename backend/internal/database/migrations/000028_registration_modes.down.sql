@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS signup_waitlist;
+DROP TABLE IF EXISTS signup_invites;
+
+DROP INDEX IF EXISTS idx_users_invited_by_user_id;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_signup_invite_quota;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_invited_by;
+ALTER TABLE users DROP COLUMN IF EXISTS signup_invite_quota;
+ALTER TABLE users DROP COLUMN IF EXISTS invited_by_user_id;
@@ -0,0 +1,56 @@
+-- Invite-only and waitlist registration.
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS invited_by_user_id BIGINT,
+ADD COLUMN IF NOT EXISTS signup_invite_quota INT;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'fk_users_invited_by'
+    ) THEN
+        ALTER TABLE users
+        ADD CONSTRAINT fk_users_invited_by FOREIGN KEY (invited_by_user_id) REFERENCES users(id) ON DELETE SET NULL;
+    END IF;
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'chk_users_signup_invite_quota'
+    ) THEN
+        ALTER TABLE users
+        ADD CONSTRAINT chk_users_signup_invite_quota CHECK (signup_invite_quota IS NULL OR signup_invite_quota >= 0);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_users_invited_by_user_id ON users (invited_by_user_id);
+
+CREATE TABLE IF NOT EXISTS signup_invites (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(16) NOT NULL,
+    created_by_user_id BIGINT NOT NULL,
+    used_by_user_id BIGINT,
+    used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_signup_invites_code UNIQUE (code),
+    CONSTRAINT fk_signup_invites_created_by FOREIGN KEY (created_by_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_signup_invites_used_by FOREIGN KEY (used_by_user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_signup_invites_created_by_user_id ON signup_invites (created_by_user_id);
+CREATE INDEX IF NOT EXISTS idx_signup_invites_used_by_user_id ON signup_invites (used_by_user_id);
+
+CREATE TABLE IF NOT EXISTS signup_waitlist (
+    id BIGSERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    reviewed_by_user_id BIGINT,
+    reviewed_at TIMESTAMPTZ,
+    user_id BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT uq_signup_waitlist_email UNIQUE (email),
+    CONSTRAINT fk_signup_waitlist_reviewed_by FOREIGN KEY (reviewed_by_user_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT fk_signup_waitlist_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL,
+    CONSTRAINT chk_signup_waitlist_status CHECK (status IN ('pending', 'approved', 'rejected'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_signup_waitlist_status ON signup_waitlist (status);
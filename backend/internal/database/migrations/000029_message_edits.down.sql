@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS message_edits;
+
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS fk_messages_deleted_by;
+ALTER TABLE messages DROP COLUMN IF EXISTS deleted_by_user_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS is_deleted;
+ALTER TABLE messages DROP COLUMN IF EXISTS edited_at;
@@ -0,0 +1,28 @@
+-- Chat message editing and tombstone deletion.
+ALTER TABLE messages
+ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ,
+ADD COLUMN IF NOT EXISTS is_deleted BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS deleted_by_user_id BIGINT;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'fk_messages_deleted_by'
+    ) THEN
+        ALTER TABLE messages
+        ADD CONSTRAINT fk_messages_deleted_by FOREIGN KEY (deleted_by_user_id) REFERENCES users(id) ON DELETE SET NULL;
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS message_edits (
+    id BIGSERIAL PRIMARY KEY,
+    message_id BIGINT NOT NULL,
+    editor_id BIGINT NOT NULL,
+    previous_content TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT fk_message_edits_message FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE,
+    CONSTRAINT fk_message_edits_editor FOREIGN KEY (editor_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_edits_message_id ON message_edits (message_id);
+CREATE INDEX IF NOT EXISTS idx_message_edits_editor_id ON message_edits (editor_id);
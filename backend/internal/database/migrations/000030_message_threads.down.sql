@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS message_thread_reads;
+
+DROP INDEX IF EXISTS idx_messages_thread_root_id;
+DROP INDEX IF EXISTS idx_messages_reply_to_message_id;
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS chk_messages_reply_count;
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS fk_messages_thread_root;
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS fk_messages_reply_to;
+ALTER TABLE messages DROP COLUMN IF EXISTS last_reply_at;
+ALTER TABLE messages DROP COLUMN IF EXISTS reply_count;
+ALTER TABLE messages DROP COLUMN IF EXISTS thread_root_id;
+ALTER TABLE messages DROP COLUMN IF EXISTS reply_to_message_id;
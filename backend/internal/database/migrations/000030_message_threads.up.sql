@@ -0,0 +1,42 @@
+-- Reply-to references and side threads for chat messages.
+ALTER TABLE messages
+ADD COLUMN IF NOT EXISTS reply_to_message_id BIGINT,
+ADD COLUMN IF NOT EXISTS thread_root_id BIGINT,
+ADD COLUMN IF NOT EXISTS reply_count INT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS last_reply_at TIMESTAMPTZ;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'fk_messages_reply_to'
+    ) THEN
+        ALTER TABLE messages
+        ADD CONSTRAINT fk_messages_reply_to FOREIGN KEY (reply_to_message_id) REFERENCES messages(id) ON DELETE SET NULL;
+    END IF;
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'fk_messages_thread_root'
+    ) THEN
+        ALTER TABLE messages
+        ADD CONSTRAINT fk_messages_thread_root FOREIGN KEY (thread_root_id) REFERENCES messages(id) ON DELETE CASCADE;
+    END IF;
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'chk_messages_reply_count'
+    ) THEN
+        ALTER TABLE messages
+        ADD CONSTRAINT chk_messages_reply_count CHECK (reply_count >= 0);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_messages_reply_to_message_id ON messages (reply_to_message_id);
+CREATE INDEX IF NOT EXISTS idx_messages_thread_root_id ON messages (thread_root_id, created_at);
+
+CREATE TABLE IF NOT EXISTS message_thread_reads (
+    user_id BIGINT NOT NULL,
+    root_message_id BIGINT NOT NULL,
+    last_read_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, root_message_id),
+    CONSTRAINT fk_message_thread_reads_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_message_thread_reads_root FOREIGN KEY (root_message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_thread_reads_root_message_id ON message_thread_reads (root_message_id);
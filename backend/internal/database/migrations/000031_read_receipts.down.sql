@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN IF EXISTS hide_read_receipts;
+
+ALTER TABLE conversation_participants DROP COLUMN IF EXISTS last_delivered_message_id;
+ALTER TABLE conversation_participants DROP COLUMN IF EXISTS last_read_message_id;
@@ -0,0 +1,21 @@
+-- Per-participant read and delivery watermarks, and the read receipt privacy toggle.
+ALTER TABLE conversation_participants
+ADD COLUMN IF NOT EXISTS last_read_message_id BIGINT NOT NULL DEFAULT 0,
+ADD COLUMN IF NOT EXISTS last_delivered_message_id BIGINT NOT NULL DEFAULT 0;
+
+-- Derive watermarks from the existing last_read_at timestamps.
+UPDATE conversation_participants cp
+SET last_read_message_id = sub.max_id,
+    last_delivered_message_id = sub.max_id
+FROM (
+    SELECT cp2.conversation_id, cp2.user_id, MAX(m.id) AS max_id
+    FROM conversation_participants cp2
+    JOIN messages m ON m.conversation_id = cp2.conversation_id AND m.created_at <= cp2.last_read_at
+    GROUP BY cp2.conversation_id, cp2.user_id
+) sub
+WHERE cp.conversation_id = sub.conversation_id
+  AND cp.user_id = sub.user_id
+  AND cp.last_read_message_id = 0;
+
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS hide_read_receipts BOOLEAN NOT NULL DEFAULT FALSE;
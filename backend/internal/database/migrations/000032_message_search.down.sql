@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_messages_sender_created;
+DROP INDEX IF EXISTS idx_messages_content_fts;
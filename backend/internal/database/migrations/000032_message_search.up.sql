@@ -0,0 +1,9 @@
+-- Full-text search over chat history. The 'simple' configuration avoids
+-- English-only stemming since rooms are multilingual.
+CREATE INDEX IF NOT EXISTS idx_messages_content_fts
+    ON messages USING gin (to_tsvector('simple', content))
+    WHERE deleted_at IS NULL AND is_deleted = FALSE;
+
+-- Filtering search results by sender.
+CREATE INDEX IF NOT EXISTS idx_messages_sender_created
+    ON messages (sender_id, created_at DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_images_released_at;
+
+ALTER TABLE images DROP COLUMN IF EXISTS released_at;
@@ -0,0 +1,8 @@
+-- Images dropped by their last post are stamped and garbage collected by a
+-- background sweep instead of inside the post update/delete request.
+ALTER TABLE images
+ADD COLUMN IF NOT EXISTS released_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_images_released_at
+    ON images (released_at)
+    WHERE released_at IS NOT NULL;
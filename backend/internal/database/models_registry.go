@@ -12,8 +12,12 @@ func PersistentModels() []interface{} {
 		&models.PollVote{},
 		&models.Image{},
 		&models.ImageVariant{},
+		&models.PostImage{},
+		&models.PostTag{},
 		&models.Comment{},
 		&models.Like{},
+		&models.BookmarkCollection{},
+		&models.Bookmark{},
 		&models.Conversation{},
 		&models.ChatroomModerator{},
 		&models.Message{},
@@ -31,5 +35,25 @@ func PersistentModels() []interface{} {
 		&models.Sanctum{},
 		&models.SanctumRequest{},
 		&models.SanctumMembership{},
+		&models.SanctumFlair{},
+		&models.SanctumModLogEntry{},
+		&models.SanctumBan{},
+		&models.SanctumJoinRequest{},
+		&models.SanctumSettings{},
+		&models.SanctumAutomod{},
+		&models.SanctumOwnershipTransfer{},
+		&models.ModmailThread{},
+		&models.ModmailMessage{},
+		&models.SanctumWikiPage{},
+		&models.SanctumWikiRevision{},
+		&models.SanctumWikiContributor{},
+		&models.SanctumStats{},
+		&models.SanctumRecommendation{},
+		&models.Invite{},
+		&models.InviteRedemption{},
+		&models.SignupInvite{},
+		&models.WaitlistEntry{},
+		&models.MessageEdit{},
+		&models.MessageThreadRead{},
 	}
 }
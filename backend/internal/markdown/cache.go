@@ -0,0 +1,53 @@
+package markdown
+
+import (
+	"container/list"
+	"sync"
+)
+
+// renderCache is a fixed-size LRU of rendered HTML keyed by content hash.
+type renderCache struct {
+	mu      sync.Mutex
+	max     int
+	order   *list.List
+	entries map[[32]byte]*list.Element
+}
+
+type renderCacheEntry struct {
+	key  [32]byte
+	html string
+}
+
+func newRenderCache(max int) *renderCache {
+	return &renderCache{
+		max:     max,
+		order:   list.New(),
+		entries: make(map[[32]byte]*list.Element, max),
+	}
+}
+
+func (c *renderCache) get(key [32]byte) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*renderCacheEntry).html, true
+}
+
+func (c *renderCache) add(key [32]byte, html string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&renderCacheEntry{key: key, html: html})
+	if c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*renderCacheEntry).key)
+	}
+}
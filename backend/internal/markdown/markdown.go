@@ -0,0 +1,89 @@
+// Package markdown renders user-authored Markdown (CommonMark plus a GFM
+// subset) to sanitized HTML for posts, comments and chat messages.
+package markdown
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/util"
+)
+
+// renderCacheSize bounds the number of rendered documents kept in memory.
+const renderCacheSize = 4096
+
+var (
+	md = goldmark.New(
+		goldmark.WithExtensions(
+			extension.Strikethrough,
+			extension.Linkify,
+			extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+		),
+		goldmark.WithParserOptions(
+			parser.WithInlineParsers(util.Prioritized(&referenceParser{}, 998)),
+		),
+		// Raw HTML in the source is dropped by goldmark (no WithUnsafe) and
+		// anything that slips through is removed by the sanitizer below.
+		goldmark.WithRendererOptions(
+			gmhtml.WithHardWraps(),
+			renderer.WithNodeRenderers(util.Prioritized(&mentionRenderer{}, 500)),
+		),
+	)
+
+	policy = newPolicy()
+	cache  = newRenderCache(renderCacheSize)
+)
+
+// Render converts Markdown to sanitized HTML. Output is memoized by content
+// hash, so repeated renders of the same text are cheap.
+func Render(content string) string {
+	if strings.TrimSpace(content) == "" {
+		return ""
+	}
+	key := sha256.Sum256([]byte(content))
+	if out, ok := cache.get(key); ok {
+		return out
+	}
+
+	var buf bytes.Buffer
+	out := ""
+	if err := md.Convert([]byte(content), &buf); err != nil {
+		out = "<p>" + html.EscapeString(content) + "</p>"
+	} else {
+		out = policy.Sanitize(buf.String())
+	}
+	cache.add(key, out)
+	return out
+}
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements(
+		"p", "br", "hr", "strong", "em", "del", "code", "pre", "blockquote",
+		"ul", "ol", "li", "h1", "h2", "h3", "h4", "h5", "h6",
+		"table", "thead", "tbody", "tr", "th", "td",
+	)
+	p.AllowAttrs("start").Matching(bluemonday.Integer).OnElements("ol")
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|center|right)$`)).OnElements("th", "td")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]{1,32}$`)).OnElements("code")
+
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^` + sanctumClass + `$`)).OnElements("a")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^` + mentionClass + `$`)).OnElements("span")
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.AllowRelativeURLs(true)
+	p.RequireParseableURLs(true)
+	p.RequireNoFollowOnFullyQualifiedLinks(true)
+	p.RequireNoReferrerOnFullyQualifiedLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
@@ -0,0 +1,78 @@
+package markdown
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender_Basics(t *testing.T) {
+	assert.Equal(t, "", Render("   "))
+	assert.Equal(t, "<p><strong>bold</strong> and <del>gone</del></p>\n", Render("**bold** and ~~gone~~"))
+	assert.Contains(t, Render("| a | b |\n|:--|--:|\n| 1 | 2 |"), `<th align="left">a</th>`)
+	assert.Contains(t, Render("```go\nx := 1\n```"), `<code class="language-go">`)
+	assert.Equal(t, "<p>line one<br>\nline two</p>\n", Render("line one\nline two"))
+}
+
+func TestRender_Sanitizes(t *testing.T) {
+	cases := map[string]string{
+		"raw script":      `<script>alert(1)</script>hi`,
+		"inline handler":  `<img src=x onerror=alert(1)>`,
+		"javascript link": `[click](javascript:alert(1))`,
+		"data link":       `[x](data:text/html;base64,PHNjcmlwdD4=)`,
+		"html attribute":  `<a href="https://e.com" onclick="x()">e</a>`,
+	}
+	for name, in := range cases {
+		out := Render(in)
+		for _, bad := range []string{"<script", "onerror", "onclick", "javascript:", "data:text"} {
+			assert.NotContains(t, out, bad, name)
+		}
+	}
+	assert.NotContains(t, Render("![alt](https://tracker.example/p.gif)"), "<img", "images are not embedded")
+}
+
+func TestRender_Links(t *testing.T) {
+	out := Render("see https://example.com/a")
+	assert.Contains(t, out, `href="https://example.com/a"`)
+	assert.Contains(t, out, `rel="nofollow noreferrer noopener"`)
+	assert.Contains(t, out, `target="_blank"`)
+}
+
+func TestRender_References(t *testing.T) {
+	assert.Equal(t,
+		`<p>hey <span class="mention">@alice_1</span>, try <a href="/s/go-lang" class="sanctum-link">s/go-lang</a>!</p>`+"\n",
+		Render("hey @alice_1, try s/go-lang!"))
+	assert.Contains(t, Render("@bob at line start"), `<span class="mention">@bob</span>`)
+	assert.Contains(t, Render("(s/news)"), `(<a href="/s/news" class="sanctum-link">s/news</a>)`)
+
+	for _, in := range []string{
+		"mail bob@example.org",
+		"path is/s/news",
+		"s/news/today",
+		"`@alice in code`",
+		"@a",
+	} {
+		out := Render(in)
+		assert.NotContains(t, out, `class="mention"`, in)
+		assert.NotContains(t, out, `class="sanctum-link"`, in)
+	}
+}
+
+func TestRender_CachesByContentHash(t *testing.T) {
+	in := "cached *render*"
+	first := Render(in)
+	got, ok := cache.get(sha256.Sum256([]byte(in)))
+	assert.True(t, ok)
+	assert.Equal(t, first, got)
+
+	small := newRenderCache(2)
+	small.add([32]byte{1}, "a")
+	small.add([32]byte{2}, "b")
+	small.get([32]byte{1})
+	small.add([32]byte{3}, "c")
+	_, ok = small.get([32]byte{2})
+	assert.False(t, ok, "least recently used entry is evicted")
+	_, ok = small.get([32]byte{1})
+	assert.True(t, ok)
+}
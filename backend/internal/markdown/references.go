@@ -0,0 +1,126 @@
+package markdown
+
+import (
+	"regexp"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+const (
+	mentionClass = "mention"
+	sanctumClass = "sanctum-link"
+)
+
+var (
+	// Same username shape the chat mention extractor accepts.
+	mentionRef = regexp.MustCompile(`^@([a-zA-Z0-9_]{2,32})`)
+	// Same slug shape validation.ValidateSanctumSlug accepts.
+	sanctumRef = regexp.MustCompile(`^s/([a-z0-9-]{3,24})`)
+)
+
+// referenceParser highlights @username mentions and autolinks s/slug sanctum
+// references that start at a word boundary. Profiles are addressed by ID, so
+// mentions are not links.
+type referenceParser struct{}
+
+func (p *referenceParser) Trigger() []byte {
+	// ' ' means any whitespace and the head of a line. '@' is punctuation, so
+	// goldmark dispatches on it directly when it starts a line or follows
+	// another inline node.
+	return []byte{' ', '(', '*', '_', '~', '@'}
+}
+
+func (p *referenceParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	if pc.IsInLinkLabel() {
+		return nil
+	}
+	line, segment := block.PeekLine()
+	if len(line) == 0 {
+		return nil
+	}
+	consumes := 0
+	start := segment.Start
+	switch line[0] {
+	case ' ', '\t', '(', '*', '_', '~':
+		consumes++
+		start++
+		line = line[1:]
+	case '@':
+		if isReferenceContinuation(byteOf(block.PrecendingCharacter())) {
+			return nil
+		}
+	}
+
+	var href string
+	var n int
+	mention := false
+	if m := mentionRef.FindSubmatchIndex(line); m != nil {
+		n, mention = m[1], true
+	} else if m := sanctumRef.FindSubmatchIndex(line); m != nil {
+		n = m[1]
+		href = "/s/" + string(line[m[2]:m[3]])
+	} else {
+		return nil
+	}
+	// Reject partial matches such as "@bob.smith@host" or "s/slug/path".
+	if n < len(line) && isReferenceContinuation(line[n]) {
+		return nil
+	}
+
+	if consumes != 0 {
+		ast.MergeOrAppendTextSegment(parent, segment.WithStop(segment.Start+1))
+	}
+	block.Advance(consumes + n)
+
+	if mention {
+		node := &mentionNode{}
+		node.AppendChild(node, ast.NewTextSegment(text.NewSegment(start, start+n)))
+		return node
+	}
+	link := ast.NewLink()
+	link.Destination = []byte(href)
+	link.SetAttributeString("class", []byte(sanctumClass))
+	link.AppendChild(link, ast.NewTextSegment(text.NewSegment(start, start+n)))
+	return link
+}
+
+// mentionNode is a highlighted @username.
+type mentionNode struct {
+	ast.BaseInline
+}
+
+var kindMention = ast.NewNodeKind("Mention")
+
+func (n *mentionNode) Kind() ast.NodeKind { return kindMention }
+
+func (n *mentionNode) Dump(source []byte, level int) { ast.DumpHelper(n, source, level, nil, nil) }
+
+// mentionRenderer writes mentions as <span class="mention">.
+type mentionRenderer struct{}
+
+func (r *mentionRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMention, func(w util.BufWriter, _ []byte, _ ast.Node, entering bool) (ast.WalkStatus, error) {
+		if entering {
+			_, _ = w.WriteString(`<span class="` + mentionClass + `">`)
+		} else {
+			_, _ = w.WriteString("</span>")
+		}
+		return ast.WalkContinue, nil
+	})
+}
+
+func byteOf(r rune) byte {
+	if r > 0x7f {
+		return 'a' // any non-ASCII letter counts as part of a word
+	}
+	return byte(r)
+}
+
+func isReferenceContinuation(c byte) bool {
+	return c == '@' || c == '/' || c == '-' || c == '_' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Bookmark target types
+const (
+	BookmarkTargetPost    = "post"
+	BookmarkTargetComment = "comment"
+)
+
+// BookmarkCollection is a user-defined, named folder of bookmarks.
+type BookmarkCollection struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:uq_bookmark_collections_user_name" json:"user_id"`
+	Name      string    `gorm:"type:varchar(100);not null;uniqueIndex:uq_bookmark_collections_user_name" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// BookmarksCount is not persisted; computed at query time
+	BookmarksCount int `gorm:"->" json:"bookmarks_count"`
+}
+
+// TableName returns the database table name for BookmarkCollection.
+func (BookmarkCollection) TableName() string {
+	return "bookmark_collections"
+}
+
+// Bookmark stores a user's saved post or comment. A target is saved at most
+// once per user; CollectionID nil means the bookmark is unsorted.
+type Bookmark struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"not null;uniqueIndex:uq_bookmarks_user_target" json:"user_id"`
+	CollectionID *uint     `gorm:"index" json:"collection_id,omitempty"`
+	TargetType   string    `gorm:"type:varchar(20);not null;uniqueIndex:uq_bookmarks_user_target" json:"target_type"`
+	TargetID     uint      `gorm:"not null;uniqueIndex:uq_bookmarks_user_target" json:"target_id"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// Post and Comment are resolved at read time. When the target has been
+	// deleted both stay nil and Deleted is set so clients can render a tombstone.
+	Post    *Post    `gorm:"-" json:"post,omitempty"`
+	Comment *Comment `gorm:"-" json:"comment,omitempty"`
+	Deleted bool     `gorm:"-" json:"deleted"`
+}
+
+// TableName returns the database table name for Bookmark.
+func (Bookmark) TableName() string {
+	return "bookmarks"
+}
@@ -10,18 +10,38 @@ import (
 
 // Conversation represents a chat conversation (can be 1-on-1 or group)
 type Conversation struct {
-	ID           uint           `gorm:"primaryKey" json:"id"`
-	Name         string         `json:"name"` // For group chats
-	IsGroup      bool           `gorm:"default:false" json:"is_group"`
-	Avatar       string         `json:"avatar"` // For group chats
-	CreatedBy    uint           `json:"created_by"`
-	SanctumID    *uint          `gorm:"uniqueIndex:idx_conversations_sanctum_id_unique,where:sanctum_id IS NOT NULL" json:"sanctum_id,omitempty"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
-	Participants []User         `gorm:"many2many:conversation_participants;" json:"participants,omitempty"`
-	Messages     []Message      `gorm:"foreignKey:ConversationID" json:"messages,omitempty"`
-	UnreadCount  int            `gorm:"-" json:"unread_count"`
+	ID             uint                  `gorm:"primaryKey" json:"id"`
+	Name           string                `json:"name"` // For group chats
+	IsGroup        bool                  `gorm:"default:false" json:"is_group"`
+	Avatar         string                `json:"avatar"` // For group chats
+	CreatedBy      uint                  `json:"created_by"`
+	SanctumID      *uint                 `gorm:"index;uniqueIndex:uq_conversations_sanctum_default,where:is_default = true" json:"sanctum_id,omitempty"`
+	Position       int                   `gorm:"column:channel_position;not null;default:0" json:"position"` // Sanctum channel order
+	Topic          string                `gorm:"size:300" json:"topic,omitempty"`
+	PostPermission ChannelPostPermission `gorm:"type:varchar(20);not null;default:'members'" json:"post_permission,omitempty"`
+	IsDefault      bool                  `gorm:"not null;default:false" json:"is_default"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt        `gorm:"index" json:"-"`
+	Participants   []User                `gorm:"many2many:conversation_participants;" json:"participants,omitempty"`
+	Messages       []Message             `gorm:"foreignKey:ConversationID" json:"messages,omitempty"`
+	UnreadCount    int                   `gorm:"-" json:"unread_count"`
+}
+
+// ChannelPostPermission controls who may post in a sanctum channel.
+type ChannelPostPermission string
+
+const (
+	// ChannelPostMembers lets every participant post.
+	ChannelPostMembers ChannelPostPermission = "members"
+	// ChannelPostMods limits posting to the sanctum's moderators, e.g. for
+	// announcement channels.
+	ChannelPostMods ChannelPostPermission = "mods"
+)
+
+// Valid reports whether p is a known permission.
+func (p ChannelPostPermission) Valid() bool {
+	return p == ChannelPostMembers || p == ChannelPostMods
 }
 
 // Message represents a chat message
@@ -32,11 +52,23 @@ type Message struct {
 	SenderID       uint              `gorm:"not null;index" json:"sender_id"`
 	Sender         *User             `gorm:"foreignKey:SenderID" json:"sender,omitempty"`
 	Content        string            `gorm:"type:text;not null" json:"content"`
+	ContentHTML    string            `gorm:"-" json:"content_html,omitempty"`                          // rendered Markdown, not persisted
 	MessageType    string            `gorm:"default:'text'" json:"message_type"`                       // text, image, file, etc.
 	Metadata       json.RawMessage   `gorm:"type:json" json:"metadata,omitempty" swaggertype:"object"` // For file URLs, image URLs, etc.
+	LinkPreview    *LinkPreview      `gorm:"serializer:json;type:jsonb" json:"link_preview,omitempty"`
 	IsRead         bool              `gorm:"default:false" json:"is_read"`
 	ReadAt         *time.Time        `json:"read_at,omitempty"`
 	Reactions      []MessageReaction `gorm:"foreignKey:MessageID" json:"reactions,omitempty"`
+	ReplyToID      *uint             `gorm:"column:reply_to_message_id;index" json:"reply_to_message_id,omitempty"`
+	ReplyTo        *MessagePreview   `gorm:"-" json:"reply_to,omitempty"`           // quoted message, filled on read
+	ThreadRootID   *uint             `gorm:"index" json:"thread_root_id,omitempty"` // set on side-thread replies
+	ReplyCount     int               `gorm:"not null;default:0" json:"reply_count"` // thread replies, on roots
+	LastReplyAt    *time.Time        `json:"last_reply_at,omitempty"`               // latest thread reply, on roots
+	ThreadReadAt   *time.Time        `gorm:"-" json:"thread_read_at,omitempty"`     // caller's thread read state
+	EditedAt       *time.Time        `json:"edited_at,omitempty"`
+	IsDeleted      bool              `gorm:"not null;default:false" json:"is_deleted"` // tombstone: content is cleared
+	DeletedBy      *uint             `gorm:"column:deleted_by_user_id" json:"deleted_by_user_id,omitempty"`
+	DeliveryState  string            `gorm:"-" json:"delivery_state,omitempty"` // sent, delivered or read; own DMs only
 	CreatedAt      time.Time         `json:"created_at"`
 	UpdatedAt      time.Time         `json:"updated_at"`
 	DeletedAt      gorm.DeletedAt    `gorm:"index" json:"-"`
@@ -50,4 +82,7 @@ type ConversationParticipant struct {
 	JoinedAt       time.Time `gorm:"autoCreateTime" json:"joined_at"`
 	LastReadAt     time.Time `json:"last_read_at"`
 	UnreadCount    int       `gorm:"default:0" json:"unread_count"`
+	// Watermarks: the newest message the user has read / received.
+	LastReadMessageID      uint `gorm:"not null;default:0" json:"last_read_message_id"`
+	LastDeliveredMessageID uint `gorm:"not null;default:0" json:"last_delivered_message_id"`
 }
@@ -18,4 +18,14 @@ type Comment struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	// RemovedAt is set when a sanctum moderator removes the comment.
+	RemovedAt       *time.Time `json:"removed_at,omitempty"`
+	RemovedByUserID *uint      `json:"removed_by_user_id,omitempty"`
+	RemovalReason   string     `gorm:"type:text" json:"removal_reason,omitempty"`
+	// NeedsReview marks a comment AutoModerator filtered into the mod queue.
+	NeedsReview bool `gorm:"not null;default:false" json:"needs_review,omitempty"`
+	// Distinguished marks a moderator speaking in an official capacity.
+	Distinguished bool `gorm:"not null;default:false" json:"distinguished"`
+	// ContentHTML is Content rendered from Markdown and sanitized; not persisted.
+	ContentHTML string `gorm:"-" json:"content_html,omitempty"`
 }
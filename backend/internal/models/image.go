@@ -26,6 +26,8 @@ type Image struct {
 	CropH               int            `gorm:"not null;default:0" json:"crop_h"`
 	ProcessingStartedAt *time.Time     `json:"-"`
 	ProcessingAttempts  int            `gorm:"not null;default:0" json:"-"`
+	RefCount            int            `gorm:"not null;default:0" json:"-"` // live posts referencing this image
+	ReleasedAt          *time.Time     `json:"-"`                           // set when the last reference went away; swept later
 	Variants            []ImageVariant `gorm:"foreignKey:ImageID" json:"variants,omitempty"`
 	UploadedAt          time.Time      `gorm:"not null;default:now()" json:"uploaded_at"`
 	LastAccessedAt      *time.Time     `json:"last_accessed_at,omitempty"`
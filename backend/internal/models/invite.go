@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// InviteTargetType is what an invite code lets its holder join.
+type InviteTargetType string
+
+const (
+	// InviteTargetSanctum joins the sanctum and, through membership, its channels.
+	InviteTargetSanctum InviteTargetType = "sanctum"
+	// InviteTargetConversation joins a standalone chatroom or group DM.
+	InviteTargetConversation InviteTargetType = "conversation"
+)
+
+// Invite is a shareable code that admits its holder to a sanctum or a group
+// conversation, optionally limited in lifetime and number of uses.
+type Invite struct {
+	ID              uint             `gorm:"primaryKey" json:"id"`
+	Code            string           `gorm:"size:16;not null;uniqueIndex:uq_invites_code" json:"code"`
+	TargetType      InviteTargetType `gorm:"type:varchar(20);not null" json:"target_type"`
+	SanctumID       *uint            `gorm:"index" json:"sanctum_id,omitempty"`
+	Sanctum         *Sanctum         `gorm:"foreignKey:SanctumID" json:"sanctum,omitempty"`
+	ConversationID  *uint            `gorm:"index" json:"conversation_id,omitempty"`
+	Conversation    *Conversation    `gorm:"foreignKey:ConversationID" json:"conversation,omitempty"`
+	CreatedByUserID uint             `gorm:"not null;index" json:"created_by_user_id"`
+	CreatedBy       *User            `gorm:"foreignKey:CreatedByUserID" json:"created_by,omitempty"`
+	MaxUses         int              `gorm:"not null;default:0" json:"max_uses"` // 0 means unlimited
+	Uses            int              `gorm:"not null;default:0" json:"uses"`
+	ExpiresAt       *time.Time       `json:"expires_at,omitempty"`
+	RevokedAt       *time.Time       `json:"revoked_at,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (Invite) TableName() string {
+	return "invites"
+}
+
+// Usable reports whether the invite can still be redeemed at now.
+func (i *Invite) Usable(now time.Time) bool {
+	if i.RevokedAt != nil {
+		return false
+	}
+	if i.ExpiresAt != nil && !now.Before(*i.ExpiresAt) {
+		return false
+	}
+	return i.MaxUses == 0 || i.Uses < i.MaxUses
+}
+
+// InviteRedemption records who joined through an invite. Each user counts
+// once towards the invite's uses.
+type InviteRedemption struct {
+	InviteID  uint      `gorm:"primaryKey;autoIncrement:false" json:"invite_id"`
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false;index" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (InviteRedemption) TableName() string {
+	return "invite_redemptions"
+}
@@ -0,0 +1,18 @@
+package models
+
+// LinkPreview is unfurled metadata for an external URL, gathered from
+// OpenGraph, Twitter card and oEmbed markup. ImageURL always points at a
+// locally stored copy of the preview image, never at the remote site.
+type LinkPreview struct {
+	URL           string            `json:"url"`
+	Title         string            `json:"title,omitempty"`
+	Description   string            `json:"description,omitempty"`
+	SiteName      string            `json:"site_name,omitempty"`
+	ImageURL      string            `json:"image_url,omitempty"`
+	ImageVariants map[string]string `json:"image_variants,omitempty"`
+}
+
+// IsEmpty reports whether the preview carries nothing worth rendering.
+func (p *LinkPreview) IsEmpty() bool {
+	return p == nil || (p.Title == "" && p.Description == "" && p.ImageURL == "")
+}
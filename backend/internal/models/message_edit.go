@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// MessageEdit keeps the content a chat message had before an edit.
+type MessageEdit struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	MessageID       uint      `gorm:"not null;index" json:"message_id"`
+	EditorID        uint      `gorm:"not null;index" json:"editor_id"`
+	PreviousContent string    `gorm:"type:text;not null" json:"previous_content"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// TableName returns the database table name for MessageEdit.
+func (MessageEdit) TableName() string {
+	return "message_edits"
+}
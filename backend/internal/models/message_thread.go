@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// MessagePreview is the quoted form of a message shown with replies to it.
+type MessagePreview struct {
+	ID             uint   `json:"id"`
+	SenderID       uint   `json:"sender_id"`
+	SenderUsername string `json:"sender_username"`
+	Content        string `json:"content"` // truncated
+	IsDeleted      bool   `json:"is_deleted"`
+}
+
+// MessageThreadRead records how far a user has read a side thread.
+type MessageThreadRead struct {
+	UserID        uint      `gorm:"primaryKey" json:"user_id"`
+	RootMessageID uint      `gorm:"primaryKey;index" json:"root_message_id"`
+	LastReadAt    time.Time `gorm:"not null" json:"last_read_at"`
+}
+
+// TableName returns the database table name for MessageThreadRead.
+func (MessageThreadRead) TableName() string {
+	return "message_thread_reads"
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// ModmailStatus is the state of a modmail thread.
+type ModmailStatus string
+
+const (
+	ModmailStatusOpen     ModmailStatus = "open"
+	ModmailStatusArchived ModmailStatus = "archived"
+)
+
+// ModmailThread is a conversation between a user and a sanctum's whole mod
+// team. Every owner and moderator of the sanctum can read and answer it.
+type ModmailThread struct {
+	ID               uint          `gorm:"primaryKey" json:"id"`
+	SanctumID        uint          `gorm:"not null;index:idx_modmail_threads_sanctum_status,priority:1" json:"sanctum_id"`
+	Sanctum          *Sanctum      `gorm:"foreignKey:SanctumID" json:"sanctum,omitempty"`
+	UserID           uint          `gorm:"not null;index" json:"user_id"`
+	User             *User         `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Subject          string        `gorm:"type:varchar(200);not null" json:"subject"`
+	Status           ModmailStatus `gorm:"type:varchar(20);not null;default:'open';index:idx_modmail_threads_sanctum_status,priority:2" json:"status"`
+	AssignedToUserID *uint         `json:"assigned_to_user_id,omitempty"`
+	AssignedTo       *User         `gorm:"foreignKey:AssignedToUserID" json:"assigned_to,omitempty"`
+	LastMessageAt    time.Time     `json:"last_message_at"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
+
+	Messages []ModmailMessage `gorm:"foreignKey:ThreadID" json:"messages,omitempty"`
+}
+
+// TableName specifies the table name for GORM.
+func (ModmailThread) TableName() string {
+	return "modmail_threads"
+}
+
+// ModmailMessage is one message in a modmail thread. Internal messages are
+// notes between moderators and are never shown to the user.
+type ModmailMessage struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	ThreadID uint   `gorm:"not null;index" json:"thread_id"`
+	AuthorID uint   `gorm:"not null" json:"author_id"`
+	Author   *User  `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+	Body     string `gorm:"type:text;not null" json:"body"`
+	// FromMod marks replies sent on behalf of the mod team.
+	FromMod   bool      `gorm:"not null;default:false" json:"from_mod"`
+	Internal  bool      `gorm:"not null;default:false" json:"internal"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (ModmailMessage) TableName() string {
+	return "modmail_messages"
+}
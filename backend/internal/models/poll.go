@@ -3,6 +3,16 @@ package models
 
 import "time"
 
+// PollSettings are the author-chosen rules of a poll.
+type PollSettings struct {
+	// MaxSelections is how many options a voter may pick (1 = single choice).
+	MaxSelections int `gorm:"not null;default:1" json:"max_selections"`
+	// ClosesAt is when voting ends; nil keeps the poll open indefinitely.
+	ClosesAt *time.Time `json:"closes_at,omitempty"`
+	// HideResults keeps counts hidden from a user until they vote or the poll closes.
+	HideResults bool `gorm:"not null;default:false" json:"hide_results"`
+}
+
 // Poll represents a poll attached to a post.
 type Poll struct {
 	ID               uint         `gorm:"primaryKey" json:"id"`
@@ -13,6 +23,18 @@ type Poll struct {
 	UserVoteOptionID *uint        `gorm:"-" json:"user_vote_option_id,omitempty"` // filled when loading for current user
 	CreatedAt        time.Time    `json:"created_at"`
 	UpdatedAt        time.Time    `json:"updated_at"`
+	PollSettings     `gorm:"embedded"`
+
+	// Computed when loading for display.
+	UserVoteOptionIDs []uint `gorm:"-" json:"user_vote_option_ids,omitempty"`
+	TotalVoters       int    `gorm:"-" json:"total_voters"`
+	Closed            bool   `gorm:"-" json:"closed"`
+	ResultsHidden     bool   `gorm:"-" json:"results_hidden"`
+}
+
+// IsClosed reports whether voting has ended at time now.
+func (p *Poll) IsClosed(now time.Time) bool {
+	return p.ClosesAt != nil && !now.Before(*p.ClosesAt)
 }
 
 // PollOption represents a single choice in a poll.
@@ -25,12 +47,13 @@ type PollOption struct {
 	VotesCount int `gorm:"->" json:"votes_count,omitempty"`
 }
 
-// PollVote records a user's vote for one option in a poll.
+// PollVote records a user's vote for one option in a poll. Multi-select
+// polls store one row per selected option.
 type PollVote struct {
 	ID           uint      `gorm:"primaryKey" json:"id"`
-	UserID       uint      `gorm:"uniqueIndex:uq_poll_votes_user_poll;not null" json:"user_id"`
-	PollID       uint      `gorm:"uniqueIndex:uq_poll_votes_user_poll;not null" json:"poll_id"`
-	PollOptionID uint      `gorm:"not null" json:"poll_option_id"`
+	UserID       uint      `gorm:"uniqueIndex:uq_poll_votes_user_option;index:idx_poll_votes_poll_user,priority:2;not null" json:"user_id"`
+	PollID       uint      `gorm:"index:idx_poll_votes_poll_user,priority:1;not null" json:"poll_id"`
+	PollOptionID uint      `gorm:"uniqueIndex:uq_poll_votes_user_option;not null" json:"poll_option_id"`
 	CreatedAt    time.Time `json:"created_at"`
 }
 
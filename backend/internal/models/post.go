@@ -7,13 +7,21 @@ import (
 	"gorm.io/gorm"
 )
 
-// PostType represents the kind of post (text, media, video, link, poll).
+// PostType represents the kind of post (text, media, video, link, poll, gallery).
 const (
-	PostTypeText  = "text"
-	PostTypeMedia = "media"
-	PostTypeVideo = "video"
-	PostTypeLink  = "link"
-	PostTypePoll  = "poll"
+	PostTypeText    = "text"
+	PostTypeMedia   = "media"
+	PostTypeVideo   = "video"
+	PostTypeLink    = "link"
+	PostTypePoll    = "poll"
+	PostTypeGallery = "gallery"
+)
+
+// PostStatus represents the publication state of a post (published, draft, scheduled).
+const (
+	PostStatusPublished = "published"
+	PostStatusDraft     = "draft"
+	PostStatusScheduled = "scheduled"
 )
 
 // Post represents a post in the Sanctum application.
@@ -31,6 +39,33 @@ type Post struct {
 	SanctumID  *uint    `gorm:"index" json:"sanctum_id,omitempty"`
 	Sanctum    *Sanctum `gorm:"foreignKey:SanctumID" json:"sanctum,omitempty"`
 	Poll       *Poll    `gorm:"foreignKey:PostID" json:"poll,omitempty"`
+	// FlairID optionally labels a sanctum post with one of the sanctum's flairs.
+	FlairID *uint         `gorm:"index" json:"flair_id,omitempty"`
+	Flair   *SanctumFlair `gorm:"foreignKey:FlairID" json:"flair,omitempty"`
+	// Tags are free-form labels chosen by the author.
+	Tags []PostTag `gorm:"foreignKey:PostID" json:"tags,omitempty"`
+	// Images are the ordered items of a gallery post.
+	Images []PostImage `gorm:"foreignKey:PostID" json:"images,omitempty"`
+	// Status controls visibility; only published posts appear in feeds and search.
+	Status string `gorm:"type:varchar(20);not null;default:published;index" json:"status"`
+	// PublishAt is when a scheduled post will be published by the scheduler.
+	PublishAt *time.Time `gorm:"index" json:"publish_at,omitempty"`
+	// RemovedAt is set when a sanctum moderator removes the post; removed posts
+	// leave feeds but stay readable by the author and moderators.
+	RemovedAt       *time.Time `gorm:"index" json:"removed_at,omitempty"`
+	RemovedByUserID *uint      `json:"removed_by_user_id,omitempty"`
+	RemovalReason   string     `gorm:"type:text" json:"removal_reason,omitempty"`
+	// NeedsReview marks a post AutoModerator filtered into the mod queue; it
+	// stays removed until a moderator approves or confirms the removal.
+	NeedsReview bool `gorm:"not null;default:false" json:"needs_review,omitempty"`
+	// PinnedAt keeps the post at the top of its sanctum feed.
+	PinnedAt *time.Time `json:"pinned_at,omitempty"`
+	// Locked posts accept no new comments.
+	Locked bool `gorm:"not null;default:false" json:"locked"`
+	// LinkPreview is filled in asynchronously after the post is created.
+	LinkPreview *LinkPreview `gorm:"serializer:json;type:jsonb" json:"link_preview,omitempty"`
+	// ContentHTML is Content rendered from Markdown and sanitized; not persisted.
+	ContentHTML string `gorm:"-" json:"content_html,omitempty"`
 	// LikesCount is not persisted; computed at query time
 	LikesCount int `gorm:"->" json:"likes_count"`
 	// CommentsCount is not persisted; computed at query time
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// MaxGalleryImages is the most images a single gallery post may hold.
+const MaxGalleryImages = 20
+
+// PostImage is one item of a gallery post.
+type PostImage struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PostID    uint      `gorm:"not null;uniqueIndex:uq_post_images_post_position" json:"post_id"`
+	ImageHash string    `gorm:"size:64;not null;index" json:"image_hash"`
+	Position  int       `gorm:"not null;uniqueIndex:uq_post_images_post_position" json:"position"`
+	Caption   string    `gorm:"type:varchar(300)" json:"caption,omitempty"`
+	AltText   string    `gorm:"type:varchar(500)" json:"alt_text,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Filled in from the image record when the post is loaded.
+	ImageURL      string            `gorm:"-" json:"image_url"`
+	ImageVariants map[string]string `gorm:"-" json:"image_variants,omitempty"`
+	ImageCropMode string            `gorm:"-" json:"image_crop_mode,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (PostImage) TableName() string {
+	return "post_images"
+}
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// RegistrationMode controls who may sign up.
+type RegistrationMode string
+
+const (
+	// RegistrationOpen lets anyone sign up.
+	RegistrationOpen RegistrationMode = "open"
+	// RegistrationInviteOnly requires a signup invite code.
+	RegistrationInviteOnly RegistrationMode = "invite_only"
+	// RegistrationWaitlist admits invite holders and emails approved from
+	// the waitlist.
+	RegistrationWaitlist RegistrationMode = "waitlist"
+)
+
+// SignupInvite is a single-use code that lets someone create an account
+// while registration is restricted.
+type SignupInvite struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	Code            string     `gorm:"size:16;not null;uniqueIndex:uq_signup_invites_code" json:"code"`
+	CreatedByUserID uint       `gorm:"not null;index" json:"created_by_user_id"`
+	UsedByUserID    *uint      `gorm:"index" json:"used_by_user_id,omitempty"`
+	UsedBy          *User      `gorm:"foreignKey:UsedByUserID" json:"used_by,omitempty"`
+	UsedAt          *time.Time `json:"used_at,omitempty"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (SignupInvite) TableName() string {
+	return "signup_invites"
+}
+
+// WaitlistStatus is the review state of a waitlist entry.
+type WaitlistStatus string
+
+const (
+	WaitlistStatusPending  WaitlistStatus = "pending"
+	WaitlistStatusApproved WaitlistStatus = "approved"
+	WaitlistStatusRejected WaitlistStatus = "rejected"
+)
+
+// WaitlistEntry is a request to sign up while registration is waitlisted.
+// Once approved, the email may register without an invite code.
+type WaitlistEntry struct {
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	Email            string         `gorm:"size:255;not null;uniqueIndex:uq_signup_waitlist_email" json:"email"`
+	Note             string         `gorm:"type:text;not null;default:''" json:"note"`
+	Status           WaitlistStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	ReviewedByUserID *uint          `json:"reviewed_by_user_id,omitempty"`
+	ReviewedAt       *time.Time     `json:"reviewed_at,omitempty"`
+	UserID           *uint          `json:"user_id,omitempty"` // Account created after approval
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (WaitlistEntry) TableName() string {
+	return "signup_waitlist"
+}
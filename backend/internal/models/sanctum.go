@@ -16,17 +16,77 @@ const (
 	SanctumStatusBanned SanctumStatus = "banned"
 )
 
+// SanctumVisibility controls who may read and post in a sanctum.
+type SanctumVisibility string
+
+const (
+	// SanctumVisibilityPublic lets anyone read, join and post.
+	SanctumVisibilityPublic SanctumVisibility = "public"
+	// SanctumVisibilityRestricted lets anyone read but only approved members post.
+	SanctumVisibilityRestricted SanctumVisibility = "restricted"
+	// SanctumVisibilityPrivate hides the sanctum's content from non-members.
+	SanctumVisibilityPrivate SanctumVisibility = "private"
+)
+
+// Valid reports whether v is a known visibility.
+func (v SanctumVisibility) Valid() bool {
+	switch v {
+	case SanctumVisibilityPublic, SanctumVisibilityRestricted, SanctumVisibilityPrivate:
+		return true
+	}
+	return false
+}
+
+// SanctumCategory groups sanctums in the directory.
+type SanctumCategory string
+
+const (
+	SanctumCategoryCommunity     SanctumCategory = "community"
+	SanctumCategoryEntertainment SanctumCategory = "entertainment"
+	SanctumCategoryGaming        SanctumCategory = "gaming"
+	SanctumCategoryTechnology    SanctumCategory = "technology"
+	SanctumCategoryLifestyle     SanctumCategory = "lifestyle"
+	// SanctumCategoryOther is the default for sanctums nobody has categorised.
+	SanctumCategoryOther SanctumCategory = "other"
+)
+
+// SanctumCategories lists the directory categories in display order.
+var SanctumCategories = []SanctumCategory{
+	SanctumCategoryCommunity,
+	SanctumCategoryEntertainment,
+	SanctumCategoryGaming,
+	SanctumCategoryTechnology,
+	SanctumCategoryLifestyle,
+	SanctumCategoryOther,
+}
+
+// Valid reports whether c is a known category.
+func (c SanctumCategory) Valid() bool {
+	for _, known := range SanctumCategories {
+		if c == known {
+			return true
+		}
+	}
+	return false
+}
+
 // Sanctum represents a branded community namespace.
 type Sanctum struct {
-	ID              uint          `gorm:"primaryKey" json:"id"`
-	Name            string        `gorm:"size:120;not null" json:"name"`
-	Slug            string        `gorm:"size:24;not null;unique" json:"slug"`
-	Description     string        `gorm:"type:text" json:"description"`
-	CreatedByUserID *uint         `json:"created_by_user_id"`
-	CreatedByUser   *User         `gorm:"foreignKey:CreatedByUserID" json:"created_by_user,omitempty"`
-	Status          SanctumStatus `gorm:"type:varchar(20);not null;default:'active'" json:"status"`
-	CreatedAt       time.Time     `json:"created_at"`
-	UpdatedAt       time.Time     `json:"updated_at"`
+	ID              uint              `gorm:"primaryKey" json:"id"`
+	Name            string            `gorm:"size:120;not null" json:"name"`
+	Slug            string            `gorm:"size:24;not null;unique" json:"slug"`
+	Description     string            `gorm:"type:text" json:"description"`
+	CreatedByUserID *uint             `json:"created_by_user_id"`
+	CreatedByUser   *User             `gorm:"foreignKey:CreatedByUserID" json:"created_by_user,omitempty"`
+	Status          SanctumStatus     `gorm:"type:varchar(20);not null;default:'active'" json:"status"`
+	Visibility      SanctumVisibility `gorm:"type:varchar(20);not null;default:'public'" json:"visibility"`
+	IconHash        string            `gorm:"size:64;not null;default:''" json:"-"`
+	BannerHash      string            `gorm:"size:64;not null;default:''" json:"-"`
+	AccentColor     string            `gorm:"size:7;not null;default:''" json:"accent_color"`
+	AccentColorDark string            `gorm:"size:7;not null;default:''" json:"accent_color_dark"`
+	Category        SanctumCategory   `gorm:"type:varchar(30);not null;default:'other';index" json:"category"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
 }
 
 // TableName specifies the table name for GORM.
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// MaxAutomodSourceLen bounds the size of a sanctum's AutoModerator config.
+const MaxAutomodSourceLen = 20000
+
+// SanctumAutomod stores a sanctum's AutoModerator rule set exactly as its
+// moderators wrote it, in YAML or JSON.
+type SanctumAutomod struct {
+	SanctumID       uint      `gorm:"primaryKey;autoIncrement:false" json:"sanctum_id"`
+	Source          string    `gorm:"type:text;not null;default:''" json:"source"`
+	Enabled         bool      `gorm:"not null" json:"enabled"`
+	UpdatedByUserID *uint     `json:"updated_by_user_id,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (SanctumAutomod) TableName() string {
+	return "sanctum_automod"
+}
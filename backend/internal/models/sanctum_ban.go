@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// SanctumBanKind distinguishes full bans from temporary mutes.
+type SanctumBanKind string
+
+const (
+	// SanctumBanKindBan removes a user from a sanctum and blocks rejoining.
+	SanctumBanKindBan SanctumBanKind = "ban"
+	// SanctumBanKindMute silences a user for a while without removing them.
+	SanctumBanKindMute SanctumBanKind = "mute"
+)
+
+// SanctumBan blocks a user from posting, commenting and chatting in one
+// sanctum until ExpiresAt (or indefinitely when nil).
+type SanctumBan struct {
+	ID              uint           `gorm:"primaryKey" json:"id"`
+	SanctumID       uint           `gorm:"not null;uniqueIndex:uq_sanctum_bans_sanctum_user" json:"sanctum_id"`
+	UserID          uint           `gorm:"not null;uniqueIndex:uq_sanctum_bans_sanctum_user;index" json:"user_id"`
+	User            *User          `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Kind            SanctumBanKind `gorm:"type:varchar(10);not null;default:'ban'" json:"kind"`
+	Reason          string         `gorm:"type:varchar(500);not null;default:''" json:"reason"`
+	ExpiresAt       *time.Time     `json:"expires_at,omitempty"`
+	CreatedByUserID uint           `gorm:"not null" json:"created_by_user_id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (SanctumBan) TableName() string {
+	return "sanctum_bans"
+}
+
+// ActiveAt reports whether the ban is still in force at now.
+func (b *SanctumBan) ActiveAt(now time.Time) bool {
+	return b.ExpiresAt == nil || b.ExpiresAt.After(now)
+}
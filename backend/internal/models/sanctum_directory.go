@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// SanctumStats is a sanctum's directory activity, recomputed periodically.
+type SanctumStats struct {
+	SanctumID          uint      `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	MemberCount        int64     `gorm:"not null;default:0" json:"member_count"`
+	NewMembersThisWeek int64     `gorm:"not null;default:0" json:"new_members_this_week"`
+	PostsThisWeek      int64     `gorm:"not null;default:0" json:"posts_this_week"`
+	CommentsThisWeek   int64     `gorm:"not null;default:0" json:"comments_this_week"`
+	PostsPerDay        float64   `gorm:"not null;default:0" json:"posts_per_day"`
+	TrendingScore      float64   `gorm:"not null;default:0;index" json:"trending_score"`
+	ComputedAt         time.Time `gorm:"not null" json:"computed_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (SanctumStats) TableName() string {
+	return "sanctum_stats"
+}
+
+// SanctumRecommendation is a precomputed "recommended for you" sanctum.
+type SanctumRecommendation struct {
+	UserID     uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	SanctumID  uint      `gorm:"primaryKey;autoIncrement:false" json:"sanctum_id"`
+	Sanctum    *Sanctum  `gorm:"foreignKey:SanctumID" json:"sanctum,omitempty"`
+	Score      float64   `gorm:"not null" json:"score"`
+	ComputedAt time.Time `gorm:"not null" json:"computed_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (SanctumRecommendation) TableName() string {
+	return "sanctum_recommendations"
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	// MaxSanctumFlairs is the most flairs a single sanctum may define.
+	MaxSanctumFlairs = 50
+	// MaxPostTags is the most free tags a single post may carry.
+	MaxPostTags = 10
+)
+
+// SanctumFlair is a sanctum-defined label that posts in the sanctum can carry.
+type SanctumFlair struct {
+	ID        uint   `gorm:"primaryKey" json:"id"`
+	SanctumID uint   `gorm:"not null;uniqueIndex:uq_sanctum_flairs_sanctum_name" json:"sanctum_id"`
+	Name      string `gorm:"size:40;not null;uniqueIndex:uq_sanctum_flairs_sanctum_name" json:"name"`
+	// Color is a #rrggbb hex string.
+	Color string `gorm:"type:varchar(7);not null" json:"color"`
+	// ModOnly flairs can only be applied by sanctum owners and moderators.
+	ModOnly   bool      `gorm:"not null;default:false" json:"mod_only"`
+	Position  int       `gorm:"not null;default:0" json:"position"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (SanctumFlair) TableName() string {
+	return "sanctum_flairs"
+}
+
+// PostTag is a free-form, lowercase tag on a post. It serializes as the bare
+// tag string.
+type PostTag struct {
+	PostID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Tag    string `gorm:"primaryKey;size:32;index"`
+}
+
+// TableName specifies the table name for GORM
+func (PostTag) TableName() string {
+	return "post_tags"
+}
+
+// MarshalJSON encodes the tag as a plain string.
+func (t PostTag) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Tag)
+}
+
+// UnmarshalJSON decodes a plain string tag (used when reading cached posts).
+func (t *PostTag) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &t.Tag)
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// SanctumJoinRequest asks the moderators of a restricted or private sanctum
+// to approve a user's membership. A user has at most one request per
+// sanctum; asking again after a rejection reopens it.
+type SanctumJoinRequest struct {
+	ID               uint                 `gorm:"primaryKey" json:"id"`
+	SanctumID        uint                 `gorm:"not null;uniqueIndex:uq_sanctum_join_requests_sanctum_user;index:idx_sanctum_join_requests_sanctum_status,priority:1" json:"sanctum_id"`
+	UserID           uint                 `gorm:"not null;uniqueIndex:uq_sanctum_join_requests_sanctum_user" json:"user_id"`
+	User             *User                `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Message          string               `gorm:"type:varchar(500);not null;default:''" json:"message"`
+	Status           SanctumRequestStatus `gorm:"type:varchar(20);not null;default:'pending';index:idx_sanctum_join_requests_sanctum_status,priority:2" json:"status"`
+	ReviewedByUserID *uint                `json:"reviewed_by_user_id,omitempty"`
+	ReviewedAt       *time.Time           `json:"reviewed_at,omitempty"`
+	CreatedAt        time.Time            `json:"created_at"`
+	UpdatedAt        time.Time            `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (SanctumJoinRequest) TableName() string {
+	return "sanctum_join_requests"
+}
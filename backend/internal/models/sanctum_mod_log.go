@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// MaxSanctumPinnedPosts is how many posts a sanctum may pin at once.
+const MaxSanctumPinnedPosts = 3
+
+// Sanctum moderator actions recorded in the mod log.
+const (
+	ModActionRemovePost           = "remove_post"
+	ModActionRestorePost          = "restore_post"
+	ModActionPinPost              = "pin_post"
+	ModActionUnpinPost            = "unpin_post"
+	ModActionLockPost             = "lock_post"
+	ModActionUnlockPost           = "unlock_post"
+	ModActionRemoveComment        = "remove_comment"
+	ModActionRestoreComment       = "restore_comment"
+	ModActionDistinguishComment   = "distinguish_comment"
+	ModActionUndistinguishComment = "undistinguish_comment"
+	ModActionBanUser              = "ban_user"
+	ModActionMuteUser             = "mute_user"
+	ModActionUnbanUser            = "unban_user"
+	ModActionApproveJoin          = "approve_join"
+	ModActionRejectJoin           = "reject_join"
+	ModActionFilterPost           = "filter_post"
+	ModActionFilterComment        = "filter_comment"
+	ModActionFlairPost            = "flair_post"
+	ModActionOfferOwnership       = "offer_ownership"
+	ModActionTransferOwnership    = "transfer_ownership"
+	ModActionReassignOwnership    = "reassign_ownership"
+)
+
+// Mod log target kinds.
+const (
+	ModTargetPost    = "post"
+	ModTargetComment = "comment"
+	ModTargetUser    = "user"
+)
+
+// SanctumModLogEntry records one moderator action taken in a sanctum.
+type SanctumModLogEntry struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	SanctumID   uint      `gorm:"not null;index:idx_sanctum_mod_log_sanctum_created,priority:1" json:"sanctum_id"`
+	ModeratorID uint      `gorm:"not null;index" json:"moderator_id"`
+	Moderator   *User     `gorm:"foreignKey:ModeratorID" json:"moderator,omitempty"`
+	Action      string    `gorm:"type:varchar(40);not null" json:"action"`
+	TargetType  string    `gorm:"type:varchar(20);not null" json:"target_type"`
+	TargetID    uint      `gorm:"not null" json:"target_id"`
+	Reason      string    `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt   time.Time `gorm:"index:idx_sanctum_mod_log_sanctum_created,priority:2" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (SanctumModLogEntry) TableName() string {
+	return "sanctum_mod_log"
+}
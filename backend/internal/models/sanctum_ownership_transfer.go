@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// SanctumTransferStatus is the state of an ownership transfer offer.
+type SanctumTransferStatus string
+
+const (
+	SanctumTransferStatusPending   SanctumTransferStatus = "pending"
+	SanctumTransferStatusAccepted  SanctumTransferStatus = "accepted"
+	SanctumTransferStatusDeclined  SanctumTransferStatus = "declined"
+	SanctumTransferStatusCancelled SanctumTransferStatus = "cancelled"
+)
+
+// SanctumOwnershipTransfer is an owner's offer to hand a sanctum to another
+// user. Ownership only changes once the recipient accepts.
+type SanctumOwnershipTransfer struct {
+	ID          uint                  `gorm:"primaryKey" json:"id"`
+	SanctumID   uint                  `gorm:"not null;index" json:"sanctum_id"`
+	Sanctum     *Sanctum              `gorm:"foreignKey:SanctumID" json:"sanctum,omitempty"`
+	FromUserID  uint                  `gorm:"not null" json:"from_user_id"`
+	FromUser    *User                 `gorm:"foreignKey:FromUserID" json:"from_user,omitempty"`
+	ToUserID    uint                  `gorm:"not null;index" json:"to_user_id"`
+	ToUser      *User                 `gorm:"foreignKey:ToUserID" json:"to_user,omitempty"`
+	Status      SanctumTransferStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	RespondedAt *time.Time            `json:"responded_at,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (SanctumOwnershipTransfer) TableName() string {
+	return "sanctum_ownership_transfers"
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	// MaxSanctumRules bounds the number of rules a sanctum can list.
+	MaxSanctumRules = 15
+	// MaxSanctumTitlePatternLen bounds the title regex a sanctum may require.
+	MaxSanctumTitlePatternLen = 200
+)
+
+// SanctumRule is one numbered community rule. Rules are shown to users and
+// offered as report reasons.
+type SanctumRule struct {
+	Number      int    `json:"number"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// SanctumSettings holds a sanctum's rules and posting policy. Sanctums
+// without a row use the zero value, which allows every post.
+type SanctumSettings struct {
+	SanctumID uint          `gorm:"primaryKey;autoIncrement:false" json:"sanctum_id"`
+	Rules     []SanctumRule `gorm:"serializer:json;type:jsonb;not null" json:"rules"`
+	// AllowedPostTypes lists the post types accepted; empty allows all.
+	AllowedPostTypes []string `gorm:"serializer:json;type:jsonb;not null" json:"allowed_post_types"`
+	// MinAccountAgeDays and MinKarma gate posting by new or unproven accounts.
+	// Karma is the number of likes the author's posts have received.
+	MinAccountAgeDays int  `gorm:"not null;default:0" json:"min_account_age_days"`
+	MinKarma          int  `gorm:"not null;default:0" json:"min_karma"`
+	RequireImage      bool `gorm:"not null;default:false" json:"require_image"`
+	// TitlePattern is a regular expression every post title must match.
+	TitlePattern string    `gorm:"type:varchar(200);not null;default:''" json:"title_pattern"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (SanctumSettings) TableName() string {
+	return "sanctum_settings"
+}
+
+// Rule returns the rule with the given number, or nil.
+func (s *SanctumSettings) Rule(number int) *SanctumRule {
+	for i := range s.Rules {
+		if s.Rules[i].Number == number {
+			return &s.Rules[i]
+		}
+	}
+	return nil
+}
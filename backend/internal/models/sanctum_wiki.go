@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// WikiEditPermission controls who may edit a sanctum wiki page.
+type WikiEditPermission string
+
+const (
+	// WikiEditMods limits editing to the sanctum's owners and moderators.
+	WikiEditMods WikiEditPermission = "mods"
+	// WikiEditContributors also admits the sanctum's approved wiki contributors.
+	WikiEditContributors WikiEditPermission = "contributors"
+	// WikiEditMembers lets any sanctum member edit.
+	WikiEditMembers WikiEditPermission = "members"
+)
+
+// Valid reports whether p is a known permission.
+func (p WikiEditPermission) Valid() bool {
+	switch p {
+	case WikiEditMods, WikiEditContributors, WikiEditMembers:
+		return true
+	}
+	return false
+}
+
+// SanctumWikiPage is the current version of a sanctum wiki page. Slugs are
+// hierarchical, e.g. "guides/getting-started".
+type SanctumWikiPage struct {
+	ID              uint               `gorm:"primaryKey" json:"id"`
+	SanctumID       uint               `gorm:"not null;uniqueIndex:uq_sanctum_wiki_pages_sanctum_slug" json:"sanctum_id"`
+	Slug            string             `gorm:"type:varchar(200);not null;uniqueIndex:uq_sanctum_wiki_pages_sanctum_slug" json:"slug"`
+	Title           string             `gorm:"type:varchar(200);not null" json:"title"`
+	Content         string             `gorm:"type:text;not null" json:"content"`
+	ContentHTML     string             `gorm:"-" json:"content_html"`
+	EditPermission  WikiEditPermission `gorm:"type:varchar(20);not null;default:'mods'" json:"edit_permission"`
+	Revision        int                `gorm:"not null;default:1" json:"revision"`
+	UpdatedByUserID *uint              `json:"updated_by_user_id,omitempty"`
+	UpdatedBy       *User              `gorm:"foreignKey:UpdatedByUserID" json:"updated_by,omitempty"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (SanctumWikiPage) TableName() string {
+	return "sanctum_wiki_pages"
+}
+
+// SanctumWikiRevision is one saved version of a wiki page. Revisions are
+// numbered from 1 per page and never change once written.
+type SanctumWikiRevision struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	PageID   uint   `gorm:"not null;uniqueIndex:uq_sanctum_wiki_revisions_page_revision" json:"page_id"`
+	Revision int    `gorm:"not null;uniqueIndex:uq_sanctum_wiki_revisions_page_revision" json:"revision"`
+	Title    string `gorm:"type:varchar(200);not null" json:"title"`
+	Content  string `gorm:"type:text;not null" json:"content"`
+	Reason   string `gorm:"type:varchar(300);not null;default:''" json:"reason"`
+	// RevertedFrom is set when this revision restores an earlier one.
+	RevertedFrom *int      `json:"reverted_from,omitempty"`
+	AuthorID     uint      `gorm:"not null" json:"author_id"`
+	Author       *User     `gorm:"foreignKey:AuthorID" json:"author,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (SanctumWikiRevision) TableName() string {
+	return "sanctum_wiki_revisions"
+}
+
+// SanctumWikiContributor is a user approved to edit a sanctum's
+// contributor-editable wiki pages.
+type SanctumWikiContributor struct {
+	SanctumID     uint      `gorm:"primaryKey;autoIncrement:false" json:"sanctum_id"`
+	UserID        uint      `gorm:"primaryKey;autoIncrement:false" json:"user_id"`
+	User          *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	AddedByUserID uint      `gorm:"not null" json:"added_by_user_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for GORM.
+func (SanctumWikiContributor) TableName() string {
+	return "sanctum_wiki_contributors"
+}
@@ -341,6 +341,25 @@ func (h *ChatHub) BroadcastToAllUsers(message ChatMessage) {
 	}
 }
 
+// SendToUser sends a message to every websocket client of one user.
+func (h *ChatHub) SendToUser(userID uint, message ChatMessage) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	clients, ok := h.userConns[userID]
+	if !ok {
+		return
+	}
+	messageJSON, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("ChatHub: Failed to marshal user message: %v", err)
+		return
+	}
+	for client := range clients {
+		client.TrySend(messageJSON)
+	}
+}
+
 // GetActiveUsers returns the list of userIDs currently viewing a conversation
 func (h *ChatHub) GetActiveUsers(conversationID uint) []uint {
 	h.mu.RLock()
@@ -146,6 +146,41 @@ func TestChatHub_BroadcastToConversation_DoesNotSendToNonParticipants(t *testing
 	_ = hub.Shutdown(context.Background())
 }
 
+func TestChatHub_SendToUser(t *testing.T) {
+	hub := NewChatHub()
+	hub.presence.SetOfflineGracePeriod(20 * time.Millisecond)
+
+	phone := &Client{UserID: 1, Send: make(chan []byte, 10)}
+	laptop := &Client{UserID: 1, Send: make(chan []byte, 10)}
+	other := &Client{UserID: 2, Send: make(chan []byte, 10)}
+	hub.RegisterUser(phone)
+	hub.RegisterUser(laptop)
+	hub.RegisterUser(other)
+
+	hub.SendToUser(1, ChatMessage{Type: "thread_read", ConversationID: 7})
+
+	// Drain presence updates and report whether thread_read arrived.
+	gotThreadRead := func(client *Client) bool {
+		for {
+			select {
+			case sent := <-client.Send:
+				var received ChatMessage
+				assert.NoError(t, json.Unmarshal(sent, &received))
+				if received.Type == "thread_read" {
+					return true
+				}
+			default:
+				return false
+			}
+		}
+	}
+	assert.True(t, gotThreadRead(phone))
+	assert.True(t, gotThreadRead(laptop))
+	assert.False(t, gotThreadRead(other), "another user unexpectedly received the message")
+
+	_ = hub.Shutdown(context.Background())
+}
+
 func TestChatHub_UnregisterCleanup(t *testing.T) {
 	hub := NewChatHub()
 	hub.presence.SetOfflineGracePeriod(20 * time.Millisecond)
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"sanctum/internal/database"
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BookmarkRepository defines storage operations for saved posts/comments and their collections.
+type BookmarkRepository interface {
+	CreateCollection(ctx context.Context, collection *models.BookmarkCollection) error
+	GetCollection(ctx context.Context, userID, id uint) (*models.BookmarkCollection, error)
+	ListCollections(ctx context.Context, userID uint) ([]*models.BookmarkCollection, error)
+	UpdateCollection(ctx context.Context, collection *models.BookmarkCollection) error
+	DeleteCollection(ctx context.Context, userID, id uint) error
+	Save(ctx context.Context, bookmark *models.Bookmark) error
+	Remove(ctx context.Context, userID uint, targetType string, targetID uint) error
+	List(ctx context.Context, userID uint, collectionID *uint, cursor uint, limit int) ([]*models.Bookmark, error)
+	GetCommentsByIDs(ctx context.Context, ids []uint, currentUserID uint) ([]*models.Comment, error)
+}
+
+type bookmarkRepository struct {
+	db *gorm.DB
+}
+
+// NewBookmarkRepository creates a new BookmarkRepository
+func NewBookmarkRepository(db *gorm.DB) BookmarkRepository {
+	return &bookmarkRepository{db: db}
+}
+
+func (r *bookmarkRepository) CreateCollection(ctx context.Context, collection *models.BookmarkCollection) error {
+	if err := r.db.WithContext(ctx).Create(collection).Error; err != nil {
+		if database.IsUniqueConstraintError(err) {
+			return models.NewValidationError("A collection with that name already exists")
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *bookmarkRepository) GetCollection(ctx context.Context, userID, id uint) (*models.BookmarkCollection, error) {
+	var collection models.BookmarkCollection
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&collection).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("Bookmark collection", id)
+		}
+		return nil, err
+	}
+	return &collection, nil
+}
+
+func (r *bookmarkRepository) ListCollections(ctx context.Context, userID uint) ([]*models.BookmarkCollection, error) {
+	var collections []*models.BookmarkCollection
+	err := r.db.WithContext(ctx).
+		Select("bookmark_collections.*, "+
+			"(SELECT COUNT(*) FROM bookmarks WHERE bookmarks.collection_id = bookmark_collections.id) as bookmarks_count").
+		Where("user_id = ?", userID).
+		Order("name ASC").
+		Find(&collections).Error
+	return collections, err
+}
+
+func (r *bookmarkRepository) UpdateCollection(ctx context.Context, collection *models.BookmarkCollection) error {
+	err := r.db.WithContext(ctx).
+		Model(collection).
+		Select("name", "updated_at").
+		Updates(collection).Error
+	if database.IsUniqueConstraintError(err) {
+		return models.NewValidationError("A collection with that name already exists")
+	}
+	return err
+}
+
+// DeleteCollection removes a collection. Its bookmarks are kept and become unsorted.
+func (r *bookmarkRepository) DeleteCollection(ctx context.Context, userID, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Bookmark{}).
+			Where("user_id = ? AND collection_id = ?", userID, id).
+			Update("collection_id", nil).Error; err != nil {
+			return err
+		}
+		res := tx.Where("id = ? AND user_id = ?", id, userID).Delete(&models.BookmarkCollection{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return models.NewNotFoundError("Bookmark collection", id)
+		}
+		return nil
+	})
+}
+
+// Save inserts a bookmark, or moves an existing bookmark of the same target
+// into the requested collection.
+func (r *bookmarkRepository) Save(ctx context.Context, bookmark *models.Bookmark) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "target_type"}, {Name: "target_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"collection_id"}),
+	}).Create(bookmark).Error
+}
+
+func (r *bookmarkRepository) Remove(ctx context.Context, userID uint, targetType string, targetID uint) error {
+	res := r.db.WithContext(ctx).
+		Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).
+		Delete(&models.Bookmark{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return models.NewNotFoundError("Bookmark", targetID)
+	}
+	return nil
+}
+
+// List returns bookmarks newest first. cursor is the ID of the last bookmark
+// from the previous page (0 for the first page).
+func (r *bookmarkRepository) List(ctx context.Context, userID uint, collectionID *uint, cursor uint, limit int) ([]*models.Bookmark, error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", userID)
+	if collectionID != nil {
+		query = query.Where("collection_id = ?", *collectionID)
+	}
+	if cursor != 0 {
+		query = query.Where("id < ?", cursor)
+	}
+
+	var bookmarks []*models.Bookmark
+	err := query.Order("id DESC").Limit(limit).Find(&bookmarks).Error
+	return bookmarks, err
+}
+
+// GetCommentsByIDs loads live (non-deleted) comments with their authors.
+// Comments the reader may not see are omitted: those removed by a moderator
+// (unless the reader wrote them) and those on posts GetByIDs would hide.
+func (r *bookmarkRepository) GetCommentsByIDs(ctx context.Context, ids []uint, currentUserID uint) ([]*models.Comment, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var comments []*models.Comment
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Joins("JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL").
+		Where("comments.id IN ?", ids).
+		Where("(comments.removed_at IS NULL OR comments.user_id = ?)", currentUserID).
+		Scopes(publishedOrAuthoredBy(currentUserID), readableBy(currentUserID)).
+		Find(&comments).Error
+	return comments, err
+}
@@ -22,6 +22,7 @@ type ChatRepository interface {
 	RemoveParticipant(ctx context.Context, convID, userID uint) error
 	CreateMessage(ctx context.Context, msg *models.Message) error
 	GetMessages(ctx context.Context, convID uint, limit, offset int) ([]*models.Message, error)
+	GetMessagesByCursor(ctx context.Context, convID uint, cursor MessageCursor, limit int) ([]*models.Message, error)
 	MarkMessageRead(ctx context.Context, msgID uint) error
 	UpdateLastRead(ctx context.Context, convID, userID uint) error
 	IsUserParticipant(ctx context.Context, conversationID, userID uint) (bool, error)
@@ -196,8 +197,9 @@ func (r *chatRepository) GetMessages(ctx context.Context, convID uint, limit, of
 		if readDB == nil {
 			readDB = r.db
 		}
+		// Side-thread replies are listed under their root, not the timeline.
 		query := readDB.WithContext(ctx).
-			Where("conversation_id = ?", convID).
+			Where("conversation_id = ? AND thread_root_id IS NULL", convID).
 			Preload("Sender")
 		if readDB.Migrator().HasTable(&models.MessageReaction{}) {
 			query = query.Preload("Reactions")
@@ -229,6 +231,67 @@ func (r *chatRepository) GetMessages(ctx context.Context, convID uint, limit, of
 	return messages, nil
 }
 
+// MessageCursor selects a window of a conversation's timeline relative to a
+// message ID. Exactly one field should be set.
+type MessageCursor struct {
+	Before uint // messages older than this ID
+	After  uint // messages newer than this ID
+	Around uint // this message with older and newer messages on either side
+}
+
+// GetMessagesByCursor returns up to limit timeline messages around a cursor,
+// oldest first. Unlike GetMessages it pages by message ID, so windows stay
+// stable while new messages arrive, and it always reads through to the
+// database.
+func (r *chatRepository) GetMessagesByCursor(ctx context.Context, convID uint, cursor MessageCursor, limit int) ([]*models.Message, error) {
+	start := time.Now()
+	defer func() {
+		observability.DatabaseQueryLatency.WithLabelValues("read", "messages").Observe(time.Since(start).Seconds())
+	}()
+	readDB := database.GetReadDB()
+	if readDB == nil {
+		readDB = r.db
+	}
+	page := func(cond string, id uint, order string, n int) ([]*models.Message, error) {
+		var messages []*models.Message
+		err := readDB.WithContext(ctx).
+			Where("conversation_id = ? AND thread_root_id IS NULL", convID).
+			Where(cond, id).
+			Preload("Sender").
+			Preload("Reactions").
+			Order(order).Limit(n).Find(&messages).Error
+		return messages, err
+	}
+
+	var older, newer []*models.Message
+	var err error
+	switch {
+	case cursor.Around != 0:
+		// The anchor counts towards the older half.
+		older, err = page("id <= ?", cursor.Around, "id DESC", limit-limit/2)
+		if err == nil && limit/2 > 0 {
+			newer, err = page("id > ?", cursor.Around, "id ASC", limit/2)
+		}
+	case cursor.After != 0:
+		newer, err = page("id > ?", cursor.After, "id ASC", limit)
+	default:
+		older, err = page("id < ?", cursor.Before, "id DESC", limit)
+	}
+	if err != nil {
+		r.logger.LogError(ctx, err, "get_messages_by_cursor")
+		return nil, err
+	}
+
+	messages := make([]*models.Message, 0, len(older)+len(newer))
+	for i := len(older) - 1; i >= 0; i-- {
+		messages = append(messages, older[i])
+	}
+	messages = append(messages, newer...)
+
+	r.logger.LogRead(ctx, map[string]interface{}{"conversation_id": convID, "count": len(messages)})
+	return messages, nil
+}
+
 func (r *chatRepository) MarkMessageRead(ctx context.Context, msgID uint) error {
 	start := time.Now()
 	err := r.db.WithContext(ctx).Model(&models.Message{}).Where("id = ?", msgID).Update("is_read", true).Error
@@ -79,4 +79,35 @@ func TestChatRepository_Integration(t *testing.T) {
 		assert.Equal(t, 1, len(msgs))
 		assert.Equal(t, "Msg 1", msgs[0].Content)
 	})
+
+	t.Run("GetMessagesByCursor", func(t *testing.T) {
+		conv := &models.Conversation{CreatedBy: user1.ID}
+		testDB.Create(conv)
+
+		ids := make([]uint, 0, 7)
+		for i := 1; i <= 7; i++ {
+			msg := &models.Message{ConversationID: conv.ID, SenderID: user1.ID, Content: fmt.Sprintf("Msg %d", i)}
+			testDB.Create(msg)
+			ids = append(ids, msg.ID)
+		}
+		collect := func(msgs []*models.Message) []uint {
+			out := make([]uint, 0, len(msgs))
+			for _, m := range msgs {
+				out = append(out, m.ID)
+			}
+			return out
+		}
+
+		msgs, err := repo.GetMessagesByCursor(ctx, conv.ID, MessageCursor{Before: ids[4]}, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, ids[2:4], collect(msgs))
+
+		msgs, err = repo.GetMessagesByCursor(ctx, conv.ID, MessageCursor{After: ids[4]}, 10)
+		assert.NoError(t, err)
+		assert.Equal(t, ids[5:], collect(msgs))
+
+		msgs, err = repo.GetMessagesByCursor(ctx, conv.ID, MessageCursor{Around: ids[3]}, 4)
+		assert.NoError(t, err)
+		assert.Equal(t, ids[2:6], collect(msgs))
+	})
 }
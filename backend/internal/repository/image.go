@@ -9,6 +9,7 @@ import (
 	"sanctum/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
@@ -30,6 +31,9 @@ type ImageRepository interface {
 	MarkReady(ctx context.Context, imageID uint) error
 	MarkFailed(ctx context.Context, imageID uint, errMsg string) error
 	RequeueStaleProcessing(ctx context.Context, olderThan time.Duration) (int64, error)
+	GetByHashes(ctx context.Context, hashes []string) ([]models.Image, error)
+	MarkReleased(ctx context.Context, hashes []string) error
+	DeleteReleased(ctx context.Context, releasedBefore time.Time, limit int) ([]models.Image, error)
 }
 
 type imageRepository struct {
@@ -190,3 +194,111 @@ func (r *imageRepository) RequeueStaleProcessing(ctx context.Context, olderThan
 		})
 	return res.RowsAffected, res.Error
 }
+
+// GetByHashes loads images (with variants) for the given hashes; unknown hashes are omitted.
+func (r *imageRepository) GetByHashes(ctx context.Context, hashes []string) ([]models.Image, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	var images []models.Image
+	err := r.db.WithContext(ctx).
+		Preload("Variants").
+		Where("hash IN ?", hashes).
+		Find(&images).Error
+	return images, err
+}
+
+// MarkReleased stamps those of the given images that no live post references
+// (ref_count = 0) as released, queueing them for DeleteReleased.
+func (r *imageRepository) MarkReleased(ctx context.Context, hashes []string) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&models.Image{}).
+		Where("hash IN ? AND ref_count <= 0", hashes).
+		Update("released_at", time.Now().UTC()).Error
+}
+
+// DeleteReleased removes up to limit images released by releasedBefore
+// that no live post references and that are not used as an avatar, a sanctum
+// icon or banner, in chat message metadata, or as a link preview image. It
+// returns the removed rows, with variants, so the caller can delete their
+// files. Released images found still in use are unmarked. The usage check and
+// the delete run in one transaction, with one usage query per batch.
+func (r *imageRepository) DeleteReleased(ctx context.Context, releasedBefore time.Time, limit int) ([]models.Image, error) {
+	var removed []models.Image
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Preload("Variants").
+			Where("released_at IS NOT NULL AND released_at <= ? AND ref_count <= 0", releasedBefore).
+			Order("released_at").
+			Limit(limit)
+		if tx.Name() == "postgres" {
+			// Concurrent sweeps take disjoint batches; reference count
+			// updates wait for this one to finish.
+			query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+		}
+		var candidates []models.Image
+		if err := query.Find(&candidates).Error; err != nil {
+			return err
+		}
+		if len(candidates) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(candidates))
+		for i, img := range candidates {
+			ids[i] = img.ID
+		}
+
+		var usedIDs []uint
+		if err := tx.Raw(imagesUsedOutsidePostsSQL, ids).Scan(&usedIDs).Error; err != nil {
+			return err
+		}
+		if len(usedIDs) > 0 {
+			if err := tx.Model(&models.Image{}).Where("id IN ?", usedIDs).Update("released_at", nil).Error; err != nil {
+				return err
+			}
+		}
+		used := make(map[uint]bool, len(usedIDs))
+		for _, id := range usedIDs {
+			used[id] = true
+		}
+
+		for _, img := range candidates {
+			if used[img.ID] {
+				continue
+			}
+			// Guarded on ref_count so a reference added meanwhile wins.
+			res := tx.Where("id = ? AND ref_count <= 0", img.ID).Delete(&models.Image{})
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				continue
+			}
+			if err := tx.Where("image_id = ?", img.ID).Delete(&models.ImageVariant{}).Error; err != nil {
+				return err
+			}
+			removed = append(removed, img)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// imagesUsedOutsidePostsSQL returns those of the given image IDs whose hash
+// is still used outside post image references.
+const imagesUsedOutsidePostsSQL = `
+SELECT i.id
+FROM images i
+WHERE i.id IN ?
+AND (
+	EXISTS (SELECT 1 FROM users WHERE avatar LIKE '%' || i.hash || '%')
+	OR EXISTS (SELECT 1 FROM conversations WHERE avatar LIKE '%' || i.hash || '%')
+	OR EXISTS (SELECT 1 FROM sanctums WHERE icon_hash = i.hash OR banner_hash = i.hash)
+	OR EXISTS (SELECT 1 FROM messages WHERE CAST(metadata AS TEXT) LIKE '%' || i.hash || '%' OR CAST(link_preview AS TEXT) LIKE '%' || i.hash || '%')
+	OR EXISTS (SELECT 1 FROM posts WHERE CAST(link_preview AS TEXT) LIKE '%' || i.hash || '%')
+)
+`
@@ -3,16 +3,22 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"sanctum/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // PollRepository defines the interface for poll data operations.
 type PollRepository interface {
-	Create(ctx context.Context, postID uint, question string, options []string) (*models.Poll, error)
-	Vote(ctx context.Context, userID, pollID, pollOptionID uint) error
+	Create(ctx context.Context, postID uint, question string, options []string, settings models.PollSettings) (*models.Poll, error)
+	// SetVotes replaces the user's selections on a poll; an empty set retracts
+	// the vote. The poll row is locked while the selections are checked against
+	// its current settings and written.
+	SetVotes(ctx context.Context, userID, pollID uint, optionIDs []uint) error
 	EnrichWithResults(ctx context.Context, poll *models.Poll, currentUserID uint) error
 }
 
@@ -25,10 +31,14 @@ func NewPollRepository(db *gorm.DB) PollRepository {
 	return &pollRepository{db: db}
 }
 
-func (r *pollRepository) Create(ctx context.Context, postID uint, question string, options []string) (*models.Poll, error) {
+func (r *pollRepository) Create(ctx context.Context, postID uint, question string, options []string, settings models.PollSettings) (*models.Poll, error) {
+	if settings.MaxSelections < 1 {
+		settings.MaxSelections = 1
+	}
 	poll := &models.Poll{
-		PostID:   postID,
-		Question: question,
+		PostID:       postID,
+		Question:     question,
+		PollSettings: settings,
 	}
 
 	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
@@ -59,34 +69,102 @@ func (r *pollRepository) Create(ctx context.Context, postID uint, question strin
 	return poll, nil
 }
 
-func (r *pollRepository) Vote(ctx context.Context, userID, pollID, pollOptionID uint) error {
-	// Upsert: one vote per user per poll. Use raw SQL for ON CONFLICT.
-	return r.db.WithContext(ctx).Exec(
-		`INSERT INTO poll_votes (user_id, poll_id, poll_option_id, created_at)
-		 VALUES (?, ?, ?, NOW())
-		 ON CONFLICT (user_id, poll_id) DO UPDATE SET poll_option_id = ?`,
-		userID, pollID, pollOptionID, pollOptionID,
-	).Error
+func (r *pollRepository) SetVotes(ctx context.Context, userID, pollID uint, optionIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var poll models.Poll
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&poll, pollID).Error; err != nil {
+			return err
+		}
+		if poll.IsClosed(time.Now()) {
+			return models.NewValidationError("This poll is closed")
+		}
+		maxSelections := poll.MaxSelections
+		if maxSelections < 1 {
+			maxSelections = 1
+		}
+		if len(optionIDs) > maxSelections {
+			return models.NewValidationError(fmt.Sprintf("You can select at most %d option(s)", maxSelections))
+		}
+		if len(optionIDs) > 0 {
+			var valid int64
+			if err := tx.Model(&models.PollOption{}).
+				Where("poll_id = ? AND id IN ?", pollID, optionIDs).
+				Count(&valid).Error; err != nil {
+				return err
+			}
+			if int(valid) != len(optionIDs) {
+				return models.NewValidationError("Invalid poll option")
+			}
+		}
+		if err := tx.Where("poll_id = ? AND user_id = ?", pollID, userID).
+			Delete(&models.PollVote{}).Error; err != nil {
+			return err
+		}
+		if len(optionIDs) == 0 {
+			return nil
+		}
+		votes := make([]models.PollVote, 0, len(optionIDs))
+		for _, optionID := range optionIDs {
+			votes = append(votes, models.PollVote{UserID: userID, PollID: pollID, PollOptionID: optionID})
+		}
+		return tx.Create(&votes).Error
+	})
 }
 
 func (r *pollRepository) EnrichWithResults(ctx context.Context, poll *models.Poll, currentUserID uint) error {
 	if poll == nil || len(poll.Options) == 0 {
 		return nil
 	}
-	for i := range poll.Options {
-		var count int64
+	var counts []struct {
+		PollOptionID uint
+		Count        int
+	}
+	if err := r.db.WithContext(ctx).Model(&models.PollVote{}).
+		Select("poll_option_id, COUNT(*) AS count").
+		Where("poll_id = ?", poll.ID).
+		Group("poll_option_id").
+		Scan(&counts).Error; err != nil {
+		return err
+	}
+	byOption := make(map[uint]int, len(counts))
+	for _, c := range counts {
+		byOption[c.PollOptionID] = c.Count
+	}
+
+	var voters int64
+	if err := r.db.WithContext(ctx).Model(&models.PollVote{}).
+		Where("poll_id = ?", poll.ID).
+		Distinct("user_id").
+		Count(&voters).Error; err != nil {
+		return err
+	}
+	poll.TotalVoters = int(voters)
+	poll.Closed = poll.IsClosed(time.Now())
+
+	poll.UserVoteOptionID = nil
+	poll.UserVoteOptionIDs = nil
+	if currentUserID != 0 {
+		var optionIDs []uint
 		if err := r.db.WithContext(ctx).Model(&models.PollVote{}).
-			Where("poll_option_id = ?", poll.Options[i].ID).
-			Count(&count).Error; err != nil {
+			Where("poll_id = ? AND user_id = ?", poll.ID, currentUserID).
+			Order("poll_option_id").
+			Pluck("poll_option_id", &optionIDs).Error; err != nil {
 			return err
 		}
-		poll.Options[i].VotesCount = int(count)
+		if len(optionIDs) > 0 {
+			poll.UserVoteOptionIDs = optionIDs
+			poll.UserVoteOptionID = &optionIDs[0]
+		}
 	}
-	if currentUserID != 0 {
-		var vote models.PollVote
-		if err := r.db.WithContext(ctx).Where("poll_id = ? AND user_id = ?", poll.ID, currentUserID).First(&vote).Error; err == nil {
-			poll.UserVoteOptionID = &vote.PollOptionID
+
+	// Hidden polls reveal counts only once the viewer has voted or voting ends.
+	poll.ResultsHidden = poll.HideResults && !poll.Closed && len(poll.UserVoteOptionIDs) == 0
+	for i := range poll.Options {
+		if poll.ResultsHidden {
+			poll.Options[i].VotesCount = 0
+			continue
 		}
+		poll.Options[i].VotesCount = byOption[poll.Options[i].ID]
 	}
 	return nil
 }
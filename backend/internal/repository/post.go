@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"sanctum/internal/cache"
 	"sanctum/internal/models"
@@ -16,16 +17,27 @@ import (
 type PostRepository interface {
 	Create(ctx context.Context, post *models.Post) error
 	GetByID(ctx context.Context, id uint, currentUserID uint) (*models.Post, error)
+	GetByIDs(ctx context.Context, ids []uint, currentUserID uint) ([]*models.Post, error)
 	GetByUserID(ctx context.Context, userID uint, limit, offset int, currentUserID uint) ([]*models.Post, error)
-	GetBySanctumID(ctx context.Context, sanctumID uint, limit, offset int, currentUserID uint) ([]*models.Post, error)
+	GetBySanctumID(ctx context.Context, sanctumID uint, filter SanctumFeedFilter, limit, offset int, currentUserID uint) ([]*models.Post, error)
 	List(ctx context.Context, limit, offset int, currentUserID uint) ([]*models.Post, error)
 	Search(ctx context.Context, query string, limit, offset int, currentUserID uint) ([]*models.Post, error)
-	Update(ctx context.Context, post *models.Post) error
+	Update(ctx context.Context, post *models.Post, images []models.PostImage) error
+	ReplaceTags(ctx context.Context, postID uint, tags []string) error
 	Delete(ctx context.Context, id uint) error
 	IsLiked(ctx context.Context, userID, postID uint) (bool, error)
 	GetLikedPostIDs(ctx context.Context, userID uint, postIDs []uint) ([]uint, error)
 	Like(ctx context.Context, userID, postID uint) error
 	Unlike(ctx context.Context, userID, postID uint) error
+	ListDrafts(ctx context.Context, userID uint, limit, offset int) ([]*models.Post, error)
+	PublishDue(ctx context.Context, now time.Time, limit int) ([]*models.Post, error)
+	Publish(ctx context.Context, postID uint, now time.Time) (bool, error)
+}
+
+// SanctumFeedFilter narrows a sanctum feed. Zero values match every post.
+type SanctumFeedFilter struct {
+	FlairID uint
+	Tag     string
 }
 
 // postRepository implements PostRepository
@@ -39,7 +51,12 @@ func NewPostRepository(db *gorm.DB) PostRepository {
 }
 
 func (r *postRepository) Create(ctx context.Context, post *models.Post) error {
-	err := r.db.WithContext(ctx).Create(post).Error
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(post).Error; err != nil {
+			return err
+		}
+		return adjustImageRefs(tx, postImageHashes(post), 1)
+	})
 	if err == nil {
 		cache.InvalidatePostsList(ctx)
 	}
@@ -77,6 +94,31 @@ func (r *postRepository) GetByID(ctx context.Context, id uint, currentUserID uin
 	return &post, nil
 }
 
+// GetByIDs loads live posts by ID with the same enrichment as the feed
+// queries. Deleted IDs, posts in private sanctums the reader has left and
+// drafts or removed posts of other authors are silently omitted; the result
+// is unordered.
+func (r *postRepository) GetByIDs(ctx context.Context, ids []uint, currentUserID uint) ([]*models.Post, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var posts []*models.Post
+	err := r.applyPostDetails(r.db.WithContext(ctx), currentUserID).
+		Preload("User").
+		Preload("Poll").
+		Preload("Poll.Options").
+		Where("posts.id IN ?", ids).
+		Scopes(publishedOrAuthoredBy(currentUserID), readableBy(currentUserID)).
+		Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	if enrichErr := r.enrichImageMetadata(ctx, posts); enrichErr != nil {
+		return nil, enrichErr
+	}
+	return posts, nil
+}
+
 func (r *postRepository) GetByUserID(ctx context.Context, userID uint, limit, offset int, currentUserID uint) ([]*models.Post, error) {
 	var posts []*models.Post
 	err := r.applyPostDetails(r.db.WithContext(ctx), currentUserID).
@@ -84,6 +126,7 @@ func (r *postRepository) GetByUserID(ctx context.Context, userID uint, limit, of
 		Preload("Poll").
 		Preload("Poll.Options").
 		Where("user_id = ?", userID).
+		Scopes(publishedOnly, readableBy(currentUserID)).
 		Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -97,14 +140,23 @@ func (r *postRepository) GetByUserID(ctx context.Context, userID uint, limit, of
 	return posts, err
 }
 
-func (r *postRepository) GetBySanctumID(ctx context.Context, sanctumID uint, limit, offset int, currentUserID uint) ([]*models.Post, error) {
+func (r *postRepository) GetBySanctumID(ctx context.Context, sanctumID uint, filter SanctumFeedFilter, limit, offset int, currentUserID uint) ([]*models.Post, error) {
 	var posts []*models.Post
-	err := r.applyPostDetails(r.db.WithContext(ctx), currentUserID).
+	query := r.applyPostDetails(r.db.WithContext(ctx), currentUserID).
 		Preload("User").
 		Preload("Poll").
 		Preload("Poll.Options").
-		Where("sanctum_id = ?", sanctumID).
-		Order("created_at DESC").
+		Where("sanctum_id = ?", sanctumID)
+	if filter.FlairID != 0 {
+		query = query.Where("posts.flair_id = ?", filter.FlairID)
+	}
+	if filter.Tag != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM post_tags WHERE post_tags.post_id = posts.id AND post_tags.tag = ?)", filter.Tag)
+	}
+	err := query.
+		Scopes(publishedOnly).
+		// Pinned posts lead the feed, most recently pinned first.
+		Order("posts.pinned_at IS NULL, posts.pinned_at DESC, posts.created_at DESC").
 		Limit(limit).
 		Offset(offset).
 		Find(&posts).Error
@@ -123,6 +175,7 @@ func (r *postRepository) List(ctx context.Context, limit, offset int, currentUse
 		Preload("User").
 		Preload("Poll").
 		Preload("Poll.Options").
+		Scopes(publishedOnly, readableBy(currentUserID)).
 		Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -144,6 +197,7 @@ func (r *postRepository) Search(ctx context.Context, query string, limit, offset
 		Preload("Poll").
 		Preload("Poll.Options").
 		Where("title ILIKE ? OR content ILIKE ?", like, like).
+		Scopes(publishedOnly, readableBy(currentUserID)).
 		Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -157,8 +211,162 @@ func (r *postRepository) Search(ctx context.Context, query string, limit, offset
 	return posts, nil
 }
 
-// applyPostDetails adds subqueries to fetch counts and liked status in a single query.
+// ListDrafts returns the author's unpublished (draft and scheduled) posts, most recently edited first.
+func (r *postRepository) ListDrafts(ctx context.Context, userID uint, limit, offset int) ([]*models.Post, error) {
+	var posts []*models.Post
+	err := r.applyPostDetails(r.db.WithContext(ctx), userID).
+		Preload("User").
+		Preload("Poll").
+		Preload("Poll.Options").
+		Where("user_id = ? AND status IN ?", userID, []string{models.PostStatusDraft, models.PostStatusScheduled}).
+		Order("updated_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&posts).Error
+	if err != nil {
+		return nil, err
+	}
+	if enrichErr := r.enrichImageMetadata(ctx, posts); enrichErr != nil {
+		return nil, enrichErr
+	}
+	return posts, nil
+}
+
+// PublishDue flips scheduled posts whose publish_at has passed to published and
+// returns them. The status transition is a single guarded UPDATE, so a post is
+// published exactly once even when several replicas run the scheduler; on
+// Postgres SKIP LOCKED keeps concurrent schedulers from blocking each other.
+// created_at is moved to publish_at so the post lands in feeds at its
+// scheduled time rather than when it was drafted.
+func (r *postRepository) PublishDue(ctx context.Context, now time.Time, limit int) ([]*models.Post, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	var published []*models.Post
+	if r.db.Name() == "postgres" {
+		err := r.db.WithContext(ctx).Raw(`
+WITH due AS (
+	SELECT id
+	FROM posts
+	WHERE status = ? AND publish_at <= ? AND deleted_at IS NULL
+	ORDER BY publish_at
+	FOR UPDATE SKIP LOCKED
+	LIMIT ?
+)
+UPDATE posts p
+SET status = ?,
+    created_at = p.publish_at,
+    updated_at = NOW()
+FROM due
+WHERE p.id = due.id
+RETURNING p.*
+`, models.PostStatusScheduled, now, limit, models.PostStatusPublished).Scan(&published).Error
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// SQLite/test fallback (best-effort atomicity).
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var due []*models.Post
+			if err := tx.Where("status = ? AND publish_at <= ?", models.PostStatusScheduled, now).
+				Order("publish_at ASC").
+				Limit(limit).
+				Find(&due).Error; err != nil {
+				return err
+			}
+			for _, p := range due {
+				res := tx.Model(&models.Post{}).
+					Where("id = ? AND status = ?", p.ID, models.PostStatusScheduled).
+					Updates(map[string]interface{}{
+						"status":     models.PostStatusPublished,
+						"created_at": p.PublishAt,
+						"updated_at": time.Now().UTC(),
+					})
+				if res.Error != nil {
+					return res.Error
+				}
+				if res.RowsAffected == 0 {
+					continue
+				}
+				p.Status = models.PostStatusPublished
+				p.CreatedAt = *p.PublishAt
+				published = append(published, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(published) == 0 {
+		return nil, nil
+	}
+	for _, p := range published {
+		cache.Invalidate(ctx, cache.PostKey(p.ID))
+	}
+	cache.InvalidatePostsList(ctx)
+	return published, nil
+}
+
+// Publish flips a draft or scheduled post to published at now. Like
+// PublishDue it is a single guarded UPDATE, so a post racing the scheduler
+// is published once; it reports whether this call published it.
+func (r *postRepository) Publish(ctx context.Context, postID uint, now time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&models.Post{}).
+		Where("id = ? AND status IN ?", postID, []string{models.PostStatusDraft, models.PostStatusScheduled}).
+		Updates(map[string]interface{}{
+			"status":     models.PostStatusPublished,
+			"publish_at": nil,
+			"created_at": now,
+			"updated_at": now,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+	cache.Invalidate(ctx, cache.PostKey(postID))
+	cache.InvalidatePostsList(ctx)
+	return true, nil
+}
+
+// publishedOnly restricts a post query to posts visible in public listings:
+// published and not removed by a moderator.
+func publishedOnly(db *gorm.DB) *gorm.DB {
+	return db.Where("posts.status = ? AND posts.removed_at IS NULL", models.PostStatusPublished)
+}
+
+// publishedOrAuthoredBy is publishedOnly, except that userID also sees their
+// own drafts, scheduled and removed posts.
+func publishedOrAuthoredBy(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("((posts.status = ? AND posts.removed_at IS NULL) OR posts.user_id = ?)",
+			models.PostStatusPublished, userID)
+	}
+}
+
+// readableBy hides posts in private sanctums from users who are not members
+// of them. Anonymous readers (userID 0) see public and restricted sanctums only.
+func readableBy(userID uint) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(posts.sanctum_id IS NULL"+
+			" OR NOT EXISTS (SELECT 1 FROM sanctums WHERE sanctums.id = posts.sanctum_id AND sanctums.visibility = ?)"+
+			" OR EXISTS (SELECT 1 FROM sanctum_memberships WHERE sanctum_memberships.sanctum_id = posts.sanctum_id AND sanctum_memberships.user_id = ?))",
+			models.SanctumVisibilityPrivate, userID)
+	}
+}
+
+// applyPostDetails adds subqueries to fetch counts and liked status in a single query,
+// and preloads gallery items in display order along with flair and tags.
 func (r *postRepository) applyPostDetails(db *gorm.DB, currentUserID uint) *gorm.DB {
+	db = db.Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order("post_images.position ASC")
+	}).Preload("Flair").Preload("Tags", func(db *gorm.DB) *gorm.DB {
+		return db.Order("post_tags.tag ASC")
+	})
 	selectQuery := "posts.*, " +
 		"(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL) as comments_count, " +
 		"(SELECT COUNT(*) FROM likes WHERE likes.post_id = posts.id) as likes_count"
@@ -177,17 +385,23 @@ func (r *postRepository) enrichImageMetadata(ctx context.Context, posts []*model
 
 	hashes := make([]string, 0, len(posts))
 	seen := map[string]struct{}{}
-	for _, p := range posts {
-		h := strings.TrimSpace(p.ImageHash)
+	addHash := func(h string) {
+		h = strings.TrimSpace(h)
 		if h == "" {
-			continue
+			return
 		}
 		if _, exists := seen[h]; exists {
-			continue
+			return
 		}
 		seen[h] = struct{}{}
 		hashes = append(hashes, h)
 	}
+	for _, p := range posts {
+		addHash(p.ImageHash)
+		for _, item := range p.Images {
+			addHash(item.ImageHash)
+		}
+	}
 	if len(hashes) == 0 {
 		return nil
 	}
@@ -206,34 +420,149 @@ func (r *postRepository) enrichImageMetadata(ctx context.Context, posts []*model
 	}
 
 	for _, p := range posts {
-		img := byHash[p.ImageHash]
-		if img == nil {
-			continue
+		if img := byHash[p.ImageHash]; img != nil {
+			p.ImageCropMode = img.CropMode
+			p.ImageVariants = imageVariantURLs(img)
 		}
-		p.ImageCropMode = img.CropMode
-		if len(img.Variants) == 0 {
-			continue
+		for i := range p.Images {
+			item := &p.Images[i]
+			item.ImageURL = fmt.Sprintf("/media/i/%s/master.jpg", item.ImageHash)
+			if img := byHash[item.ImageHash]; img != nil {
+				item.ImageCropMode = img.CropMode
+				item.ImageVariants = imageVariantURLs(img)
+			}
 		}
-		variants := make(map[string]string, len(img.Variants))
-		for _, v := range img.Variants {
-			key := fmt.Sprintf("%d_%s", v.SizePx, v.Format)
-			variants[key] = fmt.Sprintf("/media/i/%s/%d.%s", img.Hash, v.SizePx, v.Format)
-		}
-		p.ImageVariants = variants
 	}
 	return nil
 }
 
-func (r *postRepository) Update(ctx context.Context, post *models.Post) error {
-	if err := r.db.WithContext(ctx).Save(post).Error; err != nil {
+func imageVariantURLs(img *models.Image) map[string]string {
+	if len(img.Variants) == 0 {
+		return nil
+	}
+	variants := make(map[string]string, len(img.Variants))
+	for _, v := range img.Variants {
+		key := fmt.Sprintf("%d_%s", v.SizePx, v.Format)
+		variants[key] = fmt.Sprintf("/media/i/%s/%d.%s", img.Hash, v.SizePx, v.Format)
+	}
+	return variants
+}
+
+// authorEditableColumns are the post columns an author's edit writes.
+// Moderation state (removal, pins, locks, review) is left alone so an edit
+// made from a stale copy of the post cannot undo a moderator's action.
+var authorEditableColumns = []string{
+	"title", "content", "image_url", "image_hash", "link_url", "youtube_url", "flair_id",
+}
+
+// Update saves an author's edit. A non-nil images replaces the gallery items
+// in the same transaction. Scheduling only applies while the post is still
+// unpublished, so a concurrent publish is not reverted.
+func (r *postRepository) Update(ctx context.Context, post *models.Post, images []models.PostImage) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var previous models.Post
+		if err := tx.Select("id", "image_hash").First(&previous, post.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Post{ID: post.ID}).Select(authorEditableColumns).Updates(post).Error; err != nil {
+			return err
+		}
+		if post.Status == models.PostStatusScheduled {
+			if err := tx.Model(&models.Post{}).
+				Where("id = ? AND status IN ?", post.ID, []string{models.PostStatusDraft, models.PostStatusScheduled}).
+				Updates(map[string]interface{}{"status": post.Status, "publish_at": post.PublishAt}).Error; err != nil {
+				return err
+			}
+		}
+		if images != nil {
+			if err := replaceImages(tx, post.ID, images); err != nil {
+				return err
+			}
+		}
+		if previous.ImageHash == post.ImageHash {
+			return nil
+		}
+		if err := adjustImageRefs(tx, []string{previous.ImageHash}, -1); err != nil {
+			return err
+		}
+		return adjustImageRefs(tx, []string{post.ImageHash}, 1)
+	})
+	if err != nil {
 		return err
 	}
 	cache.Invalidate(ctx, cache.PostKey(post.ID))
+	if images != nil {
+		cache.InvalidatePostsList(ctx)
+	}
+	return nil
+}
+
+// replaceImages swaps a gallery's items for images (in order) and moves image
+// reference counts from the old items to the new ones.
+func replaceImages(tx *gorm.DB, postID uint, images []models.PostImage) error {
+	var old []string
+	if err := tx.Model(&models.PostImage{}).Where("post_id = ?", postID).Pluck("image_hash", &old).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("post_id = ?", postID).Delete(&models.PostImage{}).Error; err != nil {
+		return err
+	}
+	if len(images) > 0 {
+		for i := range images {
+			images[i].ID = 0
+			images[i].PostID = postID
+		}
+		if err := tx.Create(&images).Error; err != nil {
+			return err
+		}
+	}
+	newHashes := make([]string, 0, len(images))
+	for _, img := range images {
+		newHashes = append(newHashes, img.ImageHash)
+	}
+	if err := adjustImageRefs(tx, old, -1); err != nil {
+		return err
+	}
+	return adjustImageRefs(tx, newHashes, 1)
+}
+
+// ReplaceTags swaps a post's tags for tags.
+func (r *postRepository) ReplaceTags(ctx context.Context, postID uint, tags []string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("post_id = ?", postID).Delete(&models.PostTag{}).Error; err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+		rows := make([]models.PostTag, 0, len(tags))
+		for _, tag := range tags {
+			rows = append(rows, models.PostTag{PostID: postID, Tag: tag})
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return err
+	}
+	cache.Invalidate(ctx, cache.PostKey(postID))
+	cache.InvalidatePostsList(ctx)
 	return nil
 }
 
+// Delete soft-deletes a post and releases its image references. Gallery rows
+// are kept with the post so the references are not counted twice.
 func (r *postRepository) Delete(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&models.Post{}, id).Error; err != nil {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var post models.Post
+		if err := tx.Preload("Images").First(&post, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Post{}, id).Error; err != nil {
+			return err
+		}
+		return adjustImageRefs(tx, postImageHashes(&post), -1)
+	})
+	if err != nil {
 		return err
 	}
 	cache.Invalidate(ctx, cache.PostKey(id))
@@ -241,6 +570,39 @@ func (r *postRepository) Delete(ctx context.Context, id uint) error {
 	return nil
 }
 
+// postImageHashes lists every image a post references, once per reference.
+func postImageHashes(post *models.Post) []string {
+	hashes := make([]string, 0, len(post.Images)+1)
+	if post.ImageHash != "" {
+		hashes = append(hashes, post.ImageHash)
+	}
+	for _, img := range post.Images {
+		hashes = append(hashes, img.ImageHash)
+	}
+	return hashes
+}
+
+// adjustImageRefs adds delta to the reference count of each hash (repeated
+// hashes count repeatedly). Counts never drop below zero.
+func adjustImageRefs(tx *gorm.DB, hashes []string, delta int) error {
+	counts := make(map[string]int, len(hashes))
+	for _, h := range hashes {
+		if h = strings.TrimSpace(h); h != "" {
+			counts[h]++
+		}
+	}
+	for hash, n := range counts {
+		change := delta * n
+		if err := tx.Model(&models.Image{}).
+			Where("hash = ?", hash).
+			UpdateColumn("ref_count", gorm.Expr(
+				"CASE WHEN ref_count + ? < 0 THEN 0 ELSE ref_count + ? END", change, change)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *postRepository) IsLiked(ctx context.Context, userID, postID uint) (bool, error) {
 	var count int64
 	if err := r.db.WithContext(ctx).
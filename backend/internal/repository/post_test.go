@@ -74,4 +74,86 @@ func TestPostRepository_Integration(t *testing.T) {
 		assert.NoError(t, err)
 		assert.GreaterOrEqual(t, len(all), 2)
 	})
+
+	t.Run("Drafts are hidden and scheduled posts publish once", func(t *testing.T) {
+		publishAt := time.Now().Add(-time.Minute).UTC()
+		scheduled := &models.Post{
+			Title:     "Scheduled Post",
+			Content:   "Soon",
+			UserID:    user.ID,
+			Status:    models.PostStatusScheduled,
+			PublishAt: &publishAt,
+		}
+		require.NoError(t, repo.Create(ctx, scheduled))
+
+		listed, err := repo.GetByUserID(ctx, user.ID, 100, 0, user.ID)
+		require.NoError(t, err)
+		for _, p := range listed {
+			assert.NotEqual(t, scheduled.ID, p.ID)
+		}
+
+		drafts, err := repo.ListDrafts(ctx, user.ID, 10, 0)
+		require.NoError(t, err)
+		require.NotEmpty(t, drafts)
+
+		published, err := repo.PublishDue(ctx, time.Now().UTC(), 100)
+		require.NoError(t, err)
+		var found bool
+		for _, p := range published {
+			if p.ID == scheduled.ID {
+				found = true
+				assert.Equal(t, models.PostStatusPublished, p.Status)
+			}
+		}
+		assert.True(t, found)
+
+		again, err := repo.PublishDue(ctx, time.Now().UTC(), 100)
+		require.NoError(t, err)
+		for _, p := range again {
+			assert.NotEqual(t, scheduled.ID, p.ID)
+		}
+
+		// A manual publish racing the scheduler loses.
+		ok, err := repo.Publish(ctx, scheduled.ID, time.Now().UTC())
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		draft := &models.Post{Title: "Draft", Content: "WIP", UserID: user.ID, Status: models.PostStatusDraft}
+		require.NoError(t, repo.Create(ctx, draft))
+		ok, err = repo.Publish(ctx, draft.ID, time.Now().UTC())
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = repo.Publish(ctx, draft.ID, time.Now().UTC())
+		require.NoError(t, err)
+		assert.False(t, ok, "a post is published once")
+	})
+
+	t.Run("Author edits keep moderation state", func(t *testing.T) {
+		post := &models.Post{Title: "Before", Content: "x", UserID: user.ID}
+		require.NoError(t, repo.Create(ctx, post))
+		stale, err := repo.GetByID(ctx, post.ID, user.ID)
+		require.NoError(t, err)
+
+		// A moderator removes and locks the post after the author loaded it.
+		removedAt := time.Now().UTC()
+		require.NoError(t, testDB.Model(&models.Post{}).Where("id = ?", post.ID).
+			Updates(map[string]interface{}{"removed_at": removedAt, "locked": true}).Error)
+
+		stale.Title = "After"
+		require.NoError(t, repo.Update(ctx, stale, nil))
+
+		var stored models.Post
+		require.NoError(t, testDB.First(&stored, post.ID).Error)
+		assert.Equal(t, "After", stored.Title)
+		assert.NotNil(t, stored.RemovedAt)
+		assert.True(t, stored.Locked)
+
+		// Scheduling a post that was published meanwhile leaves it published.
+		publishAt := time.Now().Add(time.Hour).UTC()
+		stale.Status, stale.PublishAt = models.PostStatusScheduled, &publishAt
+		require.NoError(t, repo.Update(ctx, stale, nil))
+		require.NoError(t, testDB.First(&stored, post.ID).Error)
+		assert.Equal(t, models.PostStatusPublished, stored.Status)
+		assert.Nil(t, stored.PublishAt)
+	})
 }
@@ -14,27 +14,28 @@ type BuiltInSanctum struct {
 	Name        string
 	Slug        string
 	Description string
+	Category    models.SanctumCategory
 }
 
 // BuiltInSanctums defines the permanent system sanctums.
 var BuiltInSanctums = []BuiltInSanctum{
-	{Name: "Atrium", Slug: "atrium", Description: "General community discussions."},
-	{Name: "General", Slug: "general", Description: "Core discussion for Sanctum."},
-	{Name: "Herald Announcements", Slug: "herald", Description: "Announcements and platform updates."},
-	{Name: "Support", Slug: "support", Description: "Help and troubleshooting."},
-	{Name: "Movies", Slug: "movies", Description: "Film discussion and recommendations."},
-	{Name: "Television", Slug: "television", Description: "TV shows and series conversation."},
-	{Name: "Books", Slug: "books", Description: "Books, writing, and reading lists."},
-	{Name: "Music", Slug: "music", Description: "Music discovery and discussion."},
-	{Name: "Anime", Slug: "anime", Description: "Anime and manga talk."},
-	{Name: "Gaming", Slug: "gaming", Description: "Gaming across all platforms."},
-	{Name: "Pcgaming", Slug: "pcgaming", Description: "PC gaming hardware and titles."},
-	{Name: "Development", Slug: "development", Description: "Software development discussions."},
-	{Name: "Hardware", Slug: "hardware", Description: "Hardware builds and tuning."},
-	{Name: "Linux", Slug: "linux", Description: "Linux distros, tooling, and workflows."},
-	{Name: "Ai", Slug: "ai", Description: "AI trends, tools, and research."},
-	{Name: "Fitness", Slug: "fitness", Description: "Fitness and training programs."},
-	{Name: "Food", Slug: "food", Description: "Food, cooking, and nutrition."},
+	{Name: "Atrium", Slug: "atrium", Description: "General community discussions.", Category: models.SanctumCategoryCommunity},
+	{Name: "General", Slug: "general", Description: "Core discussion for Sanctum.", Category: models.SanctumCategoryCommunity},
+	{Name: "Herald Announcements", Slug: "herald", Description: "Announcements and platform updates.", Category: models.SanctumCategoryCommunity},
+	{Name: "Support", Slug: "support", Description: "Help and troubleshooting.", Category: models.SanctumCategoryCommunity},
+	{Name: "Movies", Slug: "movies", Description: "Film discussion and recommendations.", Category: models.SanctumCategoryEntertainment},
+	{Name: "Television", Slug: "television", Description: "TV shows and series conversation.", Category: models.SanctumCategoryEntertainment},
+	{Name: "Books", Slug: "books", Description: "Books, writing, and reading lists.", Category: models.SanctumCategoryEntertainment},
+	{Name: "Music", Slug: "music", Description: "Music discovery and discussion.", Category: models.SanctumCategoryEntertainment},
+	{Name: "Anime", Slug: "anime", Description: "Anime and manga talk.", Category: models.SanctumCategoryEntertainment},
+	{Name: "Gaming", Slug: "gaming", Description: "Gaming across all platforms.", Category: models.SanctumCategoryGaming},
+	{Name: "Pcgaming", Slug: "pcgaming", Description: "PC gaming hardware and titles.", Category: models.SanctumCategoryGaming},
+	{Name: "Development", Slug: "development", Description: "Software development discussions.", Category: models.SanctumCategoryTechnology},
+	{Name: "Hardware", Slug: "hardware", Description: "Hardware builds and tuning.", Category: models.SanctumCategoryTechnology},
+	{Name: "Linux", Slug: "linux", Description: "Linux distros, tooling, and workflows.", Category: models.SanctumCategoryTechnology},
+	{Name: "Ai", Slug: "ai", Description: "AI trends, tools, and research.", Category: models.SanctumCategoryTechnology},
+	{Name: "Fitness", Slug: "fitness", Description: "Fitness and training programs.", Category: models.SanctumCategoryLifestyle},
+	{Name: "Food", Slug: "food", Description: "Food, cooking, and nutrition.", Category: models.SanctumCategoryLifestyle},
 }
 
 // Sanctums seeds permanent built-in sanctums and their default chat rooms.
@@ -52,6 +53,7 @@ func Sanctums(db *gorm.DB) error {
 						Name:        item.Name,
 						Slug:        item.Slug,
 						Description: item.Description,
+						Category:    item.Category,
 						Status:      models.SanctumStatusActive,
 					}
 					if createErr := tx.Create(&sanctum).Error; createErr != nil {
@@ -64,15 +66,16 @@ func Sanctums(db *gorm.DB) error {
 				// Update existing
 				sanctum.Name = item.Name
 				sanctum.Description = item.Description
+				sanctum.Category = item.Category
 				sanctum.Status = models.SanctumStatusActive
 				if err := tx.Save(&sanctum).Error; err != nil {
 					return fmt.Errorf("failed to update sanctum %s: %w", item.Slug, err)
 				}
 			}
 
-			// Check for existing conversation
+			// Check for the existing default channel
 			var existing models.Conversation
-			queryErr := tx.Where("sanctum_id = ?", sanctum.ID).First(&existing).Error
+			queryErr := tx.Where("sanctum_id = ? AND is_default = ?", sanctum.ID, true).First(&existing).Error
 
 			if queryErr == nil {
 				// Update existing conversation name if needed
@@ -95,6 +98,7 @@ func Sanctums(db *gorm.DB) error {
 				IsGroup:   true,
 				CreatedBy: 0,
 				SanctumID: &sid,
+				IsDefault: true,
 			}
 			if err := tx.Create(&conv).Error; err != nil {
 				return fmt.Errorf("failed to create conversation for sanctum %s (ID: %d): %w", item.Slug, sid, err)
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"sanctum/internal/models"
+	"sanctum/internal/service"
 	"sanctum/internal/validation"
 
 	"github.com/gofiber/fiber/v2"
@@ -21,20 +22,22 @@ import (
 
 // Signup handles POST /api/auth/signup
 // @Summary User signup
-// @Description Register a new user account
+// @Description Register a new user account. When registration is invite-only, invite_code is required; when it is waitlisted, either invite_code or an approved waitlist email is.
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Param request body object{username=string,email=string,password=string} true "Signup request"
+// @Param request body object{username=string,email=string,password=string,invite_code=string} true "Signup request"
 // @Success 201 {object} object{token=string,refresh_token=string,user=models.User}
 // @Failure 400 {object} object{error=string}
+// @Failure 403 {object} object{error=string}
 // @Failure 409 {object} object{error=string}
 // @Router /auth/signup [post]
 func (s *Server) Signup(c *fiber.Ctx) error {
 	var req struct {
-		Username string `json:"username"`
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Username   string `json:"username"`
+		Email      string `json:"email"`
+		Password   string `json:"password"`
+		InviteCode string `json:"invite_code"`
 	}
 	// Manually inspect raw body so we can reliably distinguish an empty body
 	// from malformed JSON when Content-Type: application/json is present.
@@ -94,7 +97,17 @@ func (s *Server) Signup(c *fiber.Ctx) error {
 		Password: string(hashedPassword),
 	}
 
-	if createErr := s.userRepo.Create(c.Context(), user); createErr != nil {
+	if s.registrationSvc != nil {
+		// Enforces the registration mode; any invite code is claimed in the
+		// same transaction that creates the account.
+		if err := s.registrationSvc.Register(c.Context(), user, req.InviteCode); err != nil {
+			status := appErrorStatus(err)
+			if errors.Is(err, service.ErrUserExists) {
+				status = fiber.StatusConflict
+			}
+			return models.RespondWithError(c, status, err)
+		}
+	} else if createErr := s.userRepo.Create(c.Context(), user); createErr != nil {
 		status := fiber.StatusInternalServerError
 		var appErr *models.AppError
 		if errors.As(createErr, &appErr) && appErr.Code == "VALIDATION_ERROR" {
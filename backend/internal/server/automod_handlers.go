@@ -0,0 +1,184 @@
+package server
+
+import (
+	"sanctum/internal/models"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type automodUpdateRequest struct {
+	Source  string `json:"source"`
+	Enabled *bool  `json:"enabled"`
+}
+
+// automodTestRequest carries an optional draft rule set; when Source is
+// omitted the stored rules are used.
+type automodTestRequest struct {
+	Source  *string                `json:"source"`
+	Subject service.AutomodSubject `json:"subject"`
+}
+
+type automodDryRunRequest struct {
+	Source *string `json:"source"`
+}
+
+type modQueueResponse struct {
+	Posts    []models.Post    `json:"posts"`
+	Comments []models.Comment `json:"comments"`
+}
+
+// GetSanctumAutomod handles GET /api/sanctums/:slug/automod.
+// @Summary Get AutoModerator config
+// @Description Get a sanctum's AutoModerator rule set. Sanctum moderators only.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Success 200 {object} models.SanctumAutomod
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/automod [get]
+func (s *Server) GetSanctumAutomod(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	cfg, err := s.automodService.Get(ctx, c.Locals("userID").(uint), sanctum.ID)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(cfg)
+}
+
+// UpdateSanctumAutomod handles PUT /api/sanctums/:slug/automod.
+// @Summary Update AutoModerator config
+// @Description Replace a sanctum's AutoModerator rule set, written in YAML or JSON. Sanctum moderators only.
+// @Tags sanctums-admin
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param request body automodUpdateRequest true "Rule set"
+// @Success 200 {object} models.SanctumAutomod
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/automod [put]
+func (s *Server) UpdateSanctumAutomod(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req automodUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	cfg, err := s.automodService.Update(ctx, service.UpdateAutomodInput{
+		ActorID:   c.Locals("userID").(uint),
+		SanctumID: sanctum.ID,
+		Source:    req.Source,
+		Enabled:   enabled,
+	})
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(cfg)
+}
+
+// TestSanctumAutomod handles POST /api/sanctums/:slug/automod/test.
+// @Summary Test AutoModerator rules
+// @Description Evaluate the stored or a draft rule set against sample content without acting on anything. Sanctum moderators only.
+// @Tags sanctums-admin
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param request body automodTestRequest true "Draft rules and sample content"
+// @Success 200 {array} service.AutomodMatch
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/automod/test [post]
+func (s *Server) TestSanctumAutomod(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req automodTestRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	matches, err := s.automodService.TestRules(ctx, c.Locals("userID").(uint), sanctum.ID, req.Source, req.Subject)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(matches)
+}
+
+// DryRunSanctumAutomod handles POST /api/sanctums/:slug/automod/dry-run.
+// @Summary Dry-run AutoModerator rules
+// @Description Replay the stored or a draft rule set over the sanctum's recent posts and comments and report what would fire. Sanctum moderators only.
+// @Tags sanctums-admin
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param request body automodDryRunRequest false "Draft rules"
+// @Success 200 {array} service.AutomodMatch
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/automod/dry-run [post]
+func (s *Server) DryRunSanctumAutomod(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req automodDryRunRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("Invalid request body"))
+		}
+	}
+	matches, err := s.automodService.DryRun(ctx, c.Locals("userID").(uint), sanctum.ID, req.Source)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(matches)
+}
+
+// GetSanctumModQueue handles GET /api/sanctums/:slug/modqueue.
+// @Summary Get sanctum mod queue
+// @Description List posts and comments AutoModerator filtered for review. Approve with the restore endpoints; confirm with the remove endpoints. Sanctum moderators only.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param limit query int false "Max items of each kind (default 50)"
+// @Success 200 {object} modQueueResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/modqueue [get]
+func (s *Server) GetSanctumModQueue(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	page := parsePagination(c, 50)
+	posts, comments, err := s.automodService.ModQueue(ctx, c.Locals("userID").(uint), sanctum.ID, page.Limit)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(modQueueResponse{Posts: posts, Comments: comments})
+}
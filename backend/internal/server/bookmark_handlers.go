@@ -0,0 +1,169 @@
+// Package server contains HTTP and WebSocket handlers for the application's API endpoints.
+package server
+
+import (
+	"strconv"
+
+	"sanctum/internal/models"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetBookmarks handles GET /api/bookmarks?collection_id=&cursor=&limit=
+func (s *Server) GetBookmarks(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+
+	in := service.ListBookmarksInput{
+		UserID: userID,
+		Limit:  c.QueryInt("limit", 20),
+	}
+	if raw := c.Query("collection_id"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil || parsed == 0 {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("Invalid collection ID"))
+		}
+		id := uint(parsed)
+		in.CollectionID = &id
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("Invalid cursor"))
+		}
+		in.Cursor = uint(parsed)
+	}
+
+	page, err := s.bookmarkSvc().ListBookmarks(ctx, in)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+
+	return c.JSON(page)
+}
+
+// AddBookmark handles POST /api/bookmarks
+func (s *Server) AddBookmark(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+
+	var req struct {
+		TargetType   string `json:"target_type"`
+		TargetID     uint   `json:"target_id"`
+		CollectionID *uint  `json:"collection_id,omitempty"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+
+	bookmark, err := s.bookmarkSvc().AddBookmark(ctx, service.AddBookmarkInput{
+		UserID:       userID,
+		TargetType:   req.TargetType,
+		TargetID:     req.TargetID,
+		CollectionID: req.CollectionID,
+	})
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(bookmark)
+}
+
+// RemoveBookmark handles DELETE /api/bookmarks/:type/:id
+func (s *Server) RemoveBookmark(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	targetID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	if err := s.bookmarkSvc().RemoveBookmark(ctx, userID, c.Params("type"), targetID); err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetBookmarkCollections handles GET /api/bookmarks/collections
+func (s *Server) GetBookmarkCollections(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+
+	collections, err := s.bookmarkSvc().ListCollections(ctx, userID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(collections)
+}
+
+// CreateBookmarkCollection handles POST /api/bookmarks/collections
+func (s *Server) CreateBookmarkCollection(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+
+	collection, err := s.bookmarkSvc().CreateCollection(ctx, userID, req.Name)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(collection)
+}
+
+// RenameBookmarkCollection handles PUT /api/bookmarks/collections/:id
+func (s *Server) RenameBookmarkCollection(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	collectionID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+
+	collection, err := s.bookmarkSvc().RenameCollection(ctx, userID, collectionID, req.Name)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+
+	return c.JSON(collection)
+}
+
+// DeleteBookmarkCollection handles DELETE /api/bookmarks/collections/:id
+func (s *Server) DeleteBookmarkCollection(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	collectionID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	if err := s.bookmarkSvc().DeleteCollection(ctx, userID, collectionID); err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (s *Server) bookmarkSvc() *service.BookmarkService {
+	return s.bookmarkService
+}
@@ -5,10 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strconv"
 	"time"
 
 	"sanctum/internal/models"
 	"sanctum/internal/notifications"
+	"sanctum/internal/repository"
 	"sanctum/internal/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -128,9 +130,11 @@ func (s *Server) SendMessage(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		Content     string          `json:"content"`
-		MessageType string          `json:"message_type,omitempty"`
-		Metadata    json.RawMessage `json:"metadata,omitempty"`
+		Content          string          `json:"content"`
+		MessageType      string          `json:"message_type,omitempty"`
+		Metadata         json.RawMessage `json:"metadata,omitempty"`
+		ReplyToMessageID uint            `json:"reply_to_message_id,omitempty"`
+		ThreadRootID     uint            `json:"thread_root_id,omitempty"`
 	}
 	if parseErr := c.BodyParser(&req); parseErr != nil {
 		return models.RespondWithError(c, fiber.StatusBadRequest,
@@ -143,6 +147,8 @@ func (s *Server) SendMessage(c *fiber.Ctx) error {
 		Content:        req.Content,
 		MessageType:    req.MessageType,
 		Metadata:       req.Metadata,
+		ReplyToID:      req.ReplyToMessageID,
+		ThreadRootID:   req.ThreadRootID,
 	})
 	if err != nil {
 		status := fiber.StatusInternalServerError
@@ -151,8 +157,10 @@ func (s *Server) SendMessage(c *fiber.Ctx) error {
 			switch appErr.Code {
 			case "VALIDATION_ERROR":
 				status = fiber.StatusBadRequest
-			case "UNAUTHORIZED":
+			case "UNAUTHORIZED", "FORBIDDEN":
 				status = fiber.StatusForbidden
+			case "NOT_FOUND":
+				status = fiber.StatusNotFound
 			}
 		} else if errors.Is(err, gorm.ErrRecordNotFound) {
 			status = fiber.StatusNotFound
@@ -165,9 +173,16 @@ func (s *Server) SendMessage(c *fiber.Ctx) error {
 		senderUsername = message.Sender.Username
 	}
 	s.persistMessageMentions(ctx, convID, message, userID, conv.Participants)
+	s.unfurlMessageLink(message)
 
-	// Broadcast message to all WebSocket-connected participants in real-time via ChatHub
-	if s.chatHub != nil {
+	// Thread replies stay out of the main timeline; viewers get a thread
+	// update instead.
+	if message.ThreadRootID != nil {
+		if s.chatHub != nil {
+			s.chatHub.BroadcastToConversation(convID, s.threadReplyEvent(ctx, message, senderUsername))
+		}
+	} else if s.chatHub != nil {
+		// Broadcast message to all WebSocket-connected participants in real-time via ChatHub
 		s.chatHub.BroadcastToConversation(convID, notifications.ChatMessage{
 			Type:           "message",
 			ConversationID: convID,
@@ -179,7 +194,7 @@ func (s *Server) SendMessage(c *fiber.Ctx) error {
 
 	// For public chatrooms, broadcast room-level realtime updates only to
 	// connected participants in that conversation.
-	if conv.IsGroup && s.chatHub != nil {
+	if conv.IsGroup && message.ThreadRootID == nil && s.chatHub != nil {
 		s.chatHub.BroadcastToConversation(convID, notifications.ChatMessage{
 			Type:           "room_message",
 			ConversationID: convID,
@@ -211,6 +226,9 @@ func (s *Server) SendMessage(c *fiber.Ctx) error {
 }
 
 // GetMessages handles GET /api/conversations/:id/messages
+// By default it pages back from the newest message with limit/offset. One of
+// before, after or around (message IDs) instead returns the window next to
+// that message, e.g. to jump to a mention, search hit or unread marker.
 func (s *Server) GetMessages(c *fiber.Ctx) error {
 	ctx := c.UserContext()
 	userID := c.Locals("userID").(uint)
@@ -220,23 +238,48 @@ func (s *Server) GetMessages(c *fiber.Ctx) error {
 	}
 
 	page := parsePagination(c, 50)
+	var cursor repository.MessageCursor
+	for param, dst := range map[string]*uint{"before": &cursor.Before, "after": &cursor.After, "around": &cursor.Around} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		id, perr := strconv.ParseUint(raw, 10, 64)
+		if perr != nil || id == 0 {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("Invalid "+param+" message ID"))
+		}
+		*dst = uint(id)
+	}
 
-	messages, err := s.chatSvc().GetMessagesForUser(ctx, convID, userID, page.Limit, page.Offset)
+	var messages []*models.Message
+	if cursor != (repository.MessageCursor{}) {
+		messages, err = s.chatSvc().GetMessagesByCursorForUser(ctx, convID, userID, cursor, page.Limit)
+	} else {
+		messages, err = s.chatSvc().GetMessagesForUser(ctx, convID, userID, page.Limit, page.Offset)
+	}
 	if err != nil {
-		status := fiber.StatusInternalServerError
-		var appErr *models.AppError
-		if errors.As(err, &appErr) && appErr.Code == "UNAUTHORIZED" {
-			status = fiber.StatusForbidden
-		} else if errors.Is(err, gorm.ErrRecordNotFound) {
-			status = fiber.StatusNotFound
+		return models.RespondWithError(c, chatMessageErrorStatus(err), err)
+	}
+
+	// Fetching history delivers it to the caller
+	var newestID uint
+	for _, m := range messages {
+		if m.ID > newestID && m.SenderID != userID {
+			newestID = m.ID
 		}
-		return models.RespondWithError(c, status, err)
+	}
+	if newestID != 0 {
+		s.markDelivered(ctx, convID, userID, newestID)
 	}
 
 	return c.JSON(messages)
 }
 
 // MarkConversationRead handles POST /api/conversations/:id/read
+// Moves the caller's read watermark to message_id, or to the newest message
+// when the body is empty, and broadcasts a read_receipt event unless the
+// caller has turned read receipts off.
 func (s *Server) MarkConversationRead(c *fiber.Ctx) error {
 	ctx := c.UserContext()
 	userID := c.Locals("userID").(uint)
@@ -245,61 +288,32 @@ func (s *Server) MarkConversationRead(c *fiber.Ctx) error {
 		return nil
 	}
 
-	conv, err := s.chatSvc().GetConversationForUser(ctx, convID, userID)
-	if err != nil {
-		status := fiber.StatusInternalServerError
-		var appErr *models.AppError
-		if errors.As(err, &appErr) && appErr.Code == "UNAUTHORIZED" {
-			status = fiber.StatusForbidden
-		} else if errors.Is(err, gorm.ErrRecordNotFound) {
-			status = fiber.StatusNotFound
-		}
-		return models.RespondWithError(c, status, err)
+	var req struct {
+		MessageID uint `json:"message_id"`
 	}
-	if conv.IsGroup {
-		return models.RespondWithError(c, fiber.StatusBadRequest,
-			models.NewValidationError("Read receipts are only supported for direct messages"))
-	}
-
-	now := time.Now().UTC()
-	txErr := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Model(&models.ConversationParticipant{}).
-			Where("conversation_id = ? AND user_id = ?", convID, userID).
-			Updates(map[string]interface{}{
-				"last_read_at": now,
-				"unread_count": 0,
-			}).Error; err != nil {
-			return err
+	if len(c.Body()) > 0 {
+		if parseErr := c.BodyParser(&req); parseErr != nil {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("Invalid request body"))
 		}
+	}
 
-		if err := tx.Model(&models.Message{}).
-			Where("conversation_id = ? AND sender_id <> ? AND is_read = ?", convID, userID, false).
-			Updates(map[string]interface{}{
-				"is_read": true,
-				"read_at": now,
-			}).Error; err != nil {
-			return err
-		}
-		return nil
-	})
-	if txErr != nil {
-		return models.RespondWithError(c, fiber.StatusInternalServerError, txErr)
+	receipt, err := s.chatSvc().MarkRead(ctx, convID, userID, req.MessageID)
+	if err != nil {
+		return models.RespondWithError(c, chatMessageErrorStatus(err), err)
 	}
 
-	if s.chatHub != nil {
-		s.chatHub.BroadcastToConversation(convID, notifications.ChatMessage{
-			Type:           "message_read",
-			ConversationID: convID,
-			UserID:         userID,
-			Payload: map[string]interface{}{
-				"conversation_id": convID,
-				"user_id":         userID,
-				"read_at":         now.Format(time.RFC3339Nano),
-			},
-		})
+	if s.chatHub != nil && !receipt.Hidden {
+		for _, event := range readReceiptEvents(receipt, "") {
+			s.chatHub.BroadcastToConversation(convID, event)
+		}
 	}
 
-	return c.JSON(fiber.Map{"message": "Conversation marked as read"})
+	return c.JSON(fiber.Map{
+		"message":              "Conversation marked as read",
+		"last_read_message_id": receipt.LastReadMessageID,
+		"read_at":              receipt.ReadAt,
+	})
 }
 
 // AddParticipant handles POST /api/conversations/:id/participants
@@ -324,7 +338,7 @@ func (s *Server) AddParticipant(c *fiber.Ctx) error {
 		var appErr *models.AppError
 		if errors.As(err, &appErr) {
 			switch appErr.Code {
-			case "UNAUTHORIZED":
+			case "UNAUTHORIZED", "FORBIDDEN":
 				status = fiber.StatusForbidden
 			case "VALIDATION_ERROR":
 				status = fiber.StatusBadRequest
@@ -441,6 +455,8 @@ func (s *Server) JoinChatroom(c *fiber.Ctx) error {
 				status = fiber.StatusNotFound
 			case "VALIDATION_ERROR":
 				status = fiber.StatusBadRequest
+			case "FORBIDDEN":
+				status = fiber.StatusForbidden
 			}
 		} else if errors.Is(err, gorm.ErrRecordNotFound) {
 			status = fiber.StatusNotFound
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"sanctum/internal/models"
+	"sanctum/internal/repository"
 	"sanctum/internal/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -59,6 +60,11 @@ func (m *MockChatRepository) GetMessages(ctx context.Context, convID uint, limit
 	return args.Get(0).([]*models.Message), args.Error(1)
 }
 
+func (m *MockChatRepository) GetMessagesByCursor(ctx context.Context, convID uint, cursor repository.MessageCursor, limit int) ([]*models.Message, error) {
+	args := m.Called(ctx, convID, cursor, limit)
+	return args.Get(0).([]*models.Message), args.Error(1)
+}
+
 func (m *MockChatRepository) MarkMessageRead(ctx context.Context, msgID uint) error {
 	args := m.Called(ctx, msgID)
 	return args.Error(0)
@@ -77,7 +83,7 @@ func (m *MockChatRepository) IsUserParticipant(ctx context.Context, conversation
 func TestCreateConversation(t *testing.T) {
 	app := fiber.New()
 	mockChatRepo := new(MockChatRepository)
-	chatService := service.NewChatService(mockChatRepo, nil, nil, nil, nil)
+	chatService := service.NewChatService(mockChatRepo, nil, nil, nil, nil, nil)
 	s := &Server{chatRepo: mockChatRepo, chatService: chatService}
 
 	app.Use(func(c *fiber.Ctx) error {
@@ -2,39 +2,15 @@ package server
 
 import (
 	"context"
-	"regexp"
-	"strings"
 	"time"
 
 	"sanctum/internal/models"
 	"sanctum/internal/notifications"
+	"sanctum/internal/service"
 
 	"gorm.io/gorm/clause"
 )
 
-var mentionPattern = regexp.MustCompile(`@([a-zA-Z0-9_]{2,32})`)
-
-func extractMentionUsernames(content string) []string {
-	matches := mentionPattern.FindAllStringSubmatch(content, -1)
-	if len(matches) == 0 {
-		return nil
-	}
-	seen := map[string]bool{}
-	result := make([]string, 0, len(matches))
-	for _, match := range matches {
-		if len(match) < 2 {
-			continue
-		}
-		username := strings.TrimSpace(strings.ToLower(match[1]))
-		if username == "" || seen[username] {
-			continue
-		}
-		seen[username] = true
-		result = append(result, username)
-	}
-	return result
-}
-
 func (s *Server) persistMessageMentions(
 	ctx context.Context,
 	conversationID uint,
@@ -45,21 +21,7 @@ func (s *Server) persistMessageMentions(
 	if message == nil {
 		return
 	}
-	mentions := extractMentionUsernames(message.Content)
-	if len(mentions) == 0 {
-		return
-	}
-
-	participantsByUsername := make(map[string]uint, len(participants))
-	for _, participant := range participants {
-		participantsByUsername[strings.ToLower(participant.Username)] = participant.ID
-	}
-
-	for _, mention := range mentions {
-		mentionedUserID, ok := participantsByUsername[mention]
-		if !ok || mentionedUserID == senderID {
-			continue
-		}
+	for _, mentionedUserID := range service.MentionedUserIDs(message.Content, senderID, participants) {
 		record := models.MessageMention{
 			MessageID:         message.ID,
 			ConversationID:    conversationID,
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"errors"
+
+	"sanctum/internal/models"
+	"sanctum/internal/notifications"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// EditMessage handles PUT /api/conversations/:id/messages/:messageId.
+// @Summary Edit a chat message
+// @Description Replace the content of your own message. Allowed within 15 minutes of sending; the previous content is kept in the edit history. Participants receive a message_edited event.
+// @Tags chat
+// @Accept json
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Param messageId path int true "Message ID"
+// @Param request body object{content=string} true "New content"
+// @Success 200 {object} models.Message
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /conversations/{id}/messages/{messageId} [put]
+func (s *Server) EditMessage(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	messageID, err := s.parseID(c, "messageId")
+	if err != nil {
+		return nil
+	}
+	var req struct {
+		Content string `json:"content"`
+	}
+	if parseErr := c.BodyParser(&req); parseErr != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+
+	message, err := s.chatSvc().EditMessage(ctx, service.EditMessageInput{
+		UserID:         userID,
+		ConversationID: convID,
+		MessageID:      messageID,
+		Content:        req.Content,
+	})
+	if err != nil {
+		return models.RespondWithError(c, chatMessageErrorStatus(err), err)
+	}
+	s.unfurlMessageLink(message)
+
+	if s.chatHub != nil {
+		s.chatHub.BroadcastToConversation(convID, notifications.ChatMessage{
+			Type:           "message_edited",
+			ConversationID: convID,
+			UserID:         userID,
+			Payload:        message,
+		})
+	}
+
+	return c.JSON(message)
+}
+
+// DeleteMessage handles DELETE /api/conversations/:id/messages/:messageId.
+// @Summary Delete a chat message
+// @Description Replace a message with a tombstone. Authors may delete their own messages and room moderators any message. Participants receive a message_deleted event.
+// @Tags chat
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Param messageId path int true "Message ID"
+// @Success 200 {object} models.Message
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /conversations/{id}/messages/{messageId} [delete]
+func (s *Server) DeleteMessage(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	messageID, err := s.parseID(c, "messageId")
+	if err != nil {
+		return nil
+	}
+
+	message, err := s.chatSvc().DeleteMessage(ctx, convID, messageID, userID)
+	if err != nil {
+		return models.RespondWithError(c, chatMessageErrorStatus(err), err)
+	}
+
+	if s.chatHub != nil {
+		s.chatHub.BroadcastToConversation(convID, notifications.ChatMessage{
+			Type:           "message_deleted",
+			ConversationID: convID,
+			UserID:         userID,
+			Payload: map[string]interface{}{
+				"conversation_id":    convID,
+				"message_id":         message.ID,
+				"deleted_by_user_id": userID,
+			},
+		})
+	}
+
+	return c.JSON(message)
+}
+
+// GetMessageEdits handles GET /api/conversations/:id/messages/:messageId/edits.
+// @Summary Get a chat message's edit history
+// @Description List the previous versions of a message, oldest first.
+// @Tags chat
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Param messageId path int true "Message ID"
+// @Success 200 {array} models.MessageEdit
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /conversations/{id}/messages/{messageId}/edits [get]
+func (s *Server) GetMessageEdits(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	messageID, err := s.parseID(c, "messageId")
+	if err != nil {
+		return nil
+	}
+
+	edits, err := s.chatSvc().GetMessageEdits(ctx, convID, messageID, userID)
+	if err != nil {
+		return models.RespondWithError(c, chatMessageErrorStatus(err), err)
+	}
+	return c.JSON(edits)
+}
+
+func chatMessageErrorStatus(err error) int {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fiber.StatusNotFound
+	}
+	return appErrorStatus(err)
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"sanctum/internal/models"
+	"sanctum/internal/notifications"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetReadReceipts handles GET /api/conversations/:id/receipts.
+// @Summary Get conversation read receipts
+// @Description List every participant's read and delivery watermarks. Read watermarks are omitted for users who turned read receipts off.
+// @Tags chat
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Success 200 {array} service.ParticipantReceipt
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /conversations/{id}/receipts [get]
+func (s *Server) GetReadReceipts(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	receipts, err := s.chatSvc().GetReadReceipts(ctx, convID, userID)
+	if err != nil {
+		return models.RespondWithError(c, chatMessageErrorStatus(err), err)
+	}
+	return c.JSON(receipts)
+}
+
+// GetMessageSeenBy handles GET /api/conversations/:id/messages/:messageId/seen-by.
+// @Summary List who has seen a message
+// @Description List the participants who have read a message, excluding its sender and users who turned read receipts off.
+// @Tags chat
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Param messageId path int true "Message ID"
+// @Success 200 {array} service.SeenByEntry
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /conversations/{id}/messages/{messageId}/seen-by [get]
+func (s *Server) GetMessageSeenBy(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	messageID, err := s.parseID(c, "messageId")
+	if err != nil {
+		return nil
+	}
+
+	seenBy, err := s.chatSvc().GetSeenBy(ctx, convID, messageID, userID)
+	if err != nil {
+		return models.RespondWithError(c, chatMessageErrorStatus(err), err)
+	}
+	return c.JSON(seenBy)
+}
+
+// SetMyReadReceipts handles PUT /api/users/me/read-receipts.
+// @Summary Turn read receipts on or off
+// @Description When off, others no longer see when you have read their messages. Delivery is still reported.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body object{enabled=bool} true "Read receipts setting"
+// @Success 200 {object} MyProfileResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/read-receipts [put]
+func (s *Server) SetMyReadReceipts(c *fiber.Ctx) error {
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Enabled == nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("enabled is required"))
+	}
+
+	user, err := s.userSvc().SetReadReceipts(c.UserContext(), c.Locals("userID").(uint), *req.Enabled)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(myProfileResponse(user))
+}
+
+// markDelivered advances the user's delivery watermark and tells the
+// conversation when it moved.
+func (s *Server) markDelivered(ctx context.Context, convID, userID, messageID uint) {
+	advanced, err := s.chatSvc().MarkDelivered(ctx, convID, userID, messageID)
+	if err != nil {
+		log.Printf("mark delivered conversation %d user %d: %v", convID, userID, err)
+		return
+	}
+	if !advanced || s.chatHub == nil {
+		return
+	}
+	s.chatHub.BroadcastToConversation(convID, notifications.ChatMessage{
+		Type:           "delivery_receipt",
+		ConversationID: convID,
+		UserID:         userID,
+		Payload: map[string]interface{}{
+			"conversation_id":           convID,
+			"user_id":                   userID,
+			"last_delivered_message_id": messageID,
+		},
+	})
+}
+
+// readReceiptEvents builds the websocket events announcing a read watermark.
+// message_read is kept for clients that predate read_receipt.
+func readReceiptEvents(receipt *service.ReadReceipt, username string) []notifications.ChatMessage {
+	readAt := receipt.ReadAt.Format(time.RFC3339Nano)
+	return []notifications.ChatMessage{
+		{
+			Type:           "read_receipt",
+			ConversationID: receipt.ConversationID,
+			UserID:         receipt.UserID,
+			Username:       username,
+			Payload: map[string]interface{}{
+				"conversation_id":      receipt.ConversationID,
+				"user_id":              receipt.UserID,
+				"last_read_message_id": receipt.LastReadMessageID,
+				"read_at":              readAt,
+			},
+		},
+		{
+			Type:           "message_read",
+			ConversationID: receipt.ConversationID,
+			UserID:         receipt.UserID,
+			Username:       username,
+			Payload: map[string]interface{}{
+				"conversation_id": receipt.ConversationID,
+				"user_id":         receipt.UserID,
+				"read_at":         readAt,
+			},
+		},
+	}
+}
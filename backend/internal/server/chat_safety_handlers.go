@@ -8,6 +8,7 @@ import (
 
 	"sanctum/internal/models"
 	"sanctum/internal/notifications"
+	"sanctum/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -167,6 +168,11 @@ func (s *Server) GetMyMentions(c *fiber.Ctx) error {
 		Find(&mentions).Error; err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
+	for i := range mentions {
+		if m := mentions[i].Message; m != nil {
+			service.RenderMessageHTML(m)
+		}
+	}
 
 	return c.JSON(mentions)
 }
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"strconv"
+	"time"
+
+	"sanctum/internal/models"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SearchConversationMessages handles GET /api/conversations/:id/messages/search.
+// @Summary Search a conversation's history
+// @Description Full-text search over one conversation's messages, newest first. Each hit carries an anchor for loading the messages around it.
+// @Tags chat
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Param q query string true "Search query"
+// @Param sender_id query int false "Only messages from this user"
+// @Param since query string false "Only messages sent at or after this RFC3339 time"
+// @Param until query string false "Only messages sent before this RFC3339 time"
+// @Param has_attachment query bool false "Only messages with (true) or without (false) an attachment"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {array} service.MessageSearchHit
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /conversations/{id}/messages/search [get]
+func (s *Server) SearchConversationMessages(c *fiber.Ctx) error {
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	return s.searchMessages(c, convID)
+}
+
+// SearchMessages handles GET /api/conversations/messages/search.
+// @Summary Search chat history
+// @Description Full-text search over every conversation the caller participates in, newest first. Each hit carries an anchor for loading the messages around it.
+// @Tags chat
+// @Produce json
+// @Param q query string true "Search query"
+// @Param sender_id query int false "Only messages from this user"
+// @Param since query string false "Only messages sent at or after this RFC3339 time"
+// @Param until query string false "Only messages sent before this RFC3339 time"
+// @Param has_attachment query bool false "Only messages with (true) or without (false) an attachment"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {array} service.MessageSearchHit
+// @Failure 400 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /conversations/messages/search [get]
+func (s *Server) SearchMessages(c *fiber.Ctx) error {
+	return s.searchMessages(c, 0)
+}
+
+func (s *Server) searchMessages(c *fiber.Ctx, convID uint) error {
+	page := parsePagination(c, 20)
+	in := service.MessageSearchInput{
+		UserID:         c.Locals("userID").(uint),
+		ConversationID: convID,
+		Query:          c.Query("q"),
+		Limit:          page.Limit,
+		Offset:         page.Offset,
+	}
+	if raw := c.Query("sender_id"); raw != "" {
+		senderID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || senderID == 0 {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("Invalid sender_id"))
+		}
+		in.SenderID = uint(senderID)
+	}
+	for param, dst := range map[string]**time.Time{"since": &in.Since, "until": &in.Until} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError(param+" must be an RFC3339 time"))
+		}
+		*dst = &parsed
+	}
+	if raw := c.Query("has_attachment"); raw != "" {
+		hasAttachment, err := strconv.ParseBool(raw)
+		if err != nil {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("has_attachment must be true or false"))
+		}
+		in.HasAttachment = &hasAttachment
+	}
+
+	hits, err := s.chatSvc().SearchMessages(c.UserContext(), in)
+	if err != nil {
+		return models.RespondWithError(c, chatMessageErrorStatus(err), err)
+	}
+	return c.JSON(hits)
+}
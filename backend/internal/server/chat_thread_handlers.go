@@ -0,0 +1,125 @@
+package server
+
+import (
+	"context"
+	"log"
+
+	"sanctum/internal/models"
+	"sanctum/internal/notifications"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetMessageThread handles GET /api/conversations/:id/messages/:messageId/thread.
+// @Summary Get a message's side thread
+// @Description Return the root message and its thread replies, oldest first, with the caller's last read time and unread count.
+// @Tags chat
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Param messageId path int true "Root message ID"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {object} service.MessageThread
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /conversations/{id}/messages/{messageId}/thread [get]
+func (s *Server) GetMessageThread(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	rootID, err := s.parseID(c, "messageId")
+	if err != nil {
+		return nil
+	}
+	page := parsePagination(c, 50)
+
+	thread, err := s.chatSvc().GetThread(ctx, convID, rootID, userID, page.Limit, page.Offset)
+	if err != nil {
+		return models.RespondWithError(c, chatMessageErrorStatus(err), err)
+	}
+	return c.JSON(thread)
+}
+
+// MarkThreadRead handles POST /api/conversations/:id/messages/:messageId/thread/read.
+// @Summary Mark a side thread as read
+// @Description Record that the caller has read the thread. The caller's other websocket sessions receive a thread_read event.
+// @Tags chat
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Param messageId path int true "Root message ID"
+// @Success 200 {object} object{conversation_id=int,root_message_id=int,last_read_at=string}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /conversations/{id}/messages/{messageId}/thread/read [post]
+func (s *Server) MarkThreadRead(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	rootID, err := s.parseID(c, "messageId")
+	if err != nil {
+		return nil
+	}
+
+	payload, err := s.markThreadRead(ctx, convID, rootID, userID)
+	if err != nil {
+		return models.RespondWithError(c, chatMessageErrorStatus(err), err)
+	}
+	return c.JSON(payload)
+}
+
+// markThreadRead records the thread read state and syncs it to the user's
+// websocket sessions.
+func (s *Server) markThreadRead(ctx context.Context, convID, rootID, userID uint) (map[string]interface{}, error) {
+	readAt, err := s.chatSvc().MarkThreadRead(ctx, convID, rootID, userID)
+	if err != nil {
+		return nil, err
+	}
+	payload := map[string]interface{}{
+		"conversation_id": convID,
+		"root_message_id": rootID,
+		"last_read_at":    readAt,
+	}
+	if s.chatHub != nil {
+		s.chatHub.SendToUser(userID, notifications.ChatMessage{
+			Type:           "thread_read",
+			ConversationID: convID,
+			UserID:         userID,
+			Payload:        payload,
+		})
+	}
+	return payload, nil
+}
+
+// threadReplyEvent builds the websocket event for a new thread reply,
+// carrying the root's updated reply stats.
+func (s *Server) threadReplyEvent(ctx context.Context, message *models.Message, username string) notifications.ChatMessage {
+	payload := map[string]interface{}{
+		"conversation_id": message.ConversationID,
+		"root_message_id": *message.ThreadRootID,
+		"message":         message,
+	}
+	root, err := s.chatSvc().ThreadRoot(ctx, message.ConversationID, *message.ThreadRootID)
+	if err != nil {
+		log.Printf("load thread root %d: %v", *message.ThreadRootID, err)
+	} else {
+		payload["reply_count"] = root.ReplyCount
+		payload["last_reply_at"] = root.LastReplyAt
+	}
+	return notifications.ChatMessage{
+		Type:           "thread_reply",
+		ConversationID: message.ConversationID,
+		UserID:         message.SenderID,
+		Username:       username,
+		Payload:        payload,
+	}
+}
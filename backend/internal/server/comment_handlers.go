@@ -70,7 +70,12 @@ func (s *Server) GetComments(c *fiber.Ctx) error {
 		return nil
 	}
 
-	comments, err := s.commentSvc().ListComments(ctx, postID)
+	userID, _ := s.optionalUserID(c)
+	if ok, readErr := s.canReadPostSanctum(ctx, userID, postID); readErr != nil || !ok {
+		return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Post", postID))
+	}
+
+	comments, err := s.commentSvc().ListComments(ctx, postID, userID)
 	if err != nil {
 		status := fiber.StatusInternalServerError
 		var appErr *models.AppError
@@ -64,6 +64,25 @@ func (s *Server) parseID(c *fiber.Ctx, param string) (uint, error) {
 	return uint(id), nil
 }
 
+// appErrorStatus maps an AppError code to the HTTP status handlers respond with.
+// Errors that are not AppErrors map to 500.
+func appErrorStatus(err error) int {
+	var appErr *models.AppError
+	if !errors.As(err, &appErr) {
+		return fiber.StatusInternalServerError
+	}
+	switch appErr.Code {
+	case "VALIDATION_ERROR":
+		return fiber.StatusBadRequest
+	case "NOT_FOUND":
+		return fiber.StatusNotFound
+	case "UNAUTHORIZED", "FORBIDDEN":
+		return fiber.StatusForbidden
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
 // humanizeParam converts a route param name into a human-readable label.
 // Examples: "id" -> "ID", "userId" -> "user ID", "commentId" -> "comment ID".
 func humanizeParam(param string) string {
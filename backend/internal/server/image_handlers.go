@@ -33,29 +33,12 @@ type ImageStatusResponse struct {
 
 // UploadImage handles POST /api/images/upload
 func (s *Server) UploadImage(c *fiber.Ctx) error {
-	userID := c.Locals("userID").(uint)
-	file, err := c.FormFile("image")
-	if err != nil {
-		return models.RespondWithError(c, fiber.StatusBadRequest, models.NewValidationError("No file uploaded"))
-	}
-
-	src, err := file.Open()
-	if err != nil {
-		return models.RespondWithError(c, fiber.StatusBadRequest, models.NewValidationError("Unable to read uploaded file"))
-	}
-	defer func() { _ = src.Close() }()
-
-	content, err := io.ReadAll(src)
+	in, err := readImageUpload(c)
 	if err != nil {
-		return models.RespondWithError(c, fiber.StatusBadRequest, models.NewValidationError("Unable to read uploaded file"))
+		return models.RespondWithError(c, fiber.StatusBadRequest, err)
 	}
 
-	uploaded, err := s.imageSvc().Upload(c.UserContext(), service.UploadImageInput{
-		UserID:      userID,
-		Filename:    file.Filename,
-		ContentType: file.Header.Get("Content-Type"),
-		Content:     content,
-	})
+	uploaded, err := s.imageSvc().Upload(c.UserContext(), in)
 	if err != nil {
 		status := fiber.StatusInternalServerError
 		var appErr *models.AppError
@@ -73,6 +56,32 @@ func (s *Server) UploadImage(c *fiber.Ctx) error {
 	return c.JSON(toImageUploadResponse(s.imageSvc(), uploaded))
 }
 
+// readImageUpload reads the multipart "image" field of an upload request.
+func readImageUpload(c *fiber.Ctx) (service.UploadImageInput, error) {
+	file, err := c.FormFile("image")
+	if err != nil {
+		return service.UploadImageInput{}, models.NewValidationError("No file uploaded")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return service.UploadImageInput{}, models.NewValidationError("Unable to read uploaded file")
+	}
+	defer func() { _ = src.Close() }()
+
+	content, err := io.ReadAll(src)
+	if err != nil {
+		return service.UploadImageInput{}, models.NewValidationError("Unable to read uploaded file")
+	}
+
+	return service.UploadImageInput{
+		UserID:      c.Locals("userID").(uint),
+		Filename:    file.Filename,
+		ContentType: file.Header.Get("Content-Type"),
+		Content:     content,
+	}, nil
+}
+
 // GetImageStatus handles GET /api/images/:hash/status
 func (s *Server) GetImageStatus(c *fiber.Ctx) error {
 	hash := strings.TrimSpace(c.Params("hash"))
@@ -111,6 +111,24 @@ func (s *imageRepoTestStub) RequeueStaleProcessing(_ context.Context, _ time.Dur
 	return 0, nil
 }
 
+func (s *imageRepoTestStub) GetByHashes(_ context.Context, hashes []string) ([]models.Image, error) {
+	var out []models.Image
+	for _, h := range hashes {
+		if item, ok := s.items[h]; ok {
+			out = append(out, *item)
+		}
+	}
+	return out, nil
+}
+
+func (s *imageRepoTestStub) MarkReleased(_ context.Context, _ []string) error {
+	return nil
+}
+
+func (s *imageRepoTestStub) DeleteReleased(_ context.Context, _ time.Time, _ int) ([]models.Image, error) {
+	return nil, nil
+}
+
 func TestUploadAndServeImage(t *testing.T) {
 	cfg := &config.Config{ImageUploadDir: t.TempDir(), ImageMaxUploadSizeMB: 10}
 	repo := newImageRepoTestStub()
@@ -0,0 +1,200 @@
+package server
+
+import (
+	"time"
+
+	"sanctum/internal/cache"
+	"sanctum/internal/models"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type createInviteRequest struct {
+	// MaxUses limits how many users may join; 0 means unlimited.
+	MaxUses int `json:"max_uses"`
+	// ExpiresInSeconds is the invite's lifetime; 0 means it never expires.
+	ExpiresInSeconds int `json:"expires_in_seconds"`
+}
+
+// GetInvite handles GET /api/invites/:code.
+// @Summary Preview an invite
+// @Description Show the sanctum or conversation an invite code leads to.
+// @Tags invites
+// @Produce json
+// @Param code path string true "Invite code"
+// @Success 200 {object} models.Invite
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /invites/{code} [get]
+func (s *Server) GetInvite(c *fiber.Ctx) error {
+	invite, err := s.inviteService.GetInvite(c.UserContext(), c.Params("code"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(invite)
+}
+
+// RedeemInvite handles POST /api/invites/:code/redeem.
+// @Summary Redeem an invite
+// @Description Join the invite's sanctum or conversation. Joining a sanctum also opens its channels. New joiners are greeted by the welcome bot.
+// @Tags invites
+// @Produce json
+// @Param code path string true "Invite code"
+// @Success 200 {object} object{invite=models.Invite,room_id=int,joined=bool}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /invites/{code}/redeem [post]
+func (s *Server) RedeemInvite(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	redeemed, err := s.inviteService.RedeemInvite(ctx, userID, c.Params("code"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	if redeemed.Joined {
+		if redeemed.Invite.Sanctum != nil {
+			cache.InvalidateSanctum(ctx, redeemed.Invite.Sanctum.Slug)
+		}
+		if redeemed.RoomID != 0 {
+			s.broadcastChatroomPresenceSnapshot(ctx, redeemed.RoomID, userID, "", "joined_room")
+			s.maybeSendWelcomeRoomJoinMessage(ctx, userID, redeemed.RoomID)
+		}
+	}
+	var roomID *uint
+	if redeemed.RoomID != 0 {
+		roomID = &redeemed.RoomID
+	}
+	return c.JSON(fiber.Map{
+		"invite":  redeemed.Invite,
+		"room_id": roomID,
+		"joined":  redeemed.Joined,
+	})
+}
+
+// RevokeInvite handles DELETE /api/invites/:code.
+// @Summary Revoke an invite
+// @Description Disable an invite code. Allowed for its creator and the target's moderators.
+// @Tags invites
+// @Produce json
+// @Param code path string true "Invite code"
+// @Success 200 {object} models.Invite
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /invites/{code} [delete]
+func (s *Server) RevokeInvite(c *fiber.Ctx) error {
+	invite, err := s.inviteService.RevokeInvite(c.UserContext(), c.Locals("userID").(uint), c.Params("code"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(invite)
+}
+
+// CreateSanctumInvite handles POST /api/sanctums/:slug/invites.
+// @Summary Create a sanctum invite
+// @Description Create an invite code for the sanctum. Members may invite to public sanctums; restricted and private sanctums need a moderator.
+// @Tags invites
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param request body createInviteRequest true "Invite limits"
+// @Success 201 {object} models.Invite
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/invites [post]
+func (s *Server) CreateSanctumInvite(c *fiber.Ctx) error {
+	sanctum, err := s.findSanctumBySlug(c.UserContext(), c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return s.createInvite(c, models.InviteTargetSanctum, sanctum.ID)
+}
+
+// GetSanctumInvites handles GET /api/sanctums/:slug/invites.
+// @Summary List sanctum invites
+// @Description List the sanctum's invite codes. Moderators see all codes, other members their own.
+// @Tags invites
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Success 200 {array} models.Invite
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/invites [get]
+func (s *Server) GetSanctumInvites(c *fiber.Ctx) error {
+	sanctum, err := s.findSanctumBySlug(c.UserContext(), c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return s.listInvites(c, models.InviteTargetSanctum, sanctum.ID)
+}
+
+// CreateConversationInvite handles POST /api/conversations/:id/invites.
+// @Summary Create a conversation invite
+// @Description Create an invite code for a chatroom or group DM. Participants only; sanctum channels are joined through sanctum invites.
+// @Tags invites
+// @Accept json
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Param request body createInviteRequest true "Invite limits"
+// @Success 201 {object} models.Invite
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /conversations/{id}/invites [post]
+func (s *Server) CreateConversationInvite(c *fiber.Ctx) error {
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	return s.createInvite(c, models.InviteTargetConversation, convID)
+}
+
+// GetConversationInvites handles GET /api/conversations/:id/invites.
+// @Summary List conversation invites
+// @Description List a conversation's invite codes. Room moderators see all codes, other participants their own.
+// @Tags invites
+// @Produce json
+// @Param id path int true "Conversation ID"
+// @Success 200 {array} models.Invite
+// @Security BearerAuth
+// @Router /conversations/{id}/invites [get]
+func (s *Server) GetConversationInvites(c *fiber.Ctx) error {
+	convID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	return s.listInvites(c, models.InviteTargetConversation, convID)
+}
+
+func (s *Server) createInvite(c *fiber.Ctx, targetType models.InviteTargetType, targetID uint) error {
+	var req createInviteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	invite, err := s.inviteService.CreateInvite(c.UserContext(), service.CreateInviteInput{
+		ActorID:    c.Locals("userID").(uint),
+		TargetType: targetType,
+		TargetID:   targetID,
+		MaxUses:    req.MaxUses,
+		ExpiresIn:  time.Duration(req.ExpiresInSeconds) * time.Second,
+	})
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(invite)
+}
+
+func (s *Server) listInvites(c *fiber.Ctx, targetType models.InviteTargetType, targetID uint) error {
+	invites, err := s.inviteService.ListInvites(c.UserContext(), c.Locals("userID").(uint), targetType, targetID)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(invites)
+}
@@ -0,0 +1,131 @@
+// Package server contains HTTP and WebSocket handlers for the application's API endpoints.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"sanctum/internal/cache"
+	"sanctum/internal/models"
+	"sanctum/internal/notifications"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// linkUnfurlTimeout bounds a background unfurl, including the preview image upload.
+const linkUnfurlTimeout = 20 * time.Second
+
+// GetLinkPreview handles GET /api/link-preview?url=
+func (s *Server) GetLinkPreview(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+
+	preview, err := s.linkPreviewSvc.Unfurl(ctx, c.Query("url"), userID)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+
+	return c.JSON(preview)
+}
+
+// unfurlPostLink fetches a preview for a link post in the background and
+// stores it on the post once ready.
+func (s *Server) unfurlPostLink(post *models.Post) {
+	if s.linkPreviewSvc == nil || s.db == nil ||
+		post.PostType != models.PostTypeLink || post.LinkURL == "" {
+		return
+	}
+	postID, userID, linkURL := post.ID, post.UserID, post.LinkURL
+	published := post.Status == "" || post.Status == models.PostStatusPublished
+
+	go func() {
+		ctx, cancel := context.WithTimeout(s.backgroundCtx(), linkUnfurlTimeout)
+		defer cancel()
+
+		preview, err := s.linkPreviewSvc.Unfurl(ctx, linkURL, userID)
+		if err != nil || preview.IsEmpty() {
+			return
+		}
+		if err := s.db.WithContext(ctx).
+			Model(&models.Post{ID: postID}).
+			Select("link_preview").
+			Updates(&models.Post{LinkPreview: preview}).Error; err != nil {
+			log.Printf("store link preview for post %d: %v", postID, err)
+			return
+		}
+		cache.Invalidate(ctx, cache.PostKey(postID))
+		cache.InvalidatePostsList(ctx)
+
+		if published {
+			s.publishBroadcastEvent(EventPostLinkPreview, map[string]interface{}{
+				"post_id":      postID,
+				"link_preview": preview,
+			})
+		}
+	}()
+}
+
+// unfurlMessageLink previews the first URL in a chat message and pushes the
+// result to everyone viewing the conversation.
+func (s *Server) unfurlMessageLink(message *models.Message) {
+	if s.linkPreviewSvc == nil || s.db == nil {
+		return
+	}
+	linkURL := service.FirstLinkInText(message.Content)
+	if linkURL == "" {
+		return
+	}
+	messageID, convID, senderID := message.ID, message.ConversationID, message.SenderID
+
+	go func() {
+		ctx, cancel := context.WithTimeout(s.backgroundCtx(), linkUnfurlTimeout)
+		defer cancel()
+
+		preview, err := s.linkPreviewSvc.Unfurl(ctx, linkURL, senderID)
+		if err != nil || preview.IsEmpty() {
+			return
+		}
+		if err := s.db.WithContext(ctx).
+			Model(&models.Message{ID: messageID}).
+			Select("link_preview").
+			Updates(&models.Message{LinkPreview: preview}).Error; err != nil {
+			log.Printf("store link preview for message %d: %v", messageID, err)
+			return
+		}
+		cache.InvalidateRoom(ctx, convID)
+
+		event := notifications.ChatMessage{
+			Type:           "message_link_preview",
+			ConversationID: convID,
+			UserID:         senderID,
+			Payload: map[string]interface{}{
+				"message_id":   messageID,
+				"link_preview": preview,
+			},
+		}
+		if s.notifier != nil {
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return
+			}
+			if err := s.notifier.PublishChatMessage(ctx, convID, string(payload)); err != nil {
+				log.Printf("publish link preview for message %d: %v", messageID, err)
+			}
+			return
+		}
+		if s.chatHub != nil {
+			s.chatHub.BroadcastToConversation(convID, event)
+		}
+	}()
+}
+
+// backgroundCtx is the parent context for work that outlives a request.
+func (s *Server) backgroundCtx() context.Context {
+	if s.shutdownCtx == nil {
+		return context.Background()
+	}
+	return s.shutdownCtx
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -121,6 +122,8 @@ func (s *Server) ReportPost(c *fiber.Ctx) error {
 	var req struct {
 		Reason  string `json:"reason"`
 		Details string `json:"details"`
+		// RuleNumber cites one of the post's sanctum rules as the reason.
+		RuleNumber int `json:"rule_number"`
 	}
 	if bodyErr := c.BodyParser(&req); bodyErr != nil {
 		return models.RespondWithError(c, fiber.StatusBadRequest,
@@ -128,13 +131,31 @@ func (s *Server) ReportPost(c *fiber.Ctx) error {
 	}
 
 	var post models.Post
-	if dbErr := s.db.WithContext(ctx).Select("id", "user_id").First(&post, postID).Error; dbErr != nil {
+	if dbErr := s.db.WithContext(ctx).Select("id", "user_id", "sanctum_id").First(&post, postID).Error; dbErr != nil {
 		if errors.Is(dbErr, gorm.ErrRecordNotFound) {
 			return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Post", postID))
 		}
 		return models.RespondWithError(c, fiber.StatusInternalServerError, dbErr)
 	}
 
+	if req.RuleNumber != 0 {
+		if post.SanctumID == nil {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("rule_number can only be used on sanctum posts"))
+		}
+		settings, settingsErr := s.sanctumRulesSvc.Get(ctx, *post.SanctumID)
+		if settingsErr != nil {
+			return models.RespondWithError(c, fiber.StatusInternalServerError, settingsErr)
+		}
+		rule := settings.Rule(req.RuleNumber)
+		if rule == nil {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("rule_number does not match a sanctum rule"))
+		}
+		// Rule titles are capped at 100 characters, so this fits the reason column.
+		req.Reason = fmt.Sprintf("Rule %d: %s", rule.Number, rule.Title)
+	}
+
 	report, createErr := s.createModerationReport(ctx, reporterID, models.ReportTargetPost, postID, &post.UserID, req.Reason, req.Details)
 	if createErr != nil {
 		return models.RespondWithError(c, fiber.StatusBadRequest, createErr)
@@ -381,6 +402,10 @@ func (s *Server) GetAdminUserDetail(c *fiber.Ctx) error {
 		}
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
+	if s.registrationSvc != nil && !detail.User.IsAdmin {
+		quota := s.registrationSvc.InviteQuota(&detail.User)
+		detail.InviteQuota = &quota
+	}
 
 	return c.JSON(detail)
 }
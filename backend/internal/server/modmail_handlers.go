@@ -0,0 +1,278 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"sanctum/internal/middleware"
+	"sanctum/internal/models"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type createModmailRequest struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+type modmailReplyRequest struct {
+	Body     string `json:"body"`
+	Internal bool   `json:"internal"`
+}
+
+type modmailAssignRequest struct {
+	// UserID is the moderator to assign; null clears the assignment.
+	UserID *uint `json:"user_id"`
+}
+
+func modmailThreadSummary(thread *models.ModmailThread) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                  thread.ID,
+		"sanctum_id":          thread.SanctumID,
+		"user_id":             thread.UserID,
+		"subject":             thread.Subject,
+		"status":              thread.Status,
+		"assigned_to_user_id": thread.AssignedToUserID,
+		"last_message_at":     thread.LastMessageAt.Format(time.RFC3339Nano),
+	}
+}
+
+// notifyModmail sends a modmail event to the sanctum's mod team, and to the
+// thread's user unless the event is mod-only.
+func (s *Server) notifyModmail(ctx context.Context, thread *models.ModmailThread, eventType string, payload map[string]interface{}, includeUser bool) {
+	teamIDs, err := s.modmailService.TeamIDs(ctx, thread.SanctumID)
+	if err != nil {
+		middleware.Logger.ErrorContext(ctx, "modmail: failed to load mod team", "error", err, "sanctum_id", thread.SanctumID)
+		return
+	}
+	notified := make(map[uint]struct{}, len(teamIDs)+1)
+	for _, id := range teamIDs {
+		notified[id] = struct{}{}
+		s.publishUserEvent(id, eventType, payload)
+	}
+	if _, isMod := notified[thread.UserID]; includeUser && !isMod {
+		s.publishUserEvent(thread.UserID, eventType, payload)
+	}
+}
+
+// CreateModmailThread handles POST /api/sanctums/:slug/modmail.
+// @Summary Message a sanctum's moderators
+// @Description Open a modmail thread addressed to the sanctum's whole mod team.
+// @Tags modmail
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param request body createModmailRequest true "First message"
+// @Success 201 {object} models.ModmailThread
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/modmail [post]
+func (s *Server) CreateModmailThread(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req createModmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	thread, err := s.modmailService.CreateThread(ctx, c.Locals("userID").(uint), sanctum.ID, req.Subject, req.Body)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	s.notifyModmail(ctx, thread, EventModmailThreadCreated, modmailThreadSummary(thread), false)
+	return c.Status(fiber.StatusCreated).JSON(thread)
+}
+
+// GetSanctumModmail handles GET /api/sanctums/:slug/modmail.
+// @Summary List a sanctum's modmail
+// @Description List the sanctum's modmail threads, most recently active first. Sanctum moderators only.
+// @Tags modmail
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param status query string false "open (default) or archived"
+// @Param assigned_to query int false "Only threads assigned to this moderator"
+// @Param limit query int false "Limit (default 50)"
+// @Param offset query int false "Offset"
+// @Success 200 {array} models.ModmailThread
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/modmail [get]
+func (s *Server) GetSanctumModmail(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	page := parsePagination(c, 50)
+	threads, err := s.modmailService.ListForSanctum(ctx, service.ListModmailInput{
+		ActorID:    c.Locals("userID").(uint),
+		SanctumID:  sanctum.ID,
+		Status:     models.ModmailStatus(c.Query("status")),
+		AssignedTo: uint(c.QueryInt("assigned_to", 0)),
+		Limit:      page.Limit,
+		Offset:     page.Offset,
+	})
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(threads)
+}
+
+// GetMyModmail handles GET /api/modmail/me.
+// @Summary List my modmail
+// @Description List the modmail threads the current user has opened.
+// @Tags modmail
+// @Produce json
+// @Param limit query int false "Limit (default 50)"
+// @Param offset query int false "Offset"
+// @Success 200 {array} models.ModmailThread
+// @Security BearerAuth
+// @Router /modmail/me [get]
+func (s *Server) GetMyModmail(c *fiber.Ctx) error {
+	page := parsePagination(c, 50)
+	threads, err := s.modmailService.ListMine(c.UserContext(), c.Locals("userID").(uint), page.Limit, page.Offset)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(threads)
+}
+
+// GetModmailThread handles GET /api/modmail/:id.
+// @Summary Get a modmail thread
+// @Description Get a modmail thread and its messages. Internal notes are only returned to moderators.
+// @Tags modmail
+// @Produce json
+// @Param id path int true "Thread ID"
+// @Success 200 {object} models.ModmailThread
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /modmail/{id} [get]
+func (s *Server) GetModmailThread(c *fiber.Ctx) error {
+	threadID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	thread, err := s.modmailService.GetThread(c.UserContext(), c.Locals("userID").(uint), threadID)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(thread)
+}
+
+// ReplyModmailThread handles POST /api/modmail/:id/messages.
+// @Summary Reply to a modmail thread
+// @Description Add a message to a modmail thread. Moderators may set internal to leave a note only the mod team sees.
+// @Tags modmail
+// @Accept json
+// @Produce json
+// @Param id path int true "Thread ID"
+// @Param request body modmailReplyRequest true "Message"
+// @Success 201 {object} models.ModmailMessage
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /modmail/{id}/messages [post]
+func (s *Server) ReplyModmailThread(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	threadID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	var req modmailReplyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	thread, message, err := s.modmailService.Reply(ctx, c.Locals("userID").(uint), threadID, req.Body, req.Internal)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	payload := modmailThreadSummary(thread)
+	payload["message"] = message
+	s.notifyModmail(ctx, thread, EventModmailMessage, payload, !message.Internal)
+	return c.Status(fiber.StatusCreated).JSON(message)
+}
+
+// AssignModmailThread handles PUT /api/modmail/:id/assignee.
+// @Summary Assign a modmail thread
+// @Description Assign a thread to one of the sanctum's moderators, or clear the assignment. Sanctum moderators only.
+// @Tags modmail
+// @Accept json
+// @Produce json
+// @Param id path int true "Thread ID"
+// @Param request body modmailAssignRequest true "Assignee"
+// @Success 200 {object} models.ModmailThread
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /modmail/{id}/assignee [put]
+func (s *Server) AssignModmailThread(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	threadID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	var req modmailAssignRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	thread, err := s.modmailService.Assign(ctx, c.Locals("userID").(uint), threadID, req.UserID)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	s.notifyModmail(ctx, thread, EventModmailThreadUpdated, modmailThreadSummary(thread), false)
+	return c.JSON(thread)
+}
+
+func (s *Server) setModmailArchived(c *fiber.Ctx, archived bool) error {
+	ctx := c.UserContext()
+	threadID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	thread, err := s.modmailService.SetArchived(ctx, c.Locals("userID").(uint), threadID, archived)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	s.notifyModmail(ctx, thread, EventModmailThreadUpdated, modmailThreadSummary(thread), true)
+	return c.JSON(thread)
+}
+
+// ArchiveModmailThread handles POST /api/modmail/:id/archive.
+// @Summary Archive a modmail thread
+// @Description Move a thread out of the open inbox. A reply from the user reopens it. Sanctum moderators only.
+// @Tags modmail
+// @Produce json
+// @Param id path int true "Thread ID"
+// @Success 200 {object} models.ModmailThread
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /modmail/{id}/archive [post]
+func (s *Server) ArchiveModmailThread(c *fiber.Ctx) error {
+	return s.setModmailArchived(c, true)
+}
+
+// UnarchiveModmailThread handles POST /api/modmail/:id/unarchive.
+// @Summary Reopen a modmail thread
+// @Description Move an archived thread back to the open inbox. Sanctum moderators only.
+// @Tags modmail
+// @Produce json
+// @Param id path int true "Thread ID"
+// @Success 200 {object} models.ModmailThread
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /modmail/{id}/unarchive [post]
+func (s *Server) UnarchiveModmailThread(c *fiber.Ctx) error {
+	return s.setModmailArchived(c, false)
+}
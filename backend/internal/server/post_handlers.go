@@ -2,7 +2,9 @@
 package server
 
 import (
+	"context"
 	"errors"
+	"log"
 	"strconv"
 	"time"
 
@@ -46,6 +48,11 @@ func (s *Server) CreatePost(c *fiber.Ctx) error {
 		YoutubeURL string                       `json:"youtube_url,omitempty"`
 		SanctumID  *uint                        `json:"sanctum_id,omitempty"`
 		Poll       *service.CreatePostPollInput `json:"poll,omitempty"`
+		Images     []service.GalleryImageInput  `json:"images,omitempty"`
+		FlairID    *uint                        `json:"flair_id,omitempty"`
+		Tags       []string                     `json:"tags,omitempty"`
+		Draft      bool                         `json:"draft,omitempty"`
+		PublishAt  *time.Time                   `json:"publish_at,omitempty"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return models.RespondWithError(c, fiber.StatusBadRequest,
@@ -62,23 +69,98 @@ func (s *Server) CreatePost(c *fiber.Ctx) error {
 		YoutubeURL: req.YoutubeURL,
 		SanctumID:  req.SanctumID,
 		Poll:       req.Poll,
+		Images:     req.Images,
+		FlairID:    req.FlairID,
+		Tags:       req.Tags,
+		Draft:      req.Draft,
+		PublishAt:  req.PublishAt,
 	})
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+
+	if post.Status == models.PostStatusPublished {
+		s.publishPostCreated(ctx, post)
+	}
+	s.unfurlPostLink(post)
+
+	return c.Status(fiber.StatusCreated).JSON(post)
+}
+
+// GetMyDrafts handles GET /api/users/me/drafts
+func (s *Server) GetMyDrafts(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	page := parsePagination(c, 20)
+
+	posts, err := s.postSvc().ListDrafts(ctx, userID, page.Limit, page.Offset)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+
+	return c.JSON(posts)
+}
+
+// PublishPost handles POST /api/posts/:id/publish
+func (s *Server) PublishPost(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	postID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	post, err := s.postSvc().PublishPost(ctx, userID, postID)
 	if err != nil {
 		status := fiber.StatusInternalServerError
 		var appErr *models.AppError
-		if errors.As(err, &appErr) && appErr.Code == "VALIDATION_ERROR" {
-			status = fiber.StatusBadRequest
+		if errors.As(err, &appErr) {
+			switch appErr.Code {
+			case "VALIDATION_ERROR":
+				status = fiber.StatusBadRequest
+			case "UNAUTHORIZED":
+				status = fiber.StatusForbidden
+			case "NOT_FOUND":
+				status = fiber.StatusNotFound
+			}
 		}
 		return models.RespondWithError(c, status, err)
 	}
 
-	s.publishBroadcastEvent(EventPostCreated, map[string]interface{}{
+	s.publishPostCreated(ctx, post)
+
+	return c.JSON(post)
+}
+
+func (s *Server) publishPostCreated(ctx context.Context, post *models.Post) {
+	s.publishPostEvent(ctx, post, EventPostCreated, map[string]interface{}{
 		"post_id":    post.ID,
 		"author_id":  post.UserID,
 		"created_at": time.Now().UTC().Format(time.RFC3339Nano),
 	})
+}
 
-	return c.Status(fiber.StatusCreated).JSON(post)
+// publishScheduledPosts periodically publishes scheduled posts that are due.
+// Safe to run on every replica; PublishDue claims each post exactly once.
+func (s *Server) publishScheduledPosts(ctx context.Context) {
+	const batchSize = 50
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			posts, err := s.postSvc().PublishDuePosts(ctx, batchSize)
+			if err != nil {
+				log.Printf("scheduled post publisher: %v", err)
+				continue
+			}
+			for _, post := range posts {
+				s.publishPostCreated(ctx, post)
+			}
+		}
+	}
 }
 
 // GetPosts handles GET /api/posts
@@ -102,9 +184,11 @@ func (s *Server) GetPosts(c *fiber.Ctx) error {
 		Offset:        page.Offset,
 		CurrentUserID: userID,
 		SanctumID:     sanctumID,
+		FlairID:       uint(c.QueryInt("flair_id", 0)),
+		Tag:           c.Query("tag"),
 	})
 	if err != nil {
-		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+		return models.RespondWithError(c, appErrorStatus(err), err)
 	}
 
 	return c.JSON(posts)
@@ -156,11 +240,15 @@ func (s *Server) UpdatePost(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		Title      string `json:"title"`
-		Content    string `json:"content"`
-		ImageURL   string `json:"image_url,omitempty"`
-		LinkURL    string `json:"link_url,omitempty"`
-		YoutubeURL string `json:"youtube_url,omitempty"`
+		Title      string                      `json:"title"`
+		Content    string                      `json:"content"`
+		ImageURL   string                      `json:"image_url,omitempty"`
+		LinkURL    string                      `json:"link_url,omitempty"`
+		YoutubeURL string                      `json:"youtube_url,omitempty"`
+		PublishAt  *time.Time                  `json:"publish_at,omitempty"`
+		Images     []service.GalleryImageInput `json:"images,omitempty"`
+		FlairID    *uint                       `json:"flair_id,omitempty"`
+		Tags       []string                    `json:"tags,omitempty"`
 	}
 	parseErr := c.BodyParser(&req)
 	if parseErr != nil {
@@ -176,19 +264,13 @@ func (s *Server) UpdatePost(c *fiber.Ctx) error {
 		ImageURL:   req.ImageURL,
 		LinkURL:    req.LinkURL,
 		YoutubeURL: req.YoutubeURL,
+		PublishAt:  req.PublishAt,
+		Images:     req.Images,
+		FlairID:    req.FlairID,
+		Tags:       req.Tags,
 	})
 	if err != nil {
-		status := fiber.StatusInternalServerError
-		var appErr *models.AppError
-		if errors.As(err, &appErr) {
-			switch appErr.Code {
-			case "UNAUTHORIZED":
-				status = fiber.StatusForbidden
-			case "NOT_FOUND":
-				status = fiber.StatusNotFound
-			}
-		}
-		return models.RespondWithError(c, status, err)
+		return models.RespondWithError(c, appErrorStatus(err), err)
 	}
 
 	return c.JSON(post)
@@ -235,7 +317,7 @@ func (s *Server) LikePost(c *fiber.Ctx) error {
 
 	post, err := s.postSvc().ToggleLike(ctx, userID, postID)
 	if err != nil {
-		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+		return models.RespondWithError(c, appErrorStatus(err), err)
 	}
 
 	s.publishBroadcastEvent(EventPostReactionUpdated, map[string]interface{}{
@@ -259,7 +341,7 @@ func (s *Server) UnlikePost(c *fiber.Ctx) error {
 
 	post, err := s.postSvc().UnlikePost(ctx, userID, postID)
 	if err != nil {
-		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+		return models.RespondWithError(c, appErrorStatus(err), err)
 	}
 
 	s.publishBroadcastEvent(EventPostReactionUpdated, map[string]interface{}{
@@ -282,35 +364,75 @@ func (s *Server) VotePoll(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		PollOptionID uint `json:"poll_option_id"`
+		PollOptionID  uint   `json:"poll_option_id"`
+		PollOptionIDs []uint `json:"poll_option_ids"`
 	}
 	if bodyErr := c.BodyParser(&req); bodyErr != nil {
 		return models.RespondWithError(c, fiber.StatusBadRequest,
 			models.NewValidationError("Invalid request body"))
 	}
-	if req.PollOptionID == 0 {
+	optionIDs := req.PollOptionIDs
+	if len(optionIDs) == 0 && req.PollOptionID != 0 {
+		optionIDs = []uint{req.PollOptionID}
+	}
+	if len(optionIDs) == 0 {
 		return models.RespondWithError(c, fiber.StatusBadRequest,
-			models.NewValidationError("poll_option_id is required"))
+			models.NewValidationError("poll_option_id or poll_option_ids is required"))
 	}
 
-	post, err := s.postSvc().VotePoll(ctx, userID, postID, req.PollOptionID)
+	post, err := s.postSvc().VotePoll(ctx, userID, postID, optionIDs)
 	if err != nil {
-		status := fiber.StatusInternalServerError
-		var appErr *models.AppError
-		if errors.As(err, &appErr) {
-			switch appErr.Code {
-			case "VALIDATION_ERROR":
-				status = fiber.StatusBadRequest
-			case "NOT_FOUND":
-				status = fiber.StatusNotFound
-			}
-		}
-		return models.RespondWithError(c, status, err)
+		return models.RespondWithError(c, appErrorStatus(err), err)
 	}
 
+	s.publishPollVotes(ctx, post)
 	return c.JSON(post)
 }
 
+// RetractPollVote handles DELETE /api/posts/:id/poll/vote
+func (s *Server) RetractPollVote(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+	postID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+
+	post, err := s.postSvc().RetractPollVote(ctx, userID, postID)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+
+	s.publishPollVotes(ctx, post)
+	return c.JSON(post)
+}
+
+// publishPollVotes pushes fresh vote counts to connected clients who can see
+// the post. Per-option counts are left out while a poll hides its results.
+func (s *Server) publishPollVotes(ctx context.Context, post *models.Post) {
+	if post == nil || post.Poll == nil {
+		return
+	}
+	poll := post.Poll
+	payload := map[string]interface{}{
+		"post_id":      post.ID,
+		"poll_id":      poll.ID,
+		"total_voters": poll.TotalVoters,
+		"closed":       poll.Closed,
+	}
+	if !poll.HideResults || poll.Closed {
+		options := make([]map[string]interface{}, 0, len(poll.Options))
+		for _, opt := range poll.Options {
+			options = append(options, map[string]interface{}{
+				"id":          opt.ID,
+				"votes_count": opt.VotesCount,
+			})
+		}
+		payload["options"] = options
+	}
+	s.publishPostEvent(ctx, post, EventPollVotesUpdated, payload)
+}
+
 func (s *Server) postSvc() *service.PostService {
 	return s.postService
 }
@@ -7,8 +7,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"sanctum/internal/models"
+	"sanctum/internal/repository"
 	"sanctum/internal/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -34,13 +36,21 @@ func (m *MockPostRepository) GetByID(ctx context.Context, id uint, currentUserID
 	return args.Get(0).(*models.Post), args.Error(1)
 }
 
+func (m *MockPostRepository) GetByIDs(ctx context.Context, ids []uint, currentUserID uint) ([]*models.Post, error) {
+	args := m.Called(ctx, ids, currentUserID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Post), args.Error(1)
+}
+
 func (m *MockPostRepository) GetByUserID(ctx context.Context, userID uint, limit, offset int, currentUserID uint) ([]*models.Post, error) {
 	args := m.Called(ctx, userID, limit, offset, currentUserID)
 	return args.Get(0).([]*models.Post), args.Error(1)
 }
 
-func (m *MockPostRepository) GetBySanctumID(ctx context.Context, sanctumID uint, limit, offset int, currentUserID uint) ([]*models.Post, error) {
-	args := m.Called(ctx, sanctumID, limit, offset, currentUserID)
+func (m *MockPostRepository) GetBySanctumID(ctx context.Context, sanctumID uint, filter repository.SanctumFeedFilter, limit, offset int, currentUserID uint) ([]*models.Post, error) {
+	args := m.Called(ctx, sanctumID, filter, limit, offset, currentUserID)
 	return args.Get(0).([]*models.Post), args.Error(1)
 }
 
@@ -54,8 +64,13 @@ func (m *MockPostRepository) Search(ctx context.Context, query string, limit, of
 	return args.Get(0).([]*models.Post), args.Error(1)
 }
 
-func (m *MockPostRepository) Update(ctx context.Context, post *models.Post) error {
-	args := m.Called(ctx, post)
+func (m *MockPostRepository) Update(ctx context.Context, post *models.Post, images []models.PostImage) error {
+	args := m.Called(ctx, post, images)
+	return args.Error(0)
+}
+
+func (m *MockPostRepository) ReplaceTags(ctx context.Context, postID uint, tags []string) error {
+	args := m.Called(ctx, postID, tags)
 	return args.Error(0)
 }
 
@@ -87,21 +102,39 @@ func (m *MockPostRepository) Unlike(ctx context.Context, userID, postID uint) er
 	return args.Error(0)
 }
 
+func (m *MockPostRepository) ListDrafts(ctx context.Context, userID uint, limit, offset int) ([]*models.Post, error) {
+	args := m.Called(ctx, userID, limit, offset)
+	return args.Get(0).([]*models.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) PublishDue(ctx context.Context, now time.Time, limit int) ([]*models.Post, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Post), args.Error(1)
+}
+
+func (m *MockPostRepository) Publish(ctx context.Context, postID uint, now time.Time) (bool, error) {
+	args := m.Called(ctx, postID, now)
+	return args.Bool(0), args.Error(1)
+}
+
 // MockPollRepository is a mock of the PollRepository interface
 type MockPollRepository struct {
 	mock.Mock
 }
 
-func (m *MockPollRepository) Create(ctx context.Context, postID uint, question string, options []string) (*models.Poll, error) {
-	args := m.Called(ctx, postID, question, options)
+func (m *MockPollRepository) Create(ctx context.Context, postID uint, question string, options []string, settings models.PollSettings) (*models.Poll, error) {
+	args := m.Called(ctx, postID, question, options, settings)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Poll), args.Error(1)
 }
 
-func (m *MockPollRepository) Vote(ctx context.Context, userID, pollID, pollOptionID uint) error {
-	args := m.Called(ctx, userID, pollID, pollOptionID)
+func (m *MockPollRepository) SetVotes(ctx context.Context, userID, pollID uint, optionIDs []uint) error {
+	args := m.Called(ctx, userID, pollID, optionIDs)
 	return args.Error(0)
 }
 
@@ -113,7 +146,7 @@ func (m *MockPollRepository) EnrichWithResults(ctx context.Context, poll *models
 func TestCreatePost(t *testing.T) {
 	app := fiber.New()
 	mockRepo := new(MockPostRepository)
-	postService := service.NewPostService(mockRepo, nil, nil)
+	postService := service.NewPostService(mockRepo, nil, nil, nil, nil, nil, nil, nil, nil)
 	s := &Server{postRepo: mockRepo, postService: postService}
 
 	app.Use(func(c *fiber.Ctx) error {
@@ -184,3 +217,56 @@ func TestVotePoll_MissingOptionID(t *testing.T) {
 	defer func() { _ = resp.Body.Close() }()
 	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 }
+
+func TestGetPost_DraftVisibleOnlyToAuthor(t *testing.T) {
+	mockRepo := new(MockPostRepository)
+	s := &Server{postRepo: mockRepo, postService: service.NewPostService(mockRepo, nil, nil, nil, nil, nil, nil, nil, nil)}
+	draft := &models.Post{ID: 7, Title: "WIP", UserID: 1, Status: models.PostStatusDraft}
+	mockRepo.On("GetByID", mock.Anything, uint(7), mock.Anything).Return(draft, nil)
+
+	newApp := func(userID uint) *fiber.App {
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("userID", userID)
+			return c.Next()
+		})
+		app.Post("/posts/:id/publish", s.PublishPost)
+		return app
+	}
+
+	// Anonymous readers cannot see the draft.
+	anon := fiber.New()
+	anon.Get("/posts/:id", s.GetPost)
+	resp, _ := anon.Test(httptest.NewRequest(http.MethodGet, "/posts/7", nil))
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// Only the author may publish it.
+	resp, _ = newApp(2).Test(httptest.NewRequest(http.MethodPost, "/posts/7/publish", nil))
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestCreatePost_PublishAtMustBeFuture(t *testing.T) {
+	app := fiber.New()
+	mockRepo := new(MockPostRepository)
+	s := &Server{postRepo: mockRepo, postService: service.NewPostService(mockRepo, nil, nil, nil, nil, nil, nil, nil, nil)}
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userID", uint(1))
+		return c.Next()
+	})
+	app.Post("/posts", s.CreatePost)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"title":      "Later",
+		"content":    "Scheduled",
+		"publish_at": time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+	})
+	req := httptest.NewRequest(http.MethodPost, "/posts", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, _ := app.Test(req)
+	defer func() { _ = resp.Body.Close() }()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
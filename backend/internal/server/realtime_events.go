@@ -11,7 +11,9 @@ import (
 // Event type constants prevent typos in event names.
 const (
 	EventPostCreated            = "post_created"
+	EventPostLinkPreview        = "post_link_preview"
 	EventPostReactionUpdated    = "post_reaction_updated"
+	EventPollVotesUpdated       = "poll_votes_updated"
 	EventCommentCreated         = "comment_created"
 	EventCommentUpdated         = "comment_updated"
 	EventCommentDeleted         = "comment_deleted"
@@ -27,6 +29,9 @@ const (
 	EventFriendPresenceChanged  = "friend_presence_changed"
 	EventSanctumRequestCreated  = "sanctum_request_created"
 	EventSanctumRequestReviewed = "sanctum_request_reviewed"
+	EventModmailThreadCreated   = "modmail_thread_created"
+	EventModmailMessage         = "modmail_message"
+	EventModmailThreadUpdated   = "modmail_thread_updated"
 )
 
 func (s *Server) publishAdminEvent(eventType string, payload map[string]interface{}) {
@@ -84,6 +89,35 @@ func (s *Server) publishBroadcastEvent(eventType string, payload map[string]inte
 	}
 }
 
+// publishPostEvent sends a post's event to everyone, or only to sanctum
+// members when the post is in a private or restricted sanctum.
+func (s *Server) publishPostEvent(ctx context.Context, post *models.Post, eventType string, payload map[string]interface{}) {
+	if post.SanctumID == nil {
+		s.publishBroadcastEvent(eventType, payload)
+		return
+	}
+	var sanctum models.Sanctum
+	if err := s.db.WithContext(ctx).Select("id", "visibility").First(&sanctum, *post.SanctumID).Error; err != nil {
+		log.Printf("failed to load sanctum %d for %s event: %v", *post.SanctumID, eventType, err)
+		return
+	}
+	if sanctum.Visibility == "" || sanctum.Visibility == models.SanctumVisibilityPublic {
+		s.publishBroadcastEvent(eventType, payload)
+		return
+	}
+
+	var memberIDs []uint
+	if err := s.db.WithContext(ctx).Model(&models.SanctumMembership{}).
+		Where("sanctum_id = ?", sanctum.ID).
+		Pluck("user_id", &memberIDs).Error; err != nil {
+		log.Printf("failed to fetch sanctum %d members for %s event: %v", sanctum.ID, eventType, err)
+		return
+	}
+	for _, memberID := range memberIDs {
+		s.publishUserEvent(memberID, eventType, payload)
+	}
+}
+
 func userSummary(user models.User) map[string]interface{} {
 	return map[string]interface{}{
 		"id":       user.ID,
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"sanctum/internal/models"
+	"sanctum/internal/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type joinWaitlistRequest struct {
+	Email string `json:"email"`
+	Note  string `json:"note"`
+}
+
+type setInviteQuotaRequest struct {
+	// Quota overrides the configured default; null restores it.
+	Quota *int `json:"quota"`
+}
+
+// GetRegistrationInfo handles GET /api/auth/registration.
+// @Summary Get the registration mode
+// @Description Report whether signup is open, invite-only or waitlisted so clients can show the right form.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} object{mode=string}
+// @Router /auth/registration [get]
+func (s *Server) GetRegistrationInfo(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"mode": s.registrationSvc.Mode()})
+}
+
+// JoinWaitlist handles POST /api/auth/waitlist.
+// @Summary Join the signup waitlist
+// @Description Ask for an account while registration is waitlisted. Approved emails can sign up without an invite code.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body joinWaitlistRequest true "Waitlist request"
+// @Success 201 {object} models.WaitlistEntry
+// @Failure 400 {object} models.ErrorResponse
+// @Router /auth/waitlist [post]
+func (s *Server) JoinWaitlist(c *fiber.Ctx) error {
+	var req joinWaitlistRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	if err := validation.ValidateEmail(req.Email); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError(err.Error()))
+	}
+	entry, err := s.registrationSvc.JoinWaitlist(c.UserContext(), req.Email, req.Note)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(entry)
+}
+
+// GetMySignupInvites handles GET /api/users/me/signup-invites.
+// @Summary List my signup invites
+// @Description List the signup invite codes you have issued and how many more you may create.
+// @Tags users
+// @Produce json
+// @Success 200 {object} service.SignupInviteList
+// @Security BearerAuth
+// @Router /users/me/signup-invites [get]
+func (s *Server) GetMySignupInvites(c *fiber.Ctx) error {
+	list, err := s.registrationSvc.ListSignupInvites(c.UserContext(), c.Locals("userID").(uint))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(list)
+}
+
+// CreateSignupInvite handles POST /api/users/me/signup-invites.
+// @Summary Create a signup invite
+// @Description Issue a single-use code that lets someone sign up. Limited by your invite quota; revoked unused codes do not count.
+// @Tags users
+// @Produce json
+// @Success 201 {object} models.SignupInvite
+// @Failure 400 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/signup-invites [post]
+func (s *Server) CreateSignupInvite(c *fiber.Ctx) error {
+	invite, err := s.registrationSvc.CreateSignupInvite(c.UserContext(), c.Locals("userID").(uint))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(invite)
+}
+
+// RevokeSignupInvite handles DELETE /api/users/me/signup-invites/:id.
+// @Summary Revoke a signup invite
+// @Description Disable one of your unused signup invite codes and return it to your quota.
+// @Tags users
+// @Produce json
+// @Param id path int true "Signup invite ID"
+// @Success 200 {object} models.SignupInvite
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /users/me/signup-invites/{id} [delete]
+func (s *Server) RevokeSignupInvite(c *fiber.Ctx) error {
+	inviteID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	invite, err := s.registrationSvc.RevokeSignupInvite(c.UserContext(), c.Locals("userID").(uint), inviteID)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(invite)
+}
+
+// GetAdminWaitlist handles GET /api/admin/waitlist.
+// @Summary List the signup waitlist
+// @Description List waitlist entries, oldest first. Filter with status=pending|approved|rejected.
+// @Tags moderation-admin
+// @Produce json
+// @Param status query string false "Entry status"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {array} models.WaitlistEntry
+// @Failure 400 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/waitlist [get]
+func (s *Server) GetAdminWaitlist(c *fiber.Ctx) error {
+	status := models.WaitlistStatus(c.Query("status"))
+	switch status {
+	case "", models.WaitlistStatusPending, models.WaitlistStatusApproved, models.WaitlistStatusRejected:
+	default:
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("status must be pending, approved or rejected"))
+	}
+	page := parsePagination(c, 50)
+	entries, err := s.registrationSvc.ListWaitlist(c.UserContext(), status, page.Limit, page.Offset)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(entries)
+}
+
+// ApproveWaitlistEntry handles POST /api/admin/waitlist/:id/approve.
+// @Summary Approve a waitlist entry
+// @Description Let the entry's email sign up without an invite code.
+// @Tags moderation-admin
+// @Produce json
+// @Param id path int true "Waitlist entry ID"
+// @Success 200 {object} models.WaitlistEntry
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/waitlist/{id}/approve [post]
+func (s *Server) ApproveWaitlistEntry(c *fiber.Ctx) error {
+	return s.reviewWaitlistEntry(c, true)
+}
+
+// RejectWaitlistEntry handles POST /api/admin/waitlist/:id/reject.
+// @Summary Reject a waitlist entry
+// @Description Decline the entry's request for an account.
+// @Tags moderation-admin
+// @Produce json
+// @Param id path int true "Waitlist entry ID"
+// @Success 200 {object} models.WaitlistEntry
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/waitlist/{id}/reject [post]
+func (s *Server) RejectWaitlistEntry(c *fiber.Ctx) error {
+	return s.reviewWaitlistEntry(c, false)
+}
+
+func (s *Server) reviewWaitlistEntry(c *fiber.Ctx, approve bool) error {
+	entryID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	entry, err := s.registrationSvc.ReviewWaitlist(c.UserContext(), c.Locals("userID").(uint), entryID, approve)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(entry)
+}
+
+// SetUserInviteQuota handles PUT /api/admin/users/:id/invite-quota.
+// @Summary Set a user's signup invite quota
+// @Description Override how many signup invites the user may issue. Send null to restore the configured default.
+// @Tags moderation-admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param request body setInviteQuotaRequest true "Quota"
+// @Success 200 {object} object{user_id=int,invite_quota=int}
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/users/{id}/invite-quota [put]
+func (s *Server) SetUserInviteQuota(c *fiber.Ctx) error {
+	targetID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	var req setInviteQuotaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	user, err := s.registrationSvc.SetInviteQuota(c.UserContext(), targetID, req.Quota)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(fiber.Map{
+		"user_id":      user.ID,
+		"invite_quota": s.registrationSvc.InviteQuota(user),
+	})
+}
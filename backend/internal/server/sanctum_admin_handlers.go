@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"sanctum/internal/models"
+	"sanctum/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
@@ -173,16 +174,22 @@ func (s *Server) PromoteSanctumAdmin(c *fiber.Ctx) error {
 		UserID:    targetUserID,
 		Role:      models.SanctumMembershipRoleMod,
 	}
-	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
-		Columns: []clause.Column{
-			{Name: "sanctum_id"},
-			{Name: "user_id"},
-		},
-		DoUpdates: clause.Assignments(map[string]any{
-			"role":       models.SanctumMembershipRoleMod,
-			"updated_at": time.Now(),
-		}),
-	}).Create(&membership).Error; err != nil {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{
+				{Name: "sanctum_id"},
+				{Name: "user_id"},
+			},
+			DoUpdates: clause.Assignments(map[string]any{
+				"role":       models.SanctumMembershipRoleMod,
+				"updated_at": time.Now(),
+			}),
+		}).Create(&membership).Error; err != nil {
+			return err
+		}
+		return service.SyncSanctumChannelAccess(ctx, tx, sanctum.ID, targetUserID, true)
+	})
+	if err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
 
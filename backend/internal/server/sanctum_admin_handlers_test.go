@@ -24,6 +24,8 @@ func setupSanctumAdminTestDB(t *testing.T) *gorm.DB {
 		&models.User{},
 		&models.Sanctum{},
 		&models.SanctumMembership{},
+		&models.Conversation{},
+		&models.ConversationParticipant{},
 	); err != nil {
 		t.Fatalf("migrate sqlite: %v", err)
 	}
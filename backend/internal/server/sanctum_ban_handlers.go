@@ -0,0 +1,107 @@
+package server
+
+import (
+	"time"
+
+	"sanctum/internal/models"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type banSanctumUserRequest struct {
+	UserID    uint                  `json:"user_id"`
+	Kind      models.SanctumBanKind `json:"kind"`
+	Reason    string                `json:"reason"`
+	ExpiresAt *time.Time            `json:"expires_at"`
+}
+
+// GetSanctumBans handles GET /api/sanctums/:slug/bans.
+// @Summary List sanctum bans
+// @Description List bans and mutes currently in force in a sanctum. Sanctum owners only.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Success 200 {array} models.SanctumBan
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/bans [get]
+func (s *Server) GetSanctumBans(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	bans, err := s.sanctumBanService.ListBans(ctx, c.Locals("userID").(uint), sanctum.ID)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(bans)
+}
+
+// BanSanctumUser handles POST /api/sanctums/:slug/bans.
+// @Summary Ban or mute a user in a sanctum
+// @Description Ban a user from a sanctum, or mute them until expires_at. Replaces any existing ban. Sanctum owners only.
+// @Tags sanctums-admin
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param request body banSanctumUserRequest true "Ban"
+// @Success 201 {object} models.SanctumBan
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/bans [post]
+func (s *Server) BanSanctumUser(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req banSanctumUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	ban, err := s.sanctumBanService.Ban(ctx, service.BanSanctumUserInput{
+		ActorID:   c.Locals("userID").(uint),
+		SanctumID: sanctum.ID,
+		UserID:    req.UserID,
+		Kind:      req.Kind,
+		Reason:    req.Reason,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(ban)
+}
+
+// UnbanSanctumUser handles DELETE /api/sanctums/:slug/bans/:userId.
+// @Summary Lift a sanctum ban
+// @Description Lift a user's ban or mute in a sanctum. Sanctum owners only.
+// @Tags sanctums-admin
+// @Param slug path string true "Sanctum slug"
+// @Param userId path int true "User ID"
+// @Success 204
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/bans/{userId} [delete]
+func (s *Server) UnbanSanctumUser(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID, err := s.parseID(c, "userId")
+	if err != nil {
+		return nil
+	}
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	if err := s.sanctumBanService.Unban(ctx, c.Locals("userID").(uint), sanctum.ID, userID); err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"sanctum/internal/cache"
+	"sanctum/internal/models"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type sanctumThemeRequest struct {
+	// Omitted colours are left unchanged; "" resets to the default theme.
+	AccentColor     *string `json:"accent_color"`
+	AccentColorDark *string `json:"accent_color_dark"`
+}
+
+// respondSanctumBranding drops the cached sanctum and returns its DTO.
+func (s *Server) respondSanctumBranding(c *fiber.Ctx, sanctum *models.Sanctum) error {
+	ctx := c.UserContext()
+	cache.InvalidateSanctum(ctx, sanctum.Slug)
+	roomID, err := s.sanctumRoomID(ctx, sanctum.ID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	return c.JSON(toSanctumDTO(s.imageSvc(), *sanctum, roomID, s.loadSanctumImages(ctx, *sanctum)))
+}
+
+func (s *Server) uploadSanctumImage(c *fiber.Ctx, slot service.SanctumImageSlot) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	in, err := readImageUpload(c)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest, err)
+	}
+	updated, _, err := s.brandingService.SetImage(ctx, sanctum.ID, slot, in)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return s.respondSanctumBranding(c, updated)
+}
+
+func (s *Server) clearSanctumImage(c *fiber.Ctx, slot service.SanctumImageSlot) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	updated, err := s.brandingService.ClearImage(ctx, c.Locals("userID").(uint), sanctum.ID, slot)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return s.respondSanctumBranding(c, updated)
+}
+
+// UploadSanctumIcon handles PUT /api/sanctums/:slug/icon.
+// @Summary Upload a sanctum icon
+// @Description Upload the sanctum's icon through the image pipeline, replacing any existing one. Sanctum owners only.
+// @Tags sanctums-admin
+// @Accept multipart/form-data
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param image formData file true "Icon image"
+// @Success 200 {object} SanctumDTO
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/icon [put]
+func (s *Server) UploadSanctumIcon(c *fiber.Ctx) error {
+	return s.uploadSanctumImage(c, service.SanctumImageIcon)
+}
+
+// DeleteSanctumIcon handles DELETE /api/sanctums/:slug/icon.
+// @Summary Remove a sanctum icon
+// @Description Remove the sanctum's icon. Sanctum owners only.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Success 200 {object} SanctumDTO
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/icon [delete]
+func (s *Server) DeleteSanctumIcon(c *fiber.Ctx) error {
+	return s.clearSanctumImage(c, service.SanctumImageIcon)
+}
+
+// UploadSanctumBanner handles PUT /api/sanctums/:slug/banner.
+// @Summary Upload a sanctum banner
+// @Description Upload the sanctum's banner through the image pipeline, replacing any existing one. Sanctum owners only.
+// @Tags sanctums-admin
+// @Accept multipart/form-data
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param image formData file true "Banner image"
+// @Success 200 {object} SanctumDTO
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/banner [put]
+func (s *Server) UploadSanctumBanner(c *fiber.Ctx) error {
+	return s.uploadSanctumImage(c, service.SanctumImageBanner)
+}
+
+// DeleteSanctumBanner handles DELETE /api/sanctums/:slug/banner.
+// @Summary Remove a sanctum banner
+// @Description Remove the sanctum's banner. Sanctum owners only.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Success 200 {object} SanctumDTO
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/banner [delete]
+func (s *Server) DeleteSanctumBanner(c *fiber.Ctx) error {
+	return s.clearSanctumImage(c, service.SanctumImageBanner)
+}
+
+// UpdateSanctumTheme handles PUT /api/sanctums/:slug/theme.
+// @Summary Set sanctum accent colours
+// @Description Set the sanctum's accent colours for light and dark themes as #rrggbb hex. Sanctum owners only.
+// @Tags sanctums-admin
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param request body sanctumThemeRequest true "Accent colours"
+// @Success 200 {object} SanctumDTO
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/theme [put]
+func (s *Server) UpdateSanctumTheme(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req sanctumThemeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	updated, err := s.brandingService.UpdateTheme(ctx, service.UpdateSanctumThemeInput{
+		ActorID:         c.Locals("userID").(uint),
+		SanctumID:       sanctum.ID,
+		AccentColor:     req.AccentColor,
+		AccentColorDark: req.AccentColorDark,
+	})
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return s.respondSanctumBranding(c, updated)
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"sanctum/internal/models"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type createSanctumChannelRequest struct {
+	Name           string                       `json:"name"`
+	Topic          string                       `json:"topic"`
+	PostPermission models.ChannelPostPermission `json:"post_permission"`
+}
+
+type updateSanctumChannelRequest struct {
+	Name           *string                       `json:"name"`
+	Topic          *string                       `json:"topic"`
+	PostPermission *models.ChannelPostPermission `json:"post_permission"`
+}
+
+type reorderSanctumChannelsRequest struct {
+	ChannelIDs []uint `json:"channel_ids"`
+}
+
+// GetSanctumChannels handles GET /api/sanctums/:slug/channels.
+// @Summary List a sanctum's chat channels
+// @Description List the sanctum's chat channels in display order. Members of the sanctum are participants of every channel.
+// @Tags sanctum-channels
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Success 200 {array} models.Conversation
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /sanctums/{slug}/channels [get]
+func (s *Server) GetSanctumChannels(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	userID, _ := s.optionalUserID(c)
+	channels, err := s.channelService.ListChannels(ctx, sanctum.ID, userID)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(channels)
+}
+
+// CreateSanctumChannel handles POST /api/sanctums/:slug/channels.
+// @Summary Create a chat channel
+// @Description Add a channel to the end of the sanctum's channel list. Set post_permission to mods for announcement channels. Sanctum moderators only.
+// @Tags sanctum-channels
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param request body createSanctumChannelRequest true "Channel"
+// @Success 201 {object} models.Conversation
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/channels [post]
+func (s *Server) CreateSanctumChannel(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req createSanctumChannelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	channel, err := s.channelService.CreateChannel(ctx, service.CreateSanctumChannelInput{
+		ActorID:        c.Locals("userID").(uint),
+		SanctumID:      sanctum.ID,
+		Name:           req.Name,
+		Topic:          req.Topic,
+		PostPermission: req.PostPermission,
+	})
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(channel)
+}
+
+// UpdateSanctumChannel handles PATCH /api/sanctums/:slug/channels/:channelId.
+// @Summary Update a chat channel
+// @Description Rename a channel or change its topic or post permission. Omitted fields are unchanged. Sanctum moderators only.
+// @Tags sanctum-channels
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param channelId path int true "Channel ID"
+// @Param request body updateSanctumChannelRequest true "Channel changes"
+// @Success 200 {object} models.Conversation
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/channels/{channelId} [patch]
+func (s *Server) UpdateSanctumChannel(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	channelID, err := s.parseID(c, "channelId")
+	if err != nil {
+		return nil
+	}
+	var req updateSanctumChannelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	channel, err := s.channelService.UpdateChannel(ctx, service.UpdateSanctumChannelInput{
+		ActorID:        c.Locals("userID").(uint),
+		SanctumID:      sanctum.ID,
+		ChannelID:      channelID,
+		Name:           req.Name,
+		Topic:          req.Topic,
+		PostPermission: req.PostPermission,
+	})
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(channel)
+}
+
+// ReorderSanctumChannels handles PUT /api/sanctums/:slug/channels/order.
+// @Summary Reorder chat channels
+// @Description Set the display order of the sanctum's channels. channel_ids must list every channel once. Sanctum moderators only.
+// @Tags sanctum-channels
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param request body reorderSanctumChannelsRequest true "Channel order"
+// @Success 200 {array} models.Conversation
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/channels/order [put]
+func (s *Server) ReorderSanctumChannels(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req reorderSanctumChannelsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	channels, err := s.channelService.ReorderChannels(ctx, c.Locals("userID").(uint), sanctum.ID, req.ChannelIDs)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(channels)
+}
+
+// DeleteSanctumChannel handles DELETE /api/sanctums/:slug/channels/:channelId.
+// @Summary Delete a chat channel
+// @Description Delete a channel. The sanctum's default channel cannot be deleted. Sanctum moderators only.
+// @Tags sanctum-channels
+// @Param slug path string true "Sanctum slug"
+// @Param channelId path int true "Channel ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/channels/{channelId} [delete]
+func (s *Server) DeleteSanctumChannel(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	channelID, err := s.parseID(c, "channelId")
+	if err != nil {
+		return nil
+	}
+	if err := s.channelService.DeleteChannel(ctx, c.Locals("userID").(uint), sanctum.ID, channelID); err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
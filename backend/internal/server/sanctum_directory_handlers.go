@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"sanctum/internal/cache"
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// directoryRefreshInterval is how often sanctum stats and recommendations
+// are recomputed.
+const directoryRefreshInterval = 15 * time.Minute
+
+// refreshSanctumDirectory recomputes directory stats and recommendations at
+// startup and then periodically. Called from Start().
+func (s *Server) refreshSanctumDirectory(ctx context.Context) {
+	refresh := func() {
+		if err := s.directoryService.Refresh(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("sanctum directory refresh: %v", err)
+		}
+	}
+	refresh()
+	ticker := time.NewTicker(directoryRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refresh()
+		}
+	}
+}
+
+// GetSanctumCategories handles GET /api/sanctums/categories.
+// @Summary List sanctum categories
+// @Description List the directory categories with the number of active sanctums in each.
+// @Tags sanctums
+// @Produce json
+// @Success 200 {array} service.SanctumCategoryCount
+// @Router /sanctums/categories [get]
+func (s *Server) GetSanctumCategories(c *fiber.Ctx) error {
+	categories, err := s.directoryService.Categories(c.UserContext())
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(categories)
+}
+
+// GetTrendingSanctums handles GET /api/sanctums/trending.
+// @Summary Trending sanctums
+// @Description List the sanctums trending this week: the most posts, comments and new members relative to their size.
+// @Tags sanctums
+// @Produce json
+// @Param category query string false "Category"
+// @Param limit query int false "Limit (default 10)"
+// @Success 200 {array} SanctumDTO
+// @Failure 400 {object} models.ErrorResponse
+// @Router /sanctums/trending [get]
+func (s *Server) GetTrendingSanctums(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	page := parsePagination(c, 10)
+	entries, err := s.directoryService.Trending(ctx, models.SanctumCategory(c.Query("category")), page.Limit)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	resp, err := s.sanctumDirectoryDTOs(ctx, entries)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	return c.JSON(resp)
+}
+
+// GetRecommendedSanctums handles GET /api/sanctums/recommended.
+// @Summary Recommended sanctums
+// @Description List sanctums popular with members of the current user's sanctums. Falls back to trending sanctums for users without recommendations.
+// @Tags sanctums
+// @Produce json
+// @Param limit query int false "Limit (default 10)"
+// @Success 200 {array} SanctumDTO
+// @Security BearerAuth
+// @Router /sanctums/recommended [get]
+func (s *Server) GetRecommendedSanctums(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	page := parsePagination(c, 10)
+	entries, err := s.directoryService.Recommended(ctx, c.Locals("userID").(uint), page.Limit)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	resp, err := s.sanctumDirectoryDTOs(ctx, entries)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	return c.JSON(resp)
+}
+
+// UpdateSanctumCategory handles PUT /api/sanctums/:slug/category.
+// @Summary Set sanctum category
+// @Description File the sanctum under a directory category. Sanctum owners only.
+// @Tags sanctums-admin
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param request body object{category=string} true "Category"
+// @Success 200 {object} SanctumDTO
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/category [put]
+func (s *Server) UpdateSanctumCategory(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req struct {
+		Category models.SanctumCategory `json:"category"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	updated, err := s.directoryService.SetCategory(ctx, c.Locals("userID").(uint), sanctum.ID, req.Category)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	cache.InvalidateSanctum(ctx, updated.Slug)
+	roomID, err := s.sanctumRoomID(ctx, updated.ID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	return c.JSON(toSanctumDTO(s.imageSvc(), *updated, roomID, s.loadSanctumImages(ctx, *updated)))
+}
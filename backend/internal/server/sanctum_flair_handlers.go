@@ -0,0 +1,145 @@
+package server
+
+import (
+	"sanctum/internal/models"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type sanctumFlairRequest struct {
+	Name     *string `json:"name"`
+	Color    *string `json:"color"`
+	ModOnly  *bool   `json:"mod_only"`
+	Position *int    `json:"position"`
+}
+
+func (r sanctumFlairRequest) input(actorID, sanctumID uint) service.SanctumFlairInput {
+	return service.SanctumFlairInput{
+		ActorID:   actorID,
+		SanctumID: sanctumID,
+		Name:      r.Name,
+		Color:     r.Color,
+		ModOnly:   r.ModOnly,
+		Position:  r.Position,
+	}
+}
+
+// GetSanctumFlairs handles GET /api/sanctums/:slug/flairs.
+// @Summary List sanctum flairs
+// @Description List the post flairs a sanctum defines.
+// @Tags sanctums
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Success 200 {array} models.SanctumFlair
+// @Failure 404 {object} models.ErrorResponse
+// @Router /sanctums/{slug}/flairs [get]
+func (s *Server) GetSanctumFlairs(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+
+	flairs, err := s.flairService.List(ctx, sanctum.ID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	return c.JSON(flairs)
+}
+
+// CreateSanctumFlair handles POST /api/sanctums/:slug/flairs.
+// @Summary Create sanctum flair
+// @Description Define a new post flair. Requires sanctum owner or moderator.
+// @Tags sanctums-admin
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Success 201 {object} models.SanctumFlair
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/flairs [post]
+func (s *Server) CreateSanctumFlair(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req sanctumFlairRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	flair, err := s.flairService.Create(ctx, req.input(c.Locals("userID").(uint), sanctum.ID))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(flair)
+}
+
+// UpdateSanctumFlair handles PATCH /api/sanctums/:slug/flairs/:flairId.
+// @Summary Update sanctum flair
+// @Description Rename, recolour or reorder a flair. Requires sanctum owner or moderator.
+// @Tags sanctums-admin
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param flairId path int true "Flair ID"
+// @Success 200 {object} models.SanctumFlair
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/flairs/{flairId} [patch]
+func (s *Server) UpdateSanctumFlair(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	flairID, err := s.parseID(c, "flairId")
+	if err != nil {
+		return nil
+	}
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req sanctumFlairRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	flair, err := s.flairService.Update(ctx, flairID, req.input(c.Locals("userID").(uint), sanctum.ID))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(flair)
+}
+
+// DeleteSanctumFlair handles DELETE /api/sanctums/:slug/flairs/:flairId.
+// Posts that carried the flair keep their content and lose the label.
+// @Summary Delete sanctum flair
+// @Description Remove a flair from a sanctum. Requires sanctum owner or moderator.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param flairId path int true "Flair ID"
+// @Success 204
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/flairs/{flairId} [delete]
+func (s *Server) DeleteSanctumFlair(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	flairID, err := s.parseID(c, "flairId")
+	if err != nil {
+		return nil
+	}
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	if err := s.flairService.Delete(ctx, c.Locals("userID").(uint), sanctum.ID, flairID); err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
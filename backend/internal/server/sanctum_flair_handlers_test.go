@@ -0,0 +1,96 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sanctum/internal/models"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanctumFlairManagement(t *testing.T) {
+	t.Parallel()
+	db := setupSanctumAdminTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.SanctumFlair{}, &models.Post{}, &models.SanctumAutomod{}))
+	s := &Server{db: db}
+	s.flairService = service.NewSanctumFlairService(db, s.canManageSanctumByUserID)
+
+	mod := models.User{Username: "mod", Email: "mod@e.com"}
+	member := models.User{Username: "member", Email: "member@e.com"}
+	db.Create(&mod)
+	db.Create(&member)
+	sanctum := models.Sanctum{Name: "Gardening", Slug: "gardening"}
+	db.Create(&sanctum)
+	db.Create(&models.SanctumMembership{SanctumID: sanctum.ID, UserID: mod.ID, Role: models.SanctumMembershipRoleMod})
+	db.Create(&models.SanctumMembership{SanctumID: sanctum.ID, UserID: member.ID, Role: models.SanctumMembershipRoleMember})
+
+	newApp := func(userID uint) *fiber.App {
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("userID", userID)
+			return c.Next()
+		})
+		app.Get("/sanctums/:slug/flairs", s.GetSanctumFlairs)
+		app.Post("/sanctums/:slug/flairs", s.CreateSanctumFlair)
+		app.Patch("/sanctums/:slug/flairs/:flairId", s.UpdateSanctumFlair)
+		app.Delete("/sanctums/:slug/flairs/:flairId", s.DeleteSanctumFlair)
+		return app
+	}
+	send := func(app *fiber.App, method, path string, body interface{}) *http.Response {
+		var payload []byte
+		if body != nil {
+			payload, _ = json.Marshal(body)
+		}
+		req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		return resp
+	}
+	base := fmt.Sprintf("/sanctums/%s/flairs", sanctum.Slug)
+
+	resp := send(newApp(member.ID), http.MethodPost, base, map[string]interface{}{"name": "News", "color": "#ff0000"})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "members cannot manage flairs")
+
+	modApp := newApp(mod.ID)
+	resp = send(modApp, http.MethodPost, base, map[string]interface{}{"name": "News", "color": "red"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "colour must be hex")
+
+	resp = send(modApp, http.MethodPost, base, map[string]interface{}{"name": "Announcement", "color": "#FF0000", "mod_only": true})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var flair models.SanctumFlair
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&flair))
+	assert.Equal(t, "#ff0000", flair.Color)
+	assert.True(t, flair.ModOnly)
+
+	resp = send(modApp, http.MethodPost, base, map[string]interface{}{"name": "Announcement", "color": "#00ff00"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "names are unique per sanctum")
+
+	resp = send(modApp, http.MethodPatch, fmt.Sprintf("%s/%d", base, flair.ID+100), map[string]interface{}{"name": "Missing"})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = send(modApp, http.MethodPatch, fmt.Sprintf("%s/%d", base, flair.ID), map[string]interface{}{"name": "Official"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	post := models.Post{Title: "Hello", Content: "x", UserID: mod.ID, SanctumID: &sanctum.ID, FlairID: &flair.ID}
+	require.NoError(t, db.Create(&post).Error)
+	resp = send(modApp, http.MethodDelete, fmt.Sprintf("%s/%d", base, flair.ID), nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.NoError(t, db.First(&post, post.ID).Error)
+	assert.Nil(t, post.FlairID, "deleting a flair clears it from posts")
+
+	resp = send(newApp(member.ID), http.MethodGet, base, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var flairs []models.SanctumFlair
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&flairs))
+	assert.Empty(t, flairs)
+}
@@ -1,11 +1,14 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"time"
 
+	"sanctum/internal/middleware"
 	"sanctum/internal/models"
+	"sanctum/internal/service"
 	"sanctum/internal/validation"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,15 +18,30 @@ import (
 
 // SanctumDTO is the API response model for sanctum endpoints.
 type SanctumDTO struct {
-	ID                uint                 `json:"id"`
-	Name              string               `json:"name"`
-	Slug              string               `json:"slug"`
-	Description       string               `json:"description"`
-	CreatedByUserID   *uint                `json:"created_by_user_id"`
-	Status            models.SanctumStatus `json:"status"`
-	CreatedAt         string               `json:"created_at"`
-	UpdatedAt         string               `json:"updated_at"`
-	DefaultChatRoomID *uint                `json:"default_chat_room_id"`
+	ID                uint                     `json:"id"`
+	Name              string                   `json:"name"`
+	Slug              string                   `json:"slug"`
+	Description       string                   `json:"description"`
+	CreatedByUserID   *uint                    `json:"created_by_user_id"`
+	Status            models.SanctumStatus     `json:"status"`
+	Visibility        models.SanctumVisibility `json:"visibility"`
+	CreatedAt         string                   `json:"created_at"`
+	UpdatedAt         string                   `json:"updated_at"`
+	DefaultChatRoomID *uint                    `json:"default_chat_room_id"`
+	Icon              *SanctumImageDTO         `json:"icon"`
+	Banner            *SanctumImageDTO         `json:"banner"`
+	AccentColor       string                   `json:"accent_color"`
+	AccentColorDark   string                   `json:"accent_color_dark"`
+	Category          models.SanctumCategory   `json:"category"`
+	// Stats is included by directory listings only.
+	Stats *models.SanctumStats `json:"stats,omitempty"`
+}
+
+// SanctumImageDTO is a sanctum icon or banner with its resized variants.
+type SanctumImageDTO struct {
+	Hash     string            `json:"hash"`
+	URL      string            `json:"url"`
+	Variants map[string]string `json:"variants"`
 }
 
 // SanctumMembershipDTO is the API response model for sanctum memberships.
@@ -36,7 +54,10 @@ type SanctumMembershipDTO struct {
 	Sanctum   SanctumDTO                   `json:"sanctum"`
 }
 
-func toSanctumDTO(s models.Sanctum, defaultRoomID *uint) SanctumDTO {
+// toSanctumDTO converts a sanctum for the API. images holds the sanctum's
+// branding images by hash, as loaded by loadSanctumImages; images missing
+// from it are returned without variants.
+func toSanctumDTO(imageSvc *service.ImageService, s models.Sanctum, defaultRoomID *uint, images map[string]*models.Image) SanctumDTO {
 	return SanctumDTO{
 		ID:                s.ID,
 		Name:              s.Name,
@@ -44,44 +65,100 @@ func toSanctumDTO(s models.Sanctum, defaultRoomID *uint) SanctumDTO {
 		Description:       s.Description,
 		CreatedByUserID:   s.CreatedByUserID,
 		Status:            s.Status,
+		Visibility:        s.Visibility,
 		CreatedAt:         s.CreatedAt.UTC().Format("2006-01-02T15:04:05.999999999Z07:00"),
 		UpdatedAt:         s.UpdatedAt.UTC().Format("2006-01-02T15:04:05.999999999Z07:00"),
 		DefaultChatRoomID: defaultRoomID,
+		Icon:              toSanctumImageDTO(imageSvc, s.IconHash, images),
+		Banner:            toSanctumImageDTO(imageSvc, s.BannerHash, images),
+		AccentColor:       s.AccentColor,
+		AccentColorDark:   s.AccentColorDark,
+		Category:          s.Category,
+	}
+}
+
+func toSanctumImageDTO(imageSvc *service.ImageService, hash string, images map[string]*models.Image) *SanctumImageDTO {
+	if hash == "" {
+		return nil
+	}
+	dto := &SanctumImageDTO{
+		Hash:     hash,
+		URL:      imageSvc.BuildMasterImageURL(hash),
+		Variants: map[string]string{},
+	}
+	if img := images[hash]; img != nil {
+		dto.Variants = imageSvc.BuildVariantsMap(hash, img.Variants)
+	}
+	return dto
+}
+
+// loadSanctumImages loads the icons and banners of sanctums, with variants,
+// keyed by hash. Failures are logged and yield images without variants.
+func (s *Server) loadSanctumImages(ctx context.Context, sanctums ...models.Sanctum) map[string]*models.Image {
+	hashes := make([]string, 0, 2*len(sanctums))
+	for _, sanctum := range sanctums {
+		for _, hash := range []string{sanctum.IconHash, sanctum.BannerHash} {
+			if hash != "" {
+				hashes = append(hashes, hash)
+			}
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	images, err := s.imageSvc().GetByHashes(ctx, hashes)
+	if err != nil {
+		middleware.Logger.ErrorContext(ctx, "sanctum: failed to load branding images", "error", err)
+		return nil
 	}
+	return images
 }
 
-func toSanctumMembershipDTO(m models.SanctumMembership, sanctum models.Sanctum, defaultRoomID *uint) SanctumMembershipDTO {
+func toSanctumMembershipDTO(imageSvc *service.ImageService, m models.SanctumMembership, sanctum models.Sanctum, defaultRoomID *uint, images map[string]*models.Image) SanctumMembershipDTO {
 	return SanctumMembershipDTO{
 		SanctumID: m.SanctumID,
 		UserID:    m.UserID,
 		Role:      m.Role,
 		CreatedAt: m.CreatedAt.UTC().Format("2006-01-02T15:04:05.999999999Z07:00"),
 		UpdatedAt: m.UpdatedAt.UTC().Format("2006-01-02T15:04:05.999999999Z07:00"),
-		Sanctum:   toSanctumDTO(sanctum, defaultRoomID),
+		Sanctum:   toSanctumDTO(imageSvc, sanctum, defaultRoomID, images),
 	}
 }
 
 // GetSanctums handles GET /api/sanctums
 // @Summary List sanctums
-// @Description List active sanctums.
+// @Description List active sanctums with their member counts and activity, optionally within one category.
 // @Tags sanctums
 // @Produce json
+// @Param category query string false "Category"
+// @Param sort query string false "name (default), members or active"
 // @Success 200 {array} SanctumDTO
+// @Failure 400 {object} models.ErrorResponse
 // @Router /sanctums [get]
 func (s *Server) GetSanctums(c *fiber.Ctx) error {
-	ctx := c.Context()
-
-	var sanctums []models.Sanctum
-	if err := s.db.WithContext(ctx).
-		Where("status = ?", models.SanctumStatusActive).
-		Order("name ASC").
-		Find(&sanctums).Error; err != nil {
+	ctx := c.UserContext()
+	entries, err := s.directoryService.List(ctx, service.DirectoryQuery{
+		Category: models.SanctumCategory(c.Query("category")),
+		Sort:     c.Query("sort"),
+	})
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	resp, err := s.sanctumDirectoryDTOs(ctx, entries)
+	if err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
+	return c.JSON(resp)
+}
 
-	ids := make([]uint, 0, len(sanctums))
-	for _, sanctum := range sanctums {
-		ids = append(ids, sanctum.ID)
+// sanctumDirectoryDTOs converts directory entries, with their stats, chat
+// rooms and branding images.
+func (s *Server) sanctumDirectoryDTOs(ctx context.Context, entries []service.SanctumDirectoryEntry) ([]SanctumDTO, error) {
+	ids := make([]uint, 0, len(entries))
+	sanctums := make([]models.Sanctum, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.Sanctum.ID)
+		sanctums = append(sanctums, entry.Sanctum)
 	}
 
 	roomBySanctumID := map[uint]uint{}
@@ -89,9 +166,9 @@ func (s *Server) GetSanctums(c *fiber.Ctx) error {
 		var rooms []models.Conversation
 		if err := s.db.WithContext(ctx).
 			Select("id", "sanctum_id").
-			Where("sanctum_id IN ?", ids).
+			Where("sanctum_id IN ? AND is_default = ?", ids, true).
 			Find(&rooms).Error; err != nil {
-			return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+			return nil, err
 		}
 		for _, room := range rooms {
 			if room.SanctumID != nil {
@@ -100,16 +177,19 @@ func (s *Server) GetSanctums(c *fiber.Ctx) error {
 		}
 	}
 
-	resp := make([]SanctumDTO, 0, len(sanctums))
-	for _, sanctum := range sanctums {
+	images := s.loadSanctumImages(ctx, sanctums...)
+	resp := make([]SanctumDTO, 0, len(entries))
+	for _, entry := range entries {
 		var roomID *uint
-		if id, ok := roomBySanctumID[sanctum.ID]; ok {
+		if id, ok := roomBySanctumID[entry.Sanctum.ID]; ok {
 			roomID = &id
 		}
-		resp = append(resp, toSanctumDTO(sanctum, roomID))
+		dto := toSanctumDTO(s.imageSvc(), entry.Sanctum, roomID, images)
+		stats := entry.Stats
+		dto.Stats = &stats
+		resp = append(resp, dto)
 	}
-
-	return c.JSON(resp)
+	return resp, nil
 }
 
 // GetSanctumBySlug handles GET /api/sanctums/:slug
@@ -140,15 +220,28 @@ func (s *Server) GetSanctumBySlug(c *fiber.Ctx) error {
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
 
-	var room models.Conversation
-	var roomID *uint
-	if err := s.db.WithContext(ctx).Select("id").Where("sanctum_id = ?", sanctum.ID).First(&room).Error; err == nil {
-		roomID = &room.ID
-	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+	roomID, err := s.sanctumRoomID(ctx, sanctum.ID)
+	if err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
 
-	return c.JSON(toSanctumDTO(sanctum, roomID))
+	return c.JSON(toSanctumDTO(s.imageSvc(), sanctum, roomID, s.loadSanctumImages(ctx, sanctum)))
+}
+
+// sanctumRoomID returns the ID of the sanctum's default chat channel, or nil
+// when it has none.
+func (s *Server) sanctumRoomID(ctx context.Context, sanctumID uint) (*uint, error) {
+	var room models.Conversation
+	err := s.db.WithContext(ctx).Select("id").
+		Where("sanctum_id = ? AND is_default = ?", sanctumID, true).
+		First(&room).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &room.ID, nil
 }
 
 // CreateSanctumRequest handles POST /api/sanctums/requests
@@ -291,7 +384,7 @@ func (s *Server) GetMySanctumMemberships(c *fiber.Ctx) error {
 		var rooms []models.Conversation
 		if err := s.db.WithContext(ctx).
 			Select("id", "sanctum_id").
-			Where("sanctum_id IN ?", sanctumIDs).
+			Where("sanctum_id IN ? AND is_default = ?", sanctumIDs, true).
 			Find(&rooms).Error; err != nil {
 			return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 		}
@@ -302,6 +395,11 @@ func (s *Server) GetMySanctumMemberships(c *fiber.Ctx) error {
 		}
 	}
 
+	branded := make([]models.Sanctum, 0, len(sanctumsByID))
+	for _, sanctum := range sanctumsByID {
+		branded = append(branded, sanctum)
+	}
+	images := s.loadSanctumImages(ctx, branded...)
 	resp := make([]SanctumMembershipDTO, 0, len(memberships))
 	for _, membership := range memberships {
 		sanctum, ok := sanctumsByID[membership.SanctumID]
@@ -312,7 +410,7 @@ func (s *Server) GetMySanctumMemberships(c *fiber.Ctx) error {
 		if id, found := roomBySanctumID[membership.SanctumID]; found {
 			roomID = &id
 		}
-		resp = append(resp, toSanctumMembershipDTO(membership, sanctum, roomID))
+		resp = append(resp, toSanctumMembershipDTO(s.imageSvc(), membership, sanctum, roomID, images))
 	}
 
 	return c.JSON(resp)
@@ -320,7 +418,7 @@ func (s *Server) GetMySanctumMemberships(c *fiber.Ctx) error {
 
 // UpsertMySanctumMemberships handles POST /api/sanctums/memberships/bulk
 // @Summary Save sanctums I follow
-// @Description Upsert current user's followed sanctums by slug and keep owner/mod memberships intact.
+// @Description Upsert current user's followed sanctums by slug and keep owner/mod memberships intact. Restricted and private sanctums can only be kept, not joined; use a join request.
 // @Tags sanctums
 // @Accept json
 // @Produce json
@@ -376,14 +474,43 @@ func (s *Server) UpsertMySanctumMemberships(c *fiber.Ctx) error {
 	for _, sanctum := range sanctums {
 		sanctumIDs = append(sanctumIDs, sanctum.ID)
 		sanctumsByID[sanctum.ID] = sanctum
+		if sanctum.Visibility != "" && sanctum.Visibility != models.SanctumVisibilityPublic {
+			if _, member, err := s.getSanctumRoleByUserID(ctx, userID, sanctum.ID); err != nil {
+				return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+			} else if !member {
+				return models.RespondWithError(c, fiber.StatusForbidden,
+					models.NewForbiddenError(sanctum.Slug+" requires approval; submit a join request"))
+			}
+		}
+		if s.sanctumBanService != nil {
+			banned, err := s.sanctumBanService.IsBanned(ctx, sanctum.ID, userID)
+			if err != nil {
+				return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+			}
+			if banned {
+				return models.RespondWithError(c, fiber.StatusForbidden,
+					models.NewForbiddenError("You are banned from "+sanctum.Slug))
+			}
+		}
 	}
 
 	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var leftIDs []uint
+		if err := tx.Model(&models.SanctumMembership{}).
+			Where("user_id = ? AND role = ? AND sanctum_id NOT IN ?", userID, models.SanctumMembershipRoleMember, sanctumIDs).
+			Pluck("sanctum_id", &leftIDs).Error; err != nil {
+			return err
+		}
 		if err := tx.
 			Where("user_id = ? AND role = ? AND sanctum_id NOT IN ?", userID, models.SanctumMembershipRoleMember, sanctumIDs).
 			Delete(&models.SanctumMembership{}).Error; err != nil {
 			return err
 		}
+		for _, sanctumID := range leftIDs {
+			if err := service.SyncSanctumChannelAccess(ctx, tx, sanctumID, userID, false); err != nil {
+				return err
+			}
+		}
 
 		for _, sanctumID := range sanctumIDs {
 			membership := models.SanctumMembership{
@@ -397,6 +524,9 @@ func (s *Server) UpsertMySanctumMemberships(c *fiber.Ctx) error {
 			}).Create(&membership).Error; err != nil {
 				return err
 			}
+			if err := service.SyncSanctumChannelAccess(ctx, tx, sanctumID, userID, true); err != nil {
+				return err
+			}
 		}
 		return nil
 	})
@@ -416,7 +546,7 @@ func (s *Server) UpsertMySanctumMemberships(c *fiber.Ctx) error {
 	var rooms []models.Conversation
 	if err := s.db.WithContext(ctx).
 		Select("id", "sanctum_id").
-		Where("sanctum_id IN ?", sanctumIDs).
+		Where("sanctum_id IN ? AND is_default = ?", sanctumIDs, true).
 		Find(&rooms).Error; err != nil {
 		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
 	}
@@ -426,6 +556,7 @@ func (s *Server) UpsertMySanctumMemberships(c *fiber.Ctx) error {
 		}
 	}
 
+	images := s.loadSanctumImages(ctx, sanctums...)
 	resp := make([]SanctumMembershipDTO, 0, len(memberships))
 	for _, membership := range memberships {
 		sanctum, ok := sanctumsByID[membership.SanctumID]
@@ -436,7 +567,7 @@ func (s *Server) UpsertMySanctumMemberships(c *fiber.Ctx) error {
 		if id, found := roomBySanctumID[membership.SanctumID]; found {
 			roomID = &id
 		}
-		resp = append(resp, toSanctumMembershipDTO(membership, sanctum, roomID))
+		resp = append(resp, toSanctumMembershipDTO(s.imageSvc(), membership, sanctum, roomID, images))
 	}
 
 	return c.JSON(resp)
@@ -565,10 +696,14 @@ func (s *Server) ApproveSanctumRequest(c *fiber.Ctx) error {
 			IsGroup:   true,
 			CreatedBy: approvedRequest.RequestedByUserID,
 			SanctumID: &createdSanctum.ID,
+			IsDefault: true,
 		}
 		if err := tx.Create(&defaultRoom).Error; err != nil {
 			return err
 		}
+		if err := service.SyncSanctumChannelAccess(ctx, tx, createdSanctum.ID, approvedRequest.RequestedByUserID, true); err != nil {
+			return err
+		}
 
 		approvedRequest.Status = models.SanctumRequestStatusApproved
 		approvedRequest.ReviewedByUserID = &reviewerID
@@ -594,7 +729,7 @@ func (s *Server) ApproveSanctumRequest(c *fiber.Ctx) error {
 	roomID := defaultRoom.ID
 	resp := fiber.Map{
 		"request": approvedRequest,
-		"sanctum": toSanctumDTO(createdSanctum, &roomID),
+		"sanctum": toSanctumDTO(s.imageSvc(), createdSanctum, &roomID, nil),
 	}
 
 	s.publishBroadcastEvent(EventSanctumRequestReviewed, map[string]interface{}{
@@ -24,6 +24,7 @@ func setupSanctumHandlerTestDB(t *testing.T) *gorm.DB {
 	if err := db.AutoMigrate(
 		&models.User{},
 		&models.Conversation{},
+		&models.ConversationParticipant{},
 		&models.Sanctum{},
 		&models.SanctumRequest{},
 		&models.SanctumMembership{},
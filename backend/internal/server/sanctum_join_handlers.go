@@ -0,0 +1,233 @@
+package server
+
+import (
+	"context"
+
+	"sanctum/internal/cache"
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// checkSanctumPost rejects a new sanctum post when its author is banned or
+// muted, is not an approved member of a non-public sanctum, or the post
+// breaks the sanctum's posting policy.
+func (s *Server) checkSanctumPost(ctx context.Context, post *models.Post) error {
+	sanctumID := *post.SanctumID
+	if err := s.sanctumBanService.CheckAccess(ctx, sanctumID, post.UserID); err != nil {
+		return err
+	}
+	if err := s.sanctumJoinSvc.CheckPostAccess(ctx, sanctumID, post.UserID); err != nil {
+		return err
+	}
+	return s.sanctumRulesSvc.CheckPost(ctx, post)
+}
+
+// checkSanctumCommentAccess rejects commenters who are banned or muted in the
+// sanctum, or who cannot read it.
+func (s *Server) checkSanctumCommentAccess(ctx context.Context, sanctumID, userID uint) error {
+	if err := s.sanctumBanService.CheckAccess(ctx, sanctumID, userID); err != nil {
+		return err
+	}
+	ok, err := s.sanctumJoinSvc.CanRead(ctx, sanctumID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.NewForbiddenError("Only members can comment in this sanctum")
+	}
+	return nil
+}
+
+// canReadPostSanctum reports whether userID may read the sanctum a post
+// belongs to. Posts outside a sanctum are always readable.
+func (s *Server) canReadPostSanctum(ctx context.Context, userID, postID uint) (bool, error) {
+	if s.sanctumJoinSvc == nil {
+		return true, nil
+	}
+	var post models.Post
+	if err := s.db.WithContext(ctx).Select("id", "user_id", "sanctum_id").First(&post, postID).Error; err != nil {
+		return false, models.NewNotFoundError("Post", postID)
+	}
+	if post.SanctumID == nil || (userID != 0 && post.UserID == userID) {
+		return true, nil
+	}
+	return s.sanctumJoinSvc.CanRead(ctx, *post.SanctumID, userID)
+}
+
+// UpdateSanctumVisibility handles PUT /api/sanctums/:slug/visibility.
+// @Summary Set sanctum visibility
+// @Description Make a sanctum public, restricted (anyone reads, approved members post) or private (members only). Sanctum owners only.
+// @Tags sanctums-admin
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param request body object{visibility=string} true "Visibility"
+// @Success 200 {object} SanctumDTO
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/visibility [put]
+func (s *Server) UpdateSanctumVisibility(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	userID := c.Locals("userID").(uint)
+
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	allowed, err := s.canManageSanctumAsOwnerByUserID(ctx, userID, sanctum.ID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	if !allowed {
+		return models.RespondWithError(c, fiber.StatusForbidden,
+			models.NewForbiddenError("Sanctum owner access required"))
+	}
+
+	var req struct {
+		Visibility models.SanctumVisibility `json:"visibility"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	if !req.Visibility.Valid() {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("visibility must be 'public', 'restricted' or 'private'"))
+	}
+	if err := s.db.WithContext(ctx).Model(sanctum).Update("visibility", req.Visibility).Error; err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	// The shared front-page cache may hold posts that just became private.
+	cache.InvalidatePostsList(ctx)
+
+	roomID, err := s.sanctumRoomID(ctx, sanctum.ID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusInternalServerError, err)
+	}
+	return c.JSON(toSanctumDTO(s.imageSvc(), *sanctum, roomID, s.loadSanctumImages(ctx, *sanctum)))
+}
+
+// CreateSanctumJoinRequest handles POST /api/sanctums/:slug/join-requests.
+// @Summary Request to join a sanctum
+// @Description Ask the moderators of a restricted or private sanctum for membership.
+// @Tags sanctums
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param request body object{message=string} false "Message to the moderators"
+// @Success 201 {object} models.SanctumJoinRequest
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/join-requests [post]
+func (s *Server) CreateSanctumJoinRequest(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req struct {
+		Message string `json:"message"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return models.RespondWithError(c, fiber.StatusBadRequest,
+				models.NewValidationError("Invalid request body"))
+		}
+	}
+	joinReq, err := s.sanctumJoinSvc.RequestJoin(ctx, sanctum.ID, c.Locals("userID").(uint), req.Message)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(joinReq)
+}
+
+// GetSanctumJoinRequests handles GET /api/sanctums/:slug/join-requests.
+// @Summary List sanctum join requests
+// @Description List join requests by status, oldest first. Defaults to pending. Sanctum owners and moderators only.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param status query string false "pending, approved or rejected"
+// @Param limit query int false "Page size"
+// @Param offset query int false "Offset"
+// @Success 200 {array} models.SanctumJoinRequest
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/join-requests [get]
+func (s *Server) GetSanctumJoinRequests(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	status := models.SanctumRequestStatus(c.Query("status"))
+	switch status {
+	case "", models.SanctumRequestStatusPending, models.SanctumRequestStatusApproved, models.SanctumRequestStatusRejected:
+	default:
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("status must be 'pending', 'approved' or 'rejected'"))
+	}
+	page := parsePagination(c, 50)
+	requests, err := s.sanctumJoinSvc.ListRequests(ctx, c.Locals("userID").(uint), sanctum.ID, status, page.Limit, page.Offset)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(requests)
+}
+
+// ApproveSanctumJoinRequest handles POST /api/sanctums/:slug/join-requests/:requestId/approve.
+// @Summary Approve a sanctum join request
+// @Description Approve a pending join request, making the requester a member. Sanctum owners and moderators only.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param requestId path int true "Join request ID"
+// @Success 200 {object} models.SanctumJoinRequest
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/join-requests/{requestId}/approve [post]
+func (s *Server) ApproveSanctumJoinRequest(c *fiber.Ctx) error {
+	return s.reviewSanctumJoinRequest(c, true)
+}
+
+// RejectSanctumJoinRequest handles POST /api/sanctums/:slug/join-requests/:requestId/reject.
+// @Summary Reject a sanctum join request
+// @Description Reject a pending join request. Sanctum owners and moderators only.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param requestId path int true "Join request ID"
+// @Success 200 {object} models.SanctumJoinRequest
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/join-requests/{requestId}/reject [post]
+func (s *Server) RejectSanctumJoinRequest(c *fiber.Ctx) error {
+	return s.reviewSanctumJoinRequest(c, false)
+}
+
+func (s *Server) reviewSanctumJoinRequest(c *fiber.Ctx, approve bool) error {
+	ctx := c.UserContext()
+	requestID, err := s.parseID(c, "requestId")
+	if err != nil {
+		return nil
+	}
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	reviewed, err := s.sanctumJoinSvc.ReviewRequest(ctx, c.Locals("userID").(uint), sanctum.ID, requestID, approve)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(reviewed)
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"sanctum/internal/models"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (s *Server) modSvc() *service.SanctumModService {
+	return s.sanctumModService
+}
+
+// respondModeratedPost writes the post after a moderator action.
+func (s *Server) respondModeratedPost(c *fiber.Ctx, postID uint, err error) error {
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	post, err := s.postSvc().GetPost(c.UserContext(), postID, c.Locals("userID").(uint))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(post)
+}
+
+// respondModeratedComment writes the comment after a moderator action.
+func (s *Server) respondModeratedComment(c *fiber.Ctx, commentID uint, err error) error {
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	comment, err := s.commentRepo.GetByID(c.UserContext(), commentID)
+	if err != nil {
+		return models.RespondWithError(c, fiber.StatusNotFound, models.NewNotFoundError("Comment", commentID))
+	}
+	service.RenderCommentHTML(comment)
+	return c.JSON(comment)
+}
+
+// RemovePostAsModerator handles POST /api/posts/:id/mod/remove
+func (s *Server) RemovePostAsModerator(c *fiber.Ctx) error {
+	postID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	err = s.modSvc().RemovePost(c.UserContext(), c.Locals("userID").(uint), postID, req.Reason)
+	return s.respondModeratedPost(c, postID, err)
+}
+
+// RestorePostAsModerator handles POST /api/posts/:id/mod/restore
+func (s *Server) RestorePostAsModerator(c *fiber.Ctx) error {
+	postID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	err = s.modSvc().RestorePost(c.UserContext(), c.Locals("userID").(uint), postID)
+	return s.respondModeratedPost(c, postID, err)
+}
+
+// PinPost handles POST and DELETE /api/posts/:id/mod/pin
+func (s *Server) PinPost(c *fiber.Ctx) error {
+	postID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	pinned := c.Method() == fiber.MethodPost
+	err = s.modSvc().SetPostPinned(c.UserContext(), c.Locals("userID").(uint), postID, pinned)
+	return s.respondModeratedPost(c, postID, err)
+}
+
+// LockPost handles POST and DELETE /api/posts/:id/mod/lock
+func (s *Server) LockPost(c *fiber.Ctx) error {
+	postID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	locked := c.Method() == fiber.MethodPost
+	err = s.modSvc().SetPostLocked(c.UserContext(), c.Locals("userID").(uint), postID, locked)
+	return s.respondModeratedPost(c, postID, err)
+}
+
+// RemoveCommentAsModerator handles POST /api/posts/:id/comments/:commentId/mod/remove
+func (s *Server) RemoveCommentAsModerator(c *fiber.Ctx) error {
+	commentID, err := s.parseID(c, "commentId")
+	if err != nil {
+		return nil
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	err = s.modSvc().RemoveComment(c.UserContext(), c.Locals("userID").(uint), commentID, req.Reason)
+	return s.respondModeratedComment(c, commentID, err)
+}
+
+// RestoreCommentAsModerator handles POST /api/posts/:id/comments/:commentId/mod/restore
+func (s *Server) RestoreCommentAsModerator(c *fiber.Ctx) error {
+	commentID, err := s.parseID(c, "commentId")
+	if err != nil {
+		return nil
+	}
+	err = s.modSvc().RestoreComment(c.UserContext(), c.Locals("userID").(uint), commentID)
+	return s.respondModeratedComment(c, commentID, err)
+}
+
+// DistinguishComment handles POST and DELETE /api/posts/:id/comments/:commentId/mod/distinguish
+func (s *Server) DistinguishComment(c *fiber.Ctx) error {
+	commentID, err := s.parseID(c, "commentId")
+	if err != nil {
+		return nil
+	}
+	distinguished := c.Method() == fiber.MethodPost
+	err = s.modSvc().SetCommentDistinguished(c.UserContext(), c.Locals("userID").(uint), commentID, distinguished)
+	return s.respondModeratedComment(c, commentID, err)
+}
+
+// GetSanctumModLog handles GET /api/sanctums/:slug/modlog.
+// @Summary List sanctum mod log
+// @Description List moderator actions taken in a sanctum, newest first.
+// @Tags sanctums-admin
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param limit query int false "Page size"
+// @Param offset query int false "Offset"
+// @Success 200 {array} models.SanctumModLogEntry
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/modlog [get]
+func (s *Server) GetSanctumModLog(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	page := parsePagination(c, 50)
+	entries, err := s.modSvc().ListModLog(ctx, c.Locals("userID").(uint), sanctum.ID, page.Limit, page.Offset)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(entries)
+}
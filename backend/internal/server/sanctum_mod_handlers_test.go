@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+	"sanctum/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemovedContentVisibility(t *testing.T) {
+	t.Parallel()
+	db := setupSanctumAdminTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Post{}, &models.Comment{}, &models.Poll{}, &models.PollOption{},
+		&models.PostImage{}, &models.PostTag{}, &models.SanctumFlair{}, &models.Like{}))
+	s := &Server{db: db}
+	postRepo := repository.NewPostRepository(db)
+	posts := service.NewPostService(postRepo, nil, nil, nil, nil, nil, s.canManageSanctumByUserID, nil, nil)
+	comments := service.NewCommentService(repository.NewCommentRepository(db), postRepo, nil, nil, s.canManageSanctumByUserID, nil)
+	ctx := context.Background()
+
+	mod := models.User{Username: "mod", Email: "mod@e.com"}
+	author := models.User{Username: "author", Email: "author@e.com"}
+	reader := models.User{Username: "reader", Email: "reader@e.com"}
+	db.Create(&mod)
+	db.Create(&author)
+	db.Create(&reader)
+	sanctum := models.Sanctum{Name: "Chess", Slug: "chess"}
+	db.Create(&sanctum)
+	db.Create(&models.SanctumMembership{SanctumID: sanctum.ID, UserID: mod.ID, Role: models.SanctumMembershipRoleMod})
+
+	now := time.Now()
+	post := models.Post{Title: "t", Content: "c", UserID: author.ID, SanctumID: &sanctum.ID, RemovedAt: &now, RemovalReason: "spam"}
+	require.NoError(t, db.Create(&post).Error)
+	fine := models.Comment{PostID: post.ID, UserID: reader.ID, Content: "fine"}
+	rude := models.Comment{PostID: post.ID, UserID: reader.ID, Content: "rude",
+		RemovedAt: &now, RemovalReason: "incivility", RemovedByUserID: &mod.ID}
+	require.NoError(t, db.Create(&fine).Error)
+	require.NoError(t, db.Create(&rude).Error)
+	listFor := func(viewer uint) map[uint]*models.Comment {
+		list, err := comments.ListComments(ctx, post.ID, viewer)
+		require.NoError(t, err)
+		byID := make(map[uint]*models.Comment, len(list))
+		for _, comment := range list {
+			byID[comment.ID] = comment
+		}
+		require.Len(t, byID, 2)
+		return byID
+	}
+
+	for _, viewer := range []uint{author.ID, mod.ID} {
+		_, err := posts.GetPost(ctx, post.ID, viewer)
+		assert.NoError(t, err)
+	}
+	for _, viewer := range []uint{reader.ID, 0} {
+		_, err := posts.GetPost(ctx, post.ID, viewer)
+		assert.Error(t, err)
+		_, err = comments.ListComments(ctx, post.ID, viewer)
+		assert.Error(t, err, "a removed post's thread is hidden too")
+	}
+
+	forAuthor := listFor(author.ID)
+	assert.Equal(t, "fine", forAuthor[fine.ID].Content)
+	assert.Equal(t, "[removed]", forAuthor[rude.ID].Content)
+	assert.Empty(t, forAuthor[rude.ID].RemovalReason)
+	assert.Nil(t, forAuthor[rude.ID].RemovedByUserID)
+
+	forMod := listFor(mod.ID)
+	assert.Equal(t, "rude", forMod[rude.ID].Content)
+	assert.Equal(t, "incivility", forMod[rude.ID].RemovalReason)
+}
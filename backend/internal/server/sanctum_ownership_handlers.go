@@ -0,0 +1,184 @@
+package server
+
+import (
+	"sanctum/internal/cache"
+	"sanctum/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type sanctumOwnershipOfferRequest struct {
+	UserID uint `json:"user_id"`
+}
+
+type sanctumReassignOwnerRequest struct {
+	UserID uint   `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+// OfferSanctumOwnership handles POST /api/sanctums/:slug/ownership-transfer.
+// @Summary Offer sanctum ownership
+// @Description Offer the sanctum to another user. Ownership changes only when they accept; a new offer replaces any open one. Sanctum owner only.
+// @Tags sanctums-admin
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param request body sanctumOwnershipOfferRequest true "Recipient"
+// @Success 201 {object} models.SanctumOwnershipTransfer
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/ownership-transfer [post]
+func (s *Server) OfferSanctumOwnership(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req sanctumOwnershipOfferRequest
+	if err := c.BodyParser(&req); err != nil || req.UserID == 0 {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("user_id is required"))
+	}
+	transfer, err := s.ownershipService.OfferTransfer(ctx, c.Locals("userID").(uint), sanctum.ID, req.UserID)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(transfer)
+}
+
+// CancelSanctumOwnershipTransfer handles DELETE /api/sanctums/:slug/ownership-transfer.
+// @Summary Cancel sanctum ownership offer
+// @Description Withdraw the sanctum's open ownership offer. Sanctum owner only.
+// @Tags sanctums-admin
+// @Param slug path string true "Sanctum slug"
+// @Success 204
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/ownership-transfer [delete]
+func (s *Server) CancelSanctumOwnershipTransfer(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	if err := s.ownershipService.CancelTransfer(ctx, c.Locals("userID").(uint), sanctum.ID); err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetMySanctumOwnershipTransfers handles GET /api/sanctums/ownership-transfers/me.
+// @Summary List my ownership offers
+// @Description List open offers to take over a sanctum made to the current user.
+// @Tags sanctums
+// @Produce json
+// @Success 200 {array} models.SanctumOwnershipTransfer
+// @Security BearerAuth
+// @Router /sanctums/ownership-transfers/me [get]
+func (s *Server) GetMySanctumOwnershipTransfers(c *fiber.Ctx) error {
+	transfers, err := s.ownershipService.ListIncoming(c.UserContext(), c.Locals("userID").(uint))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(transfers)
+}
+
+func (s *Server) respondSanctumOwnershipTransfer(c *fiber.Ctx, accept bool) error {
+	transferID, err := s.parseID(c, "id")
+	if err != nil {
+		return nil
+	}
+	ctx := c.UserContext()
+	transfer, err := s.ownershipService.RespondTransfer(ctx, c.Locals("userID").(uint), transferID, accept)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	if accept && transfer.Sanctum != nil {
+		cache.InvalidateSanctum(ctx, transfer.Sanctum.Slug)
+	}
+	return c.JSON(transfer)
+}
+
+// AcceptSanctumOwnershipTransfer handles POST /api/sanctums/ownership-transfers/:id/accept.
+// @Summary Accept sanctum ownership
+// @Description Accept an ownership offer. The previous owner stays on as a moderator.
+// @Tags sanctums
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} models.SanctumOwnershipTransfer
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/ownership-transfers/{id}/accept [post]
+func (s *Server) AcceptSanctumOwnershipTransfer(c *fiber.Ctx) error {
+	return s.respondSanctumOwnershipTransfer(c, true)
+}
+
+// DeclineSanctumOwnershipTransfer handles POST /api/sanctums/ownership-transfers/:id/decline.
+// @Summary Decline sanctum ownership
+// @Description Decline an ownership offer.
+// @Tags sanctums
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} models.SanctumOwnershipTransfer
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/ownership-transfers/{id}/decline [post]
+func (s *Server) DeclineSanctumOwnershipTransfer(c *fiber.Ctx) error {
+	return s.respondSanctumOwnershipTransfer(c, false)
+}
+
+// GetAdminOrphanedSanctums handles GET /api/admin/sanctums/orphaned.
+// @Summary List orphaned sanctums
+// @Description List sanctums with no live owner, such as those whose owner deleted their account. Master admin only.
+// @Tags admin
+// @Produce json
+// @Param limit query int false "Limit (default 50)"
+// @Param offset query int false "Offset"
+// @Success 200 {array} service.OrphanedSanctum
+// @Failure 403 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/sanctums/orphaned [get]
+func (s *Server) GetAdminOrphanedSanctums(c *fiber.Ctx) error {
+	page := parsePagination(c, 50)
+	orphaned, err := s.ownershipService.ListOrphaned(c.UserContext(), page.Limit, page.Offset)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(orphaned)
+}
+
+// ReassignSanctumOwner handles POST /api/admin/sanctums/:slug/owner.
+// @Summary Reassign sanctum owner
+// @Description Make a user the sanctum's owner without an accept step. Any current owner becomes a moderator. The change is recorded in the sanctum's mod log. Master admin only.
+// @Tags admin
+// @Accept json
+// @Param slug path string true "Sanctum slug"
+// @Param request body sanctumReassignOwnerRequest true "New owner"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /admin/sanctums/{slug}/owner [post]
+func (s *Server) ReassignSanctumOwner(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req sanctumReassignOwnerRequest
+	if err := c.BodyParser(&req); err != nil || req.UserID == 0 {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("user_id is required"))
+	}
+	if err := s.ownershipService.Reassign(ctx, c.Locals("userID").(uint), sanctum.ID, req.UserID, req.Reason); err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	cache.InvalidateSanctum(ctx, sanctum.Slug)
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"sanctum/internal/models"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type sanctumSettingsRequest struct {
+	Rules             []models.SanctumRule `json:"rules"`
+	AllowedPostTypes  []string             `json:"allowed_post_types"`
+	MinAccountAgeDays int                  `json:"min_account_age_days"`
+	MinKarma          int                  `json:"min_karma"`
+	RequireImage      bool                 `json:"require_image"`
+	TitlePattern      string               `json:"title_pattern"`
+}
+
+// GetSanctumSettings handles GET /api/sanctums/:slug/settings.
+// @Summary Get sanctum settings
+// @Description Get a sanctum's numbered rules and posting policy.
+// @Tags sanctums
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Success 200 {object} models.SanctumSettings
+// @Failure 404 {object} models.ErrorResponse
+// @Router /sanctums/{slug}/settings [get]
+func (s *Server) GetSanctumSettings(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	settings, err := s.sanctumRulesSvc.Get(ctx, sanctum.ID)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(settings)
+}
+
+// UpdateSanctumSettings handles PUT /api/sanctums/:slug/settings.
+// @Summary Update sanctum settings
+// @Description Replace a sanctum's rules and posting policy. Rules are numbered in the order given. Sanctum owners only.
+// @Tags sanctums-admin
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param request body sanctumSettingsRequest true "Settings"
+// @Success 200 {object} models.SanctumSettings
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/settings [put]
+func (s *Server) UpdateSanctumSettings(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req sanctumSettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	settings, err := s.sanctumRulesSvc.Update(ctx, service.UpdateSanctumSettingsInput{
+		ActorID:           c.Locals("userID").(uint),
+		SanctumID:         sanctum.ID,
+		Rules:             req.Rules,
+		AllowedPostTypes:  req.AllowedPostTypes,
+		MinAccountAgeDays: req.MinAccountAgeDays,
+		MinKarma:          req.MinKarma,
+		RequireImage:      req.RequireImage,
+		TitlePattern:      req.TitlePattern,
+	})
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(settings)
+}
@@ -0,0 +1,358 @@
+package server
+
+import (
+	"sanctum/internal/models"
+	"sanctum/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type saveWikiPageRequest struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Reason  string `json:"reason"`
+	// BaseRevision is the revision the edit started from; omit to overwrite.
+	BaseRevision int `json:"base_revision"`
+}
+
+type revertWikiPageRequest struct {
+	Revision int    `json:"revision"`
+	Reason   string `json:"reason"`
+}
+
+type wikiPermissionRequest struct {
+	EditPermission models.WikiEditPermission `json:"edit_permission"`
+}
+
+// GetSanctumWiki handles GET /api/sanctums/:slug/wiki.
+// @Summary List a sanctum's wiki pages
+// @Description List the sanctum's wiki pages ordered by slug, so child pages follow their parents.
+// @Tags sanctum-wiki
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Success 200 {array} service.WikiPageSummary
+// @Failure 404 {object} models.ErrorResponse
+// @Router /sanctums/{slug}/wiki [get]
+func (s *Server) GetSanctumWiki(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	userID, _ := s.optionalUserID(c)
+	pages, err := s.wikiService.ListPages(ctx, userID, sanctum.ID)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(pages)
+}
+
+// GetSanctumWikiPage handles GET /api/sanctums/:slug/wiki/*.
+// @Summary Get a wiki page
+// @Description Get the current version of a wiki page. Page slugs may be nested, e.g. guides/getting-started.
+// @Tags sanctum-wiki
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param page path string true "Page slug"
+// @Success 200 {object} models.SanctumWikiPage
+// @Failure 404 {object} models.ErrorResponse
+// @Router /sanctums/{slug}/wiki/{page} [get]
+func (s *Server) GetSanctumWikiPage(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	userID, _ := s.optionalUserID(c)
+	page, err := s.wikiService.GetPage(ctx, userID, sanctum.ID, c.Params("*"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(page)
+}
+
+// SaveSanctumWikiPage handles PUT /api/sanctums/:slug/wiki/*.
+// @Summary Create or edit a wiki page
+// @Description Save a new revision of a wiki page, creating it if needed. Only moderators create pages; edits follow the page's edit permission.
+// @Tags sanctum-wiki
+// @Accept json
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Param page path string true "Page slug"
+// @Param request body saveWikiPageRequest true "Page content"
+// @Success 200 {object} models.SanctumWikiPage
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/wiki/{page} [put]
+func (s *Server) SaveSanctumWikiPage(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	var req saveWikiPageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	page, err := s.wikiService.SavePage(ctx, service.SaveWikiPageInput{
+		ActorID:      c.Locals("userID").(uint),
+		SanctumID:    sanctum.ID,
+		Slug:         c.Params("*"),
+		Title:        req.Title,
+		Content:      req.Content,
+		Reason:       req.Reason,
+		BaseRevision: req.BaseRevision,
+	})
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(page)
+}
+
+// DeleteSanctumWikiPage handles DELETE /api/sanctums/:slug/wiki/*.
+// @Summary Delete a wiki page
+// @Description Delete a wiki page and its revision history. Sanctum moderators only.
+// @Tags sanctum-wiki
+// @Param slug path string true "Sanctum slug"
+// @Param page path string true "Page slug"
+// @Success 204
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/wiki/{page} [delete]
+func (s *Server) DeleteSanctumWikiPage(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	if err := s.wikiService.DeletePage(ctx, c.Locals("userID").(uint), sanctum.ID, c.Params("*")); err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetWikiPageRevisions handles GET /api/wiki-pages/:pageId/revisions.
+// @Summary List a wiki page's revisions
+// @Description List a page's revisions, newest first, without their content.
+// @Tags sanctum-wiki
+// @Produce json
+// @Param pageId path int true "Page ID"
+// @Param limit query int false "Limit (default 50)"
+// @Param offset query int false "Offset"
+// @Success 200 {array} models.SanctumWikiRevision
+// @Failure 404 {object} models.ErrorResponse
+// @Router /wiki-pages/{pageId}/revisions [get]
+func (s *Server) GetWikiPageRevisions(c *fiber.Ctx) error {
+	pageID, err := s.parseID(c, "pageId")
+	if err != nil {
+		return nil
+	}
+	userID, _ := s.optionalUserID(c)
+	page := parsePagination(c, 50)
+	revisions, err := s.wikiService.ListRevisions(c.UserContext(), userID, pageID, page.Limit, page.Offset)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(revisions)
+}
+
+// GetWikiPageRevision handles GET /api/wiki-pages/:pageId/revisions/:revision.
+// @Summary Get a wiki page revision
+// @Description Get one revision of a page, including its content.
+// @Tags sanctum-wiki
+// @Produce json
+// @Param pageId path int true "Page ID"
+// @Param revision path int true "Revision number"
+// @Success 200 {object} models.SanctumWikiRevision
+// @Failure 404 {object} models.ErrorResponse
+// @Router /wiki-pages/{pageId}/revisions/{revision} [get]
+func (s *Server) GetWikiPageRevision(c *fiber.Ctx) error {
+	pageID, err := s.parseID(c, "pageId")
+	if err != nil {
+		return nil
+	}
+	revision, err := s.parseID(c, "revision")
+	if err != nil {
+		return nil
+	}
+	userID, _ := s.optionalUserID(c)
+	rev, err := s.wikiService.GetRevision(c.UserContext(), userID, pageID, int(revision))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(rev)
+}
+
+// GetWikiPageDiff handles GET /api/wiki-pages/:pageId/diff.
+// @Summary Diff two wiki page revisions
+// @Description Get a unified diff of a page's content between two revisions.
+// @Tags sanctum-wiki
+// @Produce json
+// @Param pageId path int true "Page ID"
+// @Param from query int true "Older revision"
+// @Param to query int true "Newer revision"
+// @Success 200 {object} service.WikiDiff
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /wiki-pages/{pageId}/diff [get]
+func (s *Server) GetWikiPageDiff(c *fiber.Ctx) error {
+	pageID, err := s.parseID(c, "pageId")
+	if err != nil {
+		return nil
+	}
+	from, to := c.QueryInt("from"), c.QueryInt("to")
+	if from <= 0 || to <= 0 {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("from and to revisions are required"))
+	}
+	userID, _ := s.optionalUserID(c)
+	diff, err := s.wikiService.Diff(c.UserContext(), userID, pageID, from, to)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(diff)
+}
+
+// RevertWikiPage handles POST /api/wiki-pages/:pageId/revert.
+// @Summary Revert a wiki page
+// @Description Restore an earlier revision by saving it as a new revision. Follows the page's edit permission.
+// @Tags sanctum-wiki
+// @Accept json
+// @Produce json
+// @Param pageId path int true "Page ID"
+// @Param request body revertWikiPageRequest true "Revision to restore"
+// @Success 200 {object} models.SanctumWikiPage
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /wiki-pages/{pageId}/revert [post]
+func (s *Server) RevertWikiPage(c *fiber.Ctx) error {
+	pageID, err := s.parseID(c, "pageId")
+	if err != nil {
+		return nil
+	}
+	var req revertWikiPageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	page, err := s.wikiService.Revert(c.UserContext(), c.Locals("userID").(uint), pageID, req.Revision, req.Reason)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(page)
+}
+
+// UpdateWikiPagePermission handles PUT /api/wiki-pages/:pageId/permission.
+// @Summary Set who may edit a wiki page
+// @Description Limit edits to moderators, approved wiki contributors or all members. Sanctum moderators only.
+// @Tags sanctum-wiki
+// @Accept json
+// @Produce json
+// @Param pageId path int true "Page ID"
+// @Param request body wikiPermissionRequest true "Edit permission"
+// @Success 200 {object} models.SanctumWikiPage
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /wiki-pages/{pageId}/permission [put]
+func (s *Server) UpdateWikiPagePermission(c *fiber.Ctx) error {
+	pageID, err := s.parseID(c, "pageId")
+	if err != nil {
+		return nil
+	}
+	var req wikiPermissionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return models.RespondWithError(c, fiber.StatusBadRequest,
+			models.NewValidationError("Invalid request body"))
+	}
+	page, err := s.wikiService.SetPermission(c.UserContext(), c.Locals("userID").(uint), pageID, req.EditPermission)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(page)
+}
+
+// GetSanctumWikiContributors handles GET /api/sanctums/:slug/wiki-contributors.
+// @Summary List wiki contributors
+// @Description List the users approved to edit contributor-editable pages. Sanctum moderators only.
+// @Tags sanctum-wiki
+// @Produce json
+// @Param slug path string true "Sanctum slug"
+// @Success 200 {array} models.SanctumWikiContributor
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/wiki-contributors [get]
+func (s *Server) GetSanctumWikiContributors(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	contributors, err := s.wikiService.ListContributors(ctx, c.Locals("userID").(uint), sanctum.ID)
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.JSON(contributors)
+}
+
+// AddSanctumWikiContributor handles POST /api/sanctums/:slug/wiki-contributors/:userId.
+// @Summary Approve a wiki contributor
+// @Description Allow a user to edit the sanctum's contributor-editable pages. Sanctum moderators only.
+// @Tags sanctum-wiki
+// @Param slug path string true "Sanctum slug"
+// @Param userId path int true "User ID"
+// @Success 204
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/wiki-contributors/{userId} [post]
+func (s *Server) AddSanctumWikiContributor(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	userID, err := s.parseID(c, "userId")
+	if err != nil {
+		return nil
+	}
+	if err := s.wikiService.AddContributor(ctx, c.Locals("userID").(uint), sanctum.ID, userID); err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RemoveSanctumWikiContributor handles DELETE /api/sanctums/:slug/wiki-contributors/:userId.
+// @Summary Remove a wiki contributor
+// @Description Revoke a user's wiki contributor approval. Sanctum moderators only.
+// @Tags sanctum-wiki
+// @Param slug path string true "Sanctum slug"
+// @Param userId path int true "User ID"
+// @Success 204
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Security BearerAuth
+// @Router /sanctums/{slug}/wiki-contributors/{userId} [delete]
+func (s *Server) RemoveSanctumWikiContributor(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	sanctum, err := s.findSanctumBySlug(ctx, c.Params("slug"))
+	if err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	userID, err := s.parseID(c, "userId")
+	if err != nil {
+		return nil
+	}
+	if err := s.wikiService.RemoveContributor(ctx, c.Locals("userID").(uint), sanctum.ID, userID); err != nil {
+		return models.RespondWithError(c, appErrorStatus(err), err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
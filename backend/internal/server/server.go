@@ -67,6 +67,7 @@ type Server struct {
 	pollRepo          repository.PollRepository
 	imageRepo         repository.ImageRepository
 	commentRepo       repository.CommentRepository
+	bookmarkRepo      repository.BookmarkRepository
 	chatRepo          repository.ChatRepository
 	friendRepo        repository.FriendRepository
 	gameRepo          repository.GameRepository
@@ -79,9 +80,25 @@ type Server struct {
 	postService       *service.PostService
 	imageService      *service.ImageService
 	commentService    *service.CommentService
+	bookmarkService   *service.BookmarkService
+	linkPreviewSvc    *service.LinkPreviewService
 	chatService       *service.ChatService
 	userService       *service.UserService
 	moderationService *service.ModerationService
+	sanctumModService *service.SanctumModService
+	sanctumBanService *service.SanctumBanService
+	sanctumJoinSvc    *service.SanctumJoinService
+	sanctumRulesSvc   *service.SanctumSettingsService
+	automodService    *service.AutomodService
+	ownershipService  *service.SanctumOwnershipService
+	modmailService    *service.ModmailService
+	wikiService       *service.SanctumWikiService
+	brandingService   *service.SanctumBrandingService
+	directoryService  *service.SanctumDirectoryService
+	channelService    *service.SanctumChannelService
+	flairService      *service.SanctumFlairService
+	inviteService     *service.InviteService
+	registrationSvc   *service.RegistrationService
 	gameService       *service.GameService
 
 	// consumedTickets is a short-lived in-process cache allowing the WS upgrade
@@ -109,6 +126,7 @@ func NewServer(cfg *config.Config) (*Server, error) {
 	pollRepo := repository.NewPollRepository(db)
 	imageRepo := repository.NewImageRepository(db)
 	commentRepo := repository.NewCommentRepository(db)
+	bookmarkRepo := repository.NewBookmarkRepository(db)
 	chatRepo := repository.NewChatRepository(db)
 	friendRepo := repository.NewFriendRepository(db)
 	gameRepo := repository.NewGameRepository(db)
@@ -129,21 +147,41 @@ func NewServer(cfg *config.Config) (*Server, error) {
 		pollRepo:        pollRepo,
 		imageRepo:       imageRepo,
 		commentRepo:     commentRepo,
+		bookmarkRepo:    bookmarkRepo,
 		chatRepo:        chatRepo,
 		friendRepo:      friendRepo,
 		gameRepo:        gameRepo,
 		featureFlags:    featureflags.NewManager(cfg.FeatureFlags),
 		consumedTickets: make(map[string]consumedTicketEntry),
 	}
-	server.postService = service.NewPostService(server.postRepo, server.pollRepo, server.isAdminByUserID)
 	server.imageService = service.NewImageService(server.imageRepo, cfg)
-	server.commentService = service.NewCommentService(server.commentRepo, server.postRepo, server.isAdminByUserID)
+	server.sanctumBanService = service.NewSanctumBanService(db, server.canManageSanctumAsOwnerByUserID)
+	server.sanctumJoinSvc = service.NewSanctumJoinService(db, server.canManageSanctumByUserID)
+	server.sanctumRulesSvc = service.NewSanctumSettingsService(db, server.canManageSanctumAsOwnerByUserID)
+	server.automodService = service.NewAutomodService(db, server.canManageSanctumByUserID)
+	server.ownershipService = service.NewSanctumOwnershipService(db)
+	server.modmailService = service.NewModmailService(db, server.canManageSanctumByUserID)
+	server.wikiService = service.NewSanctumWikiService(db, server.canManageSanctumByUserID, server.sanctumJoinSvc.CanRead)
+	server.brandingService = service.NewSanctumBrandingService(db, server.imageService, server.canManageSanctumAsOwnerByUserID)
+	server.directoryService = service.NewSanctumDirectoryService(db, server.canManageSanctumAsOwnerByUserID)
+	server.channelService = service.NewSanctumChannelService(db, server.canManageSanctumByUserID, server.sanctumJoinSvc.CanRead)
+	server.flairService = service.NewSanctumFlairService(db, server.canManageSanctumByUserID)
+	server.inviteService = service.NewInviteService(db, server.canManageSanctumByUserID, server.canModerateChatroomByUserID)
+	server.registrationSvc = service.NewRegistrationService(db, models.RegistrationMode(cfg.RegistrationMode), cfg.SignupInviteQuota)
+	server.postService = service.NewPostService(server.postRepo, server.pollRepo, server.imageService, server.isAdminByUserID,
+		server.checkSanctumPost, server.sanctumJoinSvc.CanRead, server.canManageSanctumByUserID, server.automodService, server.flairService)
+	server.commentService = service.NewCommentService(server.commentRepo, server.postRepo, server.isAdminByUserID,
+		server.checkSanctumCommentAccess, server.canManageSanctumByUserID, server.automodService)
+	server.sanctumModService = service.NewSanctumModService(db, server.canManageSanctumByUserID)
+	server.bookmarkService = service.NewBookmarkService(server.bookmarkRepo, server.postRepo, server.commentRepo)
+	server.linkPreviewSvc = service.NewLinkPreviewService(server.imageService)
 	server.chatService = service.NewChatService(
 		server.chatRepo,
 		server.userRepo,
 		server.db,
 		server.isAdminByUserID,
 		server.canModerateChatroomByUserID,
+		server.automodService,
 	)
 	server.userService = service.NewUserService(server.userRepo)
 	server.moderationService = service.NewModerationService(server.db)
@@ -189,6 +227,7 @@ func NewServerWithDeps(cfg *config.Config, db *gorm.DB, redisClient *redis.Clien
 	pollRepo := repository.NewPollRepository(db)
 	imageRepo := repository.NewImageRepository(db)
 	commentRepo := repository.NewCommentRepository(db)
+	bookmarkRepo := repository.NewBookmarkRepository(db)
 	chatRepo := repository.NewChatRepository(db)
 	friendRepo := repository.NewFriendRepository(db)
 	gameRepo := repository.NewGameRepository(db)
@@ -209,6 +248,7 @@ func NewServerWithDeps(cfg *config.Config, db *gorm.DB, redisClient *redis.Clien
 		pollRepo:        pollRepo,
 		imageRepo:       imageRepo,
 		commentRepo:     commentRepo,
+		bookmarkRepo:    bookmarkRepo,
 		chatRepo:        chatRepo,
 		friendRepo:      friendRepo,
 		gameRepo:        gameRepo,
@@ -216,15 +256,34 @@ func NewServerWithDeps(cfg *config.Config, db *gorm.DB, redisClient *redis.Clien
 		consumedTickets: make(map[string]consumedTicketEntry),
 	}
 
-	server.postService = service.NewPostService(server.postRepo, server.pollRepo, server.isAdminByUserID)
 	server.imageService = service.NewImageService(server.imageRepo, cfg)
-	server.commentService = service.NewCommentService(server.commentRepo, server.postRepo, server.isAdminByUserID)
+	server.sanctumBanService = service.NewSanctumBanService(db, server.canManageSanctumAsOwnerByUserID)
+	server.sanctumJoinSvc = service.NewSanctumJoinService(db, server.canManageSanctumByUserID)
+	server.sanctumRulesSvc = service.NewSanctumSettingsService(db, server.canManageSanctumAsOwnerByUserID)
+	server.automodService = service.NewAutomodService(db, server.canManageSanctumByUserID)
+	server.ownershipService = service.NewSanctumOwnershipService(db)
+	server.modmailService = service.NewModmailService(db, server.canManageSanctumByUserID)
+	server.wikiService = service.NewSanctumWikiService(db, server.canManageSanctumByUserID, server.sanctumJoinSvc.CanRead)
+	server.brandingService = service.NewSanctumBrandingService(db, server.imageService, server.canManageSanctumAsOwnerByUserID)
+	server.directoryService = service.NewSanctumDirectoryService(db, server.canManageSanctumAsOwnerByUserID)
+	server.channelService = service.NewSanctumChannelService(db, server.canManageSanctumByUserID, server.sanctumJoinSvc.CanRead)
+	server.flairService = service.NewSanctumFlairService(db, server.canManageSanctumByUserID)
+	server.inviteService = service.NewInviteService(db, server.canManageSanctumByUserID, server.canModerateChatroomByUserID)
+	server.registrationSvc = service.NewRegistrationService(db, models.RegistrationMode(cfg.RegistrationMode), cfg.SignupInviteQuota)
+	server.postService = service.NewPostService(server.postRepo, server.pollRepo, server.imageService, server.isAdminByUserID,
+		server.checkSanctumPost, server.sanctumJoinSvc.CanRead, server.canManageSanctumByUserID, server.automodService, server.flairService)
+	server.commentService = service.NewCommentService(server.commentRepo, server.postRepo, server.isAdminByUserID,
+		server.checkSanctumCommentAccess, server.canManageSanctumByUserID, server.automodService)
+	server.sanctumModService = service.NewSanctumModService(db, server.canManageSanctumByUserID)
+	server.bookmarkService = service.NewBookmarkService(server.bookmarkRepo, server.postRepo, server.commentRepo)
+	server.linkPreviewSvc = service.NewLinkPreviewService(server.imageService)
 	server.chatService = service.NewChatService(
 		server.chatRepo,
 		server.userRepo,
 		server.db,
 		server.isAdminByUserID,
 		server.canModerateChatroomByUserID,
+		server.automodService,
 	)
 	server.userService = service.NewUserService(server.userRepo)
 	server.moderationService = service.NewModerationService(server.db)
@@ -354,6 +413,9 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	auth.Post("/login", middleware.RateLimitWithPolicy(
 		s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "login"), s.Login)
 	auth.Post("/refresh", s.Refresh)
+	auth.Get("/registration", s.GetRegistrationInfo)
+	auth.Post("/waitlist", middleware.RateLimitWithPolicy(
+		s.redis, s.config.Env, 3, 10*time.Minute, middleware.FailClosed, "waitlist"), s.JoinWaitlist)
 	auth.Post("/logout", s.AuthRequired(), s.Logout)
 
 	// Public post routes (browse/search)
@@ -370,7 +432,21 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	// Public sanctum routes
 	sanctums := api.Group("/sanctums")
 	sanctums.Get("/", s.GetSanctums)
+	sanctums.Get("/categories", s.GetSanctumCategories)
+	sanctums.Get("/trending", s.GetTrendingSanctums)
+	sanctums.Get("/recommended", s.AuthRequired(), s.GetRecommendedSanctums)
 	sanctums.Get("/:slug", s.GetSanctumBySlug)
+	sanctums.Get("/:slug/flairs", s.GetSanctumFlairs)
+	sanctums.Get("/:slug/settings", s.GetSanctumSettings)
+	sanctums.Get("/:slug/wiki", s.GetSanctumWiki)
+	sanctums.Get("/:slug/wiki/*", s.GetSanctumWikiPage)
+	sanctums.Get("/:slug/channels", s.GetSanctumChannels)
+	wikiPages := api.Group("/wiki-pages")
+	wikiPages.Get("/:pageId/revisions", s.GetWikiPageRevisions)
+	wikiPages.Get("/:pageId/revisions/:revision", s.GetWikiPageRevision)
+	wikiPages.Get("/:pageId/diff", middleware.RateLimit(
+		s.redis, s.config.Env, 20, time.Minute, "wiki_diff"), s.GetWikiPageDiff)
+	api.Get("/invites/:code", s.GetInvite)
 
 	// Protected routes
 	protected := api.Group("", s.AuthRequired())
@@ -380,10 +456,70 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	sanctumRequests.Post("/", s.CreateSanctumRequest)
 	sanctumRequests.Get("/me", s.GetMySanctumRequests)
 	sanctumRequests.Delete("/:id", s.DeleteSanctumRequest)
+	ownershipTransfers := protected.Group("/sanctums/ownership-transfers")
+	ownershipTransfers.Get("/me", s.GetMySanctumOwnershipTransfers)
+	ownershipTransfers.Post("/:id/accept", s.AcceptSanctumOwnershipTransfer)
+	ownershipTransfers.Post("/:id/decline", s.DeclineSanctumOwnershipTransfer)
 	sanctumAdmins := protected.Group("/sanctums/:slug/admins")
 	sanctumAdmins.Get("/", s.GetSanctumAdmins)
 	sanctumAdmins.Post("/:userId", s.PromoteSanctumAdmin)
 	sanctumAdmins.Delete("/:userId", s.DemoteSanctumAdmin)
+	protected.Get("/sanctums/:slug/modlog", s.GetSanctumModLog)
+	protected.Get("/sanctums/:slug/modqueue", s.GetSanctumModQueue)
+	sanctumAutomod := protected.Group("/sanctums/:slug/automod")
+	sanctumAutomod.Get("/", s.GetSanctumAutomod)
+	sanctumAutomod.Put("/", s.UpdateSanctumAutomod)
+	sanctumAutomod.Post("/test", s.TestSanctumAutomod)
+	sanctumAutomod.Post("/dry-run", s.DryRunSanctumAutomod)
+	sanctumFlairs := protected.Group("/sanctums/:slug/flairs")
+	sanctumFlairs.Post("/", s.CreateSanctumFlair)
+	sanctumFlairs.Patch("/:flairId", s.UpdateSanctumFlair)
+	sanctumFlairs.Delete("/:flairId", s.DeleteSanctumFlair)
+	sanctumBans := protected.Group("/sanctums/:slug/bans")
+	sanctumBans.Get("/", s.GetSanctumBans)
+	sanctumBans.Post("/", s.BanSanctumUser)
+	sanctumBans.Delete("/:userId", s.UnbanSanctumUser)
+	protected.Put("/sanctums/:slug/visibility", s.UpdateSanctumVisibility)
+	protected.Put("/sanctums/:slug/settings", s.UpdateSanctumSettings)
+	protected.Put("/sanctums/:slug/icon", s.UploadSanctumIcon)
+	protected.Delete("/sanctums/:slug/icon", s.DeleteSanctumIcon)
+	protected.Put("/sanctums/:slug/banner", s.UploadSanctumBanner)
+	protected.Delete("/sanctums/:slug/banner", s.DeleteSanctumBanner)
+	protected.Put("/sanctums/:slug/theme", s.UpdateSanctumTheme)
+	protected.Put("/sanctums/:slug/category", s.UpdateSanctumCategory)
+	sanctumChannels := protected.Group("/sanctums/:slug/channels")
+	sanctumChannels.Post("/", s.CreateSanctumChannel)
+	sanctumChannels.Put("/order", s.ReorderSanctumChannels)
+	sanctumChannels.Patch("/:channelId", s.UpdateSanctumChannel)
+	sanctumChannels.Delete("/:channelId", s.DeleteSanctumChannel)
+	protected.Post("/sanctums/:slug/invites", s.CreateSanctumInvite)
+	protected.Get("/sanctums/:slug/invites", s.GetSanctumInvites)
+	protected.Post("/invites/:code/redeem", s.RedeemInvite)
+	protected.Delete("/invites/:code", s.RevokeInvite)
+	protected.Post("/sanctums/:slug/ownership-transfer", s.OfferSanctumOwnership)
+	protected.Delete("/sanctums/:slug/ownership-transfer", s.CancelSanctumOwnershipTransfer)
+	protected.Post("/sanctums/:slug/modmail", middleware.RateLimit(s.redis, s.config.Env, 5, 10*time.Minute, "modmail_create"), s.CreateModmailThread)
+	protected.Get("/sanctums/:slug/modmail", s.GetSanctumModmail)
+	modmail := protected.Group("/modmail")
+	modmail.Get("/me", s.GetMyModmail)
+	modmail.Get("/:id", s.GetModmailThread)
+	modmail.Post("/:id/messages", s.ReplyModmailThread)
+	modmail.Put("/:id/assignee", s.AssignModmailThread)
+	modmail.Post("/:id/archive", s.ArchiveModmailThread)
+	modmail.Post("/:id/unarchive", s.UnarchiveModmailThread)
+	protected.Put("/sanctums/:slug/wiki/*", s.SaveSanctumWikiPage)
+	protected.Delete("/sanctums/:slug/wiki/*", s.DeleteSanctumWikiPage)
+	protected.Post("/wiki-pages/:pageId/revert", s.RevertWikiPage)
+	protected.Put("/wiki-pages/:pageId/permission", s.UpdateWikiPagePermission)
+	wikiContributors := protected.Group("/sanctums/:slug/wiki-contributors")
+	wikiContributors.Get("/", s.GetSanctumWikiContributors)
+	wikiContributors.Post("/:userId", s.AddSanctumWikiContributor)
+	wikiContributors.Delete("/:userId", s.RemoveSanctumWikiContributor)
+	sanctumJoinRequests := protected.Group("/sanctums/:slug/join-requests")
+	sanctumJoinRequests.Post("/", s.CreateSanctumJoinRequest)
+	sanctumJoinRequests.Get("/", s.GetSanctumJoinRequests)
+	sanctumJoinRequests.Post("/:requestId/approve", s.ApproveSanctumJoinRequest)
+	sanctumJoinRequests.Post("/:requestId/reject", s.RejectSanctumJoinRequest)
 	sanctumMemberships := protected.Group("/sanctums/memberships")
 	sanctumMemberships.Get("/me", s.GetMySanctumMemberships)
 	sanctumMemberships.Post("/bulk", s.UpsertMySanctumMemberships)
@@ -393,6 +529,11 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	users.Get("/me", s.GetMyProfile)
 	users.Put("/me", s.UpdateMyProfile)
 	users.Get("/me/mentions", s.GetMyMentions)
+	users.Get("/me/drafts", s.GetMyDrafts)
+	users.Put("/me/read-receipts", s.SetMyReadReceipts)
+	users.Get("/me/signup-invites", s.GetMySignupInvites)
+	users.Post("/me/signup-invites", s.CreateSignupInvite)
+	users.Delete("/me/signup-invites/:id", s.RevokeSignupInvite)
 	users.Get("/blocks/me", s.GetMyBlocks)
 	users.Get("/", s.GetAllUsers)
 
@@ -438,23 +579,64 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	posts.Delete("/:id/comments/:commentId", s.DeleteComment)
 	posts.Post("/:id/report", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 5, 10*time.Minute, middleware.FailClosed, "report"), s.ReportPost)
 	posts.Post("/:id/poll/vote", s.VotePoll)
+	posts.Delete("/:id/poll/vote", s.RetractPollVote)
+	posts.Post("/:id/publish", s.PublishPost)
+	// Sanctum moderator actions
+	posts.Post("/:id/mod/remove", s.RemovePostAsModerator)
+	posts.Post("/:id/mod/restore", s.RestorePostAsModerator)
+	posts.Post("/:id/mod/pin", s.PinPost)
+	posts.Delete("/:id/mod/pin", s.PinPost)
+	posts.Post("/:id/mod/lock", s.LockPost)
+	posts.Delete("/:id/mod/lock", s.LockPost)
+	posts.Post("/:id/comments/:commentId/mod/remove", s.RemoveCommentAsModerator)
+	posts.Post("/:id/comments/:commentId/mod/restore", s.RestoreCommentAsModerator)
+	posts.Post("/:id/comments/:commentId/mod/distinguish", s.DistinguishComment)
+	posts.Delete("/:id/comments/:commentId/mod/distinguish", s.DistinguishComment)
 	// Generic /:id routes (for item detail, update, delete)
 	posts.Put("/:id", s.UpdatePost)
 	posts.Delete("/:id", s.DeletePost)
 
+	// Bookmark routes
+	bookmarks := protected.Group("/bookmarks")
+	bookmarks.Get("/", s.GetBookmarks)
+	bookmarks.Post("/", s.AddBookmark)
+	// Collection routes before generic /:type/:id
+	bookmarks.Get("/collections", s.GetBookmarkCollections)
+	bookmarks.Post("/collections", s.CreateBookmarkCollection)
+	bookmarks.Put("/collections/:id", s.RenameBookmarkCollection)
+	bookmarks.Delete("/collections/:id", s.DeleteBookmarkCollection)
+	bookmarks.Delete("/:type/:id", s.RemoveBookmark)
+
+	protected.Get("/link-preview", middleware.RateLimit(
+		s.redis, s.config.Env, 30, time.Minute, "link_preview"), s.GetLinkPreview)
+
 	// Chat routes
 	conversations := protected.Group("/conversations")
 	conversations.Post("/", s.CreateConversation)
 	conversations.Get("/", s.GetConversations)
+	conversations.Get("/messages/search", middleware.RateLimit(
+		s.redis, s.config.Env, 20, time.Minute, "chat_search"), s.SearchMessages)
 	// Define specific /:id/:resource routes BEFORE generic /:id route
 	conversations.Get("/:id/messages", s.GetMessages)
+	conversations.Get("/:id/messages/search", middleware.RateLimit(
+		s.redis, s.config.Env, 20, time.Minute, "chat_search"), s.SearchConversationMessages)
 	conversations.Post("/:id/messages", middleware.RateLimit(
 		s.redis, s.config.Env, 15, time.Minute, "send_chat"), s.SendMessage)
 	conversations.Post("/:id/read", s.MarkConversationRead)
+	conversations.Get("/:id/receipts", s.GetReadReceipts)
+	conversations.Put("/:id/messages/:messageId", middleware.RateLimit(
+		s.redis, s.config.Env, 15, time.Minute, "edit_chat"), s.EditMessage)
+	conversations.Delete("/:id/messages/:messageId", s.DeleteMessage)
+	conversations.Get("/:id/messages/:messageId/edits", s.GetMessageEdits)
+	conversations.Get("/:id/messages/:messageId/thread", s.GetMessageThread)
+	conversations.Post("/:id/messages/:messageId/thread/read", s.MarkThreadRead)
+	conversations.Get("/:id/messages/:messageId/seen-by", s.GetMessageSeenBy)
 	conversations.Post("/:id/messages/:messageId/reactions", s.AddMessageReaction)
 	conversations.Delete("/:id/messages/:messageId/reactions", s.RemoveMessageReaction)
 	conversations.Post("/:id/messages/:messageId/report", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 5, 10*time.Minute, middleware.FailClosed, "report"), s.ReportMessage)
 	conversations.Post("/:id/participants", s.AddParticipant)
+	conversations.Post("/:id/invites", s.CreateConversationInvite)
+	conversations.Get("/:id/invites", s.GetConversationInvites)
 	conversations.Delete("/:id", s.LeaveConversation)
 	// Generic /:id route must be last
 	conversations.Get("/:id", s.GetConversation)
@@ -489,6 +671,8 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	// Admin routes
 	admin := protected.Group("/admin", s.AdminRequired())
 	admin.Delete("/sanctums/:slug", s.DeleteSanctum)
+	admin.Get("/sanctums/orphaned", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 30, time.Minute, middleware.FailClosed, "admin_read"), s.GetAdminOrphanedSanctums)
+	admin.Post("/sanctums/:slug/owner", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.ReassignSanctumOwner)
 	admin.Get("/feature-flags", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 30, time.Minute, middleware.FailClosed, "admin_read"), s.GetFeatureFlags)
 	admin.Get("/reports", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 30, time.Minute, middleware.FailClosed, "admin_read"), s.GetAdminReports)
 	admin.Post("/reports/:id/resolve", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.ResolveAdminReport)
@@ -497,6 +681,10 @@ func (s *Server) SetupRoutes(app *fiber.App) {
 	admin.Get("/users/:id", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 30, time.Minute, middleware.FailClosed, "admin_read"), s.GetAdminUserDetail)
 	admin.Post("/users/:id/ban", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.BanUser)
 	admin.Post("/users/:id/unban", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.UnbanUser)
+	admin.Put("/users/:id/invite-quota", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.SetUserInviteQuota)
+	admin.Get("/waitlist", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 30, time.Minute, middleware.FailClosed, "admin_read"), s.GetAdminWaitlist)
+	admin.Post("/waitlist/:id/approve", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.ApproveWaitlistEntry)
+	admin.Post("/waitlist/:id/reject", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.RejectWaitlistEntry)
 	adminSanctumRequests := admin.Group("/sanctum-requests")
 	adminSanctumRequests.Get("/", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 30, time.Minute, middleware.FailClosed, "admin_read"), s.GetAdminSanctumRequests)
 	adminSanctumRequests.Post("/:id/approve", middleware.RateLimitWithPolicy(s.redis, s.config.Env, 10, 5*time.Minute, middleware.FailClosed, "admin_write"), s.ApproveSanctumRequest)
@@ -837,6 +1025,12 @@ func (s *Server) Start() error {
 	// Start consumed ticket cache cleanup
 	go s.cleanupConsumedTickets(s.shutdownCtx)
 
+	// Publish scheduled posts as they come due
+	go s.publishScheduledPosts(s.shutdownCtx)
+
+	// Recompute sanctum directory stats and recommendations
+	go s.refreshSanctumDirectory(s.shutdownCtx)
+
 	// Wire all hubs to Redis subscriber if available
 	if s.notifier != nil {
 		for _, h := range s.hubs {
@@ -13,6 +13,16 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// MyProfileResponse is the caller's own user with their private settings.
+type MyProfileResponse struct {
+	*models.User
+	HideReadReceipts bool `json:"hide_read_receipts"`
+}
+
+func myProfileResponse(user *models.User) MyProfileResponse {
+	return MyProfileResponse{User: user, HideReadReceipts: user.HideReadReceipts}
+}
+
 // GetAllUsers handles GET /api/users
 func (s *Server) GetAllUsers(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(c.Context(), 5*time.Second)
@@ -58,7 +68,7 @@ func (s *Server) GetMyProfile(c *fiber.Ctx) error {
 		return models.RespondWithError(c, fiber.StatusNotFound, err)
 	}
 
-	return c.JSON(user)
+	return c.JSON(myProfileResponse(user))
 }
 
 // UpdateMyProfile handles PUT /api/users/me
@@ -91,7 +101,7 @@ func (s *Server) UpdateMyProfile(c *fiber.Ctx) error {
 		return models.RespondWithError(c, status, err)
 	}
 
-	return c.JSON(user)
+	return c.JSON(myProfileResponse(user))
 }
 
 // PromoteToAdmin handles POST /api/users/:id/promote-admin (admin only)
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -73,10 +74,18 @@ func TestGetMyProfile(t *testing.T) {
 	})
 	app.Get("/users/me", s.GetMyProfile)
 
-	mockRepo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, Username: "me"}, nil)
+	mockRepo.On("GetByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, Username: "me", HideReadReceipts: true}, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/users/me", nil)
 	resp, _ := app.Test(req)
 	defer func() { _ = resp.Body.Close() }()
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Private settings are only serialized for the user themselves.
+	var body map[string]any
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, true, body["hide_read_receipts"])
+	public, err := json.Marshal(&models.User{ID: 1, HideReadReceipts: true})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(public), "hide_read_receipts")
 }
@@ -16,6 +16,14 @@ import (
 	"github.com/gofiber/websocket/v2"
 )
 
+// Bounds on a websocket resume request: how many conversations it may list
+// and how many missed messages are sent per conversation before the client
+// has to page over HTTP.
+const (
+	maxResumeConversations = 50
+	resumeMessageLimit     = 100
+)
+
 // WebSocketChatHandler handles WebSocket connections for real-time chat
 func (s *Server) WebSocketChatHandler() fiber.Handler {
 	return websocket.New(func(conn *websocket.Conn) {
@@ -164,11 +172,15 @@ func (s *Server) WebSocketChatHandler() fiber.Handler {
 							return
 						}
 
+						replyToID, _ := incomingMsg["reply_to_message_id"].(float64)
+						threadRootID, _ := incomingMsg["thread_root_id"].(float64)
 						message, conv, err := s.chatSvc().SendMessage(ctx, service.SendMessageInput{
 							UserID:         userID,
 							ConversationID: convID,
 							Content:        content,
 							MessageType:    "text",
+							ReplyToID:      uint(replyToID),
+							ThreadRootID:   uint(threadRootID),
 						})
 						if err != nil {
 							response := notifications.ChatMessage{
@@ -183,16 +195,21 @@ func (s *Server) WebSocketChatHandler() fiber.Handler {
 							return
 						}
 						s.persistMessageMentions(ctx, convID, message, userID, conv.Participants)
+						s.unfurlMessageLink(message)
 
 						// Broadcast via Redis
 						if s.notifier != nil {
-							messageJSON, err := json.Marshal(notifications.ChatMessage{
+							event := notifications.ChatMessage{
 								Type:           "message",
 								ConversationID: convID,
 								UserID:         userID,
 								Username:       username,
 								Payload:        message,
-							})
+							}
+							if message.ThreadRootID != nil {
+								event = s.threadReplyEvent(ctx, message, username)
+							}
+							messageJSON, err := json.Marshal(event)
 							if err != nil {
 								log.Printf("marshal chat message error: %v", err)
 								return
@@ -224,31 +241,92 @@ func (s *Server) WebSocketChatHandler() fiber.Handler {
 					}
 				}
 
+			case "thread_read":
+				// Mark a side thread as read; syncs to the user's other sessions
+				convIDFloat, ok := incomingMsg["conversation_id"].(float64)
+				rootIDFloat, rootOK := incomingMsg["root_message_id"].(float64)
+				if ok && rootOK {
+					if _, err := s.markThreadRead(ctx, uint(convIDFloat), uint(rootIDFloat), userID); err != nil {
+						response := notifications.ChatMessage{
+							Type: "error",
+							Payload: map[string]string{
+								"message": err.Error(),
+							},
+						}
+						if respJSON, marshalErr := json.Marshal(response); marshalErr == nil {
+							c.TrySend(respJSON)
+						}
+					}
+				}
+
 			case "read":
-				// Mark conversation as read
+				// Mark conversation as read, optionally up to a given message
 				if convIDFloat, ok := incomingMsg["conversation_id"].(float64); ok {
 					convID := uint(convIDFloat)
-					if s.isUserParticipant(ctx, userID, convID) {
-						if uerr := s.chatRepo.UpdateLastRead(ctx, convID, userID); uerr != nil {
-							log.Printf("update last read error: %v", uerr)
-						}
+					messageIDFloat, _ := incomingMsg["message_id"].(float64)
+					receipt, err := s.chatSvc().MarkRead(ctx, convID, userID, uint(messageIDFloat))
+					if err != nil {
+						log.Printf("mark read error: %v", err)
+						return
+					}
 
-						// Broadcast read receipt
-						if s.notifier != nil {
-							readMsg := notifications.ChatMessage{
-								Type:           "message_read",
-								ConversationID: convID,
-								UserID:         userID,
-								Username:       username,
-								Payload:        map[string]interface{}{"conversation_id": convID, "user_id": userID},
-							}
-							readJSON, _ := json.Marshal(readMsg)
+					// Broadcast read receipt unless the user keeps them private
+					if s.notifier != nil && !receipt.Hidden {
+						for _, event := range readReceiptEvents(receipt, username) {
+							readJSON, _ := json.Marshal(event)
 							if perr := s.notifier.PublishChatMessage(ctx, convID, string(readJSON)); perr != nil {
 								log.Printf("publish read receipt error: %v", perr)
 							}
 						}
 					}
 				}
+
+			case "delivered":
+				// Acknowledge that messages up to message_id reached this client
+				convIDFloat, ok := incomingMsg["conversation_id"].(float64)
+				messageIDFloat, msgOK := incomingMsg["message_id"].(float64)
+				if ok && msgOK && s.isUserParticipant(ctx, userID, uint(convIDFloat)) {
+					s.markDelivered(ctx, uint(convIDFloat), userID, uint(messageIDFloat))
+				}
+
+			case "resume":
+				// Catch up after a reconnect: the client lists the last message
+				// it saw per conversation and receives what it missed. Clients
+				// should join first so nothing slips in between.
+				id := fmt.Sprintf("user:%d", userID)
+				allowed, err := middleware.CheckRateLimit(ctx, s.redis, s.config.Env, "ws_resume", id, 10, time.Minute)
+				if err != nil {
+					log.Printf("rate limit check error: %v", err)
+				}
+				if !allowed {
+					return
+				}
+				entries, _ := incomingMsg["conversations"].([]interface{})
+				if len(entries) > maxResumeConversations {
+					entries = entries[:maxResumeConversations]
+				}
+				for _, entry := range entries {
+					fields, _ := entry.(map[string]interface{})
+					convIDFloat, ok := fields["conversation_id"].(float64)
+					lastFloat, lastOK := fields["last_message_id"].(float64)
+					if !ok || !lastOK {
+						continue
+					}
+					convID := uint(convIDFloat)
+					catchUp, err := s.chatSvc().CatchUp(ctx, convID, userID, uint(lastFloat), resumeMessageLimit)
+					if err != nil {
+						log.Printf("resume conversation %d for user %d: %v", convID, userID, err)
+						continue
+					}
+					response := notifications.ChatMessage{
+						Type:           "catch_up",
+						ConversationID: convID,
+						Payload:        catchUp,
+					}
+					if respJSON, marshalErr := json.Marshal(response); marshalErr == nil {
+						c.TrySend(respJSON)
+					}
+				}
 			}
 		}
 
@@ -8,6 +8,7 @@ import (
 	"sanctum/internal/middleware"
 	"sanctum/internal/models"
 	"sanctum/internal/notifications"
+	"sanctum/internal/service"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -91,6 +92,7 @@ func (s *Server) maybeSendWelcomeRoomJoinMessage(ctx context.Context, userID, co
 	}
 
 	// Broadcast the welcome message so the user sees it immediately
+	service.RenderMessageHTML(welcomeMsg)
 	if s.chatHub != nil {
 		s.chatHub.BroadcastToConversation(conversationID, notifications.ChatMessage{
 			Type:           "room_message",
@@ -0,0 +1,1017 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"sanctum/internal/cache"
+	"sanctum/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxAutomodRules bounds how many rules one sanctum config may hold.
+	maxAutomodRules = 50
+	// automodDryRunPosts is how many recent posts a dry run replays.
+	automodDryRunPosts = 50
+
+	automodBotUsername = "automoderator"
+	automodBotEmail    = "automoderator@sanctum.local"
+)
+
+// AutoModerator rule targets.
+const (
+	AutomodTargetPost    = "post"
+	AutomodTargetComment = "comment"
+	AutomodTargetMessage = "message"
+)
+
+// AutoModerator rule actions.
+const (
+	AutomodActionRemove = "remove"
+	AutomodActionFilter = "filter"
+)
+
+// AutomodRule is one AutoModerator rule. Every condition that is set must
+// match for the rule to fire.
+type AutomodRule struct {
+	Name string `yaml:"name" json:"name"`
+	// Targets limits the rule to posts, comments or chat messages; empty
+	// means all three.
+	Targets []string `yaml:"targets,omitempty" json:"targets,omitempty"`
+
+	Title               string   `yaml:"title,omitempty" json:"title,omitempty"`
+	Content             string   `yaml:"content,omitempty" json:"content,omitempty"`
+	Domains             []string `yaml:"domains,omitempty" json:"domains,omitempty"`
+	AccountAgeDaysBelow int      `yaml:"account_age_days_below,omitempty" json:"account_age_days_below,omitempty"`
+	KarmaBelow          int      `yaml:"karma_below,omitempty" json:"karma_below,omitempty"`
+	ReportsAtLeast      int      `yaml:"reports_at_least,omitempty" json:"reports_at_least,omitempty"`
+
+	// Action is remove or filter. Filtered content is held in the mod queue.
+	Action     string `yaml:"action,omitempty" json:"action,omitempty"`
+	SetFlairID uint   `yaml:"set_flair_id,omitempty" json:"set_flair_id,omitempty"`
+	Lock       bool   `yaml:"lock,omitempty" json:"lock,omitempty"`
+	// Comment is posted by the AutoModerator bot as a reply.
+	Comment string `yaml:"comment,omitempty" json:"comment,omitempty"`
+
+	title   *regexp.Regexp
+	content *regexp.Regexp
+}
+
+type automodConfig struct {
+	Rules []AutomodRule `yaml:"rules"`
+}
+
+// AutomodSubject is the content a rule set is evaluated against.
+type AutomodSubject struct {
+	Target   string `json:"target"`
+	Title    string `json:"title"`
+	Content  string `json:"content"`
+	LinkURL  string `json:"link_url"`
+	AuthorID uint   `json:"author_id"`
+}
+
+// AutomodMatch reports a rule that fired, and what it matched, when
+// testing or dry-running a rule set.
+type AutomodMatch struct {
+	Rule       string `json:"rule"`
+	Action     string `json:"action,omitempty"`
+	TargetType string `json:"target_type,omitempty"`
+	TargetID   uint   `json:"target_id,omitempty"`
+	Title      string `json:"title,omitempty"`
+}
+
+// AutomodService stores sanctum AutoModerator rule sets and applies them to
+// new posts, comments and chat messages.
+type AutomodService struct {
+	db *gorm.DB
+	// canManage reports whether a user may view and edit a sanctum's rules.
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error)
+	// rules holds each sanctum's parsed rule set.
+	rules sanctumConfigCache[[]AutomodRule]
+}
+
+type UpdateAutomodInput struct {
+	ActorID   uint
+	SanctumID uint
+	Source    string
+	Enabled   bool
+}
+
+func NewAutomodService(
+	db *gorm.DB,
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error),
+) *AutomodService {
+	return &AutomodService{db: db, canManage: canManage}
+}
+
+// ParseAutomodRules parses and validates a YAML or JSON rule set of the form
+// {"rules": [...]}.
+func ParseAutomodRules(source string) ([]AutomodRule, error) {
+	if len(source) > models.MaxAutomodSourceLen {
+		return nil, models.NewValidationError(fmt.Sprintf("AutoModerator config too long (max %d characters)", models.MaxAutomodSourceLen))
+	}
+	if strings.TrimSpace(source) == "" {
+		return []AutomodRule{}, nil
+	}
+	var cfg automodConfig
+	// YAML is a superset of JSON, so one decoder handles both formats.
+	if err := yaml.Unmarshal([]byte(source), &cfg); err != nil {
+		return nil, models.NewValidationError("Invalid AutoModerator config: " + err.Error())
+	}
+	if len(cfg.Rules) > maxAutomodRules {
+		return nil, models.NewValidationError(fmt.Sprintf("AutoModerator configs can hold at most %d rules", maxAutomodRules))
+	}
+	for i := range cfg.Rules {
+		if err := cfg.Rules[i].compile(i + 1); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Rules == nil {
+		cfg.Rules = []AutomodRule{}
+	}
+	return cfg.Rules, nil
+}
+
+func (r *AutomodRule) compile(n int) error {
+	invalid := func(msg string) error {
+		return models.NewValidationError(fmt.Sprintf("Rule %d: %s", n, msg))
+	}
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		r.Name = fmt.Sprintf("Rule %d", n)
+	}
+	if utf8.RuneCountInString(r.Name) > 80 {
+		return invalid("name too long (max 80 characters)")
+	}
+	for _, target := range r.Targets {
+		switch target {
+		case AutomodTargetPost, AutomodTargetComment, AutomodTargetMessage:
+		default:
+			return invalid("unknown target " + target)
+		}
+	}
+
+	var err error
+	if r.Title != "" {
+		if r.title, err = regexp.Compile(r.Title); err != nil {
+			return invalid("title is not a valid regular expression")
+		}
+	}
+	if r.Content != "" {
+		if r.content, err = regexp.Compile(r.Content); err != nil {
+			return invalid("content is not a valid regular expression")
+		}
+	}
+	for i, domain := range r.Domains {
+		r.Domains[i] = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "www.")
+	}
+	if r.AccountAgeDaysBelow < 0 || r.KarmaBelow < 0 || r.ReportsAtLeast < 0 {
+		return invalid("author thresholds cannot be negative")
+	}
+	if r.title == nil && r.content == nil && len(r.Domains) == 0 &&
+		r.AccountAgeDaysBelow == 0 && r.KarmaBelow == 0 && r.ReportsAtLeast == 0 {
+		return invalid("at least one condition is required")
+	}
+
+	switch r.Action {
+	case "", AutomodActionRemove, AutomodActionFilter:
+	default:
+		return invalid("action must be remove or filter")
+	}
+	r.Comment = strings.TrimSpace(r.Comment)
+	if len(r.Comment) > 10000 {
+		return invalid("comment too long (max 10000 characters)")
+	}
+	if r.Action == "" && r.SetFlairID == 0 && !r.Lock && r.Comment == "" {
+		return invalid("at least one action is required")
+	}
+	return nil
+}
+
+func (r *AutomodRule) appliesTo(target string) bool {
+	if len(r.Targets) == 0 {
+		return true
+	}
+	for _, t := range r.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// automodAuthor lazily loads the author facts rules can test, so rule sets
+// that only match on text never query them.
+type automodAuthor struct {
+	db      *gorm.DB
+	userID  uint
+	created *time.Time
+	karma   *int64
+	reports *int64
+}
+
+func (a *automodAuthor) accountAgeDays(ctx context.Context) (int, error) {
+	if a.created == nil {
+		var user models.User
+		if err := a.db.WithContext(ctx).Select("id", "created_at").First(&user, a.userID).Error; err != nil {
+			return 0, err
+		}
+		a.created = &user.CreatedAt
+	}
+	return int(time.Since(*a.created).Hours() / 24), nil
+}
+
+func (a *automodAuthor) karmaCount(ctx context.Context) (int64, error) {
+	if a.karma == nil {
+		karma, err := userKarma(ctx, a.db, a.userID)
+		if err != nil {
+			return 0, err
+		}
+		a.karma = &karma
+	}
+	return *a.karma, nil
+}
+
+// reportCount counts reports against the author that were not dismissed.
+func (a *automodAuthor) reportCount(ctx context.Context) (int64, error) {
+	if a.reports == nil {
+		var count int64
+		if err := a.db.WithContext(ctx).Model(&models.ModerationReport{}).
+			Where("reported_user_id = ? AND status <> ?", a.userID, models.ReportStatusDismissed).
+			Count(&count).Error; err != nil {
+			return 0, err
+		}
+		a.reports = &count
+	}
+	return *a.reports, nil
+}
+
+var automodURLPattern = regexp.MustCompile(`https?://[^\s<>()\[\]"']+`)
+
+// subjectDomains returns the lower-cased hosts linked from a subject.
+func subjectDomains(subject AutomodSubject) []string {
+	urls := automodURLPattern.FindAllString(subject.Content, -1)
+	if subject.LinkURL != "" {
+		urls = append(urls, subject.LinkURL)
+	}
+	hosts := make([]string, 0, len(urls))
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		hosts = append(hosts, strings.TrimPrefix(strings.ToLower(u.Hostname()), "www."))
+	}
+	return hosts
+}
+
+func (r *AutomodRule) matchesDomain(hosts []string) bool {
+	for _, host := range hosts {
+		for _, domain := range r.Domains {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *AutomodRule) matches(ctx context.Context, subject AutomodSubject, hosts []string, author *automodAuthor) (bool, error) {
+	if !r.appliesTo(subject.Target) {
+		return false, nil
+	}
+	if r.title != nil && (subject.Target != AutomodTargetPost || !r.title.MatchString(subject.Title)) {
+		return false, nil
+	}
+	if r.content != nil && !r.content.MatchString(subject.Content) {
+		return false, nil
+	}
+	if len(r.Domains) > 0 && !r.matchesDomain(hosts) {
+		return false, nil
+	}
+	if r.AccountAgeDaysBelow > 0 {
+		days, err := author.accountAgeDays(ctx)
+		if err != nil {
+			return false, err
+		}
+		if days >= r.AccountAgeDaysBelow {
+			return false, nil
+		}
+	}
+	if r.KarmaBelow > 0 {
+		karma, err := author.karmaCount(ctx)
+		if err != nil {
+			return false, err
+		}
+		if karma >= int64(r.KarmaBelow) {
+			return false, nil
+		}
+	}
+	if r.ReportsAtLeast > 0 {
+		reports, err := author.reportCount(ctx)
+		if err != nil {
+			return false, err
+		}
+		if reports < int64(r.ReportsAtLeast) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// evaluateAutomod returns the rules that fire for subject, in config order.
+func evaluateAutomod(ctx context.Context, db *gorm.DB, rules []AutomodRule, subject AutomodSubject) ([]*AutomodRule, error) {
+	hosts := subjectDomains(subject)
+	author := &automodAuthor{db: db, userID: subject.AuthorID}
+	var fired []*AutomodRule
+	for i := range rules {
+		ok, err := rules[i].matches(ctx, subject, hosts, author)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			fired = append(fired, &rules[i])
+		}
+	}
+	return fired, nil
+}
+
+// activeRules returns a sanctum's enabled rules, or nil when it has none.
+// Parsed rule sets are reused until the config changes; callers must not
+// modify them.
+func (s *AutomodService) activeRules(ctx context.Context, sanctumID uint) ([]AutomodRule, error) {
+	var cfg models.SanctumAutomod
+	err := s.db.WithContext(ctx).First(&cfg, "sanctum_id = ?", sanctumID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if rules, ok := s.rules.get(sanctumID, cfg.UpdatedAt); ok {
+		return rules, nil
+	}
+	rules, err := ParseAutomodRules(cfg.Source)
+	if err != nil {
+		return nil, err
+	}
+	s.rules.put(sanctumID, cfg.UpdatedAt, rules)
+	return rules, nil
+}
+
+// automodExempt reports whether a user is sanctum staff, whose content
+// AutoModerator leaves alone.
+func automodExempt(ctx context.Context, db *gorm.DB, sanctumID, userID uint) (bool, error) {
+	var count int64
+	err := db.WithContext(ctx).Model(&models.SanctumMembership{}).
+		Where("sanctum_id = ? AND user_id = ? AND role IN ?", sanctumID, userID,
+			[]models.SanctumMembershipRole{models.SanctumMembershipRoleOwner, models.SanctumMembershipRoleMod}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CheckMessage rejects a chat message in a sanctum room when a remove or
+// filter rule matches it. Other actions do not apply to chat.
+func (s *AutomodService) CheckMessage(ctx context.Context, sanctumID, userID uint, content string) error {
+	v, err := s.checkContent(ctx, sanctumID, AutomodSubject{
+		Target:   AutomodTargetMessage,
+		Content:  content,
+		AuthorID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if v != nil && v.removal != nil {
+		return models.NewValidationError("Message blocked by AutoModerator: " + v.removal.Name)
+	}
+	return nil
+}
+
+func (s *AutomodService) requireManager(ctx context.Context, actorID, sanctumID uint) error {
+	if s.canManage == nil {
+		return models.NewForbiddenError("Sanctum moderator access required")
+	}
+	ok, err := s.canManage(ctx, actorID, sanctumID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.NewForbiddenError("Sanctum moderator access required")
+	}
+	return nil
+}
+
+// Get returns a sanctum's AutoModerator config. Moderators only.
+func (s *AutomodService) Get(ctx context.Context, actorID, sanctumID uint) (*models.SanctumAutomod, error) {
+	if err := s.requireManager(ctx, actorID, sanctumID); err != nil {
+		return nil, err
+	}
+	cfg := models.SanctumAutomod{SanctumID: sanctumID, Enabled: true}
+	err := s.db.WithContext(ctx).First(&cfg, "sanctum_id = ?", sanctumID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Update validates and stores a sanctum's AutoModerator config.
+func (s *AutomodService) Update(ctx context.Context, in UpdateAutomodInput) (*models.SanctumAutomod, error) {
+	if err := s.requireManager(ctx, in.ActorID, in.SanctumID); err != nil {
+		return nil, err
+	}
+	rules, err := ParseAutomodRules(in.Source)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkFlairs(ctx, in.SanctumID, rules); err != nil {
+		return nil, err
+	}
+	actorID := in.ActorID
+	cfg := models.SanctumAutomod{
+		SanctumID:       in.SanctumID,
+		Source:          in.Source,
+		Enabled:         in.Enabled,
+		UpdatedByUserID: &actorID,
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "sanctum_id"}},
+		UpdateAll: true,
+	}).Create(&cfg).Error; err != nil {
+		return nil, err
+	}
+	s.rules.drop(in.SanctumID)
+	return &cfg, nil
+}
+
+// checkFlairs rejects rules that set a flair the sanctum does not define.
+func (s *AutomodService) checkFlairs(ctx context.Context, sanctumID uint, rules []AutomodRule) error {
+	for _, rule := range rules {
+		if rule.SetFlairID == 0 {
+			continue
+		}
+		var count int64
+		if err := s.db.WithContext(ctx).Model(&models.SanctumFlair{}).
+			Where("id = ? AND sanctum_id = ?", rule.SetFlairID, sanctumID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return models.NewValidationError(fmt.Sprintf("%s: flair %d does not belong to this sanctum", rule.Name, rule.SetFlairID))
+		}
+	}
+	return nil
+}
+
+// dropAutomodFlair removes a deleted flair from a sanctum's stored rules, so
+// the saved config never points at a flair that is gone. Runs inside the
+// flair delete transaction.
+func dropAutomodFlair(tx *gorm.DB, sanctumID, flairID uint) error {
+	var cfg models.SanctumAutomod
+	if err := tx.First(&cfg, "sanctum_id = ?", sanctumID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	source, changed, err := automodSourceWithoutFlair(cfg.Source, flairID)
+	if err != nil || !changed {
+		return err
+	}
+	return tx.Model(&cfg).Update("source", source).Error
+}
+
+// automodSourceWithoutFlair rewrites a rule set without set_flair_id keys for
+// flairID. A rule left with no other action is dropped, since it would no
+// longer do anything and would fail validation.
+func automodSourceWithoutFlair(source string, flairID uint) (string, bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(source), &doc); err != nil {
+		return "", false, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return source, false, nil
+	}
+	root := doc.Content[0]
+	var rules *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "rules" && root.Content[i+1].Kind == yaml.SequenceNode {
+			rules = root.Content[i+1]
+		}
+	}
+	if rules == nil {
+		return source, false, nil
+	}
+
+	changed := false
+	kept := rules.Content[:0]
+	for _, item := range rules.Content {
+		if item.Kind != yaml.MappingNode {
+			kept = append(kept, item)
+			continue
+		}
+		removed := false
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			var id uint
+			if item.Content[i].Value != "set_flair_id" || item.Content[i+1].Decode(&id) != nil || id != flairID {
+				continue
+			}
+			item.Content = append(item.Content[:i], item.Content[i+2:]...)
+			removed = true
+			break
+		}
+		if !removed {
+			kept = append(kept, item)
+			continue
+		}
+		changed = true
+		var rule AutomodRule
+		if err := item.Decode(&rule); err != nil {
+			return "", false, err
+		}
+		if rule.Action != "" || rule.SetFlairID != 0 || rule.Lock || strings.TrimSpace(rule.Comment) != "" {
+			kept = append(kept, item)
+		}
+	}
+	if !changed {
+		return source, false, nil
+	}
+	rules.Content = kept
+
+	var out strings.Builder
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return "", false, err
+	}
+	if err := enc.Close(); err != nil {
+		return "", false, err
+	}
+	return out.String(), true, nil
+}
+
+// rulesFor parses source when given, and the stored config otherwise.
+func (s *AutomodService) rulesFor(ctx context.Context, sanctumID uint, source *string) ([]AutomodRule, error) {
+	if source != nil {
+		return ParseAutomodRules(*source)
+	}
+	cfg := models.SanctumAutomod{}
+	err := s.db.WithContext(ctx).First(&cfg, "sanctum_id = ?", sanctumID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return ParseAutomodRules(cfg.Source)
+}
+
+func automodMatches(fired []*AutomodRule, targetType string, targetID uint, title string) []AutomodMatch {
+	matches := make([]AutomodMatch, 0, len(fired))
+	for _, rule := range fired {
+		matches = append(matches, AutomodMatch{
+			Rule:       rule.Name,
+			Action:     rule.Action,
+			TargetType: targetType,
+			TargetID:   targetID,
+			Title:      title,
+		})
+	}
+	return matches
+}
+
+// TestRules evaluates a rule set against a sample subject without touching
+// any content. A nil source tests the stored rules.
+func (s *AutomodService) TestRules(ctx context.Context, actorID, sanctumID uint, source *string, subject AutomodSubject) ([]AutomodMatch, error) {
+	if err := s.requireManager(ctx, actorID, sanctumID); err != nil {
+		return nil, err
+	}
+	rules, err := s.rulesFor(ctx, sanctumID, source)
+	if err != nil {
+		return nil, err
+	}
+	if subject.Target == "" {
+		subject.Target = AutomodTargetPost
+	}
+	if subject.AuthorID == 0 {
+		subject.AuthorID = actorID
+	}
+	fired, err := evaluateAutomod(ctx, s.db, rules, subject)
+	if err != nil {
+		return nil, err
+	}
+	return automodMatches(fired, "", 0, ""), nil
+}
+
+// DryRun replays a rule set over the sanctum's recent posts and their
+// comments and reports what would have fired. A nil source dry-runs the
+// stored rules.
+func (s *AutomodService) DryRun(ctx context.Context, actorID, sanctumID uint, source *string) ([]AutomodMatch, error) {
+	if err := s.requireManager(ctx, actorID, sanctumID); err != nil {
+		return nil, err
+	}
+	rules, err := s.rulesFor(ctx, sanctumID, source)
+	if err != nil {
+		return nil, err
+	}
+	matches := []AutomodMatch{}
+	if len(rules) == 0 {
+		return matches, nil
+	}
+
+	var posts []models.Post
+	if err := s.db.WithContext(ctx).
+		Select("id", "user_id", "title", "content", "link_url").
+		Where("sanctum_id = ?", sanctumID).
+		Order("created_at DESC, id DESC").
+		Limit(automodDryRunPosts).
+		Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	postIDs := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+		fired, err := evaluateAutomod(ctx, s.db, rules, AutomodSubject{
+			Target:   AutomodTargetPost,
+			Title:    post.Title,
+			Content:  post.Content,
+			LinkURL:  post.LinkURL,
+			AuthorID: post.UserID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, automodMatches(fired, models.ModTargetPost, post.ID, post.Title)...)
+	}
+	if len(postIDs) == 0 {
+		return matches, nil
+	}
+
+	var comments []models.Comment
+	if err := s.db.WithContext(ctx).
+		Select("id", "user_id", "post_id", "content").
+		Where("post_id IN ?", postIDs).
+		Order("id").
+		Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	for _, comment := range comments {
+		fired, err := evaluateAutomod(ctx, s.db, rules, AutomodSubject{
+			Target:   AutomodTargetComment,
+			Content:  comment.Content,
+			AuthorID: comment.UserID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, automodMatches(fired, models.ModTargetComment, comment.ID, "")...)
+	}
+	return matches, nil
+}
+
+// ensureBot returns the AutoModerator bot account, creating it on first use.
+func (s *AutomodService) ensureBot(ctx context.Context) (*models.User, error) {
+	var bot models.User
+	err := s.db.WithContext(ctx).Where("username = ?", automodBotUsername).First(&bot).Error
+	if err == nil {
+		return &bot, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Nobody logs in as the bot, so its password is random and discarded.
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(secret)), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	bot = models.User{
+		Username: automodBotUsername,
+		Email:    automodBotEmail,
+		Password: string(hash),
+		Bio:      "Enforces sanctum AutoModerator rules",
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&bot).Error; err != nil {
+		return nil, err
+	}
+	if bot.ID == 0 {
+		if err := s.db.WithContext(ctx).Where("username = ?", automodBotUsername).First(&bot).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &bot, nil
+}
+
+// automodRulesForContent loads the rules that apply to new content in a
+// sanctum, or nil when the author is staff or none are set.
+func (s *AutomodService) automodRulesForContent(ctx context.Context, sanctumID, authorID uint) ([]AutomodRule, error) {
+	rules, err := s.activeRules(ctx, sanctumID)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+	exempt, err := automodExempt(ctx, s.db, sanctumID, authorID)
+	if err != nil || exempt {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func automodReason(rule *AutomodRule) string {
+	return "AutoModerator: " + rule.Name
+}
+
+// botComment posts a distinguished AutoModerator reply on a post.
+func botComment(tx *gorm.DB, botID, postID uint, content string) error {
+	return tx.Create(&models.Comment{
+		UserID:        botID,
+		PostID:        postID,
+		Content:       content,
+		Distinguished: true,
+	}).Error
+}
+
+// AutomodVerdict is what AutoModerator decided about new content before it
+// was stored. Its mod log entries and bot replies are recorded once the
+// content has an ID.
+type AutomodVerdict struct {
+	sanctumID uint
+	bot       *models.User
+	fired     []*AutomodRule
+	// removal is the first remove or filter rule; it decides the content's fate.
+	removal *AutomodRule
+	// flairs holds the flair IDs fired rules set that still exist.
+	flairs map[uint]bool
+}
+
+// checkContent evaluates a sanctum's rules against new content and returns
+// nil when none fire.
+func (s *AutomodService) checkContent(ctx context.Context, sanctumID uint, subject AutomodSubject) (*AutomodVerdict, error) {
+	rules, err := s.automodRulesForContent(ctx, sanctumID, subject.AuthorID)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+	fired, err := evaluateAutomod(ctx, s.db, rules, subject)
+	if err != nil || len(fired) == 0 {
+		return nil, err
+	}
+	bot, err := s.ensureBot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	flairs, err := liveAutomodFlairs(ctx, s.db, sanctumID, fired)
+	if err != nil {
+		return nil, err
+	}
+	v := &AutomodVerdict{sanctumID: sanctumID, bot: bot, fired: fired, flairs: flairs}
+	for _, rule := range fired {
+		if rule.Action != "" {
+			v.removal = rule
+			break
+		}
+	}
+	return v, nil
+}
+
+// filtered reports whether the content goes to the mod queue rather than
+// being removed outright.
+func (v *AutomodVerdict) filtered() bool {
+	return v.removal != nil && v.removal.Action == AutomodActionFilter
+}
+
+// setsFlair reports whether rule's flair action applies. A rule whose flair
+// was deleted after the config was saved skips it.
+func (v *AutomodVerdict) setsFlair(rule *AutomodRule) bool {
+	return rule.SetFlairID != 0 && v.flairs[rule.SetFlairID]
+}
+
+// liveAutomodFlairs returns which of the flairs the fired rules set still
+// belong to the sanctum.
+func liveAutomodFlairs(ctx context.Context, db *gorm.DB, sanctumID uint, fired []*AutomodRule) (map[uint]bool, error) {
+	var ids []uint
+	for _, rule := range fired {
+		if rule.SetFlairID != 0 {
+			ids = append(ids, rule.SetFlairID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var found []uint
+	if err := db.WithContext(ctx).Model(&models.SanctumFlair{}).
+		Where("sanctum_id = ? AND id IN ?", sanctumID, ids).
+		Pluck("id", &found).Error; err != nil {
+		return nil, err
+	}
+	live := make(map[uint]bool, len(found))
+	for _, id := range found {
+		live[id] = true
+	}
+	return live, nil
+}
+
+// CheckPost runs a sanctum's rules against a post before it is inserted and
+// applies removal, flair and lock to the post itself, so removed content is
+// never visible. An error means the post must not be created.
+func (s *AutomodService) CheckPost(ctx context.Context, post *models.Post) (*AutomodVerdict, error) {
+	if post.SanctumID == nil {
+		return nil, nil
+	}
+	v, err := s.checkContent(ctx, *post.SanctumID, AutomodSubject{
+		Target:   AutomodTargetPost,
+		Title:    post.Title,
+		Content:  post.Content,
+		LinkURL:  post.LinkURL,
+		AuthorID: post.UserID,
+	})
+	if err != nil || v == nil {
+		return nil, err
+	}
+	if v.removal != nil {
+		now := time.Now()
+		post.RemovedAt = &now
+		post.RemovedByUserID = &v.bot.ID
+		post.RemovalReason = automodReason(v.removal)
+		post.NeedsReview = v.filtered()
+	}
+	for _, rule := range v.fired {
+		if v.setsFlair(rule) {
+			flairID := rule.SetFlairID
+			post.FlairID = &flairID
+		}
+		if rule.Lock {
+			post.Locked = true
+		}
+	}
+	return v, nil
+}
+
+// RecordPost logs the actions CheckPost took on a post that has since been
+// created and posts the bot's replies.
+func (s *AutomodService) RecordPost(ctx context.Context, post *models.Post, v *AutomodVerdict) error {
+	if v == nil {
+		return nil
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, rule := range v.fired {
+			reason := automodReason(rule)
+			if rule == v.removal {
+				action := models.ModActionRemovePost
+				if v.filtered() {
+					action = models.ModActionFilterPost
+				}
+				if err := RecordModAction(tx, v.sanctumID, v.bot.ID, action, models.ModTargetPost, post.ID, reason); err != nil {
+					return err
+				}
+			}
+			if v.setsFlair(rule) {
+				if err := RecordModAction(tx, v.sanctumID, v.bot.ID, models.ModActionFlairPost, models.ModTargetPost, post.ID, reason); err != nil {
+					return err
+				}
+			}
+			if rule.Lock {
+				if err := RecordModAction(tx, v.sanctumID, v.bot.ID, models.ModActionLockPost, models.ModTargetPost, post.ID, reason); err != nil {
+					return err
+				}
+			}
+			if rule.Comment != "" {
+				if err := botComment(tx, v.bot.ID, post.ID, rule.Comment); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	cache.Invalidate(ctx, cache.PostKey(post.ID))
+	return nil
+}
+
+// CheckComment runs a sanctum's rules against a comment before it is
+// inserted and applies a removal to the comment itself. An error means the
+// comment must not be created.
+func (s *AutomodService) CheckComment(ctx context.Context, comment *models.Comment, sanctumID uint) (*AutomodVerdict, error) {
+	v, err := s.checkContent(ctx, sanctumID, AutomodSubject{
+		Target:   AutomodTargetComment,
+		Content:  comment.Content,
+		AuthorID: comment.UserID,
+	})
+	if err != nil || v == nil {
+		return nil, err
+	}
+	if v.removal != nil {
+		now := time.Now()
+		comment.RemovedAt = &now
+		comment.RemovedByUserID = &v.bot.ID
+		comment.RemovalReason = automodReason(v.removal)
+		comment.NeedsReview = v.filtered()
+	}
+	return v, nil
+}
+
+// CreateComment stores a comment CheckComment fired rules on, together with
+// the actions they take, in one transaction. Flair and lock rules apply to
+// the comment's post.
+func (s *AutomodService) CreateComment(ctx context.Context, comment *models.Comment, v *AutomodVerdict) error {
+	postChanged := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		for _, rule := range v.fired {
+			reason := automodReason(rule)
+			if rule == v.removal {
+				action := models.ModActionRemoveComment
+				if v.filtered() {
+					action = models.ModActionFilterComment
+				}
+				if err := RecordModAction(tx, v.sanctumID, v.bot.ID, action, models.ModTargetComment, comment.ID, reason); err != nil {
+					return err
+				}
+			}
+			if v.setsFlair(rule) {
+				if err := tx.Model(&models.Post{}).Where("id = ?", comment.PostID).Update("flair_id", rule.SetFlairID).Error; err != nil {
+					return err
+				}
+				if err := RecordModAction(tx, v.sanctumID, v.bot.ID, models.ModActionFlairPost, models.ModTargetPost, comment.PostID, reason); err != nil {
+					return err
+				}
+				postChanged = true
+			}
+			if rule.Lock {
+				if err := tx.Model(&models.Post{}).Where("id = ?", comment.PostID).Update("locked", true).Error; err != nil {
+					return err
+				}
+				if err := RecordModAction(tx, v.sanctumID, v.bot.ID, models.ModActionLockPost, models.ModTargetPost, comment.PostID, reason); err != nil {
+					return err
+				}
+				postChanged = true
+			}
+			if rule.Comment != "" {
+				if err := botComment(tx, v.bot.ID, comment.PostID, rule.Comment); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if postChanged {
+		cache.Invalidate(ctx, cache.PostKey(comment.PostID))
+		cache.InvalidatePostsList(ctx)
+	}
+	return nil
+}
+
+// ModQueue lists the posts and comments AutoModerator filtered for review,
+// oldest first. Moderators only.
+func (s *AutomodService) ModQueue(ctx context.Context, actorID, sanctumID uint, limit int) ([]models.Post, []models.Comment, error) {
+	if err := s.requireManager(ctx, actorID, sanctumID); err != nil {
+		return nil, nil, err
+	}
+	posts := []models.Post{}
+	if err := s.db.WithContext(ctx).
+		Preload("User").
+		Where("sanctum_id = ? AND needs_review = ?", sanctumID, true).
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Find(&posts).Error; err != nil {
+		return nil, nil, err
+	}
+	comments := []models.Comment{}
+	if err := s.db.WithContext(ctx).
+		Preload("User").
+		Joins("JOIN posts ON posts.id = comments.post_id").
+		Where("posts.sanctum_id = ? AND comments.needs_review = ?", sanctumID, true).
+		Order("comments.created_at ASC, comments.id ASC").
+		Limit(limit).
+		Find(&comments).Error; err != nil {
+		return nil, nil, err
+	}
+	for i := range posts {
+		RenderPostHTML(&posts[i])
+	}
+	for i := range comments {
+		RenderCommentHTML(&comments[i])
+	}
+	return posts, comments, nil
+}
@@ -0,0 +1,426 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAutomodRules(t *testing.T) {
+	rules, err := ParseAutomodRules(`
+rules:
+  - name: shorteners
+    domains: [bit.ly]
+    action: remove
+`)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, "shorteners", rules[0].Name)
+
+	// JSON is accepted too.
+	rules, err = ParseAutomodRules(`{"rules": [{"content": "(?i)spam", "action": "filter"}]}`)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, "Rule 1", rules[0].Name)
+
+	rules, err = ParseAutomodRules("  ")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	for name, source := range map[string]string{
+		"bad syntax":   "rules: [",
+		"bad regex":    `{"rules": [{"title": "([", "action": "remove"}]}`,
+		"no condition": `{"rules": [{"action": "remove"}]}`,
+		"no action":    `{"rules": [{"content": "x"}]}`,
+		"bad action":   `{"rules": [{"content": "x", "action": "ban"}]}`,
+		"bad target":   `{"rules": [{"content": "x", "action": "remove", "targets": ["wiki"]}]}`,
+	} {
+		_, err := ParseAutomodRules(source)
+		assert.Error(t, err, name)
+	}
+}
+
+// automodFixture is a sanctum with a flair, an AutoModerator rule set and a
+// brand-new author next to the fixture's year-old member.
+type automodFixture struct {
+	*sanctumFixture
+	automod  *AutomodService
+	posts    *PostService
+	comments *CommentService
+	flair    *models.SanctumFlair
+	newbie   *models.User
+	source   string
+}
+
+func newAutomodFixture(t *testing.T) *automodFixture {
+	t.Helper()
+	f := newSanctumFixture(t, append(postTables(),
+		&models.SanctumAutomod{},
+		&models.SanctumModLogEntry{},
+		&models.ModerationReport{},
+	)...)
+	require.NoError(t, f.db.Model(f.member).Update("created_at", time.Now().AddDate(-1, 0, 0)).Error)
+	flair := &models.SanctumFlair{SanctumID: f.sanctum.ID, Name: "Expired", Color: "#999999"}
+	require.NoError(t, f.db.Create(flair).Error)
+	automod := NewAutomodService(f.db, f.canManage)
+	return &automodFixture{
+		sanctumFixture: f,
+		automod:        automod,
+		posts:          NewPostService(repository.NewPostRepository(f.db), repository.NewPollRepository(f.db), nil, nil, nil, nil, nil, automod, nil),
+		comments:       NewCommentService(repository.NewCommentRepository(f.db), repository.NewPostRepository(f.db), nil, nil, nil, automod),
+		flair:          flair,
+		newbie:         createTestUser(t, f.db, "newbie"),
+		source: `
+rules:
+  - name: shorteners
+    domains: [bit.ly]
+    action: remove
+  - name: new accounts
+    account_age_days_below: 7
+    content: (?i)buy now
+    action: filter
+    comment: Your post is awaiting moderator review.
+  - name: expired
+    title: (?i)\[expired\]
+    set_flair_id: ` + strconv.FormatUint(uint64(flair.ID), 10) + `
+    lock: true
+`,
+	}
+}
+
+// enable stores the fixture rule set.
+func (f *automodFixture) enable(t *testing.T, enabled bool) {
+	t.Helper()
+	_, err := f.automod.Update(context.Background(), UpdateAutomodInput{
+		ActorID: f.mod.ID, SanctumID: f.sanctum.ID, Source: f.source, Enabled: enabled,
+	})
+	require.NoError(t, err)
+}
+
+// createPost stores a sanctum post without running any rules.
+func (f *automodFixture) createPost(t *testing.T, userID uint, title, content string) *models.Post {
+	t.Helper()
+	post := &models.Post{UserID: userID, Title: title, Content: content, PostType: models.PostTypeText, SanctumID: &f.sanctum.ID}
+	require.NoError(t, f.db.Create(post).Error)
+	return post
+}
+
+// submitPost creates a sanctum post through PostService, which runs the rules.
+func (f *automodFixture) submitPost(userID uint, title, content string) (*models.Post, error) {
+	return f.posts.CreatePost(context.Background(), CreatePostInput{UserID: userID, Title: title, Content: content, SanctumID: &f.sanctum.ID})
+}
+
+func (f *automodFixture) botActions(t *testing.T) []string {
+	t.Helper()
+	var actions []string
+	require.NoError(t, f.db.Model(&models.SanctumModLogEntry{}).
+		Joins("JOIN users ON users.id = sanctum_mod_log.moderator_id").
+		Where("users.username = ?", automodBotUsername).
+		Order("sanctum_mod_log.id").
+		Pluck("action", &actions).Error)
+	return actions
+}
+
+func TestAutomodService_UpdateValidation(t *testing.T) {
+	f := newAutomodFixture(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		actorID uint
+		source  string
+		code    string
+	}{
+		{"members cannot edit rules", f.member.ID, "", "FORBIDDEN"},
+		{"invalid rules", f.mod.ID, "rules: [", "VALIDATION_ERROR"},
+		{"foreign flair", f.mod.ID, `{"rules": [{"content": "x", "set_flair_id": 9999}]}`, "VALIDATION_ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.automod.Update(ctx, UpdateAutomodInput{ActorID: tt.actorID, SanctumID: f.sanctum.ID, Source: tt.source, Enabled: true})
+			requireCode(t, err, tt.code)
+		})
+	}
+
+	f.enable(t, true)
+	cfg, err := f.automod.Get(ctx, f.owner.ID, f.sanctum.ID)
+	require.NoError(t, err)
+	assert.Equal(t, f.source, cfg.Source)
+	assert.Equal(t, f.mod.ID, *cfg.UpdatedByUserID)
+}
+
+func TestAutomodService_TestRules(t *testing.T) {
+	f := newAutomodFixture(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		subject AutomodSubject
+		want    []string
+	}{
+		{"nothing matches", AutomodSubject{Title: "TV", Content: "hello"}, []string{}},
+		{"title rule", AutomodSubject{Title: "[Expired] TV"}, []string{"expired"}},
+		{"subdomain link", AutomodSubject{Content: "see https://sub.bit.ly/y"}, []string{"shorteners"}},
+		{"link post", AutomodSubject{LinkURL: "https://www.bit.ly/abc"}, []string{"shorteners"}},
+		{"title rules skip comments", AutomodSubject{Target: AutomodTargetComment, Title: "[Expired]"}, []string{}},
+		{"account age", AutomodSubject{Content: "BUY NOW", AuthorID: f.newbie.ID}, []string{"new accounts"}},
+		{"old accounts pass", AutomodSubject{Content: "BUY NOW", AuthorID: f.member.ID}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := f.automod.TestRules(ctx, f.mod.ID, f.sanctum.ID, &f.source, tt.subject)
+			require.NoError(t, err)
+			rules := []string{}
+			for _, m := range matches {
+				rules = append(rules, m.Rule)
+			}
+			assert.Equal(t, tt.want, rules)
+		})
+	}
+
+	_, err := f.automod.TestRules(ctx, f.member.ID, f.sanctum.ID, &f.source, AutomodSubject{})
+	requireCode(t, err, "FORBIDDEN")
+}
+
+func TestAutomodService_DryRunLeavesContentAlone(t *testing.T) {
+	f := newAutomodFixture(t)
+	ctx := context.Background()
+	post := f.createPost(t, f.newbie.ID, "deal", "Buy now at https://bit.ly/x")
+
+	matches, err := f.automod.DryRun(ctx, f.mod.ID, f.sanctum.ID, &f.source)
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "shorteners", matches[0].Rule)
+	assert.Equal(t, post.ID, matches[0].TargetID)
+	assert.Equal(t, "new accounts", matches[1].Rule)
+
+	require.NoError(t, f.db.First(post, post.ID).Error)
+	assert.Nil(t, post.RemovedAt)
+	assert.Empty(t, f.botActions(t))
+}
+
+func TestAutomodService_CheckPost(t *testing.T) {
+	tests := []struct {
+		name        string
+		author      func(f *automodFixture) uint
+		title       string
+		content     string
+		removed     bool
+		needsReview bool
+		locked      bool
+		flaired     bool
+		botReplies  int64
+		actions     []string
+	}{
+		{
+			name:   "remove",
+			author: func(f *automodFixture) uint { return f.member.ID },
+			title:  "link", content: "see https://www.bit.ly/abc",
+			removed: true,
+			actions: []string{models.ModActionRemovePost},
+		},
+		{
+			name:   "filter with a bot reply",
+			author: func(f *automodFixture) uint { return f.newbie.ID },
+			title:  "offer", content: "BUY NOW",
+			removed: true, needsReview: true, botReplies: 1,
+			actions: []string{models.ModActionFilterPost},
+		},
+		{
+			name:   "flair and lock",
+			author: func(f *automodFixture) uint { return f.member.ID },
+			title:  "[EXPIRED] TV deal", content: "gone",
+			locked: true, flaired: true,
+			actions: []string{models.ModActionFlairPost, models.ModActionLockPost},
+		},
+		{
+			name:   "staff are exempt",
+			author: func(f *automodFixture) uint { return f.mod.ID },
+			title:  "link", content: "https://bit.ly/z",
+		},
+		{
+			name:   "no match",
+			author: func(f *automodFixture) uint { return f.newbie.ID },
+			title:  "hello", content: "fine",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAutomodFixture(t)
+			f.enable(t, true)
+			post, err := f.submitPost(tt.author(f), tt.title, tt.content)
+			require.NoError(t, err)
+			require.NoError(t, f.db.First(post, post.ID).Error)
+			assert.Equal(t, tt.removed, post.RemovedAt != nil)
+			assert.Equal(t, tt.needsReview, post.NeedsReview)
+			assert.Equal(t, tt.locked, post.Locked)
+			assert.Equal(t, tt.flaired, post.FlairID != nil && *post.FlairID == f.flair.ID)
+			var botReplies int64
+			f.db.Model(&models.Comment{}).Where("post_id = ? AND distinguished = ?", post.ID, true).Count(&botReplies)
+			assert.Equal(t, tt.botReplies, botReplies)
+			if tt.actions == nil {
+				assert.Empty(t, f.botActions(t))
+			} else {
+				assert.Equal(t, tt.actions, f.botActions(t))
+			}
+		})
+	}
+}
+
+func TestAutomodService_DisabledRulesDoNothing(t *testing.T) {
+	f := newAutomodFixture(t)
+	f.enable(t, false)
+	post, err := f.submitPost(f.member.ID, "link", "https://bit.ly/w")
+	require.NoError(t, err)
+	assert.Nil(t, post.RemovedAt)
+}
+
+func TestAutomodService_EvaluationErrorRejectsTheWrite(t *testing.T) {
+	f := newAutomodFixture(t)
+	ctx := context.Background()
+	f.source = `{"rules": [{"name": "reported", "reports_at_least": 1, "action": "remove"}]}`
+	f.enable(t, true)
+	require.NoError(t, f.db.Migrator().DropTable(&models.ModerationReport{}))
+
+	_, err := f.submitPost(f.member.ID, "hello", "fine")
+	require.Error(t, err)
+	_, err = f.comments.CreateComment(ctx, CreateCommentInput{UserID: f.member.ID, PostID: f.createPost(t, f.mod.ID, "ok", "fine").ID, Content: "hi"})
+	require.Error(t, err)
+
+	var posts, comments int64
+	require.NoError(t, f.db.Model(&models.Post{}).Where("user_id = ?", f.member.ID).Count(&posts).Error)
+	require.NoError(t, f.db.Model(&models.Comment{}).Count(&comments).Error)
+	assert.Zero(t, posts, "the post is not stored")
+	assert.Zero(t, comments, "the comment is not stored")
+}
+
+func TestAutomodService_CommentsAndModQueue(t *testing.T) {
+	f := newAutomodFixture(t)
+	ctx := context.Background()
+	f.enable(t, true)
+
+	filtered, err := f.submitPost(f.newbie.ID, "offer", "BUY NOW")
+	require.NoError(t, err)
+	target := f.createPost(t, f.member.ID, "ok", "fine")
+	comment, err := f.comments.CreateComment(ctx, CreateCommentInput{UserID: f.newbie.ID, PostID: target.ID, Content: "buy now!"})
+	require.NoError(t, err)
+	assert.NotNil(t, comment.RemovedAt)
+	assert.True(t, comment.NeedsReview)
+
+	_, _, err = f.automod.ModQueue(ctx, f.member.ID, f.sanctum.ID, 50)
+	requireCode(t, err, "FORBIDDEN")
+	posts, comments, err := f.automod.ModQueue(ctx, f.mod.ID, f.sanctum.ID, 50)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, filtered.ID, posts[0].ID)
+	require.Len(t, comments, 1)
+	assert.Equal(t, comment.ID, comments[0].ID)
+
+	// Approving the post and confirming the removal empty the queue.
+	mods := NewSanctumModService(f.db, f.canManage)
+	require.NoError(t, mods.RestorePost(ctx, f.mod.ID, filtered.ID))
+	require.NoError(t, mods.RemoveComment(ctx, f.mod.ID, comment.ID, "spam"))
+	posts, comments, err = f.automod.ModQueue(ctx, f.mod.ID, f.sanctum.ID, 50)
+	require.NoError(t, err)
+	assert.Empty(t, posts)
+	assert.Empty(t, comments)
+}
+
+func TestAutomodService_CheckMessage(t *testing.T) {
+	f := newAutomodFixture(t)
+	f.enable(t, true)
+
+	tests := []struct {
+		name    string
+		userID  uint
+		content string
+		blocked bool
+	}{
+		{"remove rule", f.member.ID, "https://bit.ly/q", true},
+		{"filter rule", f.newbie.ID, "buy now", true},
+		{"clean message", f.member.ID, "hello", false},
+		{"staff are exempt", f.mod.ID, "https://bit.ly/q", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := f.automod.CheckMessage(context.Background(), f.sanctum.ID, tt.userID, tt.content)
+			if tt.blocked {
+				requireCode(t, err, "VALIDATION_ERROR")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAutomodService_DeletedFlairIsSkipped(t *testing.T) {
+	f := newAutomodFixture(t)
+	f.enable(t, true)
+	// Delete the flair behind the config's back, as a race with the flair
+	// service would.
+	require.NoError(t, f.db.Delete(f.flair).Error)
+
+	post, err := f.submitPost(f.member.ID, "[expired] old deal", "gone")
+	require.NoError(t, err)
+	assert.Nil(t, post.FlairID)
+	assert.True(t, post.Locked)
+	assert.Equal(t, []string{models.ModActionLockPost}, f.botActions(t))
+}
+
+func TestAutomodService_FlairDeleteDropsRules(t *testing.T) {
+	f := newAutomodFixture(t)
+	ctx := context.Background()
+	flairID := strconv.FormatUint(uint64(f.flair.ID), 10)
+	f.source += `
+  - name: flair only
+    content: (?i)expired
+    set_flair_id: ` + flairID + `
+`
+	f.enable(t, true)
+
+	flairs := NewSanctumFlairService(f.db, f.canManage)
+	require.NoError(t, flairs.Delete(ctx, f.mod.ID, f.sanctum.ID, f.flair.ID))
+
+	cfg, err := f.automod.Get(ctx, f.mod.ID, f.sanctum.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, cfg.Source, "set_flair_id")
+	rules, err := ParseAutomodRules(cfg.Source)
+	require.NoError(t, err)
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		names = append(names, rule.Name)
+	}
+	assert.Equal(t, []string{"shorteners", "new accounts", "expired"}, names, "a rule left without actions is dropped")
+	assert.True(t, rules[2].Lock)
+}
+
+func TestAutomodService_RulesFollowConfigChanges(t *testing.T) {
+	f := newAutomodFixture(t)
+	f.enable(t, true)
+
+	post, err := f.submitPost(f.member.ID, "link", "https://bit.ly/a")
+	require.NoError(t, err)
+	assert.NotNil(t, post.RemovedAt)
+
+	// Saving new rules replaces the parsed set.
+	f.source = `{"rules": [{"name": "no cats", "content": "(?i)cats", "action": "remove"}]}`
+	f.enable(t, true)
+	post, err = f.submitPost(f.member.ID, "link", "https://bit.ly/b")
+	require.NoError(t, err)
+	assert.Nil(t, post.RemovedAt)
+
+	// So does a change saved elsewhere, once updated_at moves.
+	require.NoError(t, f.db.Model(&models.SanctumAutomod{}).Where("sanctum_id = ?", f.sanctum.ID).
+		Updates(map[string]any{"source": `{"rules": [{"name": "no dogs", "content": "(?i)dogs", "action": "remove"}]}`,
+			"updated_at": time.Now().Add(time.Minute)}).Error)
+	post, err = f.submitPost(f.member.ID, "pets", "dogs")
+	require.NoError(t, err)
+	assert.NotNil(t, post.RemovedAt)
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+)
+
+const (
+	defaultBookmarkPageSize = 20
+	maxBookmarkPageSize     = 100
+	maxCollectionNameLen    = 100
+)
+
+type BookmarkService struct {
+	bookmarkRepo repository.BookmarkRepository
+	postRepo     repository.PostRepository
+	commentRepo  repository.CommentRepository
+}
+
+type AddBookmarkInput struct {
+	UserID       uint
+	TargetType   string
+	TargetID     uint
+	CollectionID *uint
+}
+
+type ListBookmarksInput struct {
+	UserID       uint
+	CollectionID *uint
+	Cursor       uint
+	Limit        int
+}
+
+// BookmarkPage is one page of bookmarks. NextCursor is zero on the last page.
+type BookmarkPage struct {
+	Bookmarks  []*models.Bookmark `json:"bookmarks"`
+	NextCursor uint               `json:"next_cursor,omitempty"`
+}
+
+func NewBookmarkService(
+	bookmarkRepo repository.BookmarkRepository,
+	postRepo repository.PostRepository,
+	commentRepo repository.CommentRepository,
+) *BookmarkService {
+	return &BookmarkService{
+		bookmarkRepo: bookmarkRepo,
+		postRepo:     postRepo,
+		commentRepo:  commentRepo,
+	}
+}
+
+func (s *BookmarkService) CreateCollection(ctx context.Context, userID uint, name string) (*models.BookmarkCollection, error) {
+	name, err := normalizeCollectionName(name)
+	if err != nil {
+		return nil, err
+	}
+	collection := &models.BookmarkCollection{UserID: userID, Name: name}
+	if err := s.bookmarkRepo.CreateCollection(ctx, collection); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+func (s *BookmarkService) ListCollections(ctx context.Context, userID uint) ([]*models.BookmarkCollection, error) {
+	return s.bookmarkRepo.ListCollections(ctx, userID)
+}
+
+func (s *BookmarkService) RenameCollection(ctx context.Context, userID, collectionID uint, name string) (*models.BookmarkCollection, error) {
+	name, err := normalizeCollectionName(name)
+	if err != nil {
+		return nil, err
+	}
+	collection, err := s.bookmarkRepo.GetCollection(ctx, userID, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	collection.Name = name
+	if err := s.bookmarkRepo.UpdateCollection(ctx, collection); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+func (s *BookmarkService) DeleteCollection(ctx context.Context, userID, collectionID uint) error {
+	return s.bookmarkRepo.DeleteCollection(ctx, userID, collectionID)
+}
+
+// AddBookmark saves a post or comment. Saving an already-bookmarked target
+// moves it into the given collection.
+func (s *BookmarkService) AddBookmark(ctx context.Context, in AddBookmarkInput) (*models.Bookmark, error) {
+	if in.TargetID == 0 {
+		return nil, models.NewValidationError("target_id is required")
+	}
+	switch in.TargetType {
+	case models.BookmarkTargetPost:
+		post, err := s.postRepo.GetByID(ctx, in.TargetID, in.UserID)
+		if err != nil || !isPostVisibleTo(post, in.UserID) {
+			return nil, models.NewNotFoundError("Post", in.TargetID)
+		}
+	case models.BookmarkTargetComment:
+		if _, err := s.commentRepo.GetByID(ctx, in.TargetID); err != nil {
+			return nil, models.NewNotFoundError("Comment", in.TargetID)
+		}
+	default:
+		return nil, models.NewValidationError("target_type must be 'post' or 'comment'")
+	}
+
+	if in.CollectionID != nil {
+		if _, err := s.bookmarkRepo.GetCollection(ctx, in.UserID, *in.CollectionID); err != nil {
+			return nil, err
+		}
+	}
+
+	bookmark := &models.Bookmark{
+		UserID:       in.UserID,
+		CollectionID: in.CollectionID,
+		TargetType:   in.TargetType,
+		TargetID:     in.TargetID,
+	}
+	if err := s.bookmarkRepo.Save(ctx, bookmark); err != nil {
+		return nil, err
+	}
+	return bookmark, nil
+}
+
+func (s *BookmarkService) RemoveBookmark(ctx context.Context, userID uint, targetType string, targetID uint) error {
+	if targetType != models.BookmarkTargetPost && targetType != models.BookmarkTargetComment {
+		return models.NewValidationError("target_type must be 'post' or 'comment'")
+	}
+	return s.bookmarkRepo.Remove(ctx, userID, targetType, targetID)
+}
+
+// ListBookmarks returns a page of bookmarks with their targets resolved.
+// Targets that no longer exist are returned as tombstones (Deleted=true).
+func (s *BookmarkService) ListBookmarks(ctx context.Context, in ListBookmarksInput) (*BookmarkPage, error) {
+	limit := in.Limit
+	if limit <= 0 {
+		limit = defaultBookmarkPageSize
+	}
+	if limit > maxBookmarkPageSize {
+		limit = maxBookmarkPageSize
+	}
+
+	if in.CollectionID != nil {
+		if _, err := s.bookmarkRepo.GetCollection(ctx, in.UserID, *in.CollectionID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Fetch one extra row to learn whether another page exists.
+	bookmarks, err := s.bookmarkRepo.List(ctx, in.UserID, in.CollectionID, in.Cursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &BookmarkPage{Bookmarks: bookmarks}
+	if len(bookmarks) > limit {
+		page.Bookmarks = bookmarks[:limit]
+		page.NextCursor = page.Bookmarks[limit-1].ID
+	}
+
+	if err := s.resolveTargets(ctx, in.UserID, page.Bookmarks); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *BookmarkService) resolveTargets(ctx context.Context, userID uint, bookmarks []*models.Bookmark) error {
+	var postIDs, commentIDs []uint
+	for _, b := range bookmarks {
+		switch b.TargetType {
+		case models.BookmarkTargetPost:
+			postIDs = append(postIDs, b.TargetID)
+		case models.BookmarkTargetComment:
+			commentIDs = append(commentIDs, b.TargetID)
+		}
+	}
+
+	posts, err := s.postRepo.GetByIDs(ctx, postIDs, userID)
+	if err != nil {
+		return err
+	}
+	postsByID := make(map[uint]*models.Post, len(posts))
+	for _, p := range posts {
+		if isPostVisibleTo(p, userID) {
+			RenderPostHTML(p)
+			postsByID[p.ID] = p
+		}
+	}
+
+	comments, err := s.bookmarkRepo.GetCommentsByIDs(ctx, commentIDs, userID)
+	if err != nil {
+		return err
+	}
+	commentsByID := make(map[uint]*models.Comment, len(comments))
+	for _, c := range comments {
+		RenderCommentHTML(c)
+		commentsByID[c.ID] = c
+	}
+
+	for _, b := range bookmarks {
+		switch b.TargetType {
+		case models.BookmarkTargetPost:
+			b.Post = postsByID[b.TargetID]
+			b.Deleted = b.Post == nil
+		case models.BookmarkTargetComment:
+			b.Comment = commentsByID[b.TargetID]
+			b.Deleted = b.Comment == nil
+		}
+	}
+	return nil
+}
+
+func normalizeCollectionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", models.NewValidationError("Collection name is required")
+	}
+	if len(name) > maxCollectionNameLen {
+		return "", models.NewValidationError("Collection name too long (max 100 characters)")
+	}
+	return name, nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newBookmarkTestService(t *testing.T) (*BookmarkService, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.User{},
+		&models.Post{},
+		&models.Poll{},
+		&models.PollOption{},
+		&models.Comment{},
+		&models.Like{},
+		&models.PostImage{},
+		&models.PostTag{},
+		&models.SanctumFlair{},
+		&models.Sanctum{},
+		&models.SanctumMembership{},
+		&models.BookmarkCollection{},
+		&models.Bookmark{},
+	))
+	svc := NewBookmarkService(
+		repository.NewBookmarkRepository(db),
+		repository.NewPostRepository(db),
+		repository.NewCommentRepository(db),
+	)
+	return svc, db
+}
+
+func TestBookmarkService_CursorPaginationAndTombstones(t *testing.T) {
+	svc, db := newBookmarkTestService(t)
+	ctx := context.Background()
+
+	user := &models.User{Username: "saver", Email: "saver@e.com"}
+	require.NoError(t, db.Create(user).Error)
+
+	var posts []*models.Post
+	for i := 0; i < 3; i++ {
+		p := &models.Post{Title: "Post", Content: "Body", UserID: user.ID}
+		require.NoError(t, db.Create(p).Error)
+		posts = append(posts, p)
+		_, err := svc.AddBookmark(ctx, AddBookmarkInput{
+			UserID:     user.ID,
+			TargetType: models.BookmarkTargetPost,
+			TargetID:   p.ID,
+		})
+		require.NoError(t, err)
+	}
+
+	// Deleting a post keeps its bookmark as a tombstone.
+	require.NoError(t, db.Delete(&models.Post{}, posts[2].ID).Error)
+
+	first, err := svc.ListBookmarks(ctx, ListBookmarksInput{UserID: user.ID, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, first.Bookmarks, 2)
+	assert.NotZero(t, first.NextCursor)
+	assert.True(t, first.Bookmarks[0].Deleted)
+	assert.Nil(t, first.Bookmarks[0].Post)
+	require.NotNil(t, first.Bookmarks[1].Post)
+	assert.Equal(t, posts[1].ID, first.Bookmarks[1].Post.ID)
+	assert.Equal(t, "saver", first.Bookmarks[1].Post.User.Username)
+
+	second, err := svc.ListBookmarks(ctx, ListBookmarksInput{UserID: user.ID, Limit: 2, Cursor: first.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, second.Bookmarks, 1)
+	assert.Zero(t, second.NextCursor)
+	assert.Equal(t, posts[0].ID, second.Bookmarks[0].TargetID)
+}
+
+func TestBookmarkService_Collections(t *testing.T) {
+	svc, db := newBookmarkTestService(t)
+	ctx := context.Background()
+
+	owner := &models.User{Username: "owner", Email: "owner@e.com"}
+	other := &models.User{Username: "other", Email: "other@e.com"}
+	require.NoError(t, db.Create(owner).Error)
+	require.NoError(t, db.Create(other).Error)
+	post := &models.Post{Title: "Post", Content: "Body", UserID: owner.ID}
+	require.NoError(t, db.Create(post).Error)
+
+	recipes, err := svc.CreateCollection(ctx, owner.ID, "  Recipes ")
+	require.NoError(t, err)
+	assert.Equal(t, "Recipes", recipes.Name)
+
+	_, err = svc.CreateCollection(ctx, owner.ID, "Recipes")
+	assert.Error(t, err, "duplicate names are rejected")
+
+	// Another user's collection cannot be used.
+	_, err = svc.AddBookmark(ctx, AddBookmarkInput{
+		UserID:       other.ID,
+		TargetType:   models.BookmarkTargetPost,
+		TargetID:     post.ID,
+		CollectionID: &recipes.ID,
+	})
+	assert.Error(t, err)
+
+	_, err = svc.AddBookmark(ctx, AddBookmarkInput{
+		UserID:       owner.ID,
+		TargetType:   models.BookmarkTargetPost,
+		TargetID:     post.ID,
+		CollectionID: &recipes.ID,
+	})
+	require.NoError(t, err)
+
+	inCollection, err := svc.ListBookmarks(ctx, ListBookmarksInput{UserID: owner.ID, CollectionID: &recipes.ID})
+	require.NoError(t, err)
+	assert.Len(t, inCollection.Bookmarks, 1)
+
+	// Deleting the collection leaves the bookmark unsorted.
+	require.NoError(t, svc.DeleteCollection(ctx, owner.ID, recipes.ID))
+	all, err := svc.ListBookmarks(ctx, ListBookmarksInput{UserID: owner.ID})
+	require.NoError(t, err)
+	require.Len(t, all.Bookmarks, 1)
+	assert.Nil(t, all.Bookmarks[0].CollectionID)
+
+	require.NoError(t, svc.RemoveBookmark(ctx, owner.ID, models.BookmarkTargetPost, post.ID))
+	assert.Error(t, svc.RemoveBookmark(ctx, owner.ID, models.BookmarkTargetPost, post.ID))
+}
+
+func TestBookmarkService_HidesUnreadableTargets(t *testing.T) {
+	svc, db := newBookmarkTestService(t)
+	ctx := context.Background()
+
+	reader := &models.User{Username: "reader", Email: "reader@e.com"}
+	author := &models.User{Username: "author", Email: "author@e.com"}
+	require.NoError(t, db.Create(reader).Error)
+	require.NoError(t, db.Create(author).Error)
+	private := &models.Sanctum{Name: "Hidden", Slug: "hidden", Visibility: models.SanctumVisibilityPrivate}
+	require.NoError(t, db.Create(private).Error)
+
+	removedAt := time.Now()
+	newPost := func(p models.Post) *models.Post {
+		p.Title, p.Content = "Post", "Body"
+		if p.UserID == 0 {
+			p.UserID = author.ID
+		}
+		require.NoError(t, db.Create(&p).Error)
+		return &p
+	}
+	newComment := func(postID, userID uint, removed bool) *models.Comment {
+		c := &models.Comment{PostID: postID, UserID: userID, Content: "Reply"}
+		if removed {
+			c.RemovedAt = &removedAt
+		}
+		require.NoError(t, db.Create(c).Error)
+		return c
+	}
+	live := newPost(models.Post{})
+	removed := newPost(models.Post{RemovedAt: &removedAt})
+
+	tests := []struct {
+		name       string
+		targetType string
+		targetID   uint
+		visible    bool
+	}{
+		{"published post", models.BookmarkTargetPost, live.ID, true},
+		{"removed post", models.BookmarkTargetPost, removed.ID, false},
+		{"another author's draft", models.BookmarkTargetPost, newPost(models.Post{Status: models.PostStatusDraft}).ID, false},
+		{"own removed post", models.BookmarkTargetPost, newPost(models.Post{UserID: reader.ID, RemovedAt: &removedAt}).ID, true},
+		{"post in a private sanctum", models.BookmarkTargetPost, newPost(models.Post{SanctumID: &private.ID}).ID, false},
+		{"live comment", models.BookmarkTargetComment, newComment(live.ID, author.ID, false).ID, true},
+		{"removed comment", models.BookmarkTargetComment, newComment(live.ID, author.ID, true).ID, false},
+		{"own removed comment", models.BookmarkTargetComment, newComment(live.ID, reader.ID, true).ID, true},
+		{"comment on a removed post", models.BookmarkTargetComment, newComment(removed.ID, author.ID, false).ID, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bookmark := &models.Bookmark{UserID: reader.ID, TargetType: tt.targetType, TargetID: tt.targetID}
+			require.NoError(t, db.Create(bookmark).Error)
+			t.Cleanup(func() { db.Delete(bookmark) })
+
+			page, err := svc.ListBookmarks(ctx, ListBookmarksInput{UserID: reader.ID, Limit: 10})
+			require.NoError(t, err)
+			require.Len(t, page.Bookmarks, 1)
+			got := page.Bookmarks[0]
+			assert.Equal(t, !tt.visible, got.Deleted)
+			assert.Equal(t, tt.visible, got.Post != nil || got.Comment != nil)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var mentionPattern = regexp.MustCompile(`@([a-zA-Z0-9_]{2,32})`)
+
+func extractMentionUsernames(content string) []string {
+	matches := mentionPattern.FindAllStringSubmatch(content, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	seen := map[string]bool{}
+	result := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if len(match) < 2 {
+			continue
+		}
+		username := strings.TrimSpace(strings.ToLower(match[1]))
+		if username == "" || seen[username] {
+			continue
+		}
+		seen[username] = true
+		result = append(result, username)
+	}
+	return result
+}
+
+// MentionedUserIDs returns the participants content mentions by username,
+// in mention order. Senders do not mention themselves.
+func MentionedUserIDs(content string, senderID uint, participants []models.User) []uint {
+	mentions := extractMentionUsernames(content)
+	if len(mentions) == 0 {
+		return nil
+	}
+	participantsByUsername := make(map[string]uint, len(participants))
+	for _, participant := range participants {
+		participantsByUsername[strings.ToLower(participant.Username)] = participant.ID
+	}
+	ids := make([]uint, 0, len(mentions))
+	for _, mention := range mentions {
+		if id, ok := participantsByUsername[mention]; ok && id != senderID {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// syncMessageMentions makes the message's mention rows match its content.
+// Mentions that survive an edit keep their read state.
+func syncMessageMentions(tx *gorm.DB, conv *models.Conversation, message *models.Message, content string) error {
+	mentioned := MentionedUserIDs(content, message.SenderID, conv.Participants)
+	del := tx.Where("message_id = ?", message.ID)
+	if len(mentioned) > 0 {
+		del = del.Where("mentioned_user_id NOT IN ?", mentioned)
+	}
+	if err := del.Delete(&models.MessageMention{}).Error; err != nil {
+		return err
+	}
+	if len(mentioned) == 0 {
+		return nil
+	}
+	var existing []uint
+	if err := tx.Model(&models.MessageMention{}).Where("message_id = ?", message.ID).
+		Pluck("mentioned_user_id", &existing).Error; err != nil {
+		return err
+	}
+	kept := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		kept[id] = true
+	}
+	for _, id := range mentioned {
+		if kept[id] {
+			continue
+		}
+		if err := tx.Create(&models.MessageMention{
+			MessageID:         message.ID,
+			ConversationID:    conv.ID,
+			MentionedUserID:   id,
+			MentionedByUserID: message.SenderID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"sanctum/internal/cache"
+	"sanctum/internal/markdown"
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// messageEditWindow is how long after sending a message its author may
+// still edit it.
+const messageEditWindow = 15 * time.Minute
+
+type EditMessageInput struct {
+	UserID         uint
+	ConversationID uint
+	MessageID      uint
+	Content        string
+}
+
+// EditMessage replaces the content of the user's own message, keeping the
+// previous content in the edit history.
+func (s *ChatService) EditMessage(ctx context.Context, in EditMessageInput) (*models.Message, error) {
+	if in.Content == "" {
+		return nil, models.NewValidationError("Message content is required")
+	}
+	if len(in.Content) > maxMessageContentLen {
+		return nil, models.NewValidationError("Message content too long (max 10000 characters)")
+	}
+	conv, message, err := s.messageForUser(ctx, in.ConversationID, in.MessageID, in.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if message.SenderID != in.UserID {
+		return nil, models.NewForbiddenError("You can only edit your own messages")
+	}
+	if message.IsDeleted {
+		return nil, models.NewValidationError("Deleted messages cannot be edited")
+	}
+	if time.Since(message.CreatedAt) > messageEditWindow {
+		return nil, models.NewValidationError("Messages can only be edited within 15 minutes of sending")
+	}
+	if message.Content == in.Content {
+		return message, nil
+	}
+	if conv.SanctumID != nil {
+		ban, berr := activeSanctumBan(ctx, s.db, *conv.SanctumID, in.UserID)
+		if berr != nil {
+			return nil, berr
+		}
+		if ban != nil {
+			return nil, sanctumBanError(ban)
+		}
+		if err := s.checkAutomod(ctx, *conv.SanctumID, in.UserID, in.Content); err != nil {
+			return nil, err
+		}
+	}
+	if conv.IsGroup {
+		muted, merr := s.userMutedInRoom(ctx, conv.ID, in.UserID)
+		if merr != nil {
+			return nil, merr
+		}
+		if muted {
+			return nil, models.NewForbiddenError("You are muted in this room")
+		}
+	}
+	if err := s.checkPostPermission(ctx, conv, in.UserID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&models.MessageEdit{
+			MessageID:       message.ID,
+			EditorID:        in.UserID,
+			PreviousContent: message.Content,
+		}).Error; err != nil {
+			return err
+		}
+		// The old link preview no longer matches the content.
+		if err := tx.Model(message).Updates(map[string]any{
+			"content":      in.Content,
+			"edited_at":    now,
+			"link_preview": nil,
+		}).Error; err != nil {
+			return err
+		}
+		return syncMessageMentions(tx, conv, message, in.Content)
+	})
+	if err != nil {
+		return nil, err
+	}
+	cache.InvalidateRoom(ctx, conv.ID)
+
+	message.Content = in.Content
+	message.ContentHTML = markdown.Render(in.Content)
+	message.EditedAt = &now
+	message.LinkPreview = nil
+	return message, nil
+}
+
+// DeleteMessage replaces a message with a tombstone. Authors may delete
+// their own messages; room moderators may delete any message, including in
+// rooms they have not joined. The last content is kept in the edit history,
+// which only moderators can read once the message is deleted.
+func (s *ChatService) DeleteMessage(ctx context.Context, convID, messageID, userID uint) (*models.Message, error) {
+	conv, err := s.chatRepo.GetConversation(ctx, convID)
+	if err != nil {
+		return nil, err
+	}
+	canModerate, err := s.canModerateMessages(ctx, conv, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !canModerate && !isConversationParticipant(conv, userID) {
+		return nil, models.NewUnauthorizedError("You are not a participant in this conversation")
+	}
+	message, err := s.findMessage(ctx, convID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.IsDeleted {
+		return message, nil
+	}
+	if message.SenderID != userID && !canModerate {
+		return nil, models.NewForbiddenError("You can only delete your own messages")
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&models.MessageEdit{
+			MessageID:       message.ID,
+			EditorID:        userID,
+			PreviousContent: message.Content,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(message).Updates(map[string]any{
+			"content":            "",
+			"metadata":           json.RawMessage("{}"),
+			"link_preview":       nil,
+			"is_deleted":         true,
+			"deleted_by_user_id": userID,
+		}).Error; err != nil {
+			return err
+		}
+		if message.ThreadRootID != nil {
+			if err := tx.Model(&models.Message{}).
+				Where("id = ? AND reply_count > 0", *message.ThreadRootID).
+				UpdateColumns(map[string]any{
+					"reply_count": gorm.Expr("reply_count - 1"),
+					"updated_at":  time.Now(),
+				}).Error; err != nil {
+				return err
+			}
+		}
+		for _, model := range []any{&models.MessageReaction{}, &models.MessageMention{}} {
+			if err := tx.Where("message_id = ?", message.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	cache.InvalidateRoom(ctx, conv.ID)
+
+	message.Content = ""
+	message.ContentHTML = ""
+	message.Metadata = json.RawMessage("{}")
+	message.LinkPreview = nil
+	message.Reactions = nil
+	message.IsDeleted = true
+	message.DeletedBy = &userID
+	return message, nil
+}
+
+// GetMessageEdits returns a message's edit history, oldest first. The
+// history of a deleted message is only shown to moderators.
+func (s *ChatService) GetMessageEdits(ctx context.Context, convID, messageID, userID uint) ([]models.MessageEdit, error) {
+	conv, err := s.chatRepo.GetConversation(ctx, convID)
+	if err != nil {
+		return nil, err
+	}
+	canModerate, err := s.canModerateMessages(ctx, conv, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !canModerate && !isConversationParticipant(conv, userID) {
+		return nil, models.NewUnauthorizedError("You are not a participant in this conversation")
+	}
+	message, err := s.findMessage(ctx, convID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.IsDeleted && !canModerate {
+		return nil, models.NewForbiddenError("Only moderators can view the history of a deleted message")
+	}
+	var edits []models.MessageEdit
+	if err := s.db.WithContext(ctx).
+		Where("message_id = ?", messageID).
+		Order("created_at ASC, id ASC").
+		Find(&edits).Error; err != nil {
+		return nil, err
+	}
+	return edits, nil
+}
+
+// canModerateMessages reports whether the user may moderate messages in a
+// room. Direct conversations have no moderators.
+func (s *ChatService) canModerateMessages(ctx context.Context, conv *models.Conversation, userID uint) (bool, error) {
+	if !conv.IsGroup || s.canModerateChatroom == nil {
+		return false, nil
+	}
+	return s.canModerateChatroom(ctx, userID, conv.ID)
+}
+
+// messageForUser loads a message of a conversation the user participates in.
+func (s *ChatService) messageForUser(ctx context.Context, convID, messageID, userID uint) (*models.Conversation, *models.Message, error) {
+	conv, err := s.chatRepo.GetConversation(ctx, convID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !isConversationParticipant(conv, userID) {
+		return nil, nil, models.NewUnauthorizedError("You are not a participant in this conversation")
+	}
+	message, err := s.findMessage(ctx, convID, messageID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conv, message, nil
+}
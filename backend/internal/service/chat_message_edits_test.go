@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatService_EditMessage(t *testing.T) {
+	f := newChatFixture(t)
+	ctx := context.Background()
+	message := f.post(t, f.room, f.alice, "helo")
+	stale := &models.Message{ConversationID: f.room.ID, SenderID: f.alice.ID, Content: "old",
+		CreatedAt: time.Now().Add(-messageEditWindow - time.Minute)}
+	require.NoError(t, f.db.Create(stale).Error)
+	tombstone := f.post(t, f.room, f.alice, "gone")
+	_, err := f.svc.DeleteMessage(ctx, f.room.ID, tombstone.ID, f.alice.ID)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		userID    uint
+		messageID uint
+		content   string
+		code      string
+	}{
+		{"only the author edits", f.bob.ID, message.ID, "x", "FORBIDDEN"},
+		{"moderators cannot edit either", f.mod.ID, message.ID, "x", "FORBIDDEN"},
+		{"content required", f.alice.ID, message.ID, "", "VALIDATION_ERROR"},
+		{"edit window has passed", f.alice.ID, stale.ID, "new", "VALIDATION_ERROR"},
+		{"tombstones cannot be edited", f.alice.ID, tombstone.ID, "back", "VALIDATION_ERROR"},
+		{"unknown message", f.alice.ID, 9999, "x", "NOT_FOUND"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.svc.EditMessage(ctx, EditMessageInput{UserID: tt.userID, ConversationID: f.room.ID, MessageID: tt.messageID, Content: tt.content})
+			requireCode(t, err, tt.code)
+		})
+	}
+
+	edited, err := f.svc.EditMessage(ctx, EditMessageInput{UserID: f.alice.ID, ConversationID: f.room.ID, MessageID: message.ID, Content: "hello"})
+	require.NoError(t, err)
+	assert.Equal(t, "hello", edited.Content)
+	assert.NotNil(t, edited.EditedAt)
+	edits, err := f.svc.GetMessageEdits(ctx, f.room.ID, message.ID, f.bob.ID)
+	require.NoError(t, err)
+	require.Len(t, edits, 1)
+	assert.Equal(t, "helo", edits[0].PreviousContent)
+}
+
+func TestChatService_DeleteMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		deleter func(f *chatFixture) uint
+		code    string
+	}{
+		{"author", func(f *chatFixture) uint { return f.alice.ID }, ""},
+		{"moderator", func(f *chatFixture) uint { return f.mod.ID }, ""},
+		{"moderator outside the room", func(f *chatFixture) uint {
+			f.db.Where("conversation_id = ? AND user_id = ?", f.room.ID, f.mod.ID).Delete(&models.ConversationParticipant{})
+			return f.mod.ID
+		}, ""},
+		{"other participant", func(f *chatFixture) uint { return f.bob.ID }, "FORBIDDEN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newChatFixture(t)
+			ctx := context.Background()
+			message := f.post(t, f.room, f.alice, "helo")
+			_, err := f.svc.EditMessage(ctx, EditMessageInput{UserID: f.alice.ID, ConversationID: f.room.ID, MessageID: message.ID, Content: "hello"})
+			require.NoError(t, err)
+			require.NoError(t, f.db.Create(&models.MessageReaction{MessageID: message.ID, UserID: f.bob.ID, Emoji: "👍"}).Error)
+
+			deleterID := tt.deleter(f)
+			deleted, err := f.svc.DeleteMessage(ctx, f.room.ID, message.ID, deleterID)
+			if tt.code != "" {
+				requireCode(t, err, tt.code)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, deleted.IsDeleted)
+			assert.Empty(t, deleted.Content)
+
+			var stored models.Message
+			require.NoError(t, f.db.First(&stored, message.ID).Error)
+			assert.True(t, stored.IsDeleted, "the tombstone stays in the history")
+			assert.Empty(t, stored.Content)
+			require.NotNil(t, stored.DeletedBy)
+			assert.Equal(t, deleterID, *stored.DeletedBy)
+			_, err = f.svc.GetMessageEdits(ctx, f.room.ID, message.ID, f.alice.ID)
+			requireCode(t, err, "FORBIDDEN")
+			edits, err := f.svc.GetMessageEdits(ctx, f.room.ID, message.ID, f.mod.ID)
+			require.NoError(t, err)
+			require.Len(t, edits, 2, "moderators keep the original and the last content")
+			assert.Equal(t, "helo", edits[0].PreviousContent)
+			assert.Equal(t, "hello", edits[1].PreviousContent)
+			var reactions int64
+			require.NoError(t, f.db.Model(&models.MessageReaction{}).Where("message_id = ?", message.ID).Count(&reactions).Error)
+			assert.Zero(t, reactions)
+		})
+	}
+}
+
+func TestChatService_EditMessageInModsOnlyChannel(t *testing.T) {
+	f := newChatFixture(t)
+	ctx := context.Background()
+	fromAlice := f.post(t, f.room, f.alice, "before")
+	fromMod := f.post(t, f.room, f.mod, "before")
+	require.NoError(t, f.db.Model(f.room).Update("post_permission", models.ChannelPostMods).Error)
+
+	_, err := f.svc.EditMessage(ctx, EditMessageInput{UserID: f.alice.ID, ConversationID: f.room.ID, MessageID: fromAlice.ID, Content: "after"})
+	requireCode(t, err, "FORBIDDEN")
+	_, err = f.svc.EditMessage(ctx, EditMessageInput{UserID: f.mod.ID, ConversationID: f.room.ID, MessageID: fromMod.ID, Content: "after"})
+	assert.NoError(t, err)
+}
+
+func TestChatService_EditMessageRebuildsMentions(t *testing.T) {
+	f := newChatFixture(t)
+	ctx := context.Background()
+	message := f.post(t, f.room, f.alice, "@bob and @carol")
+	readAt := time.Now()
+	require.NoError(t, f.db.Create(&models.MessageMention{MessageID: message.ID, ConversationID: f.room.ID,
+		MentionedUserID: f.bob.ID, MentionedByUserID: f.alice.ID, ReadAt: &readAt}).Error)
+	require.NoError(t, f.db.Create(&models.MessageMention{MessageID: message.ID, ConversationID: f.room.ID,
+		MentionedUserID: f.carol.ID, MentionedByUserID: f.alice.ID}).Error)
+
+	_, err := f.svc.EditMessage(ctx, EditMessageInput{UserID: f.alice.ID, ConversationID: f.room.ID, MessageID: message.ID,
+		Content: "@BOB and @mod, also @alice and @nobody"})
+	require.NoError(t, err)
+
+	var mentions []models.MessageMention
+	require.NoError(t, f.db.Where("message_id = ?", message.ID).Order("mentioned_user_id").Find(&mentions).Error)
+	require.Len(t, mentions, 2)
+	byUser := map[uint]models.MessageMention{mentions[0].MentionedUserID: mentions[0], mentions[1].MentionedUserID: mentions[1]}
+	require.Contains(t, byUser, f.bob.ID)
+	assert.NotNil(t, byUser[f.bob.ID].ReadAt, "a kept mention stays read")
+	require.Contains(t, byUser, f.mod.ID)
+	assert.Nil(t, byUser[f.mod.ID].ReadAt)
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+)
+
+// MessageCatchUp is what a client missed while disconnected.
+type MessageCatchUp struct {
+	ConversationID uint              `json:"conversation_id"`
+	Messages       []*models.Message `json:"messages"`
+	// HasMore is set when more messages were missed than returned; the
+	// client pages on with after=<newest returned ID>.
+	HasMore bool `json:"has_more"`
+	// Changed holds messages the client may already have that changed
+	// since: edits, tombstones, thread roots with new reply stats, and
+	// thread replies, oldest change first. The client replaces its copies.
+	Changed []*models.Message `json:"changed"`
+	// ChangedHasMore is set when more messages changed than returned; the
+	// client should refetch the messages it shows instead.
+	ChangedHasMore bool `json:"changed_has_more"`
+}
+
+// GetMessagesByCursorForUser returns up to limit timeline messages before,
+// after or around a message ID, oldest first.
+func (s *ChatService) GetMessagesByCursorForUser(ctx context.Context, convID, userID uint, cursor repository.MessageCursor, limit int) ([]*models.Message, error) {
+	set := 0
+	for _, id := range []uint{cursor.Before, cursor.After, cursor.Around} {
+		if id != 0 {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, models.NewValidationError("Specify exactly one of before, after or around")
+	}
+	conv, err := s.chatRepo.GetConversation(ctx, convID)
+	if err != nil {
+		return nil, err
+	}
+	if !isConversationParticipant(conv, userID) {
+		return nil, models.NewUnauthorizedError("You are not a participant in this conversation")
+	}
+	if cursor.Around != 0 && s.db != nil {
+		anchor, err := s.findMessage(ctx, convID, cursor.Around)
+		if err != nil {
+			return nil, err
+		}
+		if anchor.ThreadRootID != nil {
+			return nil, models.NewValidationError("Thread replies are not in the timeline; load the thread instead")
+		}
+	}
+	messages, err := s.chatRepo.GetMessagesByCursor(ctx, convID, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.prepareMessagesForUser(ctx, conv, userID, messages)
+}
+
+// CatchUp returns up to limit timeline messages posted after lastSeenID,
+// and up to limit messages changed since lastSeenID was posted. The change
+// window starts at the last seen message, so it may repeat changes the
+// client already applied but never misses one.
+func (s *ChatService) CatchUp(ctx context.Context, convID, userID, lastSeenID uint, limit int) (*MessageCatchUp, error) {
+	if lastSeenID == 0 {
+		return nil, models.NewValidationError("last_message_id is required")
+	}
+	conv, err := s.chatRepo.GetConversation(ctx, convID)
+	if err != nil {
+		return nil, err
+	}
+	if !isConversationParticipant(conv, userID) {
+		return nil, models.NewUnauthorizedError("You are not a participant in this conversation")
+	}
+	lastSeen, err := s.findMessage(ctx, convID, lastSeenID)
+	if err != nil {
+		return nil, err
+	}
+	// Fetch one extra to learn whether the gap is longer than limit.
+	messages, err := s.chatRepo.GetMessagesByCursor(ctx, convID, repository.MessageCursor{After: lastSeenID}, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+	messages, err = s.prepareMessagesForUser(ctx, conv, userID, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	// New timeline messages are returned above; new thread replies are not
+	// in the timeline and count as changes.
+	var changed []*models.Message
+	if err := s.db.WithContext(ctx).
+		Where("conversation_id = ? AND updated_at > ?", convID, lastSeen.CreatedAt).
+		Where("id <= ? OR thread_root_id IS NOT NULL", lastSeenID).
+		Preload("Sender").
+		Preload("Reactions").
+		Order("updated_at ASC, id ASC").
+		Limit(limit + 1).
+		Find(&changed).Error; err != nil {
+		return nil, err
+	}
+	changedHasMore := len(changed) > limit
+	if changedHasMore {
+		changed = changed[:limit]
+	}
+	changed, err = s.prepareMessagesForUser(ctx, conv, userID, changed)
+	if err != nil {
+		return nil, err
+	}
+	return &MessageCatchUp{
+		ConversationID: convID,
+		Messages:       messages,
+		HasMore:        hasMore,
+		Changed:        changed,
+		ChangedHasMore: changedHasMore,
+	}, nil
+}
+
+// prepareMessagesForUser drops messages from users the caller blocked and
+// fills in reply previews, thread read state and delivery states.
+func (s *ChatService) prepareMessagesForUser(ctx context.Context, conv *models.Conversation, userID uint, messages []*models.Message) ([]*models.Message, error) {
+	messages, err := s.filterBlockedSenders(ctx, userID, messages)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decorateMessages(ctx, userID, messages); err != nil {
+		return nil, err
+	}
+	if err := s.attachDeliveryStates(ctx, conv, userID, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagingFixture is alice's private room with five timeline messages and a
+// thread reply to the first.
+func pagingFixture(t *testing.T) (*chatFixture, *models.Conversation, []uint, *models.Message) {
+	t.Helper()
+	f := newChatFixture(t)
+	room := f.conversation(t, true, f.alice)
+	var ids []uint
+	for i := 1; i <= 5; i++ {
+		ids = append(ids, f.post(t, room, f.alice, fmt.Sprintf("msg %d", i)).ID)
+	}
+	reply := &models.Message{ConversationID: room.ID, SenderID: f.alice.ID, Content: "in thread", ThreadRootID: &ids[0]}
+	require.NoError(t, f.db.Create(reply).Error)
+	return f, room, ids, reply
+}
+
+func TestChatService_CursorPaging(t *testing.T) {
+	f, room, ids, reply := pagingFixture(t)
+	ctx := context.Background()
+
+	msgs, err := f.svc.GetMessagesByCursorForUser(ctx, room.ID, f.alice.ID, repository.MessageCursor{Around: ids[2]}, 3)
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	assert.Equal(t, ids[1], msgs[0].ID)
+	assert.Equal(t, ids[3], msgs[2].ID)
+
+	tests := []struct {
+		name   string
+		userID uint
+		cursor repository.MessageCursor
+	}{
+		{"thread replies are not timeline anchors", f.alice.ID, repository.MessageCursor{Around: reply.ID}},
+		{"one direction at a time", f.alice.ID, repository.MessageCursor{Before: ids[2], After: ids[1]}},
+		{"participants only", f.bob.ID, repository.MessageCursor{After: ids[0]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.svc.GetMessagesByCursorForUser(ctx, room.ID, tt.userID, tt.cursor, 3)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestChatService_CatchUp(t *testing.T) {
+	f, room, ids, _ := pagingFixture(t)
+	ctx := context.Background()
+
+	catchUp, err := f.svc.CatchUp(ctx, room.ID, f.alice.ID, ids[1], 2)
+	require.NoError(t, err)
+	require.Len(t, catchUp.Messages, 2)
+	assert.Equal(t, ids[2], catchUp.Messages[0].ID)
+	assert.True(t, catchUp.HasMore)
+
+	catchUp, err = f.svc.CatchUp(ctx, room.ID, f.alice.ID, ids[3], 2)
+	require.NoError(t, err)
+	require.Len(t, catchUp.Messages, 1, "thread replies are not part of the timeline gap")
+	assert.False(t, catchUp.HasMore)
+}
+
+func TestChatService_CatchUpChanges(t *testing.T) {
+	f, room, ids, reply := pagingFixture(t)
+	ctx := context.Background()
+
+	_, err := f.svc.EditMessage(ctx, EditMessageInput{UserID: f.alice.ID, ConversationID: room.ID, MessageID: ids[1], Content: "msg 2, edited"})
+	require.NoError(t, err)
+	_, err = f.svc.DeleteMessage(ctx, room.ID, ids[2], f.alice.ID)
+	require.NoError(t, err)
+	newReply, _, err := f.svc.SendMessage(ctx, SendMessageInput{UserID: f.alice.ID, ConversationID: room.ID, Content: "late", ThreadRootID: ids[0]})
+	require.NoError(t, err)
+
+	catchUp, err := f.svc.CatchUp(ctx, room.ID, f.alice.ID, ids[4], 10)
+	require.NoError(t, err)
+	assert.Empty(t, catchUp.Messages)
+	changed := make([]uint, 0, len(catchUp.Changed))
+	for _, m := range catchUp.Changed {
+		changed = append(changed, m.ID)
+	}
+	assert.ElementsMatch(t, []uint{ids[0], ids[1], ids[2], reply.ID, newReply.ID}, changed,
+		"edits, tombstones, thread roots and thread replies")
+	assert.False(t, catchUp.ChangedHasMore)
+
+	catchUp, err = f.svc.CatchUp(ctx, room.ID, f.alice.ID, ids[4], 2)
+	require.NoError(t, err)
+	assert.Len(t, catchUp.Changed, 2)
+	assert.True(t, catchUp.ChangedHasMore)
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"sanctum/internal/models"
+)
+
+// maxMessageSearchLen bounds the length of a chat history search query.
+const maxMessageSearchLen = 200
+
+// MessageSearchInput filters a chat history search. ConversationID 0 searches
+// every conversation the user participates in.
+type MessageSearchInput struct {
+	UserID         uint
+	ConversationID uint
+	Query          string
+	SenderID       uint
+	Since          *time.Time
+	Until          *time.Time
+	HasAttachment  *bool
+	Limit          int
+	Offset         int
+}
+
+// MessageAnchor locates a search hit in its conversation. MessageID is the
+// timeline message to load messages around; for a thread reply that is the
+// thread root, and ThreadMessageID is the reply itself.
+type MessageAnchor struct {
+	ConversationID  uint  `json:"conversation_id"`
+	MessageID       uint  `json:"message_id"`
+	ThreadMessageID *uint `json:"thread_message_id,omitempty"`
+}
+
+// MessageSearchHit is a message matching a search, with its jump-to anchor.
+type MessageSearchHit struct {
+	Message *models.Message `json:"message"`
+	Anchor  MessageAnchor   `json:"anchor"`
+}
+
+// SearchMessages searches chat history, newest first. Deleted messages and
+// messages from users the caller blocked are never returned.
+func (s *ChatService) SearchMessages(ctx context.Context, in MessageSearchInput) ([]MessageSearchHit, error) {
+	query := strings.TrimSpace(in.Query)
+	if query == "" {
+		return nil, models.NewValidationError("Search query is required")
+	}
+	if utf8.RuneCountInString(query) > maxMessageSearchLen {
+		return nil, models.NewValidationError("Search query is too long")
+	}
+	if in.Since != nil && in.Until != nil && in.Until.Before(*in.Since) {
+		return nil, models.NewValidationError("until must not be before since")
+	}
+	if s.db == nil {
+		return nil, models.NewValidationError("Message search is not available")
+	}
+
+	if in.ConversationID != 0 {
+		conv, err := s.chatRepo.GetConversation(ctx, in.ConversationID)
+		if err != nil {
+			return nil, err
+		}
+		if !isConversationParticipant(conv, in.UserID) {
+			return nil, models.NewUnauthorizedError("You are not a participant in this conversation")
+		}
+	}
+
+	db := s.db.WithContext(ctx).Model(&models.Message{}).
+		Where("messages.is_deleted = ?", false)
+	if in.ConversationID != 0 {
+		db = db.Where("messages.conversation_id = ?", in.ConversationID)
+	} else {
+		db = db.Where("messages.conversation_id IN (?)", s.db.Model(&models.ConversationParticipant{}).
+			Select("conversation_id").
+			Where("user_id = ?", in.UserID))
+	}
+	if s.db.Name() == "postgres" {
+		db = db.Where("to_tsvector('simple', messages.content) @@ websearch_to_tsquery('simple', ?)", query)
+	} else {
+		db = db.Where("LOWER(messages.content) LIKE ? ESCAPE '\\'", "%"+escapeLike(strings.ToLower(query))+"%")
+	}
+	if in.SenderID != 0 {
+		db = db.Where("messages.sender_id = ?", in.SenderID)
+	}
+	if in.Since != nil {
+		db = db.Where("messages.created_at >= ?", *in.Since)
+	}
+	if in.Until != nil {
+		db = db.Where("messages.created_at < ?", *in.Until)
+	}
+	if in.HasAttachment != nil {
+		if *in.HasAttachment {
+			db = db.Where("messages.message_type <> ?", "text")
+		} else {
+			db = db.Where("messages.message_type = ?", "text")
+		}
+	}
+	blocked, err := s.blockedUserIDs(ctx, in.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocked) > 0 {
+		ids := make([]uint, 0, len(blocked))
+		for id := range blocked {
+			ids = append(ids, id)
+		}
+		db = db.Where("messages.sender_id NOT IN ?", ids)
+	}
+
+	var messages []*models.Message
+	if err := db.Preload("Sender").
+		Order("messages.created_at DESC, messages.id DESC").
+		Limit(in.Limit).
+		Offset(in.Offset).
+		Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	if err := s.decorateMessages(ctx, in.UserID, messages); err != nil {
+		return nil, err
+	}
+
+	hits := make([]MessageSearchHit, 0, len(messages))
+	for _, m := range messages {
+		anchor := MessageAnchor{ConversationID: m.ConversationID, MessageID: m.ID}
+		if m.ThreadRootID != nil {
+			replyID := m.ID
+			anchor.MessageID = *m.ThreadRootID
+			anchor.ThreadMessageID = &replyID
+		}
+		hits = append(hits, MessageSearchHit{Message: m, Anchor: anchor})
+	}
+	return hits, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
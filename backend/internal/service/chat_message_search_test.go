@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatService_SearchMessages(t *testing.T) {
+	f := newChatFixture(t)
+	ctx := context.Background()
+	staff := f.conversation(t, true, f.bob)
+	post := func(conv *models.Conversation, from *models.User, content, kind string, at time.Time) *models.Message {
+		m := &models.Message{ConversationID: conv.ID, SenderID: from.ID, Content: content, MessageType: kind, CreatedAt: at}
+		require.NoError(t, f.db.Create(m).Error)
+		return m
+	}
+	old := post(f.room, f.alice, "Raid tonight at 9", "text", time.Now().Add(-24*time.Hour))
+	root := post(f.room, f.bob, "Raid roster", "image", time.Now())
+	reply := post(f.room, f.alice, "count me in for the raid", "text", time.Now())
+	require.NoError(t, f.db.Model(reply).Update("thread_root_id", root.ID).Error)
+	gone := post(f.room, f.bob, "raid cancelled", "text", time.Now())
+	require.NoError(t, f.db.Model(gone).Update("is_deleted", true).Error)
+	post(staff, f.bob, "raid loot rules", "text", time.Now())
+	post(f.room, f.bob, "100% sure", "text", time.Now())
+
+	hits, err := f.svc.SearchMessages(ctx, MessageSearchInput{UserID: f.alice.ID, Query: "RAID", Limit: 20})
+	require.NoError(t, err)
+	require.Len(t, hits, 3, "deleted messages and other rooms are excluded")
+	assert.Equal(t, reply.ID, hits[0].Message.ID)
+	assert.Equal(t, root.ID, hits[0].Anchor.MessageID, "thread replies anchor on their root")
+	require.NotNil(t, hits[0].Anchor.ThreadMessageID)
+	assert.Equal(t, reply.ID, *hits[0].Anchor.ThreadMessageID)
+	assert.Equal(t, old.ID, hits[2].Anchor.MessageID)
+
+	since := time.Now().Add(-time.Hour)
+	withAttachment := true
+	tests := []struct {
+		name string
+		in   MessageSearchInput
+		want int
+	}{
+		{"members of more rooms see more", MessageSearchInput{UserID: f.bob.ID, Query: "raid"}, 4},
+		{"by sender", MessageSearchInput{UserID: f.alice.ID, ConversationID: f.room.ID, Query: "raid", SenderID: f.bob.ID}, 1},
+		{"since", MessageSearchInput{UserID: f.alice.ID, Query: "raid", Since: &since}, 2},
+		{"with attachments", MessageSearchInput{UserID: f.alice.ID, Query: "raid", HasAttachment: &withAttachment}, 1},
+		{"LIKE wildcards match literally", MessageSearchInput{UserID: f.alice.ID, Query: "%"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.Limit = 20
+			hits, err := f.svc.SearchMessages(ctx, tt.in)
+			require.NoError(t, err)
+			assert.Len(t, hits, tt.want)
+		})
+	}
+
+	require.NoError(t, f.db.Create(&models.UserBlock{BlockerID: f.alice.ID, BlockedID: f.bob.ID}).Error)
+	hits, err = f.svc.SearchMessages(ctx, MessageSearchInput{UserID: f.alice.ID, Query: "raid", Limit: 20})
+	require.NoError(t, err)
+	assert.Len(t, hits, 2, "blocked senders are hidden")
+
+	_, err = f.svc.SearchMessages(ctx, MessageSearchInput{UserID: f.alice.ID, ConversationID: staff.ID, Query: "raid", Limit: 20})
+	assert.Error(t, err)
+	_, err = f.svc.SearchMessages(ctx, MessageSearchInput{UserID: f.alice.ID, Query: "  ", Limit: 20})
+	assert.Error(t, err)
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"sanctum/internal/cache"
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// messagePreviewLen is how many characters of a quoted message are shown
+// with replies to it.
+const messagePreviewLen = 200
+
+// MessageThread is a side thread: its root message and the replies under it.
+type MessageThread struct {
+	Root    *models.Message   `json:"root"`
+	Replies []*models.Message `json:"replies"`
+	// LastReadAt is when the caller last read the thread; nil if never.
+	LastReadAt  *time.Time `json:"last_read_at"`
+	UnreadCount int64      `json:"unread_count"`
+}
+
+// checkMessageRefs validates the reply target and thread root of a message
+// about to be sent.
+func (s *ChatService) checkMessageRefs(ctx context.Context, in SendMessageInput) error {
+	if in.ReplyToID == 0 && in.ThreadRootID == 0 {
+		return nil
+	}
+	if s.db == nil {
+		return models.NewValidationError("Replies are not available")
+	}
+	if in.ThreadRootID != 0 {
+		root, err := s.findMessage(ctx, in.ConversationID, in.ThreadRootID)
+		if err != nil {
+			return err
+		}
+		if root.ThreadRootID != nil {
+			return models.NewValidationError("Threads cannot be started from a thread reply")
+		}
+	}
+	if in.ReplyToID != 0 {
+		target, err := s.findMessage(ctx, in.ConversationID, in.ReplyToID)
+		if err != nil {
+			return err
+		}
+		if target.IsDeleted {
+			return models.NewValidationError("Cannot reply to a deleted message")
+		}
+		// A quoted message must be visible where the reply appears.
+		var targetThread uint
+		if target.ThreadRootID != nil {
+			targetThread = *target.ThreadRootID
+		} else if target.ID == in.ThreadRootID {
+			targetThread = target.ID
+		}
+		if targetThread != in.ThreadRootID {
+			return models.NewValidationError("Replies must quote a message from the same thread")
+		}
+	}
+	return nil
+}
+
+// createThreadReply inserts a reply and updates its thread root's reply
+// stats in one transaction. The author has read their own reply.
+func (s *ChatService) createThreadReply(ctx context.Context, conv *models.Conversation, message *models.Message) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Message{}).
+			Where("id = ?", *message.ThreadRootID).
+			UpdateColumns(map[string]any{
+				"reply_count":   gorm.Expr("reply_count + 1"),
+				"last_reply_at": message.CreatedAt,
+				"updated_at":    message.CreatedAt,
+			}).Error; err != nil {
+			return err
+		}
+		return upsertThreadRead(tx, message.SenderID, *message.ThreadRootID, message.CreatedAt)
+	})
+	if err != nil {
+		return err
+	}
+	cache.InvalidateRoom(ctx, conv.ID)
+	for _, p := range conv.Participants {
+		cache.InvalidateUser(ctx, p.ID)
+	}
+	return nil
+}
+
+// GetThread returns a side thread with the caller's read state. Replies are
+// oldest first.
+func (s *ChatService) GetThread(ctx context.Context, convID, rootID, userID uint, limit, offset int) (*MessageThread, error) {
+	_, root, err := s.messageForUser(ctx, convID, rootID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if root.ThreadRootID != nil {
+		return nil, models.NewValidationError("This message is a thread reply, not a thread")
+	}
+
+	var replies []*models.Message
+	if err := s.db.WithContext(ctx).
+		Where("thread_root_id = ?", rootID).
+		Preload("Sender").
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&replies).Error; err != nil {
+		return nil, err
+	}
+	replies, err = s.filterBlockedSenders(ctx, userID, replies)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.decorateMessages(ctx, userID, append([]*models.Message{root}, replies...)); err != nil {
+		return nil, err
+	}
+
+	thread := &MessageThread{Root: root, Replies: replies, LastReadAt: root.ThreadReadAt}
+	unread := s.db.WithContext(ctx).Model(&models.Message{}).
+		Where("thread_root_id = ? AND sender_id <> ?", rootID, userID)
+	if root.ThreadReadAt != nil {
+		unread = unread.Where("created_at > ?", *root.ThreadReadAt)
+	}
+	if err := unread.Count(&thread.UnreadCount).Error; err != nil {
+		return nil, err
+	}
+	return thread, nil
+}
+
+// MarkThreadRead records that the user has read the thread up to now.
+func (s *ChatService) MarkThreadRead(ctx context.Context, convID, rootID, userID uint) (time.Time, error) {
+	_, root, err := s.messageForUser(ctx, convID, rootID, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if root.ThreadRootID != nil {
+		return time.Time{}, models.NewValidationError("This message is a thread reply, not a thread")
+	}
+	now := time.Now().UTC()
+	if err := upsertThreadRead(s.db.WithContext(ctx), userID, rootID, now); err != nil {
+		return time.Time{}, err
+	}
+	return now, nil
+}
+
+// ThreadRoot returns a thread's root message with its current reply stats.
+func (s *ChatService) ThreadRoot(ctx context.Context, convID, rootID uint) (*models.Message, error) {
+	return s.findMessage(ctx, convID, rootID)
+}
+
+// decorateMessages fills in rendered content, quoted reply previews and the
+// caller's thread read state.
+func (s *ChatService) decorateMessages(ctx context.Context, userID uint, messages []*models.Message) error {
+	RenderMessageHTML(messages...)
+	if s.db == nil || len(messages) == 0 {
+		return nil
+	}
+	var replyToIDs, rootIDs []uint
+	for _, m := range messages {
+		if m.ReplyToID != nil {
+			replyToIDs = append(replyToIDs, *m.ReplyToID)
+		}
+		if m.ReplyCount > 0 {
+			rootIDs = append(rootIDs, m.ID)
+		}
+	}
+
+	if len(replyToIDs) > 0 {
+		var quoted []models.Message
+		if err := s.db.WithContext(ctx).Preload("Sender").Where("id IN ?", replyToIDs).Find(&quoted).Error; err != nil {
+			return err
+		}
+		previews := make(map[uint]*models.MessagePreview, len(quoted))
+		for i := range quoted {
+			previews[quoted[i].ID] = messagePreview(&quoted[i])
+		}
+		for _, m := range messages {
+			if m.ReplyToID != nil {
+				m.ReplyTo = previews[*m.ReplyToID]
+			}
+		}
+	}
+
+	if len(rootIDs) > 0 && userID != 0 {
+		var reads []models.MessageThreadRead
+		if err := s.db.WithContext(ctx).
+			Where("user_id = ? AND root_message_id IN ?", userID, rootIDs).
+			Find(&reads).Error; err != nil {
+			return err
+		}
+		readAt := make(map[uint]time.Time, len(reads))
+		for _, r := range reads {
+			readAt[r.RootMessageID] = r.LastReadAt
+		}
+		for _, m := range messages {
+			if t, ok := readAt[m.ID]; ok {
+				m.ThreadReadAt = &t
+			}
+		}
+	}
+	return nil
+}
+
+func (s *ChatService) findMessage(ctx context.Context, convID, messageID uint) (*models.Message, error) {
+	var message models.Message
+	err := s.db.WithContext(ctx).
+		Where("id = ? AND conversation_id = ?", messageID, convID).
+		Preload("Sender").
+		First(&message).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.NewNotFoundError("Message", messageID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+func (s *ChatService) filterBlockedSenders(ctx context.Context, userID uint, messages []*models.Message) ([]*models.Message, error) {
+	blockedByUser, err := s.blockedUserIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(blockedByUser) == 0 {
+		return messages, nil
+	}
+	filtered := make([]*models.Message, 0, len(messages))
+	for _, message := range messages {
+		if blockedByUser[message.SenderID] {
+			continue
+		}
+		filtered = append(filtered, message)
+	}
+	return filtered, nil
+}
+
+func messagePreview(m *models.Message) *models.MessagePreview {
+	preview := &models.MessagePreview{
+		ID:        m.ID,
+		SenderID:  m.SenderID,
+		Content:   m.Content,
+		IsDeleted: m.IsDeleted,
+	}
+	if m.Sender != nil {
+		preview.SenderUsername = m.Sender.Username
+	}
+	if runes := []rune(preview.Content); len(runes) > messagePreviewLen {
+		preview.Content = string(runes[:messagePreviewLen]) + "…"
+	}
+	return preview
+}
+
+func upsertThreadRead(db *gorm.DB, userID, rootID uint, at time.Time) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "root_message_id"}},
+		DoUpdates: clause.Assignments(map[string]any{"last_read_at": at}),
+	}).Create(&models.MessageThreadRead{UserID: userID, RootMessageID: rootID, LastReadAt: at}).Error
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatService_Replies(t *testing.T) {
+	f := newChatFixture(t)
+	root, err := f.send(SendMessageInput{UserID: f.alice.ID, Content: "Anyone tried the new patch?"})
+	require.NoError(t, err)
+
+	reply, err := f.send(SendMessageInput{UserID: f.bob.ID, Content: "Yes!", ReplyToID: root.ID})
+	require.NoError(t, err)
+	require.NotNil(t, reply.ReplyTo)
+	assert.Equal(t, "alice", reply.ReplyTo.SenderUsername)
+	assert.Equal(t, root.Content, reply.ReplyTo.Content)
+
+	_, err = f.send(SendMessageInput{UserID: f.bob.ID, Content: "?", ReplyToID: 9999})
+	assert.Error(t, err)
+}
+
+func TestChatService_Threads(t *testing.T) {
+	f := newChatFixture(t)
+	ctx := context.Background()
+	root, err := f.send(SendMessageInput{UserID: f.alice.ID, Content: "Anyone tried the new patch?"})
+	require.NoError(t, err)
+	quote, err := f.send(SendMessageInput{UserID: f.bob.ID, Content: "Yes!", ReplyToID: root.ID})
+	require.NoError(t, err)
+	first, err := f.send(SendMessageInput{UserID: f.bob.ID, Content: "It fixed my crash", ThreadRootID: root.ID})
+	require.NoError(t, err)
+	_, err = f.send(SendMessageInput{UserID: f.alice.ID, Content: "Nice", ThreadRootID: root.ID, ReplyToID: first.ID})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		in   SendMessageInput
+	}{
+		{"thread replies cannot be thread roots", SendMessageInput{UserID: f.alice.ID, Content: "nested", ThreadRootID: first.ID}},
+		{"quotes come from the same thread", SendMessageInput{UserID: f.alice.ID, Content: "wrong place", ThreadRootID: root.ID, ReplyToID: quote.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.send(tt.in)
+			assert.Error(t, err)
+		})
+	}
+
+	timeline, err := f.svc.GetMessagesForUser(ctx, f.room.ID, f.alice.ID, 50, 0)
+	require.NoError(t, err)
+	require.Len(t, timeline, 2, "thread replies stay out of the timeline")
+	assert.Equal(t, 2, timeline[0].ReplyCount)
+	assert.NotNil(t, timeline[0].LastReplyAt)
+	require.NotNil(t, timeline[0].ThreadReadAt, "posting in a thread marks it read")
+	require.NotNil(t, timeline[1].ReplyTo)
+
+	thread, err := f.svc.GetThread(ctx, f.room.ID, root.ID, f.bob.ID, 50, 0)
+	require.NoError(t, err)
+	require.Len(t, thread.Replies, 2)
+	assert.Equal(t, first.ID, thread.Replies[0].ID)
+	require.NotNil(t, thread.Replies[1].ReplyTo)
+	assert.Equal(t, first.ID, thread.Replies[1].ReplyTo.ID)
+	assert.Equal(t, int64(1), thread.UnreadCount, "alice's reply is new to bob")
+
+	_, err = f.svc.MarkThreadRead(ctx, f.room.ID, root.ID, f.bob.ID)
+	require.NoError(t, err)
+	thread, err = f.svc.GetThread(ctx, f.room.ID, root.ID, f.bob.ID, 50, 0)
+	require.NoError(t, err)
+	assert.Zero(t, thread.UnreadCount)
+	assert.NotNil(t, thread.LastReadAt)
+}
+
+func TestChatService_DeletedThreadReplyLeavesTheCount(t *testing.T) {
+	f := newChatFixture(t)
+	ctx := context.Background()
+	root, err := f.send(SendMessageInput{UserID: f.alice.ID, Content: "Anyone tried the new patch?"})
+	require.NoError(t, err)
+	reply, err := f.send(SendMessageInput{UserID: f.bob.ID, Content: "It fixed my crash", ThreadRootID: root.ID})
+	require.NoError(t, err)
+
+	_, err = f.svc.DeleteMessage(ctx, f.room.ID, reply.ID, f.bob.ID)
+	require.NoError(t, err)
+	_, err = f.svc.DeleteMessage(ctx, f.room.ID, reply.ID, f.bob.ID)
+	require.NoError(t, err, "deleting a tombstone again is a no-op")
+	stored, err := f.svc.ThreadRoot(ctx, f.room.ID, root.ID)
+	require.NoError(t, err)
+	assert.Zero(t, stored.ReplyCount)
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"sanctum/internal/cache"
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Delivery states reported on a user's own direct messages.
+const (
+	DeliverySent      = "sent"
+	DeliveryDelivered = "delivered"
+	DeliveryRead      = "read"
+)
+
+// ReadReceipt is a participant's read watermark after marking a conversation
+// read.
+type ReadReceipt struct {
+	ConversationID    uint      `json:"conversation_id"`
+	UserID            uint      `json:"user_id"`
+	LastReadMessageID uint      `json:"last_read_message_id"`
+	ReadAt            time.Time `json:"read_at"`
+	// Hidden is set when the user has turned read receipts off; the receipt
+	// must not be shown to others.
+	Hidden bool `json:"-"`
+}
+
+// ParticipantReceipt is the read and delivery state of one participant.
+// Read fields are omitted for users who hide their read receipts.
+type ParticipantReceipt struct {
+	UserID                 uint       `json:"user_id"`
+	Username               string     `json:"username"`
+	LastReadMessageID      *uint      `json:"last_read_message_id,omitempty"`
+	LastReadAt             *time.Time `json:"last_read_at,omitempty"`
+	LastDeliveredMessageID uint       `json:"last_delivered_message_id"`
+}
+
+// SeenByEntry is a participant who has read a message.
+type SeenByEntry struct {
+	UserID   uint      `json:"user_id"`
+	Username string    `json:"username"`
+	Avatar   string    `json:"avatar"`
+	ReadAt   time.Time `json:"read_at"`
+}
+
+type participantReceiptRow struct {
+	UserID                 uint
+	Username               string
+	Avatar                 string
+	HideReadReceipts       bool
+	LastReadAt             time.Time
+	LastReadMessageID      uint
+	LastDeliveredMessageID uint
+}
+
+// MarkRead moves the user's read watermark up to messageID, or to the newest
+// message when messageID is 0. Watermarks never move backwards.
+func (s *ChatService) MarkRead(ctx context.Context, convID, userID, messageID uint) (*ReadReceipt, error) {
+	conv, err := s.chatRepo.GetConversation(ctx, convID)
+	if err != nil {
+		return nil, err
+	}
+	if !isConversationParticipant(conv, userID) {
+		return nil, models.NewUnauthorizedError("You are not a participant in this conversation")
+	}
+	watermark, err := s.resolveWatermark(ctx, convID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	var user models.User
+	if err := s.db.WithContext(ctx).Select("id", "hide_read_receipts").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ConversationParticipant{}).
+			Where("conversation_id = ? AND user_id = ?", convID, userID).
+			Updates(map[string]any{
+				"last_read_at": now,
+				"unread_count": 0,
+			}).Error; err != nil {
+			return err
+		}
+		if err := advanceWatermark(tx, convID, userID, "last_read_message_id", watermark); err != nil {
+			return err
+		}
+		if user.HideReadReceipts {
+			// Moving the delivery watermark here would reveal the read.
+			return nil
+		}
+		if err := advanceWatermark(tx, convID, userID, "last_delivered_message_id", watermark); err != nil {
+			return err
+		}
+		// The legacy per-message flag only makes sense between two people.
+		if !conv.IsGroup {
+			return tx.Model(&models.Message{}).
+				Where("conversation_id = ? AND sender_id <> ? AND is_read = ? AND id <= ?", convID, userID, false, watermark).
+				Updates(map[string]any{"is_read": true, "read_at": now}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	cache.InvalidateUser(ctx, userID)
+	if !conv.IsGroup {
+		cache.InvalidateRoom(ctx, convID)
+	}
+	return &ReadReceipt{
+		ConversationID:    convID,
+		UserID:            userID,
+		LastReadMessageID: watermark,
+		ReadAt:            now,
+		Hidden:            user.HideReadReceipts,
+	}, nil
+}
+
+// MarkDelivered moves the user's delivery watermark up to messageID. It
+// reports whether the watermark advanced.
+func (s *ChatService) MarkDelivered(ctx context.Context, convID, userID, messageID uint) (bool, error) {
+	if messageID == 0 {
+		return false, nil
+	}
+	res := s.db.WithContext(ctx).Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ? AND last_delivered_message_id < ?", convID, userID, messageID).
+		Where("EXISTS (SELECT 1 FROM messages WHERE id = ? AND conversation_id = ?)", messageID, convID).
+		Update("last_delivered_message_id", messageID)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// GetReadReceipts returns every participant's read and delivery watermarks.
+func (s *ChatService) GetReadReceipts(ctx context.Context, convID, userID uint) ([]ParticipantReceipt, error) {
+	conv, err := s.chatRepo.GetConversation(ctx, convID)
+	if err != nil {
+		return nil, err
+	}
+	if !isConversationParticipant(conv, userID) {
+		return nil, models.NewUnauthorizedError("You are not a participant in this conversation")
+	}
+	rows, err := s.participantReceipts(ctx, convID)
+	if err != nil {
+		return nil, err
+	}
+	receipts := make([]ParticipantReceipt, 0, len(rows))
+	for _, row := range rows {
+		receipt := ParticipantReceipt{
+			UserID:                 row.UserID,
+			Username:               row.Username,
+			LastDeliveredMessageID: row.LastDeliveredMessageID,
+		}
+		if !row.HideReadReceipts || row.UserID == userID {
+			readID, readAt := row.LastReadMessageID, row.LastReadAt
+			receipt.LastReadMessageID = &readID
+			receipt.LastReadAt = &readAt
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, nil
+}
+
+// GetSeenBy lists the participants who have read a message, excluding its
+// sender and users who hide their read receipts.
+func (s *ChatService) GetSeenBy(ctx context.Context, convID, messageID, userID uint) ([]SeenByEntry, error) {
+	_, message, err := s.messageForUser(ctx, convID, messageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.participantReceipts(ctx, convID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make([]SeenByEntry, 0)
+	for _, row := range rows {
+		if row.UserID == message.SenderID || row.HideReadReceipts || row.LastReadMessageID < message.ID {
+			continue
+		}
+		seen = append(seen, SeenByEntry{UserID: row.UserID, Username: row.Username, Avatar: row.Avatar, ReadAt: row.LastReadAt})
+	}
+	return seen, nil
+}
+
+// attachDeliveryStates marks the caller's own direct messages as sent,
+// delivered or read by the other participant.
+func (s *ChatService) attachDeliveryStates(ctx context.Context, conv *models.Conversation, userID uint, messages []*models.Message) error {
+	if s.db == nil || conv.IsGroup || len(messages) == 0 {
+		return nil
+	}
+	rows, err := s.participantReceipts(ctx, conv.ID)
+	if err != nil {
+		return err
+	}
+	var other *participantReceiptRow
+	for i := range rows {
+		if rows[i].UserID != userID {
+			other = &rows[i]
+			break
+		}
+	}
+	if other == nil {
+		return nil
+	}
+	for _, m := range messages {
+		if m.SenderID != userID {
+			continue
+		}
+		switch {
+		case !other.HideReadReceipts && other.LastReadMessageID >= m.ID:
+			m.DeliveryState = DeliveryRead
+		case other.LastDeliveredMessageID >= m.ID || other.LastReadMessageID >= m.ID:
+			m.DeliveryState = DeliveryDelivered
+		default:
+			m.DeliveryState = DeliverySent
+		}
+	}
+	return nil
+}
+
+func (s *ChatService) participantReceipts(ctx context.Context, convID uint) ([]participantReceiptRow, error) {
+	var rows []participantReceiptRow
+	err := s.db.WithContext(ctx).
+		Table("conversation_participants AS cp").
+		Select("cp.user_id, u.username, u.avatar, u.hide_read_receipts, cp.last_read_at, cp.last_read_message_id, cp.last_delivered_message_id").
+		Joins("JOIN users u ON u.id = cp.user_id AND u.deleted_at IS NULL").
+		Where("cp.conversation_id = ?", convID).
+		Order("cp.user_id").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// resolveWatermark checks that messageID belongs to the conversation, or
+// picks the newest message when it is 0.
+func (s *ChatService) resolveWatermark(ctx context.Context, convID, messageID uint) (uint, error) {
+	if messageID != 0 {
+		message, err := s.findMessage(ctx, convID, messageID)
+		if err != nil {
+			return 0, err
+		}
+		return message.ID, nil
+	}
+	var newest uint
+	if err := s.db.WithContext(ctx).Model(&models.Message{}).
+		Where("conversation_id = ?", convID).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&newest).Error; err != nil {
+		return 0, err
+	}
+	return newest, nil
+}
+
+func advanceWatermark(tx *gorm.DB, convID, userID uint, column string, messageID uint) error {
+	return tx.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ? AND "+column+" < ?", convID, userID, messageID).
+		Update(column, messageID).Error
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatService_DirectReceipts(t *testing.T) {
+	f := newChatFixture(t)
+	ctx := context.Background()
+	dm := f.conversation(t, false, f.alice, f.bob)
+	first := f.post(t, dm, f.alice, "hi")
+	second := f.post(t, dm, f.alice, "you there?")
+	states := func() map[uint]string {
+		messages, err := f.svc.GetMessagesForUser(ctx, dm.ID, f.alice.ID, 50, 0)
+		require.NoError(t, err)
+		out := make(map[uint]string, len(messages))
+		for _, m := range messages {
+			out[m.ID] = m.DeliveryState
+		}
+		return out
+	}
+	assert.Equal(t, DeliverySent, states()[second.ID])
+
+	advanced, err := f.svc.MarkDelivered(ctx, dm.ID, f.bob.ID, second.ID)
+	require.NoError(t, err)
+	assert.True(t, advanced)
+	advanced, err = f.svc.MarkDelivered(ctx, dm.ID, f.bob.ID, first.ID)
+	require.NoError(t, err)
+	assert.False(t, advanced, "delivery watermarks never move back")
+	assert.Equal(t, DeliveryDelivered, states()[second.ID])
+
+	receipt, err := f.svc.MarkRead(ctx, dm.ID, f.bob.ID, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, receipt.LastReadMessageID)
+	assert.False(t, receipt.Hidden)
+	got := states()
+	assert.Equal(t, DeliveryRead, got[first.ID])
+	assert.Equal(t, DeliveryDelivered, got[second.ID])
+
+	_, err = f.svc.MarkRead(ctx, dm.ID, f.carol.ID, 0)
+	assert.Error(t, err, "only participants can mark read")
+}
+
+func TestChatService_RoomReceipts(t *testing.T) {
+	f := newChatFixture(t)
+	ctx := context.Background()
+	msg := f.post(t, f.room, f.alice, "standup in 5")
+	_, err := f.svc.MarkRead(ctx, f.room.ID, f.bob.ID, 0)
+	require.NoError(t, err)
+	require.NoError(t, f.db.Model(f.carol).Update("hide_read_receipts", true).Error)
+	receipt, err := f.svc.MarkRead(ctx, f.room.ID, f.carol.ID, msg.ID)
+	require.NoError(t, err)
+	assert.True(t, receipt.Hidden)
+
+	// Seen-by lists readers, minus the sender and private users.
+	seen, err := f.svc.GetSeenBy(ctx, f.room.ID, msg.ID, f.alice.ID)
+	require.NoError(t, err)
+	require.Len(t, seen, 1)
+	assert.Equal(t, f.bob.ID, seen[0].UserID)
+
+	receipts, err := f.svc.GetReadReceipts(ctx, f.room.ID, f.alice.ID)
+	require.NoError(t, err)
+	require.Len(t, receipts, 4)
+	for _, r := range receipts {
+		if r.UserID == f.carol.ID {
+			assert.Nil(t, r.LastReadMessageID, "hidden read state stays private")
+			assert.Zero(t, r.LastDeliveredMessageID, "a hidden read does not move the delivery watermark")
+		}
+	}
+}
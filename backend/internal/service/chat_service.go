@@ -21,6 +21,8 @@ type ChatService struct {
 	db                  *gorm.DB
 	isAdmin             func(ctx context.Context, userID uint) (bool, error)
 	canModerateChatroom func(ctx context.Context, userID, roomID uint) (bool, error)
+	// automod rejects sanctum channel messages its rules would remove.
+	automod *AutomodService
 }
 
 type CreateConversationInput struct {
@@ -36,6 +38,11 @@ type SendMessageInput struct {
 	Content        string
 	MessageType    string
 	Metadata       json.RawMessage
+	// ReplyToID quotes an earlier message of the conversation; 0 for none.
+	ReplyToID uint
+	// ThreadRootID posts the message in the side thread under that root
+	// message instead of the main timeline; 0 for none.
+	ThreadRootID uint
 }
 
 func NewChatService(
@@ -44,6 +51,7 @@ func NewChatService(
 	db *gorm.DB,
 	isAdmin func(ctx context.Context, userID uint) (bool, error),
 	canModerateChatroom func(ctx context.Context, userID, roomID uint) (bool, error),
+	automod *AutomodService,
 ) *ChatService {
 	return &ChatService{
 		chatRepo:            chatRepo,
@@ -51,6 +59,7 @@ func NewChatService(
 		db:                  db,
 		isAdmin:             isAdmin,
 		canModerateChatroom: canModerateChatroom,
+		automod:             automod,
 	}
 }
 
@@ -182,6 +191,18 @@ func (s *ChatService) SendMessage(ctx context.Context, in SendMessageInput) (*mo
 			}
 		}
 	}
+	if conv.SanctumID != nil && s.db != nil {
+		ban, berr := activeSanctumBan(ctx, s.db, *conv.SanctumID, in.UserID)
+		if berr != nil {
+			return nil, nil, berr
+		}
+		if ban != nil {
+			return nil, nil, sanctumBanError(ban)
+		}
+		if err := s.checkAutomod(ctx, *conv.SanctumID, in.UserID, in.Content); err != nil {
+			return nil, nil, err
+		}
+	}
 	if conv.IsGroup {
 		muted, merr := s.userMutedInRoom(ctx, conv.ID, in.UserID)
 		if merr != nil {
@@ -191,6 +212,12 @@ func (s *ChatService) SendMessage(ctx context.Context, in SendMessageInput) (*mo
 			return nil, nil, models.NewForbiddenError("You are muted in this room")
 		}
 	}
+	if err := s.checkPostPermission(ctx, conv, in.UserID); err != nil {
+		return nil, nil, err
+	}
+	if err := s.checkMessageRefs(ctx, in); err != nil {
+		return nil, nil, err
+	}
 
 	message := &models.Message{
 		ConversationID: in.ConversationID,
@@ -199,7 +226,20 @@ func (s *ChatService) SendMessage(ctx context.Context, in SendMessageInput) (*mo
 		MessageType:    in.MessageType,
 		Metadata:       in.Metadata,
 	}
-	if err := s.chatRepo.CreateMessage(ctx, message); err != nil {
+	if in.ReplyToID != 0 {
+		message.ReplyToID = &in.ReplyToID
+	}
+	if in.ThreadRootID != 0 {
+		message.ThreadRootID = &in.ThreadRootID
+	}
+	if message.ThreadRootID != nil {
+		if err := s.createThreadReply(ctx, conv, message); err != nil {
+			return nil, nil, err
+		}
+	} else if err := s.chatRepo.CreateMessage(ctx, message); err != nil {
+		return nil, nil, err
+	}
+	if err := s.decorateMessages(ctx, in.UserID, []*models.Message{message}); err != nil {
 		return nil, nil, err
 	}
 
@@ -210,6 +250,26 @@ func (s *ChatService) SendMessage(ctx context.Context, in SendMessageInput) (*mo
 	return message, conv, nil
 }
 
+// checkPostPermission rejects writes to a mods-only channel from users who
+// do not moderate it.
+func (s *ChatService) checkPostPermission(ctx context.Context, conv *models.Conversation, userID uint) error {
+	if conv.PostPermission != models.ChannelPostMods {
+		return nil
+	}
+	canPost := false
+	if s.canModerateChatroom != nil {
+		var err error
+		canPost, err = s.canModerateChatroom(ctx, userID, conv.ID)
+		if err != nil {
+			return err
+		}
+	}
+	if !canPost {
+		return models.NewForbiddenError("Only moderators can post in this channel")
+	}
+	return nil
+}
+
 func (s *ChatService) GetMessagesForUser(ctx context.Context, convID, userID uint, limit, offset int) ([]*models.Message, error) {
 	conv, err := s.chatRepo.GetConversation(ctx, convID)
 	if err != nil {
@@ -222,21 +282,7 @@ func (s *ChatService) GetMessagesForUser(ctx context.Context, convID, userID uin
 	if err != nil {
 		return nil, err
 	}
-	blockedByUser, berr := s.blockedUserIDs(ctx, userID)
-	if berr != nil {
-		return nil, berr
-	}
-	if len(blockedByUser) == 0 {
-		return messages, nil
-	}
-	filtered := make([]*models.Message, 0, len(messages))
-	for _, message := range messages {
-		if blockedByUser[message.SenderID] {
-			continue
-		}
-		filtered = append(filtered, message)
-	}
-	return filtered, nil
+	return s.prepareMessagesForUser(ctx, conv, userID, messages)
 }
 
 func (s *ChatService) AddParticipant(ctx context.Context, convID, actorUserID, participantUserID uint) error {
@@ -250,6 +296,9 @@ func (s *ChatService) AddParticipant(ctx context.Context, convID, actorUserID, p
 	if !conv.IsGroup {
 		return models.NewValidationError("Cannot add participants to 1-on-1 conversations")
 	}
+	if conv.SanctumID != nil {
+		return errSanctumChannelAccess
+	}
 	return s.chatRepo.AddParticipant(ctx, convID, participantUserID)
 }
 
@@ -267,6 +316,13 @@ func (s *ChatService) LeaveConversation(ctx context.Context, convID, userID uint
 	return conv, nil
 }
 
+// errSanctumChannelAccess rejects joining a sanctum channel directly. Its
+// participants follow sanctum membership, bans and the channel's visibility;
+// see SyncSanctumChannelAccess.
+var errSanctumChannelAccess = models.NewForbiddenError("Join the sanctum to access its channels")
+
+// GetAllChatrooms lists group rooms. Sanctum channels are only listed for
+// their participants, since nobody else can join them here.
 func (s *ChatService) GetAllChatrooms(ctx context.Context, userID uint) ([]ChatroomWithJoined, error) {
 	var chatrooms []*models.Conversation
 	key := cache.ChatroomsAllKeyWithVersion(ctx)
@@ -295,6 +351,9 @@ func (s *ChatService) GetAllChatrooms(ctx context.Context, userID uint) ([]Chatr
 				break
 			}
 		}
+		if room.SanctumID != nil && !isJoined {
+			continue
+		}
 		result = append(result, ChatroomWithJoined{
 			Conversation: room,
 			IsJoined:     isJoined,
@@ -333,6 +392,9 @@ func (s *ChatService) JoinChatroom(ctx context.Context, roomID, userID uint) (*m
 	if !conv.IsGroup {
 		return nil, models.NewValidationError("Cannot join a 1-on-1 conversation")
 	}
+	if conv.SanctumID != nil {
+		return nil, errSanctumChannelAccess
+	}
 
 	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ConversationParticipant{
 		ConversationID: roomID,
@@ -422,6 +484,15 @@ func (s *ChatService) usersBlocked(ctx context.Context, userID, otherUserID uint
 	return count > 0, nil
 }
 
+// checkAutomod runs the sanctum's AutoModerator over a channel message
+// before it is stored.
+func (s *ChatService) checkAutomod(ctx context.Context, sanctumID, userID uint, content string) error {
+	if s.automod == nil {
+		return nil
+	}
+	return s.automod.CheckMessage(ctx, sanctumID, userID, content)
+}
+
 func (s *ChatService) userMutedInRoom(ctx context.Context, roomID, userID uint) (bool, error) {
 	if s.db == nil {
 		return false, nil
@@ -21,6 +21,7 @@ type chatRepoStub struct {
 	removeParticipantFn    func(context.Context, uint, uint) error
 	createMessageFn        func(context.Context, *models.Message) error
 	getMessagesFn          func(context.Context, uint, int, int) ([]*models.Message, error)
+	getMessagesByCursorFn  func(context.Context, uint, repository.MessageCursor, int) ([]*models.Message, error)
 	markMessageReadFn      func(context.Context, uint) error
 	updateLastReadFn       func(context.Context, uint, uint) error
 }
@@ -46,6 +47,9 @@ func (s *chatRepoStub) CreateMessage(ctx context.Context, msg *models.Message) e
 func (s *chatRepoStub) GetMessages(ctx context.Context, convID uint, limit, offset int) ([]*models.Message, error) {
 	return s.getMessagesFn(ctx, convID, limit, offset)
 }
+func (s *chatRepoStub) GetMessagesByCursor(ctx context.Context, convID uint, cursor repository.MessageCursor, limit int) ([]*models.Message, error) {
+	return s.getMessagesByCursorFn(ctx, convID, cursor, limit)
+}
 func (s *chatRepoStub) MarkMessageRead(ctx context.Context, msgID uint) error {
 	return s.markMessageReadFn(ctx, msgID)
 }
@@ -65,13 +69,14 @@ func noopChatRepo() *chatRepoStub {
 		removeParticipantFn:    func(context.Context, uint, uint) error { return nil },
 		createMessageFn:        func(context.Context, *models.Message) error { return nil },
 		getMessagesFn:          func(context.Context, uint, int, int) ([]*models.Message, error) { return nil, nil },
+		getMessagesByCursorFn:  func(context.Context, uint, repository.MessageCursor, int) ([]*models.Message, error) { return nil, nil },
 		markMessageReadFn:      func(context.Context, uint) error { return nil },
 		updateLastReadFn:       func(context.Context, uint, uint) error { return nil },
 	}
 }
 
 func TestChatService_CreateConversation_Validation(t *testing.T) {
-	svc := NewChatService(noopChatRepo(), noopUserRepo(), nil, nil, nil)
+	svc := NewChatService(noopChatRepo(), noopUserRepo(), nil, nil, nil, nil)
 
 	t.Run("Group without name", func(t *testing.T) {
 		_, err := svc.CreateConversation(context.Background(), CreateConversationInput{
@@ -105,7 +110,7 @@ func TestChatService_SendMessage_Unauthorized(t *testing.T) {
 		}, nil
 	}
 
-	svc := NewChatService(repo, noopUserRepo(), nil, nil, nil)
+	svc := NewChatService(repo, noopUserRepo(), nil, nil, nil, nil)
 
 	_, _, err := svc.SendMessage(context.Background(), SendMessageInput{
 		UserID:         1,
@@ -125,7 +130,7 @@ func TestChatService_FullFlow(t *testing.T) {
 
 	repo := repository.NewChatRepository(db)
 	userRepo := repository.NewUserRepository(db)
-	svc := NewChatService(repo, userRepo, db, nil, nil)
+	svc := NewChatService(repo, userRepo, db, nil, nil, nil)
 
 	ctx := context.Background()
 	u1 := &models.User{Username: "u1", Email: "u1@e.com"}
@@ -203,7 +208,7 @@ func TestChatService_Chatrooms(t *testing.T) {
 
 	repo := repository.NewChatRepository(db)
 	userRepo := repository.NewUserRepository(db)
-	svc := NewChatService(repo, userRepo, db, nil, nil)
+	svc := NewChatService(repo, userRepo, db, nil, nil, nil)
 
 	ctx := context.Background()
 	u1 := &models.User{Username: "u1", Email: "u1@e.com"}
@@ -242,7 +247,7 @@ func TestChatService_RemoveParticipant_Authorization(t *testing.T) {
 		return false, nil
 	}
 
-	svc := NewChatService(repo, noopUserRepo(), db, isAdmin, nil)
+	svc := NewChatService(repo, noopUserRepo(), db, isAdmin, nil, nil)
 
 	// User 1 tries to remove user 3 from room created by user 2
 	_, err := svc.RemoveParticipant(context.Background(), 1, 1, 3)
@@ -255,7 +260,7 @@ func TestChatService_RemoveParticipant_Authorization(t *testing.T) {
 	isAdminAdmin := func(_ context.Context, _ uint) (bool, error) {
 		return true, nil
 	}
-	svcAdmin := NewChatService(repo, noopUserRepo(), db, isAdminAdmin, nil)
+	svcAdmin := NewChatService(repo, noopUserRepo(), db, isAdminAdmin, nil, nil)
 	_, err = svcAdmin.RemoveParticipant(context.Background(), 1, 1, 3)
 	assert.NoError(t, err)
 }
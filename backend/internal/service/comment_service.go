@@ -7,10 +7,21 @@ import (
 	"sanctum/internal/repository"
 )
 
+// removedCommentPlaceholder replaces the content of removed comments for
+// readers who are not sanctum moderators.
+const removedCommentPlaceholder = "[removed]"
+
 type CommentService struct {
 	commentRepo repository.CommentRepository
 	postRepo    repository.PostRepository
 	isAdmin     func(ctx context.Context, userID uint) (bool, error)
+	// checkSanctumAccess rejects commenters banned or muted in a sanctum.
+	checkSanctumAccess func(ctx context.Context, sanctumID, userID uint) error
+	// canModerate reports whether a user moderates a sanctum; moderators see
+	// removed posts and comments in full.
+	canModerate func(ctx context.Context, userID, sanctumID uint) (bool, error)
+	// automod runs the sanctum's AutoModerator over a comment before it is stored.
+	automod *AutomodService
 }
 
 type CreateCommentInput struct {
@@ -34,16 +45,35 @@ func NewCommentService(
 	commentRepo repository.CommentRepository,
 	postRepo repository.PostRepository,
 	isAdmin func(ctx context.Context, userID uint) (bool, error),
+	checkSanctumAccess func(ctx context.Context, sanctumID, userID uint) error,
+	canModerate func(ctx context.Context, userID, sanctumID uint) (bool, error),
+	automod *AutomodService,
 ) *CommentService {
 	return &CommentService{
-		commentRepo: commentRepo,
-		postRepo:    postRepo,
-		isAdmin:     isAdmin,
+		commentRepo:        commentRepo,
+		postRepo:           postRepo,
+		isAdmin:            isAdmin,
+		checkSanctumAccess: checkSanctumAccess,
+		canModerate:        canModerate,
+		automod:            automod,
 	}
 }
 
 func (s *CommentService) CreateComment(ctx context.Context, in CreateCommentInput) (*models.Comment, error) {
-	if _, err := s.postRepo.GetByID(ctx, in.PostID, 0); err != nil {
+	post, err := s.postRepo.GetByID(ctx, in.PostID, 0)
+	if err != nil {
+		return nil, err
+	}
+	if isPostUnpublished(post) {
+		return nil, models.NewValidationError("Cannot comment on an unpublished post")
+	}
+	if post.RemovedAt != nil {
+		return nil, models.NewNotFoundError("Post", in.PostID)
+	}
+	if post.Locked {
+		return nil, models.NewValidationError("Comments are locked on this post")
+	}
+	if err := checkSanctumAccess(ctx, s.checkSanctumAccess, post.SanctumID, in.UserID); err != nil {
 		return nil, err
 	}
 	const maxCommentLen = 10000
@@ -60,18 +90,79 @@ func (s *CommentService) CreateComment(ctx context.Context, in CreateCommentInpu
 		UserID:  in.UserID,
 		PostID:  in.PostID,
 	}
-	if err := s.commentRepo.Create(ctx, comment); err != nil {
+	var verdict *AutomodVerdict
+	if post.SanctumID != nil && s.automod != nil {
+		if verdict, err = s.automod.CheckComment(ctx, comment, *post.SanctumID); err != nil {
+			return nil, err
+		}
+	}
+	if verdict != nil {
+		err = s.automod.CreateComment(ctx, comment, verdict)
+	} else {
+		err = s.commentRepo.Create(ctx, comment)
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	return s.commentRepo.GetByID(ctx, comment.ID)
+	return s.renderedComment(ctx, comment.ID)
 }
 
-func (s *CommentService) ListComments(ctx context.Context, postID uint) ([]*models.Comment, error) {
-	if _, err := s.postRepo.GetByID(ctx, postID, 0); err != nil {
+// ListComments returns a post's comments as viewerID may see them. A removed
+// post's thread is only listed for its author and the sanctum's moderators,
+// and removed comments are blanked for everyone but those moderators.
+func (s *CommentService) ListComments(ctx context.Context, postID, viewerID uint) ([]*models.Comment, error) {
+	post, err := s.postRepo.GetByID(ctx, postID, 0)
+	if err != nil {
 		return nil, err
 	}
-	return s.commentRepo.ListByPost(ctx, postID)
+	isModerator, err := s.isModerator(ctx, viewerID, post.SanctumID)
+	if err != nil {
+		return nil, err
+	}
+	if post.RemovedAt != nil && !isModerator && (viewerID == 0 || post.UserID != viewerID) {
+		return nil, models.NewNotFoundError("Post", postID)
+	}
+	comments, err := s.commentRepo.ListByPost(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	RenderCommentHTML(comments...)
+	if !isModerator {
+		redactRemovedComments(comments)
+	}
+	return comments, nil
+}
+
+// isModerator reports whether userID moderates the sanctum a post belongs to.
+func (s *CommentService) isModerator(ctx context.Context, userID uint, sanctumID *uint) (bool, error) {
+	if userID == 0 || sanctumID == nil || s.canModerate == nil {
+		return false, nil
+	}
+	return s.canModerate(ctx, userID, *sanctumID)
+}
+
+// redactRemovedComments blanks removed comments, keeping their place in the
+// thread.
+func redactRemovedComments(comments []*models.Comment) {
+	for _, comment := range comments {
+		if comment.RemovedAt == nil {
+			continue
+		}
+		comment.Content = removedCommentPlaceholder
+		comment.ContentHTML = ""
+		comment.RemovalReason = ""
+		comment.RemovedByUserID = nil
+	}
+}
+
+func (s *CommentService) renderedComment(ctx context.Context, id uint) (*models.Comment, error) {
+	comment, err := s.commentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	RenderCommentHTML(comment)
+	return comment, nil
 }
 
 func (s *CommentService) UpdateComment(ctx context.Context, in UpdateCommentInput) (*models.Comment, error) {
@@ -83,6 +174,9 @@ func (s *CommentService) UpdateComment(ctx context.Context, in UpdateCommentInpu
 	if comment.UserID != in.UserID {
 		return nil, models.NewUnauthorizedError("You can only update your own comments")
 	}
+	if comment.RemovedAt != nil {
+		return nil, models.NewValidationError("Removed comments cannot be edited")
+	}
 	if in.Content == "" {
 		return nil, models.NewValidationError("Content is required")
 	}
@@ -92,7 +186,7 @@ func (s *CommentService) UpdateComment(ctx context.Context, in UpdateCommentInpu
 		return nil, err
 	}
 
-	return s.commentRepo.GetByID(ctx, comment.ID)
+	return s.renderedComment(ctx, comment.ID)
 }
 
 func (s *CommentService) DeleteComment(ctx context.Context, in DeleteCommentInput) (*models.Comment, error) {
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"sanctum/internal/markdown"
+	"sanctum/internal/models"
+)
+
+// Markdown is rendered where content leaves the service layer rather than on
+// every row load, so internal queries (counts, moderation checks, fan-out)
+// don't pay for it. Handlers that load rows themselves render through these
+// helpers too.
+
+// RenderPostHTML fills in ContentHTML on posts.
+func RenderPostHTML(posts ...*models.Post) {
+	for _, p := range posts {
+		p.ContentHTML = markdown.Render(p.Content)
+	}
+}
+
+// RenderCommentHTML fills in ContentHTML on comments.
+func RenderCommentHTML(comments ...*models.Comment) {
+	for _, c := range comments {
+		c.ContentHTML = markdown.Render(c.Content)
+	}
+}
+
+// RenderMessageHTML fills in ContentHTML on chat messages.
+func RenderMessageHTML(messages ...*models.Message) {
+	for _, m := range messages {
+		m.ContentHTML = markdown.Render(m.Content)
+	}
+}
+
+// RenderWikiPageHTML fills in ContentHTML on a wiki page.
+func RenderWikiPageHTML(page *models.SanctumWikiPage) {
+	page.ContentHTML = markdown.Render(page.Content)
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newTestDB opens an in-memory SQLite database holding users and the given
+// tables.
+func newTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(append([]interface{}{&models.User{}}, tables...)...))
+	return db
+}
+
+// createTestUser stores a user named name.
+func createTestUser(t *testing.T, db *gorm.DB, name string) *models.User {
+	t.Helper()
+	user := &models.User{Username: name, Email: name + "@e.com"}
+	require.NoError(t, db.Create(user).Error)
+	return user
+}
+
+// sanctumFixture is a sanctum with one user in each role and one outsider.
+type sanctumFixture struct {
+	db       *gorm.DB
+	sanctum  *models.Sanctum
+	owner    *models.User
+	mod      *models.User
+	member   *models.User
+	outsider *models.User
+}
+
+// newSanctumFixture creates a public sanctum and its users in a database
+// holding the sanctum tables and the given extra tables.
+func newSanctumFixture(t *testing.T, tables ...interface{}) *sanctumFixture {
+	t.Helper()
+	db := newTestDB(t, append([]interface{}{&models.Sanctum{}, &models.SanctumMembership{}}, tables...)...)
+	f := &sanctumFixture{
+		db:       db,
+		owner:    createTestUser(t, db, "owner"),
+		mod:      createTestUser(t, db, "mod"),
+		member:   createTestUser(t, db, "member"),
+		outsider: createTestUser(t, db, "outsider"),
+	}
+	f.sanctum = &models.Sanctum{Name: "Books", Slug: "books", Status: models.SanctumStatusActive, CreatedByUserID: &f.owner.ID}
+	require.NoError(t, db.Create(f.sanctum).Error)
+	for user, role := range map[*models.User]models.SanctumMembershipRole{
+		f.owner:  models.SanctumMembershipRoleOwner,
+		f.mod:    models.SanctumMembershipRoleMod,
+		f.member: models.SanctumMembershipRoleMember,
+	} {
+		require.NoError(t, db.Create(&models.SanctumMembership{SanctumID: f.sanctum.ID, UserID: user.ID, Role: role}).Error)
+	}
+	return f
+}
+
+// canManage reports whether userID is an owner or moderator of the fixture
+// sanctum.
+func (f *sanctumFixture) canManage(_ context.Context, userID, sanctumID uint) (bool, error) {
+	return sanctumID == f.sanctum.ID && (userID == f.owner.ID || userID == f.mod.ID), nil
+}
+
+// isOwner reports whether userID owns the fixture sanctum.
+func (f *sanctumFixture) isOwner(_ context.Context, userID, sanctumID uint) (bool, error) {
+	return sanctumID == f.sanctum.ID && userID == f.owner.ID, nil
+}
+
+// role returns userID's role in the fixture sanctum, or "" for none.
+func (f *sanctumFixture) role(userID uint) models.SanctumMembershipRole {
+	var m models.SanctumMembership
+	if err := f.db.Where("sanctum_id = ? AND user_id = ?", f.sanctum.ID, userID).First(&m).Error; err != nil {
+		return ""
+	}
+	return m.Role
+}
+
+// requireCode asserts that err is an AppError with the given code.
+func requireCode(t *testing.T, err error, code string) {
+	t.Helper()
+	var appErr *models.AppError
+	require.ErrorAs(t, err, &appErr)
+	require.Equal(t, code, appErr.Code)
+}
+
+// postTables are the tables PostService and CommentService read for posts
+// without gallery images.
+func postTables() []interface{} {
+	return []interface{}{
+		&models.Post{},
+		&models.Poll{},
+		&models.PollOption{},
+		&models.Comment{},
+		&models.Like{},
+		&models.PostImage{},
+		&models.PostTag{},
+		&models.SanctumFlair{},
+	}
+}
+
+// chatFixture is a group room shared by alice, bob, carol and mod, served
+// by a ChatService over the real chat repository. mod moderates every room.
+type chatFixture struct {
+	db    *gorm.DB
+	svc   *ChatService
+	room  *models.Conversation
+	alice *models.User
+	bob   *models.User
+	carol *models.User
+	mod   *models.User
+}
+
+func newChatFixture(t *testing.T) *chatFixture {
+	t.Helper()
+	db := newTestDB(t,
+		&models.Conversation{},
+		&models.ConversationParticipant{},
+		&models.Message{},
+		&models.MessageEdit{},
+		&models.MessageReaction{},
+		&models.MessageMention{},
+		&models.MessageThreadRead{},
+		&models.UserBlock{},
+		&models.ChatroomMute{},
+	)
+	f := &chatFixture{
+		db:    db,
+		alice: createTestUser(t, db, "alice"),
+		bob:   createTestUser(t, db, "bob"),
+		carol: createTestUser(t, db, "carol"),
+		mod:   createTestUser(t, db, "mod"),
+	}
+	f.svc = NewChatService(repository.NewChatRepository(db), repository.NewUserRepository(db), db, nil,
+		func(_ context.Context, userID, _ uint) (bool, error) { return userID == f.mod.ID, nil }, nil)
+	f.room = f.conversation(t, true, f.alice, f.bob, f.carol, f.mod)
+	return f
+}
+
+// conversation creates a room or direct conversation between users.
+func (f *chatFixture) conversation(t *testing.T, group bool, users ...*models.User) *models.Conversation {
+	t.Helper()
+	conv := &models.Conversation{IsGroup: group, CreatedBy: users[0].ID}
+	if group {
+		conv.Name = "Lobby"
+	}
+	require.NoError(t, f.db.Create(conv).Error)
+	for _, u := range users {
+		require.NoError(t, f.db.Create(&models.ConversationParticipant{ConversationID: conv.ID, UserID: u.ID}).Error)
+	}
+	return conv
+}
+
+// post stores a message directly, bypassing SendMessage.
+func (f *chatFixture) post(t *testing.T, conv *models.Conversation, from *models.User, content string) *models.Message {
+	t.Helper()
+	m := &models.Message{ConversationID: conv.ID, SenderID: from.ID, Content: content}
+	require.NoError(t, f.db.Create(m).Error)
+	return m
+}
+
+// send posts a message to the fixture room through SendMessage.
+func (f *chatFixture) send(in SendMessageInput) (*models.Message, error) {
+	in.ConversationID = f.room.ID
+	m, _, err := f.svc.SendMessage(context.Background(), in)
+	return m, err
+}
@@ -38,6 +38,14 @@ const (
 	WebPQuality                 = 70
 )
 
+// Released images are kept for a grace period so an edit that drops and
+// re-adds an image, or a reference racing the release, doesn't lose files.
+const (
+	defaultImageReleaseGrace = 10 * time.Minute
+	releaseSweepInterval     = 5 * time.Minute
+	releaseSweepBatch        = 50
+)
+
 const (
 	ImageSizeOriginal  = "original"
 	ImageSizeThumbnail = "thumbnail"
@@ -66,6 +74,7 @@ type ImageService struct {
 	repo               repository.ImageRepository
 	uploadDir          string
 	maxUploadSizeBytes int64
+	releaseGrace       time.Duration
 	workerOnce         sync.Once
 }
 
@@ -86,6 +95,7 @@ func NewImageService(repo repository.ImageRepository, cfg *config.Config) *Image
 		repo:               repo,
 		uploadDir:          uploadDir,
 		maxUploadSizeBytes: int64(maxUploadSizeMB) * 1024 * 1024,
+		releaseGrace:       defaultImageReleaseGrace,
 	}
 }
 
@@ -95,6 +105,7 @@ func (s *ImageService) StartBackgroundWorker(ctx context.Context) {
 	}
 	s.workerOnce.Do(func() {
 		go s.workerLoop(ctx)
+		go s.releaseSweepLoop(ctx)
 	})
 }
 
@@ -214,6 +225,72 @@ func (s *ImageService) GetByHashWithVariants(ctx context.Context, hash string) (
 	return img, nil
 }
 
+// GetByHashes returns the known images among hashes, keyed by hash.
+func (s *ImageService) GetByHashes(ctx context.Context, hashes []string) (map[string]*models.Image, error) {
+	if s.repo == nil {
+		return nil, models.NewInternalError(errors.New("image repository not configured"))
+	}
+	images, err := s.repo.GetByHashes(ctx, hashes)
+	if err != nil {
+		return nil, models.NewInternalError(err)
+	}
+	byHash := make(map[string]*models.Image, len(images))
+	for i := range images {
+		byHash[images[i].Hash] = &images[i]
+	}
+	return byHash, nil
+}
+
+// ReleaseImages is called after posts drop references to hashes. Images
+// that are no longer referenced by any post are queued for the release
+// sweep, which deletes them and their files if nothing else uses them.
+func (s *ImageService) ReleaseImages(ctx context.Context, hashes []string) {
+	if s.repo == nil || len(hashes) == 0 {
+		return
+	}
+	if err := s.repo.MarkReleased(ctx, hashes); err != nil {
+		log.Printf("image service: release images: %v", err)
+	}
+}
+
+// SweepReleasedImages deletes a batch of released images that are past the
+// grace period and no longer used anywhere. It reports how many it removed.
+func (s *ImageService) SweepReleasedImages(ctx context.Context) int {
+	removed, err := s.repo.DeleteReleased(ctx, time.Now().UTC().Add(-s.releaseGrace), releaseSweepBatch)
+	if err != nil {
+		log.Printf("image service: sweep released images: %v", err)
+	}
+	for _, img := range removed {
+		if !isValidImageHash(img.Hash) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(s.uploadDir, img.Hash)); err != nil {
+			log.Printf("image service: remove files for %s: %v", img.Hash, err)
+		}
+	}
+	return len(removed)
+}
+
+// releaseSweepLoop periodically garbage collects released images. Safe to
+// run on every replica: deletes are guarded on ref_count and idempotent.
+func (s *ImageService) releaseSweepLoop(ctx context.Context) {
+	ticker := time.NewTicker(releaseSweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Drain the backlog in batches before waiting again.
+			for ctx.Err() == nil {
+				if s.SweepReleasedImages(ctx) < releaseSweepBatch {
+					break
+				}
+			}
+		}
+	}
+}
+
 func (s *ImageService) BuildMasterImageURL(hash string) string {
 	return fmt.Sprintf("/media/i/%s/master.jpg", hash)
 }
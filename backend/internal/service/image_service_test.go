@@ -117,6 +117,40 @@ func (s *imageRepoStub) RequeueStaleProcessing(_ context.Context, _ time.Duratio
 	return 0, nil
 }
 
+func (s *imageRepoStub) GetByHashes(_ context.Context, hashes []string) ([]models.Image, error) {
+	var out []models.Image
+	for _, h := range hashes {
+		if item, ok := s.items[h]; ok {
+			out = append(out, *item)
+		}
+	}
+	return out, nil
+}
+
+func (s *imageRepoStub) MarkReleased(_ context.Context, hashes []string) error {
+	now := time.Now()
+	for _, h := range hashes {
+		if item, ok := s.items[h]; ok && item.RefCount <= 0 {
+			item.ReleasedAt = &now
+		}
+	}
+	return nil
+}
+
+func (s *imageRepoStub) DeleteReleased(_ context.Context, releasedBefore time.Time, limit int) ([]models.Image, error) {
+	var removed []models.Image
+	for h, item := range s.items {
+		if len(removed) == limit {
+			break
+		}
+		if item.ReleasedAt != nil && !item.ReleasedAt.After(releasedBefore) && item.RefCount <= 0 {
+			removed = append(removed, *item)
+			delete(s.items, h)
+		}
+	}
+	return removed, nil
+}
+
 func TestImageServiceUploadAndResolve(t *testing.T) {
 	repo := newImageRepoStub()
 	cfg := &config.Config{ImageUploadDir: t.TempDir(), ImageMaxUploadSizeMB: 1}
@@ -0,0 +1,428 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"sanctum/internal/cache"
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	inviteCodeLen     = 10
+	inviteCodeChars   = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	maxInviteUses     = 1000
+	maxInviteLifetime = 30 * 24 * time.Hour
+)
+
+var errInviteUnusable = models.NewValidationError("This invite has expired or is no longer valid")
+
+// InviteService issues and redeems invite codes for sanctums and group
+// conversations.
+type InviteService struct {
+	db *gorm.DB
+	// canManageSanctum reports whether a user moderates the sanctum.
+	canManageSanctum func(ctx context.Context, userID, sanctumID uint) (bool, error)
+	// canModerateChatroom reports whether a user moderates the conversation.
+	canModerateChatroom func(ctx context.Context, userID, roomID uint) (bool, error)
+}
+
+type CreateInviteInput struct {
+	ActorID    uint
+	TargetType models.InviteTargetType
+	TargetID   uint
+	// MaxUses limits how many users may join; 0 means unlimited.
+	MaxUses int
+	// ExpiresIn is the invite's lifetime; 0 means it never expires.
+	ExpiresIn time.Duration
+}
+
+// RedeemedInvite is the outcome of redeeming an invite.
+type RedeemedInvite struct {
+	Invite *models.Invite
+	// RoomID is the chat room the user now has access to: the conversation
+	// itself, or the sanctum's default channel. 0 when the sanctum has none.
+	RoomID uint
+	// Joined is false when the user already had access; the invite is then
+	// not used up.
+	Joined bool
+}
+
+func NewInviteService(
+	db *gorm.DB,
+	canManageSanctum func(ctx context.Context, userID, sanctumID uint) (bool, error),
+	canModerateChatroom func(ctx context.Context, userID, roomID uint) (bool, error),
+) *InviteService {
+	return &InviteService{db: db, canManageSanctum: canManageSanctum, canModerateChatroom: canModerateChatroom}
+}
+
+// CreateInvite issues a new invite code. Any member may invite to a public
+// sanctum; restricted and private sanctums need a moderator, since the code
+// skips join approval. Group conversation invites need a participant.
+func (s *InviteService) CreateInvite(ctx context.Context, in CreateInviteInput) (*models.Invite, error) {
+	if in.MaxUses < 0 || in.MaxUses > maxInviteUses {
+		return nil, models.NewValidationError(fmt.Sprintf("max_uses must be between 0 and %d", maxInviteUses))
+	}
+	if in.ExpiresIn < 0 || in.ExpiresIn > maxInviteLifetime {
+		return nil, models.NewValidationError("Invites can last at most 30 days")
+	}
+
+	invite := models.Invite{
+		TargetType:      in.TargetType,
+		CreatedByUserID: in.ActorID,
+		MaxUses:         in.MaxUses,
+	}
+	switch in.TargetType {
+	case models.InviteTargetSanctum:
+		if err := s.checkCanInviteToSanctum(ctx, in.ActorID, in.TargetID); err != nil {
+			return nil, err
+		}
+		invite.SanctumID = &in.TargetID
+	case models.InviteTargetConversation:
+		if err := s.checkCanInviteToConversation(ctx, in.ActorID, in.TargetID); err != nil {
+			return nil, err
+		}
+		invite.ConversationID = &in.TargetID
+	default:
+		return nil, models.NewValidationError("target_type must be sanctum or conversation")
+	}
+	if in.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(in.ExpiresIn)
+		invite.ExpiresAt = &expiresAt
+	}
+
+	code, err := randomCode(ctx, s.db, &models.Invite{}, inviteCodeLen)
+	if err != nil {
+		return nil, err
+	}
+	invite.Code = code
+	if err := s.db.WithContext(ctx).Create(&invite).Error; err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// GetInvite returns a usable invite with a preview of its target.
+func (s *InviteService) GetInvite(ctx context.Context, code string) (*models.Invite, error) {
+	invite, err := s.findInvite(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if !invite.Usable(time.Now()) {
+		return nil, errInviteUnusable
+	}
+	return invite, nil
+}
+
+// ListInvites returns the invites for a sanctum or conversation, newest
+// first. Moderators see every invite; everyone else sees their own.
+func (s *InviteService) ListInvites(ctx context.Context, actorID uint, targetType models.InviteTargetType, targetID uint) ([]models.Invite, error) {
+	query := s.db.WithContext(ctx).Preload("CreatedBy").Order("created_at DESC, id DESC")
+	switch targetType {
+	case models.InviteTargetSanctum:
+		query = query.Where("sanctum_id = ?", targetID)
+	case models.InviteTargetConversation:
+		query = query.Where("conversation_id = ?", targetID)
+	default:
+		return nil, models.NewValidationError("target_type must be sanctum or conversation")
+	}
+	manager, err := s.canManageTarget(ctx, actorID, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if !manager {
+		query = query.Where("created_by_user_id = ?", actorID)
+	}
+	var invites []models.Invite
+	if err := query.Find(&invites).Error; err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// RevokeInvite disables an invite. Its creator and the target's moderators
+// may revoke it.
+func (s *InviteService) RevokeInvite(ctx context.Context, actorID uint, code string) (*models.Invite, error) {
+	invite, err := s.findInvite(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if invite.CreatedByUserID != actorID {
+		manager, err := s.canManageTarget(ctx, actorID, invite.TargetType, inviteTargetID(invite))
+		if err != nil {
+			return nil, err
+		}
+		if !manager {
+			return nil, models.NewForbiddenError("Only the invite's creator or a moderator can revoke it")
+		}
+	}
+	if invite.RevokedAt != nil {
+		return invite, nil
+	}
+	now := time.Now()
+	if err := s.db.WithContext(ctx).Model(invite).Update("revoked_at", now).Error; err != nil {
+		return nil, err
+	}
+	invite.RevokedAt = &now
+	return invite, nil
+}
+
+// RedeemInvite admits the user to the invite's target. Redeeming a target
+// the user can already access is a no-op that does not use up the invite.
+func (s *InviteService) RedeemInvite(ctx context.Context, userID uint, code string) (*RedeemedInvite, error) {
+	invite, err := s.findInvite(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !invite.Usable(now) {
+		return nil, errInviteUnusable
+	}
+
+	result := &RedeemedInvite{Invite: invite}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		switch invite.TargetType {
+		case models.InviteTargetSanctum:
+			return s.redeemSanctum(ctx, tx, invite, userID, now, result)
+		case models.InviteTargetConversation:
+			return s.redeemConversation(ctx, tx, invite, userID, now, result)
+		}
+		return errInviteUnusable
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Joined {
+		cache.InvalidateUser(ctx, userID)
+		if invite.ConversationID != nil {
+			cache.InvalidateRoom(ctx, *invite.ConversationID)
+		}
+	}
+	return result, nil
+}
+
+func (s *InviteService) redeemSanctum(ctx context.Context, tx *gorm.DB, invite *models.Invite, userID uint, now time.Time, result *RedeemedInvite) error {
+	sanctumID := *invite.SanctumID
+	var sanctum models.Sanctum
+	if err := tx.Select("id", "status").First(&sanctum, sanctumID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errInviteUnusable
+		}
+		return err
+	}
+	if sanctum.Status != models.SanctumStatusActive {
+		return errInviteUnusable
+	}
+	ban, err := activeSanctumBan(ctx, tx, sanctumID, userID)
+	if err != nil {
+		return err
+	}
+	if ban != nil && ban.Kind == models.SanctumBanKindBan {
+		return sanctumBanError(ban)
+	}
+
+	var roomIDs []uint
+	if err := tx.Model(&models.Conversation{}).
+		Where("sanctum_id = ? AND is_default = ?", sanctumID, true).
+		Limit(1).
+		Pluck("id", &roomIDs).Error; err != nil {
+		return err
+	}
+	if len(roomIDs) > 0 {
+		result.RoomID = roomIDs[0]
+	}
+
+	membership := models.SanctumMembership{
+		SanctumID: sanctumID,
+		UserID:    userID,
+		Role:      models.SanctumMembershipRoleMember,
+	}
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&membership)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil
+	}
+	if err := useInvite(tx, invite, userID, now); err != nil {
+		return err
+	}
+	result.Joined = true
+	return SyncSanctumChannelAccess(ctx, tx, sanctumID, userID, true)
+}
+
+func (s *InviteService) redeemConversation(_ context.Context, tx *gorm.DB, invite *models.Invite, userID uint, now time.Time, result *RedeemedInvite) error {
+	var conv models.Conversation
+	if err := tx.Select("id", "is_group").First(&conv, *invite.ConversationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errInviteUnusable
+		}
+		return err
+	}
+	result.RoomID = conv.ID
+
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ConversationParticipant{
+		ConversationID: conv.ID,
+		UserID:         userID,
+	})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil
+	}
+	if err := useInvite(tx, invite, userID, now); err != nil {
+		return err
+	}
+	result.Joined = true
+	return nil
+}
+
+// useInvite counts the user towards the invite's uses, failing when the
+// invite ran out in the meantime. Users who rejoin are only counted once.
+func useInvite(tx *gorm.DB, invite *models.Invite, userID uint, now time.Time) error {
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.InviteRedemption{InviteID: invite.ID, UserID: userID})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil
+	}
+	res = tx.Model(&models.Invite{}).
+		Where("id = ? AND revoked_at IS NULL AND (max_uses = 0 OR uses < max_uses)", invite.ID).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		UpdateColumn("uses", gorm.Expr("uses + 1"))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errInviteUnusable
+	}
+	invite.Uses++
+	return nil
+}
+
+func (s *InviteService) checkCanInviteToSanctum(ctx context.Context, actorID, sanctumID uint) error {
+	var sanctum models.Sanctum
+	if err := s.db.WithContext(ctx).First(&sanctum, sanctumID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NewNotFoundError("Sanctum", sanctumID)
+		}
+		return err
+	}
+	manager, err := s.canManageSanctum(ctx, actorID, sanctumID)
+	if err != nil || manager {
+		return err
+	}
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.SanctumMembership{}).
+		Where("sanctum_id = ? AND user_id = ?", sanctumID, actorID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return models.NewForbiddenError("Only members can invite to this sanctum")
+	}
+	if sanctum.Visibility != "" && sanctum.Visibility != models.SanctumVisibilityPublic {
+		return models.NewForbiddenError("Only moderators can invite to this sanctum")
+	}
+	return nil
+}
+
+func (s *InviteService) checkCanInviteToConversation(ctx context.Context, actorID, convID uint) error {
+	var conv models.Conversation
+	if err := s.db.WithContext(ctx).First(&conv, convID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NewNotFoundError("Conversation", convID)
+		}
+		return err
+	}
+	if !conv.IsGroup {
+		return models.NewValidationError("Only group conversations can have invites")
+	}
+	if conv.SanctumID != nil {
+		return models.NewValidationError("Sanctum channels follow membership; invite people to the sanctum instead")
+	}
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ? AND user_id = ?", convID, actorID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return models.NewForbiddenError("You are not a participant in this conversation")
+	}
+	return nil
+}
+
+func (s *InviteService) canManageTarget(ctx context.Context, actorID uint, targetType models.InviteTargetType, targetID uint) (bool, error) {
+	switch targetType {
+	case models.InviteTargetSanctum:
+		return s.canManageSanctum(ctx, actorID, targetID)
+	case models.InviteTargetConversation:
+		if s.canModerateChatroom == nil {
+			return false, nil
+		}
+		return s.canModerateChatroom(ctx, actorID, targetID)
+	}
+	return false, nil
+}
+
+func (s *InviteService) findInvite(ctx context.Context, code string) (*models.Invite, error) {
+	code = strings.TrimSpace(code)
+	var invite models.Invite
+	err := s.db.WithContext(ctx).
+		Preload("Sanctum").
+		Preload("Conversation").
+		Where("code = ?", code).
+		First(&invite).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.NewNotFoundError("Invite", code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+func inviteTargetID(invite *models.Invite) uint {
+	if invite.SanctumID != nil {
+		return *invite.SanctumID
+	}
+	if invite.ConversationID != nil {
+		return *invite.ConversationID
+	}
+	return 0
+}
+
+// randomCode returns a random code of the given length that no row of
+// model uses yet. Invites and signup invites share the alphabet.
+func randomCode(ctx context.Context, db *gorm.DB, model any, length int) (string, error) {
+	limit := big.NewInt(int64(len(inviteCodeChars)))
+	for attempt := 0; attempt < 3; attempt++ {
+		buf := make([]byte, length)
+		for i := range buf {
+			n, err := rand.Int(rand.Reader, limit)
+			if err != nil {
+				return "", err
+			}
+			buf[i] = inviteCodeChars[n.Int64()]
+		}
+		var count int64
+		if err := db.WithContext(ctx).Model(model).Where("code = ?", string(buf)).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return string(buf), nil
+		}
+	}
+	return "", errors.New("could not generate a unique invite code")
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inviteFixture is the fixture sanctum with a default channel, a group
+// conversation the member started, and a user banned from the sanctum.
+type inviteFixture struct {
+	*sanctumFixture
+	invites *InviteService
+	channel *models.Conversation
+	group   *models.Conversation
+	banned  *models.User
+}
+
+func newInviteFixture(t *testing.T, visibility models.SanctumVisibility) *inviteFixture {
+	t.Helper()
+	sf := newSanctumFixture(t,
+		&models.Conversation{},
+		&models.ConversationParticipant{},
+		&models.SanctumBan{},
+		&models.Invite{},
+		&models.InviteRedemption{},
+	)
+	require.NoError(t, sf.db.Model(sf.sanctum).Update("visibility", visibility).Error)
+	f := &inviteFixture{
+		sanctumFixture: sf,
+		invites:        NewInviteService(sf.db, sf.canManage, nil),
+		channel:        &models.Conversation{Name: "Books", IsGroup: true, SanctumID: &sf.sanctum.ID, IsDefault: true},
+		group:          &models.Conversation{Name: "Book club", IsGroup: true, CreatedBy: sf.member.ID},
+		banned:         createTestUser(t, sf.db, "banned"),
+	}
+	require.NoError(t, f.db.Create(f.channel).Error)
+	require.NoError(t, f.db.Create(f.group).Error)
+	require.NoError(t, f.db.Create(&models.ConversationParticipant{ConversationID: f.group.ID, UserID: f.member.ID}).Error)
+	require.NoError(t, f.db.Create(&models.SanctumBan{
+		SanctumID: f.sanctum.ID, UserID: f.banned.ID, Kind: models.SanctumBanKindBan, CreatedByUserID: f.mod.ID,
+	}).Error)
+	return f
+}
+
+func TestInviteService_CreateInvite(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility models.SanctumVisibility
+		in         func(f *inviteFixture) CreateInviteInput
+		code       string
+	}{
+		{"members invite to public sanctums", models.SanctumVisibilityPublic, func(f *inviteFixture) CreateInviteInput {
+			return CreateInviteInput{ActorID: f.member.ID, TargetType: models.InviteTargetSanctum, TargetID: f.sanctum.ID}
+		}, ""},
+		{"private sanctums need a moderator", models.SanctumVisibilityPrivate, func(f *inviteFixture) CreateInviteInput {
+			return CreateInviteInput{ActorID: f.member.ID, TargetType: models.InviteTargetSanctum, TargetID: f.sanctum.ID}
+		}, "FORBIDDEN"},
+		{"moderators invite to private sanctums", models.SanctumVisibilityPrivate, func(f *inviteFixture) CreateInviteInput {
+			return CreateInviteInput{ActorID: f.mod.ID, TargetType: models.InviteTargetSanctum, TargetID: f.sanctum.ID}
+		}, ""},
+		{"outsiders cannot", models.SanctumVisibilityPublic, func(f *inviteFixture) CreateInviteInput {
+			return CreateInviteInput{ActorID: f.outsider.ID, TargetType: models.InviteTargetSanctum, TargetID: f.sanctum.ID}
+		}, "FORBIDDEN"},
+		{"at most 30 days", models.SanctumVisibilityPublic, func(f *inviteFixture) CreateInviteInput {
+			return CreateInviteInput{ActorID: f.mod.ID, TargetType: models.InviteTargetSanctum, TargetID: f.sanctum.ID, ExpiresIn: 60 * 24 * time.Hour}
+		}, "VALIDATION_ERROR"},
+		{"unknown target type", models.SanctumVisibilityPublic, func(f *inviteFixture) CreateInviteInput {
+			return CreateInviteInput{ActorID: f.mod.ID, TargetType: "post", TargetID: 1}
+		}, "VALIDATION_ERROR"},
+		{"group participants", models.SanctumVisibilityPublic, func(f *inviteFixture) CreateInviteInput {
+			return CreateInviteInput{ActorID: f.member.ID, TargetType: models.InviteTargetConversation, TargetID: f.group.ID}
+		}, ""},
+		{"group outsiders", models.SanctumVisibilityPublic, func(f *inviteFixture) CreateInviteInput {
+			return CreateInviteInput{ActorID: f.outsider.ID, TargetType: models.InviteTargetConversation, TargetID: f.group.ID}
+		}, "FORBIDDEN"},
+		{"sanctum channels", models.SanctumVisibilityPublic, func(f *inviteFixture) CreateInviteInput {
+			return CreateInviteInput{ActorID: f.mod.ID, TargetType: models.InviteTargetConversation, TargetID: f.channel.ID}
+		}, "VALIDATION_ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newInviteFixture(t, tt.visibility)
+			invite, err := f.invites.CreateInvite(context.Background(), tt.in(f))
+			if tt.code != "" {
+				requireCode(t, err, tt.code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, invite.Code, inviteCodeLen)
+		})
+	}
+}
+
+func TestInviteService_RedeemSanctumInvite(t *testing.T) {
+	f := newInviteFixture(t, models.SanctumVisibilityPrivate)
+	ctx := context.Background()
+	invite, err := f.invites.CreateInvite(ctx, CreateInviteInput{
+		ActorID: f.mod.ID, TargetType: models.InviteTargetSanctum, TargetID: f.sanctum.ID, MaxUses: 1, ExpiresIn: time.Hour,
+	})
+	require.NoError(t, err)
+	preview, err := f.invites.GetInvite(ctx, invite.Code)
+	require.NoError(t, err)
+	assert.Equal(t, "books", preview.Sanctum.Slug)
+
+	_, err = f.invites.RedeemInvite(ctx, f.banned.ID, invite.Code)
+	requireCode(t, err, "FORBIDDEN")
+
+	// Existing members do not use up the invite.
+	redeemed, err := f.invites.RedeemInvite(ctx, f.member.ID, invite.Code)
+	require.NoError(t, err)
+	assert.False(t, redeemed.Joined)
+
+	redeemed, err = f.invites.RedeemInvite(ctx, f.outsider.ID, invite.Code)
+	require.NoError(t, err)
+	assert.True(t, redeemed.Joined)
+	assert.Equal(t, f.channel.ID, redeemed.RoomID)
+	assert.Equal(t, models.SanctumMembershipRoleMember, f.role(f.outsider.ID))
+	assert.Contains(t, channelParticipants(t, f.sanctumFixture, f.channel.ID), f.outsider.ID,
+		"joining the sanctum opens its channels")
+
+	// The single use is spent.
+	require.NoError(t, f.db.Where("sanctum_id = ? AND user_id = ?", f.sanctum.ID, f.outsider.ID).Delete(&models.SanctumMembership{}).Error)
+	_, err = f.invites.GetInvite(ctx, invite.Code)
+	assert.Error(t, err)
+	_, err = f.invites.RedeemInvite(ctx, f.outsider.ID, invite.Code)
+	assert.Error(t, err)
+}
+
+func TestInviteService_ConversationInvites(t *testing.T) {
+	f := newInviteFixture(t, models.SanctumVisibilityPublic)
+	ctx := context.Background()
+	invite, err := f.invites.CreateInvite(ctx, CreateInviteInput{
+		ActorID: f.member.ID, TargetType: models.InviteTargetConversation, TargetID: f.group.ID,
+	})
+	require.NoError(t, err)
+
+	listed, err := f.invites.ListInvites(ctx, f.outsider.ID, models.InviteTargetConversation, f.group.ID)
+	require.NoError(t, err)
+	assert.Empty(t, listed, "non-moderators only see their own invites")
+	listed, err = f.invites.ListInvites(ctx, f.member.ID, models.InviteTargetConversation, f.group.ID)
+	require.NoError(t, err)
+	assert.Len(t, listed, 1)
+
+	redeemed, err := f.invites.RedeemInvite(ctx, f.outsider.ID, invite.Code)
+	require.NoError(t, err)
+	assert.True(t, redeemed.Joined)
+	assert.Equal(t, f.group.ID, redeemed.RoomID)
+
+	_, err = f.invites.RevokeInvite(ctx, f.outsider.ID, invite.Code)
+	requireCode(t, err, "FORBIDDEN")
+	revoked, err := f.invites.RevokeInvite(ctx, f.member.ID, invite.Code)
+	require.NoError(t, err)
+	assert.NotNil(t, revoked.RevokedAt)
+	_, err = f.invites.RedeemInvite(ctx, f.mod.ID, invite.Code)
+	assert.Error(t, err)
+}
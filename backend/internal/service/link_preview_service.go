@@ -0,0 +1,441 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"sanctum/internal/cache"
+	"sanctum/internal/models"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	linkPreviewTimeout        = 5 * time.Second
+	linkPreviewMaxRedirects   = 3
+	linkPreviewMaxHTMLBytes   = 1 << 20 // 1MB; metadata lives in <head>
+	linkPreviewMaxOEmbedBytes = 256 << 10
+	linkPreviewMaxImageBytes  = 5 << 20
+	linkPreviewMaxURLLen      = 2048
+	linkPreviewUserAgent      = "SanctumBot/1.0 (+link preview)"
+
+	maxPreviewTitleLen       = 300
+	maxPreviewDescriptionLen = 1000
+	maxPreviewSiteNameLen    = 100
+)
+
+var errBlockedAddress = errors.New("link preview: destination address is not allowed")
+
+// blockedNetworks are special-purpose ranges not covered by the net.IP helpers.
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",       // "this" network
+	"100.64.0.0/10",   // carrier-grade NAT
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // TEST-NET-1
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // TEST-NET-2
+	"203.0.113.0/24",  // TEST-NET-3
+	"240.0.0.0/4",     // reserved
+	"64:ff9b::/96",    // NAT64 (can reach embedded IPv4 addresses)
+	"2001:db8::/32",   // documentation
+)
+
+var linkInTextPattern = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `]+`)
+
+// LinkPreviewService unfurls external URLs into previews. All outbound
+// requests go through an HTTP client that refuses to connect to private,
+// loopback or otherwise internal addresses, caps redirects, and bounds
+// response sizes and time.
+type LinkPreviewService struct {
+	imageSvc *ImageService
+	client   *http.Client
+
+	// allowPrivateNetworks disables the destination address check so tests
+	// can point the unfurler at an httptest server on loopback.
+	allowPrivateNetworks bool
+}
+
+func NewLinkPreviewService(imageSvc *ImageService) *LinkPreviewService {
+	s := &LinkPreviewService{imageSvc: imageSvc}
+
+	dialer := &net.Dialer{
+		Timeout: linkPreviewTimeout,
+		// Control runs after DNS resolution with the concrete IP being dialed,
+		// which also defeats DNS-rebinding tricks.
+		Control: s.checkDialAddress,
+	}
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   linkPreviewTimeout,
+		ResponseHeaderTimeout: linkPreviewTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	s.client = &http.Client{
+		Transport: transport,
+		Timeout:   2 * linkPreviewTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= linkPreviewMaxRedirects {
+				return fmt.Errorf("link preview: stopped after %d redirects", linkPreviewMaxRedirects)
+			}
+			return s.checkURL(req.URL)
+		},
+	}
+	return s
+}
+
+// Unfurl returns a preview for rawURL, serving from cache when possible.
+// userID owns any preview image stored through ImageService.
+func (s *LinkPreviewService) Unfurl(ctx context.Context, rawURL string, userID uint) (*models.LinkPreview, error) {
+	target, err := s.parseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var preview models.LinkPreview
+	err = cache.Aside(ctx, cache.LinkPreviewKey(target.String()), &preview, cache.LinkPreviewTTL, func() error {
+		fetched, fetchErr := s.fetch(ctx, target, userID)
+		if fetchErr != nil {
+			return fetchErr
+		}
+		preview = *fetched
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &preview, nil
+}
+
+// FirstLinkInText returns the first http(s) URL in free text, or "".
+func FirstLinkInText(text string) string {
+	match := linkInTextPattern.FindString(text)
+	// Trailing punctuation is almost always sentence punctuation, not URL.
+	return strings.TrimRight(match, ".,;:!?)]}")
+}
+
+func (s *LinkPreviewService) fetch(ctx context.Context, target *url.URL, userID uint) (*models.LinkPreview, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*linkPreviewTimeout)
+	defer cancel()
+
+	resp, err := s.get(ctx, target, "text/html,application/xhtml+xml")
+	if err != nil {
+		return nil, models.NewValidationError("Could not fetch link")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, models.NewValidationError(fmt.Sprintf("Link returned HTTP %d", resp.StatusCode))
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, models.NewValidationError("Link does not point to a web page")
+	}
+
+	meta := parsePageMeta(io.LimitReader(resp.Body, linkPreviewMaxHTMLBytes))
+	final := resp.Request.URL
+
+	preview := &models.LinkPreview{
+		URL:         final.String(),
+		Title:       firstNonEmpty(meta.props["og:title"], meta.props["twitter:title"], meta.title),
+		Description: firstNonEmpty(meta.props["og:description"], meta.props["twitter:description"], meta.props["description"]),
+		SiteName:    firstNonEmpty(meta.props["og:site_name"], meta.props["application-name"]),
+	}
+	imageRef := firstNonEmpty(meta.props["og:image:secure_url"], meta.props["og:image"], meta.props["twitter:image"], meta.props["twitter:image:src"])
+
+	if meta.oembedURL != "" && (preview.Title == "" || imageRef == "" || preview.SiteName == "") {
+		if oembed, oerr := s.fetchOEmbed(ctx, final, meta.oembedURL); oerr == nil {
+			preview.Title = firstNonEmpty(preview.Title, oembed.Title)
+			preview.SiteName = firstNonEmpty(preview.SiteName, oembed.ProviderName)
+			if preview.Description == "" && oembed.AuthorName != "" {
+				preview.Description = "by " + oembed.AuthorName
+			}
+			imageRef = firstNonEmpty(imageRef, oembed.ThumbnailURL)
+		}
+	}
+	if preview.SiteName == "" {
+		preview.SiteName = final.Hostname()
+	}
+
+	preview.Title = truncateRunes(collapseWhitespace(preview.Title), maxPreviewTitleLen)
+	preview.Description = truncateRunes(collapseWhitespace(preview.Description), maxPreviewDescriptionLen)
+	preview.SiteName = truncateRunes(collapseWhitespace(preview.SiteName), maxPreviewSiteNameLen)
+
+	if imageRef != "" && s.imageSvc != nil && userID != 0 {
+		if ref, perr := final.Parse(imageRef); perr == nil {
+			// The preview still renders without an image, so a failed
+			// download or a rejected image is not an error.
+			if img, ierr := s.storeImage(ctx, ref, userID); ierr == nil {
+				preview.ImageURL = s.imageSvc.BuildMasterImageURL(img.Hash)
+				if len(img.Variants) > 0 {
+					preview.ImageVariants = s.imageSvc.BuildVariantsMap(img.Hash, img.Variants)
+				}
+			}
+		}
+	}
+
+	return preview, nil
+}
+
+type oembedResponse struct {
+	Title        string `json:"title"`
+	AuthorName   string `json:"author_name"`
+	ProviderName string `json:"provider_name"`
+	ThumbnailURL string `json:"thumbnail_url"`
+}
+
+func (s *LinkPreviewService) fetchOEmbed(ctx context.Context, base *url.URL, href string) (*oembedResponse, error) {
+	endpoint, err := base.Parse(href)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkURL(endpoint); err != nil {
+		return nil, err
+	}
+	resp, err := s.get(ctx, endpoint, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oembed: HTTP %d", resp.StatusCode)
+	}
+	var out oembedResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, linkPreviewMaxOEmbedBytes)).Decode(&out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (s *LinkPreviewService) storeImage(ctx context.Context, ref *url.URL, userID uint) (*models.Image, error) {
+	if err := s.checkURL(ref); err != nil {
+		return nil, err
+	}
+	resp, err := s.get(ctx, ref, "image/*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("preview image: HTTP %d", resp.StatusCode)
+	}
+	if resp.ContentLength > linkPreviewMaxImageBytes {
+		return nil, errors.New("preview image: too large")
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, linkPreviewMaxImageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > linkPreviewMaxImageBytes {
+		return nil, errors.New("preview image: too large")
+	}
+
+	return s.imageSvc.Upload(ctx, UploadImageInput{
+		UserID:      userID,
+		Filename:    path.Base(ref.Path),
+		ContentType: resp.Header.Get("Content-Type"),
+		Content:     body,
+	})
+}
+
+func (s *LinkPreviewService) get(ctx context.Context, target *url.URL, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", linkPreviewUserAgent)
+	req.Header.Set("Accept", accept)
+	return s.client.Do(req)
+}
+
+// parseURL validates user input before anything is fetched.
+func (s *LinkPreviewService) parseURL(rawURL string) (*url.URL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return nil, models.NewValidationError("url is required")
+	}
+	if len(rawURL) > linkPreviewMaxURLLen {
+		return nil, models.NewValidationError("url is too long")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, models.NewValidationError("url must be a valid URL")
+	}
+	u.Fragment = ""
+	if err := s.checkURL(u); err != nil {
+		return nil, models.NewValidationError("url is not allowed")
+	}
+	return u, nil
+}
+
+// checkURL rejects non-http(s) schemes, embedded credentials and literal
+// internal addresses. Hostnames are checked again at dial time.
+func (s *LinkPreviewService) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("link preview: unsupported scheme")
+	}
+	if u.User != nil {
+		return errors.New("link preview: credentials in URL are not allowed")
+	}
+	host := u.Hostname()
+	if host == "" {
+		return errors.New("link preview: missing host")
+	}
+	if s.allowPrivateNetworks {
+		return nil
+	}
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return errBlockedAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && isBlockedIP(ip) {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+func (s *LinkPreviewService) checkDialAddress(_, address string, _ syscall.RawConn) error {
+	if s.allowPrivateNetworks {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isBlockedIP(ip) {
+		return errBlockedAddress
+	}
+	return nil
+}
+
+func isBlockedIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, n := range blockedNetworks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+type pageMeta struct {
+	title     string
+	props     map[string]string // first value of each meta property/name, lower-cased key
+	oembedURL string
+}
+
+// parsePageMeta scans the document head for title, meta and oEmbed link tags.
+// It stops at </head> or <body> since preview metadata never lives later.
+func parsePageMeta(r io.Reader) pageMeta {
+	meta := pageMeta{props: map[string]string{}}
+	z := html.NewTokenizer(r)
+	inTitle := false
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return meta
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			switch string(name) {
+			case "body":
+				return meta
+			case "title":
+				inTitle = tt == html.StartTagToken
+			case "meta":
+				attrs := tagAttrs(z, hasAttr)
+				key := strings.ToLower(firstNonEmpty(attrs["property"], attrs["name"]))
+				content := strings.TrimSpace(attrs["content"])
+				if key == "" || content == "" {
+					continue
+				}
+				if _, seen := meta.props[key]; !seen {
+					meta.props[key] = content
+				}
+			case "link":
+				attrs := tagAttrs(z, hasAttr)
+				if meta.oembedURL == "" &&
+					strings.Contains(strings.ToLower(attrs["rel"]), "alternate") &&
+					strings.EqualFold(attrs["type"], "application/json+oembed") {
+					meta.oembedURL = attrs["href"]
+				}
+			}
+		case html.TextToken:
+			if inTitle && meta.title == "" {
+				meta.title = strings.TrimSpace(string(z.Text()))
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return meta
+			}
+		}
+	}
+}
+
+func tagAttrs(z *html.Tokenizer, hasAttr bool) map[string]string {
+	attrs := map[string]string{}
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = z.TagAttr()
+		attrs[string(key)] = string(val)
+	}
+	return attrs
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func collapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func truncateRunes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:max-1])) + "…"
+}
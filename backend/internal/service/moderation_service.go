@@ -23,6 +23,9 @@ type AdminUserDetail struct {
 	ActiveMutes    []models.ChatroomMute     `json:"active_mutes"`
 	BlocksGiven    []models.UserBlock        `json:"blocks_given"`
 	BlocksReceived []models.UserBlock        `json:"blocks_received"`
+	InvitedBy      *models.User              `json:"invited_by"` // Issuer of the signup invite used
+	Invitees       []models.User             `json:"invitees"`   // Accounts created with this user's invites
+	InviteQuota    *int                      `json:"invite_quota,omitempty"`
 	Warnings       []string                  `json:"warnings,omitempty"`
 }
 
@@ -131,5 +134,24 @@ func (s *ModerationService) GetAdminUserDetail(ctx context.Context, userID uint)
 		detail.Warnings = append(detail.Warnings, "Partial data: Incoming blocks could not be loaded.")
 	}
 
+	// 5. Signup invite attribution
+	if user.InvitedByUserID != nil {
+		var inviter models.User
+		if err := s.db.WithContext(ctx).First(&inviter, *user.InvitedByUserID).Error; err != nil {
+			log.Printf("[ModerationService] Warning: Failed to load inviter for user %d: %v", userID, err)
+			detail.Warnings = append(detail.Warnings, "Partial data: Inviting user could not be loaded.")
+		} else {
+			detail.InvitedBy = &inviter
+		}
+	}
+	if err := s.db.WithContext(ctx).
+		Where("invited_by_user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(200).
+		Find(&detail.Invitees).Error; err != nil {
+		log.Printf("[ModerationService] Warning: Failed to load invitees for user %d: %v", userID, err)
+		detail.Warnings = append(detail.Warnings, "Partial data: Invited users could not be loaded.")
+	}
+
 	return detail, nil
 }
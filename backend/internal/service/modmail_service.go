@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const maxModmailBodyLen = 10000
+
+// ModmailService runs the shared inbox between users and a sanctum's mod
+// team.
+type ModmailService struct {
+	db *gorm.DB
+	// canManage reports whether a user is on a sanctum's mod team.
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error)
+}
+
+type ListModmailInput struct {
+	ActorID   uint
+	SanctumID uint
+	Status    models.ModmailStatus
+	// AssignedTo filters to threads assigned to one moderator when non-zero.
+	AssignedTo uint
+	Limit      int
+	Offset     int
+}
+
+func NewModmailService(
+	db *gorm.DB,
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error),
+) *ModmailService {
+	return &ModmailService{db: db, canManage: canManage}
+}
+
+func (s *ModmailService) isMod(ctx context.Context, userID, sanctumID uint) (bool, error) {
+	if s.canManage == nil {
+		return false, nil
+	}
+	return s.canManage(ctx, userID, sanctumID)
+}
+
+func normalizeModmailBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", models.NewValidationError("Message is required")
+	}
+	if len(body) > maxModmailBodyLen {
+		return "", models.NewValidationError("Message too long (max 10000 characters)")
+	}
+	return body, nil
+}
+
+// CreateThread opens a modmail thread from a user to a sanctum's mod team.
+func (s *ModmailService) CreateThread(ctx context.Context, userID, sanctumID uint, subject, body string) (*models.ModmailThread, error) {
+	subject = strings.TrimSpace(subject)
+	if subject == "" || utf8.RuneCountInString(subject) > 200 {
+		return nil, models.NewValidationError("Subject must be 1-200 characters")
+	}
+	body, err := normalizeModmailBody(body)
+	if err != nil {
+		return nil, err
+	}
+	mod, err := s.isMod(ctx, userID, sanctumID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	thread := models.ModmailThread{
+		SanctumID:     sanctumID,
+		UserID:        userID,
+		Subject:       subject,
+		Status:        models.ModmailStatusOpen,
+		LastMessageAt: now,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&thread).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.ModmailMessage{
+			ThreadID: thread.ID,
+			AuthorID: userID,
+			Body:     body,
+			FromMod:  mod,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetThread(ctx, userID, thread.ID)
+}
+
+// loadThread returns a thread the actor may see, and whether the actor
+// reads it as a moderator. Threads the actor cannot see are reported as not
+// found.
+func (s *ModmailService) loadThread(ctx context.Context, actorID, threadID uint) (*models.ModmailThread, bool, error) {
+	var thread models.ModmailThread
+	if err := s.db.WithContext(ctx).First(&thread, threadID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, models.NewNotFoundError("Modmail thread", threadID)
+		}
+		return nil, false, err
+	}
+	mod, err := s.isMod(ctx, actorID, thread.SanctumID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !mod && thread.UserID != actorID {
+		return nil, false, models.NewNotFoundError("Modmail thread", threadID)
+	}
+	return &thread, mod, nil
+}
+
+// GetThread returns a thread with its messages, oldest first. Internal
+// notes are included for moderators only.
+func (s *ModmailService) GetThread(ctx context.Context, actorID, threadID uint) (*models.ModmailThread, error) {
+	thread, mod, err := s.loadThread(ctx, actorID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	query := s.db.WithContext(ctx).Preload("Author").Where("thread_id = ?", thread.ID)
+	if !mod {
+		query = query.Where("internal = ?", false)
+	}
+	messages := []models.ModmailMessage{}
+	if err := query.Order("created_at ASC, id ASC").Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Preload("Sanctum").Preload("User").Preload("AssignedTo").
+		First(thread, thread.ID).Error; err != nil {
+		return nil, err
+	}
+	thread.Messages = messages
+	return thread, nil
+}
+
+// ListForSanctum returns a sanctum's modmail threads, most recently active
+// first. Mod team only.
+func (s *ModmailService) ListForSanctum(ctx context.Context, in ListModmailInput) ([]models.ModmailThread, error) {
+	mod, err := s.isMod(ctx, in.ActorID, in.SanctumID)
+	if err != nil {
+		return nil, err
+	}
+	if !mod {
+		return nil, models.NewForbiddenError("Sanctum moderator access required")
+	}
+	status := in.Status
+	if status == "" {
+		status = models.ModmailStatusOpen
+	}
+	if status != models.ModmailStatusOpen && status != models.ModmailStatusArchived {
+		return nil, models.NewValidationError("status must be open or archived")
+	}
+	query := s.db.WithContext(ctx).
+		Preload("User").
+		Preload("AssignedTo").
+		Where("sanctum_id = ? AND status = ?", in.SanctumID, status)
+	if in.AssignedTo != 0 {
+		query = query.Where("assigned_to_user_id = ?", in.AssignedTo)
+	}
+	threads := []models.ModmailThread{}
+	if err := query.Order("last_message_at DESC, id DESC").
+		Limit(in.Limit).
+		Offset(in.Offset).
+		Find(&threads).Error; err != nil {
+		return nil, err
+	}
+	return threads, nil
+}
+
+// ListMine returns the threads a user has opened, most recently active
+// first.
+func (s *ModmailService) ListMine(ctx context.Context, userID uint, limit, offset int) ([]models.ModmailThread, error) {
+	threads := []models.ModmailThread{}
+	if err := s.db.WithContext(ctx).
+		Preload("Sanctum").
+		Where("user_id = ?", userID).
+		Order("last_message_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&threads).Error; err != nil {
+		return nil, err
+	}
+	return threads, nil
+}
+
+// Reply adds a message to a thread. Only moderators may post internal
+// notes. A user replying to an archived thread reopens it.
+func (s *ModmailService) Reply(ctx context.Context, actorID, threadID uint, body string, internal bool) (*models.ModmailThread, *models.ModmailMessage, error) {
+	thread, mod, err := s.loadThread(ctx, actorID, threadID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if internal && !mod {
+		return nil, nil, models.NewForbiddenError("Only moderators can add internal notes")
+	}
+	if body, err = normalizeModmailBody(body); err != nil {
+		return nil, nil, err
+	}
+
+	message := models.ModmailMessage{
+		ThreadID: thread.ID,
+		AuthorID: actorID,
+		Body:     body,
+		FromMod:  mod,
+		Internal: internal,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+		updates := map[string]any{"last_message_at": message.CreatedAt}
+		if !mod && thread.Status == models.ModmailStatusArchived {
+			updates["status"] = models.ModmailStatusOpen
+			thread.Status = models.ModmailStatusOpen
+		}
+		return tx.Model(&models.ModmailThread{}).Where("id = ?", thread.ID).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	thread.LastMessageAt = message.CreatedAt
+	if err := s.db.WithContext(ctx).Preload("Author").First(&message, message.ID).Error; err != nil {
+		return nil, nil, err
+	}
+	return thread, &message, nil
+}
+
+func (s *ModmailService) loadThreadAsMod(ctx context.Context, actorID, threadID uint) (*models.ModmailThread, error) {
+	thread, mod, err := s.loadThread(ctx, actorID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if !mod {
+		return nil, models.NewForbiddenError("Sanctum moderator access required")
+	}
+	return thread, nil
+}
+
+// Assign hands a thread to one moderator, or clears the assignment when
+// assigneeID is nil.
+func (s *ModmailService) Assign(ctx context.Context, actorID, threadID uint, assigneeID *uint) (*models.ModmailThread, error) {
+	thread, err := s.loadThreadAsMod(ctx, actorID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	if assigneeID != nil {
+		ok, err := s.isMod(ctx, *assigneeID, thread.SanctumID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, models.NewValidationError("Threads can only be assigned to the sanctum's moderators")
+		}
+	}
+	if err := s.db.WithContext(ctx).Model(&models.ModmailThread{}).
+		Where("id = ?", thread.ID).
+		Update("assigned_to_user_id", assigneeID).Error; err != nil {
+		return nil, err
+	}
+	thread.AssignedToUserID = assigneeID
+	return thread, nil
+}
+
+// SetArchived archives or reopens a thread.
+func (s *ModmailService) SetArchived(ctx context.Context, actorID, threadID uint, archived bool) (*models.ModmailThread, error) {
+	thread, err := s.loadThreadAsMod(ctx, actorID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	status := models.ModmailStatusOpen
+	if archived {
+		status = models.ModmailStatusArchived
+	}
+	if err := s.db.WithContext(ctx).Model(&models.ModmailThread{}).
+		Where("id = ?", thread.ID).
+		Update("status", status).Error; err != nil {
+		return nil, err
+	}
+	thread.Status = status
+	return thread, nil
+}
+
+// TeamIDs returns the owners and moderators of a sanctum, who share its
+// modmail inbox.
+func (s *ModmailService) TeamIDs(ctx context.Context, sanctumID uint) ([]uint, error) {
+	var ids []uint
+	err := s.db.WithContext(ctx).Model(&models.SanctumMembership{}).
+		Where("sanctum_id = ? AND role IN ?", sanctumID, []models.SanctumMembershipRole{
+			models.SanctumMembershipRoleOwner,
+			models.SanctumMembershipRoleMod,
+		}).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"sanctum/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newModmailTestService returns a modmail service and a thread the
+// fixture member opened with the sanctum's mod team.
+func newModmailTestService(t *testing.T) (*ModmailService, *sanctumFixture, *models.ModmailThread) {
+	t.Helper()
+	f := newSanctumFixture(t, &models.ModmailThread{}, &models.ModmailMessage{})
+	modmail := NewModmailService(f.db, f.canManage)
+	thread, err := modmail.CreateThread(context.Background(), f.member.ID, f.sanctum.ID, "Removed post", "Why was my post removed?")
+	require.NoError(t, err)
+	return modmail, f, thread
+}
+
+func TestModmailService_CreateThread(t *testing.T) {
+	modmail, f, thread := newModmailTestService(t)
+	ctx := context.Background()
+	require.Len(t, thread.Messages, 1)
+	assert.False(t, thread.Messages[0].FromMod)
+
+	tests := []struct {
+		name    string
+		subject string
+		body    string
+	}{
+		{"subject required", " ", "hi"},
+		{"body required", "Hello", "  "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := modmail.CreateThread(ctx, f.member.ID, f.sanctum.ID, tt.subject, tt.body)
+			requireCode(t, err, "VALIDATION_ERROR")
+		})
+	}
+
+	team, err := modmail.TeamIDs(ctx, f.sanctum.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint{f.owner.ID, f.mod.ID}, team)
+	mine, err := modmail.ListMine(ctx, f.member.ID, 50, 0)
+	require.NoError(t, err)
+	require.Len(t, mine, 1)
+	assert.Equal(t, "books", mine[0].Sanctum.Slug)
+}
+
+func TestModmailService_Visibility(t *testing.T) {
+	modmail, f, thread := newModmailTestService(t)
+	ctx := context.Background()
+	_, _, err := modmail.Reply(ctx, f.mod.ID, thread.ID, "It broke rule 2", true)
+	require.NoError(t, err)
+	_, reply, err := modmail.Reply(ctx, f.owner.ID, thread.ID, "It broke rule 2, sorry.", false)
+	require.NoError(t, err)
+	assert.True(t, reply.FromMod)
+
+	tests := []struct {
+		name     string
+		actorID  uint
+		messages int
+		inbox    bool
+	}{
+		{"owner sees internal notes", f.owner.ID, 3, true},
+		{"moderator sees internal notes", f.mod.ID, 3, true},
+		{"author does not", f.member.ID, 2, false},
+		{"outsiders see nothing", f.outsider.ID, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := modmail.GetThread(ctx, tt.actorID, thread.ID)
+			if tt.messages == 0 {
+				requireCode(t, err, "NOT_FOUND")
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, got.Messages, tt.messages)
+			}
+			inbox, err := modmail.ListForSanctum(ctx, ListModmailInput{ActorID: tt.actorID, SanctumID: f.sanctum.ID, Limit: 50})
+			if tt.inbox {
+				require.NoError(t, err)
+				assert.Len(t, inbox, 1)
+			} else {
+				requireCode(t, err, "FORBIDDEN")
+			}
+		})
+	}
+
+	_, _, err = modmail.Reply(ctx, f.member.ID, thread.ID, "note", true)
+	requireCode(t, err, "FORBIDDEN")
+}
+
+func TestModmailService_Assign(t *testing.T) {
+	modmail, f, thread := newModmailTestService(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		actorID  uint
+		assignee uint
+		code     string
+	}{
+		{"outside the mod team", f.mod.ID, f.outsider.ID, "VALIDATION_ERROR"},
+		{"by the author", f.member.ID, f.mod.ID, "FORBIDDEN"},
+		{"to a moderator", f.owner.ID, f.mod.ID, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assigned, err := modmail.Assign(ctx, tt.actorID, thread.ID, &tt.assignee)
+			if tt.code != "" {
+				requireCode(t, err, tt.code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.assignee, *assigned.AssignedToUserID)
+		})
+	}
+
+	mine, err := modmail.ListForSanctum(ctx, ListModmailInput{ActorID: f.owner.ID, SanctumID: f.sanctum.ID, AssignedTo: f.owner.ID, Limit: 50})
+	require.NoError(t, err)
+	assert.Empty(t, mine)
+	theirs, err := modmail.ListForSanctum(ctx, ListModmailInput{ActorID: f.owner.ID, SanctumID: f.sanctum.ID, AssignedTo: f.mod.ID, Limit: 50})
+	require.NoError(t, err)
+	assert.Len(t, theirs, 1)
+}
+
+func TestModmailService_UserReplyReopensArchivedThread(t *testing.T) {
+	modmail, f, thread := newModmailTestService(t)
+	ctx := context.Background()
+	list := func(status models.ModmailStatus) []models.ModmailThread {
+		threads, err := modmail.ListForSanctum(ctx, ListModmailInput{ActorID: f.mod.ID, SanctumID: f.sanctum.ID, Status: status, Limit: 50})
+		require.NoError(t, err)
+		return threads
+	}
+
+	_, err := modmail.SetArchived(ctx, f.member.ID, thread.ID, true)
+	requireCode(t, err, "FORBIDDEN")
+	_, err = modmail.SetArchived(ctx, f.mod.ID, thread.ID, true)
+	require.NoError(t, err)
+	assert.Empty(t, list(""))
+	assert.Len(t, list(models.ModmailStatusArchived), 1)
+
+	// A moderator reply leaves the thread archived; the user's reopens it.
+	archived, _, err := modmail.Reply(ctx, f.mod.ID, thread.ID, "Closing this out", false)
+	require.NoError(t, err)
+	assert.Equal(t, models.ModmailStatusArchived, archived.Status)
+	reopened, _, err := modmail.Reply(ctx, f.member.ID, thread.ID, "Thanks, one more question", false)
+	require.NoError(t, err)
+	assert.Equal(t, models.ModmailStatusOpen, reopened.Status)
+	assert.Len(t, list(""), 1)
+}
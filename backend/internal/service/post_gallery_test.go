@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sanctum/internal/config"
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// sqliteImagesDDL mirrors the images table; models.Image uses a Postgres-only
+// default (now()) that AutoMigrate cannot express in SQLite.
+const sqliteImagesDDL = `CREATE TABLE images (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	hash TEXT NOT NULL UNIQUE,
+	user_id INTEGER NOT NULL,
+	original_filename TEXT NOT NULL DEFAULT '',
+	mime_type TEXT NOT NULL DEFAULT '',
+	size_bytes INTEGER NOT NULL DEFAULT 0,
+	width INTEGER NOT NULL DEFAULT 0,
+	height INTEGER NOT NULL DEFAULT 0,
+	original_path TEXT NOT NULL DEFAULT '',
+	thumbnail_path TEXT NOT NULL DEFAULT '',
+	medium_path TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL DEFAULT 'ready',
+	blurhash TEXT,
+	error TEXT,
+	crop_mode TEXT NOT NULL DEFAULT 'free',
+	crop_x INTEGER NOT NULL DEFAULT 0,
+	crop_y INTEGER NOT NULL DEFAULT 0,
+	crop_w INTEGER NOT NULL DEFAULT 0,
+	crop_h INTEGER NOT NULL DEFAULT 0,
+	processing_started_at DATETIME,
+	processing_attempts INTEGER NOT NULL DEFAULT 0,
+	ref_count INTEGER NOT NULL DEFAULT 0,
+	released_at DATETIME,
+	uploaded_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_accessed_at DATETIME,
+	created_at DATETIME,
+	updated_at DATETIME
+)`
+
+func newGalleryTestService(t *testing.T) (*PostService, *ImageService, *gorm.DB, string) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(sqliteImagesDDL).Error)
+	require.NoError(t, db.AutoMigrate(
+		&models.User{},
+		&models.Post{},
+		&models.Poll{},
+		&models.PollOption{},
+		&models.Comment{},
+		&models.Like{},
+		&models.PostImage{},
+		&models.PostTag{},
+		&models.SanctumFlair{},
+		&models.Sanctum{},
+		&models.SanctumMembership{},
+		&models.ImageVariant{},
+		&models.Conversation{},
+		&models.Message{},
+	))
+	uploadDir := t.TempDir()
+	images := NewImageService(repository.NewImageRepository(db), &config.Config{ImageUploadDir: uploadDir})
+	images.releaseGrace = 0
+	posts := NewPostService(repository.NewPostRepository(db), repository.NewPollRepository(db), images, nil, nil, nil, nil, nil, nil)
+	return posts, images, db, uploadDir
+}
+
+func imageRefCount(t *testing.T, db *gorm.DB, hash string) int {
+	t.Helper()
+	var img models.Image
+	require.NoError(t, db.Where("hash = ?", hash).First(&img).Error)
+	return img.RefCount
+}
+
+func TestPostService_GalleryPosts(t *testing.T) {
+	posts, images, db, uploadDir := newGalleryTestService(t)
+	ctx := context.Background()
+
+	author := &models.User{Username: "painter", Email: "painter@e.com"}
+	other := &models.User{Username: "other", Email: "other@e.com"}
+	require.NoError(t, db.Create(author).Error)
+	require.NoError(t, db.Create(other).Error)
+
+	upload := func(userID uint, w int) *models.Image {
+		img, err := images.Upload(ctx, UploadImageInput{
+			UserID: userID, Filename: "a.png", ContentType: "image/png", Content: tinyPNG(t, w, 40),
+		})
+		require.NoError(t, err)
+		return img
+	}
+	first, second, foreign := upload(author.ID, 50), upload(author.ID, 60), upload(other.ID, 70)
+	url := images.BuildMasterImageURL
+
+	_, err := posts.CreatePost(ctx, CreatePostInput{
+		UserID: author.ID, Title: "Not mine", PostType: models.PostTypeGallery,
+		Images: []GalleryImageInput{{ImageURL: url(foreign.Hash)}},
+	})
+	assert.Error(t, err, "another user's upload is rejected")
+
+	tooMany := make([]GalleryImageInput, models.MaxGalleryImages+1)
+	_, err = posts.CreatePost(ctx, CreatePostInput{
+		UserID: author.ID, Title: "Too many", PostType: models.PostTypeGallery, Images: tooMany,
+	})
+	assert.Error(t, err)
+
+	gallery, err := posts.CreatePost(ctx, CreatePostInput{
+		UserID:   author.ID,
+		Title:    "Trip",
+		PostType: models.PostTypeGallery,
+		Images: []GalleryImageInput{
+			{ImageURL: url(second.Hash), Caption: " Day one ", AltText: "A beach"},
+			{ImageURL: url(first.Hash)},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, gallery.Images, 2)
+	assert.Equal(t, second.Hash, gallery.Images[0].ImageHash)
+	assert.Equal(t, "Day one", gallery.Images[0].Caption)
+	assert.Equal(t, url(first.Hash), gallery.Images[1].ImageURL)
+	assert.Equal(t, url(second.Hash), gallery.ImageURL, "first item is the cover")
+
+	// The same image used by a plain media post holds its own reference.
+	media, err := posts.CreatePost(ctx, CreatePostInput{
+		UserID: author.ID, Title: "Solo", PostType: models.PostTypeMedia, ImageURL: url(first.Hash),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, imageRefCount(t, db, first.Hash))
+
+	// Reordering moves the cover and keeps counts balanced.
+	updated, err := posts.UpdatePost(ctx, UpdatePostInput{
+		UserID: author.ID, PostID: gallery.ID,
+		Images: []GalleryImageInput{{ImageURL: url(first.Hash)}, {ImageURL: url(second.Hash)}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, first.Hash, updated.Images[0].ImageHash)
+	assert.Equal(t, url(first.Hash), updated.ImageURL)
+	assert.Equal(t, 3, imageRefCount(t, db, first.Hash))
+	assert.Equal(t, 1, imageRefCount(t, db, second.Hash))
+
+	require.NoError(t, posts.DeletePost(ctx, DeletePostInput{UserID: author.ID, PostID: gallery.ID}))
+	assert.Equal(t, 1, images.SweepReleasedImages(ctx))
+
+	// second was only in the gallery: its record and files are gone.
+	var count int64
+	require.NoError(t, db.Model(&models.Image{}).Where("hash = ?", second.Hash).Count(&count).Error)
+	assert.Zero(t, count)
+	_, statErr := os.Stat(filepath.Join(uploadDir, second.Hash))
+	assert.True(t, os.IsNotExist(statErr))
+
+	// first is still used by the media post.
+	assert.Equal(t, 1, imageRefCount(t, db, first.Hash))
+	_, statErr = os.Stat(filepath.Join(uploadDir, first.Hash))
+	assert.NoError(t, statErr)
+
+	// An avatar keeps a released image alive and unmarks it.
+	require.NoError(t, db.Model(author).Update("avatar", url(first.Hash)).Error)
+	require.NoError(t, posts.DeletePost(ctx, DeletePostInput{UserID: author.ID, PostID: media.ID}))
+	assert.Zero(t, images.SweepReleasedImages(ctx))
+	var kept models.Image
+	require.NoError(t, db.Where("hash = ?", first.Hash).First(&kept).Error)
+	assert.Nil(t, kept.ReleasedAt)
+
+	require.NoError(t, db.Model(author).Update("avatar", "").Error)
+	require.NoError(t, db.Model(&kept).Update("released_at", time.Now().Add(-time.Hour)).Error)
+	images.SweepReleasedImages(ctx)
+	require.NoError(t, db.Model(&models.Image{}).Where("hash = ?", first.Hash).Count(&count).Error)
+	assert.Zero(t, count)
+}
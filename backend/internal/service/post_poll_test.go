@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostService_PollSettings(t *testing.T) {
+	posts, _, db, _ := newGalleryTestService(t)
+	require.NoError(t, db.AutoMigrate(&models.PollVote{}))
+	ctx := context.Background()
+
+	author := &models.User{Username: "pollster", Email: "pollster@e.com"}
+	voter := &models.User{Username: "voter", Email: "voter@e.com"}
+	require.NoError(t, db.Create(author).Error)
+	require.NoError(t, db.Create(voter).Error)
+
+	newPoll := func(poll CreatePostPollInput) (*models.Post, error) {
+		return posts.CreatePost(ctx, CreatePostInput{
+			UserID: author.ID, Title: "Vote", PostType: models.PostTypePoll, Poll: &poll,
+		})
+	}
+
+	_, err := newPoll(CreatePostPollInput{Question: "Q", Options: []string{"a", "b"}, MaxSelections: 3})
+	assert.Error(t, err, "more selections than options")
+	past := time.Now().Add(-time.Minute)
+	_, err = newPoll(CreatePostPollInput{Question: "Q", Options: []string{"a", "b"}, ClosesAt: &past})
+	assert.Error(t, err, "closing time in the past")
+
+	closesAt := time.Now().Add(time.Hour)
+	post, err := newPoll(CreatePostPollInput{
+		Question: "Pick two", Options: []string{"red", "green", "blue"},
+		MaxSelections: 2, ClosesAt: &closesAt, HideResults: true,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, post.Poll)
+	assert.Equal(t, 2, post.Poll.MaxSelections)
+	opts := post.Poll.Options
+	require.Len(t, opts, 3)
+
+	// Hidden until the viewer votes.
+	_, err = posts.VotePoll(ctx, author.ID, post.ID, []uint{opts[0].ID})
+	require.NoError(t, err)
+	seen, err := posts.GetPost(ctx, post.ID, voter.ID)
+	require.NoError(t, err)
+	assert.True(t, seen.Poll.ResultsHidden)
+	assert.Zero(t, seen.Poll.Options[0].VotesCount)
+	assert.Equal(t, 1, seen.Poll.TotalVoters)
+
+	_, err = posts.VotePoll(ctx, voter.ID, post.ID, []uint{opts[0].ID, opts[1].ID, opts[2].ID})
+	assert.Error(t, err, "exceeds max selections")
+	_, err = posts.VotePoll(ctx, voter.ID, post.ID, []uint{opts[0].ID, opts[0].ID})
+	assert.Error(t, err, "duplicate option")
+
+	voted, err := posts.VotePoll(ctx, voter.ID, post.ID, []uint{opts[0].ID, opts[1].ID})
+	require.NoError(t, err)
+	assert.False(t, voted.Poll.ResultsHidden)
+	assert.ElementsMatch(t, []uint{opts[0].ID, opts[1].ID}, voted.Poll.UserVoteOptionIDs)
+	assert.Equal(t, 2, voted.Poll.Options[0].VotesCount)
+	assert.Equal(t, 2, voted.Poll.TotalVoters)
+
+	// Changing the vote replaces the previous selection.
+	changed, err := posts.VotePoll(ctx, voter.ID, post.ID, []uint{opts[2].ID})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{opts[2].ID}, changed.Poll.UserVoteOptionIDs)
+	assert.Equal(t, 1, changed.Poll.Options[0].VotesCount)
+	assert.Equal(t, 0, changed.Poll.Options[1].VotesCount)
+	assert.Equal(t, 1, changed.Poll.Options[2].VotesCount)
+
+	retracted, err := posts.RetractPollVote(ctx, voter.ID, post.ID)
+	require.NoError(t, err)
+	assert.Empty(t, retracted.Poll.UserVoteOptionIDs)
+	assert.True(t, retracted.Poll.ResultsHidden)
+	assert.Equal(t, 1, retracted.Poll.TotalVoters)
+
+	// Once closed, votes are rejected and results are visible to everyone.
+	require.NoError(t, db.Model(&models.Poll{}).Where("id = ?", post.Poll.ID).
+		Update("closes_at", time.Now().Add(-time.Second)).Error)
+	_, err = posts.VotePoll(ctx, voter.ID, post.ID, []uint{opts[1].ID})
+	assert.Error(t, err)
+	_, err = posts.RetractPollVote(ctx, author.ID, post.ID)
+	assert.Error(t, err)
+	final, err := posts.GetPost(ctx, post.ID, voter.ID)
+	require.NoError(t, err)
+	assert.True(t, final.Poll.Closed)
+	assert.False(t, final.Poll.ResultsHidden)
+	assert.Equal(t, 1, final.Poll.Options[0].VotesCount)
+}
+
+func TestPollRepository_SetVotesRechecksPoll(t *testing.T) {
+	_, _, db, _ := newGalleryTestService(t)
+	require.NoError(t, db.AutoMigrate(&models.PollVote{}))
+	ctx := context.Background()
+	polls := repository.NewPollRepository(db)
+
+	voter := &models.User{Username: "voter", Email: "voter@e.com"}
+	require.NoError(t, db.Create(voter).Error)
+	post := &models.Post{Title: "Vote", Content: "x", UserID: voter.ID}
+	otherPost := &models.Post{Title: "Other", Content: "x", UserID: voter.ID}
+	require.NoError(t, db.Create(post).Error)
+	require.NoError(t, db.Create(otherPost).Error)
+	poll, err := polls.Create(ctx, post.ID, "Q", []string{"a", "b", "c"}, models.PollSettings{MaxSelections: 2})
+	require.NoError(t, err)
+	other, err := polls.Create(ctx, otherPost.ID, "Other", []string{"x"}, models.PollSettings{})
+	require.NoError(t, err)
+	opts := poll.Options
+
+	// Settings changed after the service validated the request.
+	require.NoError(t, db.Model(&models.Poll{}).Where("id = ?", poll.ID).Update("max_selections", 1).Error)
+
+	tests := []struct {
+		name      string
+		optionIDs []uint
+		wantErr   bool
+	}{
+		{"within the current limit", []uint{opts[0].ID}, false},
+		{"over the current limit", []uint{opts[0].ID, opts[1].ID}, true},
+		{"option from another poll", []uint{other.Options[0].ID}, true},
+		{"retract", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := polls.SetVotes(ctx, voter.ID, poll.ID, tt.optionIDs)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	require.NoError(t, db.Model(&models.Poll{}).Where("id = ?", poll.ID).
+		Update("closes_at", time.Now().Add(-time.Second)).Error)
+	assert.Error(t, polls.SetVotes(ctx, voter.ID, poll.ID, []uint{opts[0].ID}), "closed after validation")
+}
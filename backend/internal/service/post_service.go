@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"net/url"
+	"regexp"
 	"strings"
+	"time"
 
 	"sanctum/internal/cache"
 	"sanctum/internal/models"
@@ -13,13 +16,42 @@ import (
 type PostService struct {
 	postRepo repository.PostRepository
 	pollRepo repository.PollRepository
+	imageSvc *ImageService
 	isAdmin  func(ctx context.Context, userID uint) (bool, error)
+	// checkSanctumPost rejects new sanctum posts the sanctum does not accept,
+	// whether because of the author or the sanctum's posting policy.
+	checkSanctumPost func(ctx context.Context, post *models.Post) error
+	// canReadSanctum hides private sanctums from non-members.
+	canReadSanctum func(ctx context.Context, sanctumID, userID uint) (bool, error)
+	// canModerate reports whether a user moderates a sanctum; moderators can
+	// still open posts they removed.
+	canModerate func(ctx context.Context, userID, sanctumID uint) (bool, error)
+	// automod runs the sanctum's AutoModerator over a post before it is stored.
+	automod *AutomodService
+	// flairs checks who may apply or remove a sanctum flair.
+	flairs *SanctumFlairService
 }
 
 // CreatePostPollInput is the poll payload when creating a poll post.
 type CreatePostPollInput struct {
 	Question string   `json:"question"`
 	Options  []string `json:"options"`
+	// MaxSelections allows multi-select polls; zero means single choice.
+	MaxSelections int `json:"max_selections,omitempty"`
+	// ClosesAt ends voting at the given time. Nil keeps the poll open.
+	ClosesAt *time.Time `json:"closes_at,omitempty"`
+	// HideResults hides counts until the viewer votes or the poll closes.
+	HideResults bool `json:"hide_results,omitempty"`
+}
+
+// maxPollDuration bounds how far in the future a poll may close.
+const maxPollDuration = 365 * 24 * time.Hour
+
+// GalleryImageInput is one gallery item; items are displayed in slice order.
+type GalleryImageInput struct {
+	ImageURL string `json:"image_url"`
+	Caption  string `json:"caption,omitempty"`
+	AltText  string `json:"alt_text,omitempty"`
 }
 
 type CreatePostInput struct {
@@ -32,6 +64,15 @@ type CreatePostInput struct {
 	YoutubeURL string
 	SanctumID  *uint
 	Poll       *CreatePostPollInput
+	Images     []GalleryImageInput
+	// FlairID labels a sanctum post; callers check the flair belongs to the
+	// sanctum and that the author may apply it.
+	FlairID *uint
+	Tags    []string
+	// Draft keeps the post private to its author until it is published.
+	Draft bool
+	// PublishAt schedules the post; the scheduler publishes it once this time passes.
+	PublishAt *time.Time
 }
 
 type ListPostsInput struct {
@@ -39,6 +80,9 @@ type ListPostsInput struct {
 	Offset        int
 	CurrentUserID uint
 	SanctumID     *uint
+	// FlairID and Tag filter a sanctum feed and require SanctumID.
+	FlairID uint
+	Tag     string
 }
 
 type UpdatePostInput struct {
@@ -49,6 +93,14 @@ type UpdatePostInput struct {
 	ImageURL   string
 	LinkURL    string
 	YoutubeURL string
+	// PublishAt reschedules an unpublished post. Ignored when nil.
+	PublishAt *time.Time
+	// Images replaces a gallery's items. Ignored when nil.
+	Images []GalleryImageInput
+	// FlairID sets the post's flair; a pointer to zero clears it. Ignored when nil.
+	FlairID *uint
+	// Tags replaces the post's tags. Ignored when nil.
+	Tags []string
 }
 
 type DeletePostInput struct {
@@ -59,12 +111,24 @@ type DeletePostInput struct {
 func NewPostService(
 	postRepo repository.PostRepository,
 	pollRepo repository.PollRepository,
+	imageSvc *ImageService,
 	isAdmin func(ctx context.Context, userID uint) (bool, error),
+	checkSanctumPost func(ctx context.Context, post *models.Post) error,
+	canReadSanctum func(ctx context.Context, sanctumID, userID uint) (bool, error),
+	canModerate func(ctx context.Context, userID, sanctumID uint) (bool, error),
+	automod *AutomodService,
+	flairs *SanctumFlairService,
 ) *PostService {
 	return &PostService{
-		postRepo: postRepo,
-		pollRepo: pollRepo,
-		isAdmin:  isAdmin,
+		postRepo:         postRepo,
+		pollRepo:         pollRepo,
+		imageSvc:         imageSvc,
+		isAdmin:          isAdmin,
+		checkSanctumPost: checkSanctumPost,
+		canReadSanctum:   canReadSanctum,
+		canModerate:      canModerate,
+		automod:          automod,
+		flairs:           flairs,
 	}
 }
 
@@ -77,7 +141,7 @@ func (s *PostService) SearchPosts(ctx context.Context, query string, limit, offs
 		return nil, err
 	}
 	for _, p := range posts {
-		if err := s.enrichPollIfPresent(ctx, p, currentUserID); err != nil {
+		if err := s.enrichPost(ctx, p, currentUserID); err != nil {
 			return nil, err
 		}
 	}
@@ -90,7 +154,7 @@ func (s *PostService) CreatePost(ctx context.Context, in CreatePostInput) (*mode
 		postType = models.PostTypeText
 	}
 	switch postType {
-	case models.PostTypeText, models.PostTypeMedia, models.PostTypeVideo, models.PostTypeLink, models.PostTypePoll:
+	case models.PostTypeText, models.PostTypeMedia, models.PostTypeVideo, models.PostTypeLink, models.PostTypePoll, models.PostTypeGallery:
 		// valid
 	default:
 		return nil, models.NewValidationError("Invalid post_type")
@@ -115,7 +179,40 @@ func (s *PostService) CreatePost(ctx context.Context, in CreatePostInput) (*mode
 		}
 	}
 
+	if in.FlairID != nil && *in.FlairID == 0 {
+		in.FlairID = nil
+	}
+	if in.FlairID != nil {
+		if in.SanctumID == nil {
+			return nil, models.NewValidationError("Flairs can only be used on sanctum posts")
+		}
+		if s.flairs != nil {
+			if err := s.flairs.CheckPostFlair(ctx, in.UserID, in.SanctumID, nil, *in.FlairID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	tags, err := normalizeTags(in.Tags)
+	if err != nil {
+		return nil, err
+	}
+	postTags := make([]models.PostTag, 0, len(tags))
+	for _, tag := range tags {
+		postTags = append(postTags, models.PostTag{Tag: tag})
+	}
+
+	if postType != models.PostTypeGallery && len(in.Images) > 0 {
+		return nil, models.NewValidationError("images are only allowed on gallery posts")
+	}
+
+	var gallery []models.PostImage
 	switch postType {
+	case models.PostTypeGallery:
+		if gallery, err = s.buildGallery(ctx, in.UserID, in.Images); err != nil {
+			return nil, err
+		}
+		// The first item doubles as the cover for clients that only read image_url.
+		in.ImageURL = galleryCoverURL(gallery)
 	case models.PostTypeMedia:
 		if strings.TrimSpace(in.ImageURL) == "" {
 			return nil, models.NewValidationError("image_url is required for media posts")
@@ -155,6 +252,34 @@ func (s *PostService) CreatePost(ctx context.Context, in CreatePostInput) (*mode
 			return nil, models.NewValidationError("Poll must have at least two non-empty options")
 		}
 		in.Poll.Options = opts
+		if in.Poll.MaxSelections == 0 {
+			in.Poll.MaxSelections = 1
+		}
+		if in.Poll.MaxSelections < 1 || in.Poll.MaxSelections > len(opts) {
+			return nil, models.NewValidationError("max_selections must be between 1 and the number of options")
+		}
+		if in.Poll.ClosesAt != nil {
+			now := time.Now()
+			if !in.Poll.ClosesAt.After(now) {
+				return nil, models.NewValidationError("closes_at must be in the future")
+			}
+			if in.Poll.ClosesAt.After(now.Add(maxPollDuration)) {
+				return nil, models.NewValidationError("closes_at cannot be more than a year away")
+			}
+			closesAt := in.Poll.ClosesAt.UTC()
+			in.Poll.ClosesAt = &closesAt
+		}
+	}
+
+	status := models.PostStatusPublished
+	switch {
+	case in.PublishAt != nil:
+		if err := validatePublishAt(*in.PublishAt); err != nil {
+			return nil, err
+		}
+		status = models.PostStatusScheduled
+	case in.Draft:
+		status = models.PostStatusDraft
 	}
 
 	content := in.Content
@@ -168,17 +293,42 @@ func (s *PostService) CreatePost(ctx context.Context, in CreatePostInput) (*mode
 		ImageURL:   in.ImageURL,
 		ImageHash:  extractImageHash(in.ImageURL),
 		PostType:   postType,
+		Images:     gallery,
 		LinkURL:    in.LinkURL,
 		YoutubeURL: in.YoutubeURL,
 		UserID:     in.UserID,
 		SanctumID:  in.SanctumID,
+		FlairID:    in.FlairID,
+		Tags:       postTags,
+		Status:     status,
+		PublishAt:  in.PublishAt,
+	}
+	if post.SanctumID != nil && s.checkSanctumPost != nil {
+		if err := s.checkSanctumPost(ctx, post); err != nil {
+			return nil, err
+		}
+	}
+	var verdict *AutomodVerdict
+	if post.SanctumID != nil && s.automod != nil {
+		if verdict, err = s.automod.CheckPost(ctx, post); err != nil {
+			return nil, err
+		}
 	}
 	if err := s.postRepo.Create(ctx, post); err != nil {
 		return nil, err
 	}
 
 	if postType == models.PostTypePoll && s.pollRepo != nil {
-		if _, err := s.pollRepo.Create(ctx, post.ID, in.Poll.Question, in.Poll.Options); err != nil {
+		if _, err := s.pollRepo.Create(ctx, post.ID, in.Poll.Question, in.Poll.Options, models.PollSettings{
+			MaxSelections: in.Poll.MaxSelections,
+			ClosesAt:      in.Poll.ClosesAt,
+			HideResults:   in.Poll.HideResults,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if verdict != nil {
+		if err := s.automod.RecordPost(ctx, post, verdict); err != nil {
 			return nil, err
 		}
 	}
@@ -187,6 +337,10 @@ func (s *PostService) CreatePost(ctx context.Context, in CreatePostInput) (*mode
 }
 
 func (s *PostService) ListPosts(ctx context.Context, in ListPostsInput) ([]*models.Post, error) {
+	if in.SanctumID == nil && (in.FlairID != 0 || in.Tag != "") {
+		return nil, models.NewValidationError("flair_id and tag filters require sanctum_id")
+	}
+
 	var posts []*models.Post
 	var err error
 
@@ -223,7 +377,22 @@ func (s *PostService) ListPosts(ctx context.Context, in ListPostsInput) ([]*mode
 			}
 		}
 	case in.SanctumID != nil:
-		posts, err = s.postRepo.GetBySanctumID(ctx, *in.SanctumID, in.Limit, in.Offset, in.CurrentUserID)
+		if s.canReadSanctum != nil {
+			ok, readErr := s.canReadSanctum(ctx, *in.SanctumID, in.CurrentUserID)
+			if readErr != nil {
+				return nil, readErr
+			}
+			if !ok {
+				return nil, models.NewNotFoundError("Sanctum", *in.SanctumID)
+			}
+		}
+		filter := repository.SanctumFeedFilter{FlairID: in.FlairID}
+		if in.Tag != "" {
+			if filter.Tag, err = normalizeTag(in.Tag); err != nil {
+				return nil, err
+			}
+		}
+		posts, err = s.postRepo.GetBySanctumID(ctx, *in.SanctumID, filter, in.Limit, in.Offset, in.CurrentUserID)
 	default:
 		posts, err = s.postRepo.List(ctx, in.Limit, in.Offset, in.CurrentUserID)
 	}
@@ -232,7 +401,7 @@ func (s *PostService) ListPosts(ctx context.Context, in ListPostsInput) ([]*mode
 		return nil, err
 	}
 	for _, p := range posts {
-		if err := s.enrichPollIfPresent(ctx, p, in.CurrentUserID); err != nil {
+		if err := s.enrichPost(ctx, p, in.CurrentUserID); err != nil {
 			return nil, err
 		}
 	}
@@ -240,12 +409,30 @@ func (s *PostService) ListPosts(ctx context.Context, in ListPostsInput) ([]*mode
 }
 
 func (s *PostService) GetPost(ctx context.Context, id uint, currentUserID uint) (*models.Post, error) {
+	post, err := s.readablePost(ctx, id, currentUserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enrichPost(ctx, post, currentUserID); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+// readablePost loads a post the viewer may open. Drafts, posts in sanctums
+// the viewer cannot read and removed posts are reported as not found.
+func (s *PostService) readablePost(ctx context.Context, id, currentUserID uint) (*models.Post, error) {
 	post, err := s.postRepo.GetByID(ctx, id, currentUserID)
 	if err != nil {
 		return nil, err
 	}
-	if err := s.enrichPollIfPresent(ctx, post, currentUserID); err != nil {
+	if !isPostVisibleTo(post, currentUserID) {
+		return nil, models.NewNotFoundError("Post", id)
+	}
+	if ok, err := s.canReadPost(ctx, post, currentUserID); err != nil {
 		return nil, err
+	} else if !ok {
+		return nil, models.NewNotFoundError("Post", id)
 	}
 	return post, nil
 }
@@ -255,19 +442,64 @@ func (s *PostService) getPostWithPollEnriched(ctx context.Context, postID, curre
 	if err != nil {
 		return nil, err
 	}
-	if err := s.enrichPollIfPresent(ctx, post, currentUserID); err != nil {
+	if err := s.enrichPost(ctx, post, currentUserID); err != nil {
 		return nil, err
 	}
 	return post, nil
 }
 
-func (s *PostService) enrichPollIfPresent(ctx context.Context, post *models.Post, currentUserID uint) error {
+// enrichPost fills in a post's rendered content and, for polls, the viewer's
+// results.
+func (s *PostService) enrichPost(ctx context.Context, post *models.Post, currentUserID uint) error {
+	RenderPostHTML(post)
 	if post.Poll != nil && s.pollRepo != nil {
 		return s.pollRepo.EnrichWithResults(ctx, post.Poll, currentUserID)
 	}
 	return nil
 }
 
+// isPostUnpublished reports whether a post is a draft or still waiting on its schedule.
+func isPostUnpublished(post *models.Post) bool {
+	return post.Status == models.PostStatusDraft || post.Status == models.PostStatusScheduled
+}
+
+// isPostVisibleTo reports whether a post may be shown to the given user.
+// Unpublished posts are private to their author.
+func isPostVisibleTo(post *models.Post, userID uint) bool {
+	return !isPostUnpublished(post) || (userID != 0 && post.UserID == userID)
+}
+
+// canReadPost reports whether userID may read a post given its sanctum's
+// visibility. Authors can always read their own posts; a removed post is
+// otherwise only shown to the sanctum's moderators.
+func (s *PostService) canReadPost(ctx context.Context, post *models.Post, userID uint) (bool, error) {
+	if post.SanctumID == nil || (userID != 0 && post.UserID == userID) {
+		return true, nil
+	}
+	if post.RemovedAt != nil {
+		if userID == 0 || s.canModerate == nil {
+			return false, nil
+		}
+		return s.canModerate(ctx, userID, *post.SanctumID)
+	}
+	if s.canReadSanctum == nil {
+		return true, nil
+	}
+	return s.canReadSanctum(ctx, *post.SanctumID, userID)
+}
+
+func validatePublishAt(t time.Time) error {
+	const maxScheduleAhead = 365 * 24 * time.Hour
+	now := time.Now()
+	if !t.After(now) {
+		return models.NewValidationError("publish_at must be in the future")
+	}
+	if t.After(now.Add(maxScheduleAhead)) {
+		return models.NewValidationError("publish_at cannot be more than one year ahead")
+	}
+	return nil
+}
+
 // isYouTubeURL returns true if u is a YouTube watch or embed URL.
 func isYouTubeURL(u string) bool {
 	parsed, err := url.Parse(u)
@@ -278,42 +510,119 @@ func isYouTubeURL(u string) bool {
 	return strings.Contains(host, "youtube.com") || strings.Contains(host, "youtu.be")
 }
 
-// VotePoll records or updates the current user's vote on a poll.
-func (s *PostService) VotePoll(ctx context.Context, userID, postID, pollOptionID uint) (*models.Post, error) {
-	post, err := s.postRepo.GetByID(ctx, postID, userID)
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// normalizeTag lowercases a tag and strips a leading '#'.
+func normalizeTag(raw string) (string, error) {
+	tag := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(raw), "#"))
+	if !tagPattern.MatchString(tag) {
+		return "", models.NewValidationError("Tags must be 1-32 letters, digits, '-' or '_'")
+	}
+	return tag, nil
+}
+
+// normalizeTags normalizes and de-duplicates tags, keeping their order.
+func normalizeTags(raw []string) ([]string, error) {
+	if len(raw) > models.MaxPostTags {
+		return nil, models.NewValidationError(fmt.Sprintf("A post can have at most %d tags", models.MaxPostTags))
+	}
+	tags := make([]string, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	for _, r := range raw {
+		tag, err := normalizeTag(r)
+		if err != nil {
+			return nil, err
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// VotePoll replaces the current user's selections on a poll. Voting again
+// changes the vote; multi-select polls accept up to MaxSelections options.
+func (s *PostService) VotePoll(ctx context.Context, userID, postID uint, pollOptionIDs []uint) (*models.Post, error) {
+	if len(pollOptionIDs) == 0 {
+		return nil, models.NewValidationError("At least one poll option is required")
+	}
+	post, err := s.openPollPost(ctx, userID, postID)
 	if err != nil {
 		return nil, err
 	}
-	if post.PostType != models.PostTypePoll || post.Poll == nil {
-		return nil, models.NewValidationError("Post is not a poll")
+	poll := post.Poll
+	maxSelections := poll.MaxSelections
+	if maxSelections < 1 {
+		maxSelections = 1
 	}
-	var optionBelongs bool
-	for _, opt := range post.Poll.Options {
-		if opt.ID == pollOptionID {
-			optionBelongs = true
-			break
+	if len(pollOptionIDs) > maxSelections {
+		return nil, models.NewValidationError(fmt.Sprintf("You can select at most %d option(s)", maxSelections))
+	}
+	valid := make(map[uint]bool, len(poll.Options))
+	for _, opt := range poll.Options {
+		valid[opt.ID] = true
+	}
+	seen := make(map[uint]bool, len(pollOptionIDs))
+	for _, id := range pollOptionIDs {
+		if !valid[id] {
+			return nil, models.NewValidationError("Invalid poll option")
+		}
+		if seen[id] {
+			return nil, models.NewValidationError("Duplicate poll option")
 		}
+		seen[id] = true
 	}
-	if !optionBelongs {
-		return nil, models.NewValidationError("Invalid poll option")
+	if err := s.pollRepo.SetVotes(ctx, userID, poll.ID, pollOptionIDs); err != nil {
+		return nil, err
 	}
-	if s.pollRepo == nil {
-		return nil, models.NewValidationError("Poll voting is not available")
+	cache.Invalidate(ctx, cache.PostKey(postID))
+	return s.getPostWithPollEnriched(ctx, postID, userID)
+}
+
+// RetractPollVote removes the current user's vote while the poll is open.
+func (s *PostService) RetractPollVote(ctx context.Context, userID, postID uint) (*models.Post, error) {
+	post, err := s.openPollPost(ctx, userID, postID)
+	if err != nil {
+		return nil, err
 	}
-	if err := s.pollRepo.Vote(ctx, userID, post.Poll.ID, pollOptionID); err != nil {
+	if err := s.pollRepo.SetVotes(ctx, userID, post.Poll.ID, nil); err != nil {
 		return nil, err
 	}
 	cache.Invalidate(ctx, cache.PostKey(postID))
 	return s.getPostWithPollEnriched(ctx, postID, userID)
 }
 
+// openPollPost loads a published poll post that still accepts votes.
+// Removed posts take no votes, even from readers who can still open them.
+func (s *PostService) openPollPost(ctx context.Context, userID, postID uint) (*models.Post, error) {
+	post, err := s.readablePost(ctx, postID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if isPostUnpublished(post) || post.RemovedAt != nil {
+		return nil, models.NewNotFoundError("Post", postID)
+	}
+	if post.PostType != models.PostTypePoll || post.Poll == nil {
+		return nil, models.NewValidationError("Post is not a poll")
+	}
+	if s.pollRepo == nil {
+		return nil, models.NewValidationError("Poll voting is not available")
+	}
+	if post.Poll.IsClosed(time.Now()) {
+		return nil, models.NewValidationError("Poll is closed")
+	}
+	return post, nil
+}
+
 func (s *PostService) GetUserPosts(ctx context.Context, userID uint, limit, offset int, currentUserID uint) ([]*models.Post, error) {
 	posts, err := s.postRepo.GetByUserID(ctx, userID, limit, offset, currentUserID)
 	if err != nil {
 		return nil, err
 	}
 	for _, p := range posts {
-		if err := s.enrichPollIfPresent(ctx, p, currentUserID); err != nil {
+		if err := s.enrichPost(ctx, p, currentUserID); err != nil {
 			return nil, err
 		}
 	}
@@ -336,7 +645,23 @@ func (s *PostService) UpdatePost(ctx context.Context, in UpdatePostInput) (*mode
 	if in.Content != "" {
 		post.Content = in.Content
 	}
-	if in.ImageURL != "" {
+	// ImageHash is not part of the cached JSON, so derive it from the URL.
+	previousHash := extractImageHash(post.ImageURL)
+	post.ImageHash = previousHash
+	var gallery []models.PostImage
+	if in.Images != nil {
+		if post.PostType != models.PostTypeGallery {
+			return nil, models.NewValidationError("images are only allowed on gallery posts")
+		}
+		if gallery, err = s.buildGallery(ctx, in.UserID, in.Images); err != nil {
+			return nil, err
+		}
+		post.ImageURL = galleryCoverURL(gallery)
+		post.ImageHash = extractImageHash(post.ImageURL)
+	} else if in.ImageURL != "" {
+		if post.PostType == models.PostTypeGallery {
+			return nil, models.NewValidationError("Gallery covers follow the first image; update images instead")
+		}
 		post.ImageURL = in.ImageURL
 		post.ImageHash = extractImageHash(in.ImageURL)
 	}
@@ -346,10 +671,65 @@ func (s *PostService) UpdatePost(ctx context.Context, in UpdatePostInput) (*mode
 	if in.YoutubeURL != "" {
 		post.YoutubeURL = in.YoutubeURL
 	}
+	if in.PublishAt != nil {
+		if !isPostUnpublished(post) {
+			return nil, models.NewValidationError("Published posts cannot be scheduled")
+		}
+		if err := validatePublishAt(*in.PublishAt); err != nil {
+			return nil, err
+		}
+		post.Status = models.PostStatusScheduled
+		post.PublishAt = in.PublishAt
+	}
 
-	if err := s.postRepo.Update(ctx, post); err != nil {
+	if in.FlairID != nil {
+		if post.SanctumID == nil {
+			return nil, models.NewValidationError("Flairs can only be used on sanctum posts")
+		}
+		if s.flairs != nil {
+			if err := s.flairs.CheckPostFlair(ctx, in.UserID, post.SanctumID, post.FlairID, *in.FlairID); err != nil {
+				return nil, err
+			}
+		}
+		post.FlairID = in.FlairID
+		if *in.FlairID == 0 {
+			post.FlairID = nil
+		}
+		post.Flair = nil
+	}
+	var tags []string
+	if in.Tags != nil {
+		if tags, err = normalizeTags(in.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	var released []string
+	if in.Images != nil {
+		for _, item := range post.Images {
+			released = append(released, item.ImageHash)
+		}
+	}
+	if err := s.postRepo.Update(ctx, post, gallery); err != nil {
 		return nil, err
 	}
+	if in.Images != nil {
+		post.Images = gallery
+	}
+	if previousHash != post.ImageHash {
+		released = append(released, previousHash)
+	}
+	s.releaseImages(ctx, released)
+	if in.Tags != nil {
+		if err := s.postRepo.ReplaceTags(ctx, post.ID, tags); err != nil {
+			return nil, err
+		}
+	}
+
+	if in.Images != nil || in.Tags != nil || in.FlairID != nil {
+		return s.getPostWithPollEnriched(ctx, post.ID, in.UserID)
+	}
+	RenderPostHTML(post)
 	return post, nil
 }
 
@@ -372,10 +752,140 @@ func (s *PostService) DeletePost(ctx context.Context, in DeletePostInput) error
 		}
 	}
 
-	return s.postRepo.Delete(ctx, in.PostID)
+	if err := s.postRepo.Delete(ctx, in.PostID); err != nil {
+		return err
+	}
+	released := make([]string, 0, len(post.Images)+1)
+	released = append(released, extractImageHash(post.ImageURL))
+	for _, item := range post.Images {
+		released = append(released, item.ImageHash)
+	}
+	s.releaseImages(ctx, released)
+	return nil
+}
+
+// buildGallery validates gallery items and turns them into rows. Every image
+// must be an existing upload owned by the post author.
+func (s *PostService) buildGallery(ctx context.Context, userID uint, items []GalleryImageInput) ([]models.PostImage, error) {
+	const maxCaptionLen = 300
+	const maxAltTextLen = 500
+
+	if len(items) == 0 {
+		return nil, models.NewValidationError("Gallery posts need at least one image")
+	}
+	if len(items) > models.MaxGalleryImages {
+		return nil, models.NewValidationError(fmt.Sprintf("Gallery posts cannot have more than %d images", models.MaxGalleryImages))
+	}
+
+	gallery := make([]models.PostImage, 0, len(items))
+	hashes := make([]string, 0, len(items))
+	seen := make(map[string]struct{}, len(items))
+	for i, item := range items {
+		hash := extractImageHash(item.ImageURL)
+		if hash == "" {
+			return nil, models.NewValidationError(fmt.Sprintf("images[%d].image_url must be an uploaded image", i))
+		}
+		if _, dup := seen[hash]; dup {
+			return nil, models.NewValidationError("Each image can only appear once in a gallery")
+		}
+		seen[hash] = struct{}{}
+		caption := strings.TrimSpace(item.Caption)
+		altText := strings.TrimSpace(item.AltText)
+		if len(caption) > maxCaptionLen {
+			return nil, models.NewValidationError("Caption too long (max 300 characters)")
+		}
+		if len(altText) > maxAltTextLen {
+			return nil, models.NewValidationError("Alt text too long (max 500 characters)")
+		}
+		hashes = append(hashes, hash)
+		gallery = append(gallery, models.PostImage{
+			ImageHash: hash,
+			Position:  i,
+			Caption:   caption,
+			AltText:   altText,
+		})
+	}
+
+	if s.imageSvc != nil {
+		images, err := s.imageSvc.GetByHashes(ctx, hashes)
+		if err != nil {
+			return nil, err
+		}
+		for _, hash := range hashes {
+			img := images[hash]
+			if img == nil || img.UserID != userID {
+				return nil, models.NewValidationError("Gallery images must be your own uploads")
+			}
+		}
+	}
+	return gallery, nil
+}
+
+func galleryCoverURL(gallery []models.PostImage) string {
+	return fmt.Sprintf("/media/i/%s/master.jpg", gallery[0].ImageHash)
+}
+
+// releaseImages lets the image service purge images no post references any more.
+func (s *PostService) releaseImages(ctx context.Context, hashes []string) {
+	if s.imageSvc == nil {
+		return
+	}
+	live := hashes[:0]
+	for _, h := range hashes {
+		if h != "" {
+			live = append(live, h)
+		}
+	}
+	s.imageSvc.ReleaseImages(ctx, live)
+}
+
+// ListDrafts returns the caller's draft and scheduled posts.
+func (s *PostService) ListDrafts(ctx context.Context, userID uint, limit, offset int) ([]*models.Post, error) {
+	posts, err := s.postRepo.ListDrafts(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range posts {
+		if err := s.enrichPost(ctx, p, userID); err != nil {
+			return nil, err
+		}
+	}
+	return posts, nil
+}
+
+// PublishPost publishes a draft or scheduled post immediately. Only the author may publish.
+func (s *PostService) PublishPost(ctx context.Context, userID, postID uint) (*models.Post, error) {
+	post, err := s.postRepo.GetByID(ctx, postID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if post.UserID != userID {
+		return nil, models.NewUnauthorizedError("You can only publish your own posts")
+	}
+	if !isPostUnpublished(post) {
+		return nil, models.NewValidationError("Post is already published")
+	}
+
+	// The scheduler may publish the post first; only one of them wins.
+	published, err := s.postRepo.Publish(ctx, postID, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if !published {
+		return nil, models.NewValidationError("Post is already published")
+	}
+	return s.getPostWithPollEnriched(ctx, postID, userID)
+}
+
+// PublishDuePosts publishes scheduled posts whose publish_at has passed.
+func (s *PostService) PublishDuePosts(ctx context.Context, limit int) ([]*models.Post, error) {
+	return s.postRepo.PublishDue(ctx, time.Now().UTC(), limit)
 }
 
 func (s *PostService) ToggleLike(ctx context.Context, userID, postID uint) (*models.Post, error) {
+	if _, err := s.readablePost(ctx, postID, userID); err != nil {
+		return nil, err
+	}
 	isLiked, err := s.postRepo.IsLiked(ctx, userID, postID)
 	if err != nil {
 		return nil, err
@@ -395,6 +905,9 @@ func (s *PostService) ToggleLike(ctx context.Context, userID, postID uint) (*mod
 }
 
 func (s *PostService) UnlikePost(ctx context.Context, userID, postID uint) (*models.Post, error) {
+	if _, err := s.readablePost(ctx, postID, userID); err != nil {
+		return nil, err
+	}
 	if err := s.postRepo.Unlike(ctx, userID, postID); err != nil {
 		return nil, err
 	}
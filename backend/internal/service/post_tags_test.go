@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"sanctum/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostService_FlairsAndTags(t *testing.T) {
+	posts, _, db, _ := newGalleryTestService(t)
+	require.NoError(t, db.AutoMigrate(&models.Sanctum{}))
+	ctx := context.Background()
+
+	author := &models.User{Username: "tagger", Email: "tagger@e.com"}
+	require.NoError(t, db.Create(author).Error)
+	sanctum := &models.Sanctum{Name: "Cooking", Slug: "cooking"}
+	require.NoError(t, db.Create(sanctum).Error)
+	recipe := &models.SanctumFlair{SanctumID: sanctum.ID, Name: "Recipe", Color: "#00aa00"}
+	require.NoError(t, db.Create(recipe).Error)
+
+	_, err := posts.CreatePost(ctx, CreatePostInput{
+		UserID: author.ID, Title: "Loose", Content: "x", FlairID: &recipe.ID,
+	})
+	assert.Error(t, err, "flairs need a sanctum")
+	_, err = posts.CreatePost(ctx, CreatePostInput{
+		UserID: author.ID, Title: "Bad tag", Content: "x", Tags: []string{"no spaces"},
+	})
+	assert.Error(t, err)
+
+	soup, err := posts.CreatePost(ctx, CreatePostInput{
+		UserID: author.ID, Title: "Soup", Content: "x", SanctumID: &sanctum.ID,
+		FlairID: &recipe.ID, Tags: []string{"#Vegan", "soup", "vegan"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, soup.Flair)
+	assert.Equal(t, "Recipe", soup.Flair.Name)
+	assert.Equal(t, []models.PostTag{{PostID: soup.ID, Tag: "soup"}, {PostID: soup.ID, Tag: "vegan"}}, soup.Tags)
+
+	_, err = posts.CreatePost(ctx, CreatePostInput{
+		UserID: author.ID, Title: "Question", Content: "x", SanctumID: &sanctum.ID, Tags: []string{"help"},
+	})
+	require.NoError(t, err)
+
+	list := func(in ListPostsInput) []string {
+		in.Limit, in.SanctumID = 20, &sanctum.ID
+		got, err := posts.ListPosts(ctx, in)
+		require.NoError(t, err)
+		titles := make([]string, 0, len(got))
+		for _, p := range got {
+			titles = append(titles, p.Title)
+		}
+		return titles
+	}
+	assert.ElementsMatch(t, []string{"Soup", "Question"}, list(ListPostsInput{}))
+	assert.Equal(t, []string{"Soup"}, list(ListPostsInput{FlairID: recipe.ID}))
+	assert.Equal(t, []string{"Soup"}, list(ListPostsInput{Tag: "#VEGAN"}))
+	assert.Equal(t, []string{"Question"}, list(ListPostsInput{Tag: "help"}))
+
+	_, err = posts.ListPosts(ctx, ListPostsInput{Limit: 20, Tag: "help"})
+	assert.Error(t, err, "filters require a sanctum feed")
+
+	cleared := uint(0)
+	updated, err := posts.UpdatePost(ctx, UpdatePostInput{
+		UserID: author.ID, PostID: soup.ID, FlairID: &cleared, Tags: []string{"stew"},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, updated.Flair)
+	assert.Nil(t, updated.FlairID)
+	require.Len(t, updated.Tags, 1)
+	assert.Equal(t, "stew", updated.Tags[0].Tag)
+	assert.Empty(t, list(ListPostsInput{Tag: "vegan"}))
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"sanctum/internal/database"
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	signupInviteCodeLen = 12
+	maxWaitlistNoteLen  = 500
+)
+
+var errSignupInviteUnusable = models.NewValidationError("This invite code is invalid or has already been used")
+
+// RegistrationService gates signup according to the configured registration
+// mode and manages signup invites and the waitlist.
+type RegistrationService struct {
+	db   *gorm.DB
+	mode models.RegistrationMode
+	// defaultQuota is the number of signup invites a user may issue unless
+	// their account overrides it.
+	defaultQuota int
+}
+
+// ErrUserExists is returned by Register when the username or email is
+// already taken.
+var ErrUserExists = models.NewValidationError("User already exists")
+
+// SignupInviteList is a user's signup invites with their remaining quota.
+type SignupInviteList struct {
+	Invites []models.SignupInvite `json:"invites"`
+	// Remaining is nil for admins, who are not limited.
+	Remaining *int `json:"remaining"`
+	Quota     *int `json:"quota"`
+}
+
+func NewRegistrationService(db *gorm.DB, mode models.RegistrationMode, defaultQuota int) *RegistrationService {
+	if mode == "" {
+		mode = models.RegistrationOpen
+	}
+	return &RegistrationService{db: db, mode: mode, defaultQuota: defaultQuota}
+}
+
+// Mode returns the configured registration mode.
+func (s *RegistrationService) Mode() models.RegistrationMode {
+	return s.mode
+}
+
+// Register creates user if the current mode admits them. An invite code is
+// claimed, and an approved waitlist entry consumed, in the same transaction
+// that creates the account and records who invited it, so a failed signup
+// never burns the invite.
+func (s *RegistrationService) Register(ctx context.Context, user *models.User, inviteCode string) error {
+	inviteCode = strings.TrimSpace(inviteCode)
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var invite *models.SignupInvite
+		var entry *models.WaitlistEntry
+		switch {
+		case inviteCode != "":
+			var err error
+			if invite, err = claimSignupInvite(tx, inviteCode); err != nil {
+				return err
+			}
+			user.InvitedByUserID = &invite.CreatedByUserID
+		case s.mode == models.RegistrationInviteOnly:
+			return models.NewForbiddenError("Registration is invite-only; an invite code is required")
+		case s.mode == models.RegistrationWaitlist:
+			entry = &models.WaitlistEntry{}
+			err := tx.Where("email = ? AND status = ? AND user_id IS NULL", normalizeEmail(user.Email), models.WaitlistStatusApproved).
+				First(entry).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return models.NewForbiddenError("Registration is limited to invited and approved waitlist users")
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(user).Error; err != nil {
+			if database.IsUniqueConstraintError(err) {
+				return ErrUserExists
+			}
+			return err
+		}
+		if invite != nil {
+			if err := tx.Model(invite).Update("used_by_user_id", user.ID).Error; err != nil {
+				return err
+			}
+		}
+		if entry != nil {
+			res := tx.Model(&models.WaitlistEntry{}).Where("id = ? AND user_id IS NULL", entry.ID).Update("user_id", user.ID)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				return models.NewForbiddenError("Registration is limited to invited and approved waitlist users")
+			}
+		}
+		return nil
+	})
+}
+
+// claimSignupInvite marks an unused, unrevoked invite as used. The guarded
+// update lets only one of two concurrent signups claim it.
+func claimSignupInvite(tx *gorm.DB, code string) (*models.SignupInvite, error) {
+	var invite models.SignupInvite
+	err := tx.Where("code = ?", code).First(&invite).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errSignupInviteUnusable
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := tx.Model(&models.SignupInvite{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", invite.ID).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, errSignupInviteUnusable
+	}
+	return &invite, nil
+}
+
+// JoinWaitlist adds email to the waitlist. Only available in waitlist mode.
+func (s *RegistrationService) JoinWaitlist(ctx context.Context, email, note string) (*models.WaitlistEntry, error) {
+	if s.mode != models.RegistrationWaitlist {
+		return nil, models.NewValidationError("The waitlist is not open")
+	}
+	note = strings.TrimSpace(note)
+	if len(note) > maxWaitlistNoteLen {
+		return nil, models.NewValidationError("Note must be 500 characters or fewer")
+	}
+	email = normalizeEmail(email)
+
+	var users int64
+	if err := s.db.WithContext(ctx).Model(&models.User{}).Where("LOWER(email) = ?", email).Count(&users).Error; err != nil {
+		return nil, err
+	}
+	if users > 0 {
+		return nil, models.NewValidationError("An account with this email already exists")
+	}
+	var existing int64
+	if err := s.db.WithContext(ctx).Model(&models.WaitlistEntry{}).Where("email = ?", email).Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, models.NewValidationError("This email is already on the waitlist")
+	}
+
+	entry := models.WaitlistEntry{Email: email, Note: note, Status: models.WaitlistStatusPending}
+	if err := s.db.WithContext(ctx).Create(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListWaitlist returns waitlist entries, oldest first, optionally filtered by
+// status.
+func (s *RegistrationService) ListWaitlist(ctx context.Context, status models.WaitlistStatus, limit, offset int) ([]models.WaitlistEntry, error) {
+	query := s.db.WithContext(ctx).Model(&models.WaitlistEntry{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var entries []models.WaitlistEntry
+	if err := query.Order("created_at ASC, id ASC").Limit(limit).Offset(offset).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ReviewWaitlist approves or rejects a waitlist entry. Entries that already
+// became accounts cannot be changed.
+func (s *RegistrationService) ReviewWaitlist(ctx context.Context, adminID, entryID uint, approve bool) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	if err := s.db.WithContext(ctx).First(&entry, entryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("Waitlist entry", entryID)
+		}
+		return nil, err
+	}
+	if entry.UserID != nil {
+		return nil, models.NewValidationError("This waitlist entry has already signed up")
+	}
+	now := time.Now()
+	entry.Status = models.WaitlistStatusRejected
+	if approve {
+		entry.Status = models.WaitlistStatusApproved
+	}
+	entry.ReviewedByUserID = &adminID
+	entry.ReviewedAt = &now
+	if err := s.db.WithContext(ctx).Model(&entry).Updates(map[string]any{
+		"status":              entry.Status,
+		"reviewed_by_user_id": adminID,
+		"reviewed_at":         now,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListSignupInvites returns the invites userID has issued and how many more
+// they may create.
+func (s *RegistrationService) ListSignupInvites(ctx context.Context, userID uint) (*SignupInviteList, error) {
+	user, err := s.loadUser(ctx, s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	var invites []models.SignupInvite
+	if err := s.db.WithContext(ctx).Preload("UsedBy").
+		Where("created_by_user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Find(&invites).Error; err != nil {
+		return nil, err
+	}
+	list := &SignupInviteList{Invites: invites}
+	if user.IsAdmin {
+		return list, nil
+	}
+	quota := s.quotaFor(user)
+	issued, err := s.countIssued(ctx, s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	remaining := max(quota-int(issued), 0)
+	list.Quota = &quota
+	list.Remaining = &remaining
+	return list, nil
+}
+
+// CreateSignupInvite issues a new single-use signup invite. Revoked unused
+// invites are returned to the quota; admins are not limited.
+func (s *RegistrationService) CreateSignupInvite(ctx context.Context, userID uint) (*models.SignupInvite, error) {
+	var invite models.SignupInvite
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the issuer so concurrent requests count invites one at a
+		// time. SQLite has no row locks but serializes writers anyway.
+		locked := tx
+		if tx.Name() == "postgres" {
+			locked = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		user, err := s.loadUser(ctx, locked, userID)
+		if err != nil {
+			return err
+		}
+		if !user.IsAdmin {
+			issued, err := s.countIssued(ctx, tx, userID)
+			if err != nil {
+				return err
+			}
+			if int(issued) >= s.quotaFor(user) {
+				return models.NewValidationError("You have no signup invites left")
+			}
+		}
+		code, err := randomCode(ctx, tx, &models.SignupInvite{}, signupInviteCodeLen)
+		if err != nil {
+			return err
+		}
+		invite = models.SignupInvite{Code: code, CreatedByUserID: userID}
+		return tx.Create(&invite).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// RevokeSignupInvite disables one of userID's unused invites.
+func (s *RegistrationService) RevokeSignupInvite(ctx context.Context, userID, inviteID uint) (*models.SignupInvite, error) {
+	var invite models.SignupInvite
+	err := s.db.WithContext(ctx).Where("id = ? AND created_by_user_id = ?", inviteID, userID).First(&invite).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.NewNotFoundError("Signup invite", inviteID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if invite.UsedAt != nil {
+		return nil, models.NewValidationError("This invite has already been used")
+	}
+	if invite.RevokedAt != nil {
+		return &invite, nil
+	}
+	now := time.Now()
+	res := s.db.WithContext(ctx).Model(&models.SignupInvite{}).
+		Where("id = ? AND used_at IS NULL", invite.ID).
+		Update("revoked_at", now)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, models.NewValidationError("This invite has already been used")
+	}
+	invite.RevokedAt = &now
+	return &invite, nil
+}
+
+// SetInviteQuota overrides a user's signup invite quota. nil restores the
+// configured default.
+func (s *RegistrationService) SetInviteQuota(ctx context.Context, userID uint, quota *int) (*models.User, error) {
+	if quota != nil && *quota < 0 {
+		return nil, models.NewValidationError("Invite quota cannot be negative")
+	}
+	user, err := s.loadUser(ctx, s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Model(user).Update("signup_invite_quota", quota).Error; err != nil {
+		return nil, err
+	}
+	user.SignupInviteQuota = quota
+	return user, nil
+}
+
+// InviteQuota returns the number of signup invites user may issue in total.
+func (s *RegistrationService) InviteQuota(user *models.User) int {
+	return s.quotaFor(user)
+}
+
+func (s *RegistrationService) quotaFor(user *models.User) int {
+	if user.SignupInviteQuota != nil {
+		return *user.SignupInviteQuota
+	}
+	return s.defaultQuota
+}
+
+func (s *RegistrationService) countIssued(ctx context.Context, db *gorm.DB, userID uint) (int64, error) {
+	var count int64
+	err := db.WithContext(ctx).Model(&models.SignupInvite{}).
+		Where("created_by_user_id = ? AND (revoked_at IS NULL OR used_at IS NOT NULL)", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (s *RegistrationService) loadUser(ctx context.Context, db *gorm.DB, userID uint) (*models.User, error) {
+	var user models.User
+	if err := db.WithContext(ctx).First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("User", userID)
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"sanctum/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newRegistrationTestService(t *testing.T, mode models.RegistrationMode) (*RegistrationService, *gorm.DB) {
+	t.Helper()
+	db := newTestDB(t, &models.SignupInvite{}, &models.WaitlistEntry{})
+	return NewRegistrationService(db, mode, 2), db
+}
+
+func TestRegistrationService_InviteQuota(t *testing.T) {
+	reg, db := newRegistrationTestService(t, models.RegistrationInviteOnly)
+	ctx := context.Background()
+	inviter := createTestUser(t, db, "inviter")
+	admin := &models.User{Username: "admin", Email: "admin@e.com", IsAdmin: true}
+	require.NoError(t, db.Create(admin).Error)
+
+	first, err := reg.CreateSignupInvite(ctx, inviter.ID)
+	require.NoError(t, err)
+	assert.Len(t, first.Code, signupInviteCodeLen)
+	second, err := reg.CreateSignupInvite(ctx, inviter.ID)
+	require.NoError(t, err)
+	_, err = reg.CreateSignupInvite(ctx, inviter.ID)
+	requireCode(t, err, "VALIDATION_ERROR")
+
+	// Revoking an unused invite returns it to the quota.
+	_, err = reg.RevokeSignupInvite(ctx, inviter.ID, second.ID)
+	require.NoError(t, err)
+	list, err := reg.ListSignupInvites(ctx, inviter.ID)
+	require.NoError(t, err)
+	require.NotNil(t, list.Remaining)
+	assert.Equal(t, 1, *list.Remaining)
+
+	// Admins are not limited; overrides replace the default quota.
+	list, err = reg.ListSignupInvites(ctx, admin.ID)
+	require.NoError(t, err)
+	assert.Nil(t, list.Remaining)
+	zero := 0
+	_, err = reg.SetInviteQuota(ctx, inviter.ID, &zero)
+	require.NoError(t, err)
+	_, err = reg.CreateSignupInvite(ctx, inviter.ID)
+	requireCode(t, err, "VALIDATION_ERROR")
+}
+
+func TestRegistrationService_Register(t *testing.T) {
+	reg, db := newRegistrationTestService(t, models.RegistrationInviteOnly)
+	ctx := context.Background()
+	inviter := createTestUser(t, db, "inviter")
+	usable, err := reg.CreateSignupInvite(ctx, inviter.ID)
+	require.NoError(t, err)
+	revoked, err := reg.CreateSignupInvite(ctx, inviter.ID)
+	require.NoError(t, err)
+	_, err = reg.RevokeSignupInvite(ctx, inviter.ID, revoked.ID)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		user string
+		code string
+		err  string
+	}{
+		{"code required", "new1", "", "FORBIDDEN"},
+		{"unknown code", "new2", "nope", "VALIDATION_ERROR"},
+		{"revoked code", "new3", revoked.Code, "VALIDATION_ERROR"},
+		{"usable code", "new4", usable.Code, ""},
+		{"claimed code", "new5", usable.Code, "VALIDATION_ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{Username: tt.user, Email: tt.user + "@e.com"}
+			err := reg.Register(ctx, user, tt.code)
+			if tt.err != "" {
+				requireCode(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, user.InvitedByUserID)
+			assert.Equal(t, inviter.ID, *user.InvitedByUserID)
+		})
+	}
+}
+
+func TestRegistrationService_InviteSignup(t *testing.T) {
+	reg, db := newRegistrationTestService(t, models.RegistrationInviteOnly)
+	ctx := context.Background()
+	inviter := createTestUser(t, db, "inviter")
+	invite, err := reg.CreateSignupInvite(ctx, inviter.ID)
+	require.NoError(t, err)
+
+	// A failed account creation rolls back the claim.
+	taken := &models.User{Username: "inviter", Email: "other@e.com"}
+	assert.ErrorIs(t, reg.Register(ctx, taken, invite.Code), ErrUserExists)
+	require.NoError(t, db.First(invite, invite.ID).Error)
+	assert.Nil(t, invite.UsedAt)
+
+	invitee := &models.User{Username: "invitee", Email: "invitee@e.com"}
+	require.NoError(t, reg.Register(ctx, invitee, invite.Code))
+
+	require.NoError(t, db.First(invitee, invitee.ID).Error)
+	require.NotNil(t, invitee.InvitedByUserID)
+	assert.Equal(t, inviter.ID, *invitee.InvitedByUserID)
+	require.NoError(t, db.First(invite, invite.ID).Error)
+	require.NotNil(t, invite.UsedByUserID)
+	assert.Equal(t, invitee.ID, *invite.UsedByUserID)
+	_, err = reg.RevokeSignupInvite(ctx, inviter.ID, invite.ID)
+	requireCode(t, err, "VALIDATION_ERROR")
+}
+
+func TestRegistrationService_JoinWaitlist(t *testing.T) {
+	reg, db := newRegistrationTestService(t, models.RegistrationWaitlist)
+	ctx := context.Background()
+	createTestUser(t, db, "taken")
+	_, err := reg.JoinWaitlist(ctx, " Early@E.com ", "Long-time lurker")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		mode  models.RegistrationMode
+		email string
+	}{
+		{"only in waitlist mode", models.RegistrationOpen, "new@e.com"},
+		{"already waiting", models.RegistrationWaitlist, "early@e.com"},
+		{"existing account", models.RegistrationWaitlist, "taken@e.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRegistrationService(db, tt.mode, 2).JoinWaitlist(ctx, tt.email, "")
+			requireCode(t, err, "VALIDATION_ERROR")
+		})
+	}
+}
+
+func TestRegistrationService_WaitlistSignup(t *testing.T) {
+	reg, db := newRegistrationTestService(t, models.RegistrationWaitlist)
+	ctx := context.Background()
+	admin := &models.User{Username: "admin", Email: "admin@e.com", IsAdmin: true}
+	require.NoError(t, db.Create(admin).Error)
+	entry, err := reg.JoinWaitlist(ctx, " Early@E.com ", "Long-time lurker")
+	require.NoError(t, err)
+	assert.Equal(t, "early@e.com", entry.Email)
+
+	err = reg.Register(ctx, &models.User{Username: "early", Email: "early@e.com"}, "")
+	requireCode(t, err, "FORBIDDEN")
+
+	pending, err := reg.ListWaitlist(ctx, models.WaitlistStatusPending, 50, 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	approved, err := reg.ReviewWaitlist(ctx, admin.ID, entry.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, models.WaitlistStatusApproved, approved.Status)
+
+	user := &models.User{Username: "early", Email: "EARLY@e.com"}
+	require.NoError(t, reg.Register(ctx, user, ""))
+	require.NoError(t, db.First(entry, entry.ID).Error)
+	require.NotNil(t, entry.UserID)
+	assert.Equal(t, user.ID, *entry.UserID)
+
+	_, err = reg.ReviewWaitlist(ctx, admin.ID, entry.ID, false)
+	requireCode(t, err, "VALIDATION_ERROR")
+	err = reg.Register(ctx, &models.User{Username: "early2", Email: "early@e.com"}, "")
+	requireCode(t, err, "FORBIDDEN")
+}
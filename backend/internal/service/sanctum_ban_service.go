@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxSanctumMuteDuration bounds how long a mute may last; longer
+// restrictions should be bans.
+const maxSanctumMuteDuration = 365 * 24 * time.Hour
+
+// SanctumBanService manages sanctum-scoped bans and mutes.
+type SanctumBanService struct {
+	db *gorm.DB
+	// canManage reports whether a user may ban and unban in a sanctum.
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error)
+}
+
+type BanSanctumUserInput struct {
+	ActorID   uint
+	SanctumID uint
+	UserID    uint
+	Kind      models.SanctumBanKind
+	Reason    string
+	ExpiresAt *time.Time
+}
+
+func NewSanctumBanService(
+	db *gorm.DB,
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error),
+) *SanctumBanService {
+	return &SanctumBanService{db: db, canManage: canManage}
+}
+
+// activeSanctumBan returns the user's ban or mute in force in a sanctum, or
+// nil when there is none.
+func activeSanctumBan(ctx context.Context, db *gorm.DB, sanctumID, userID uint) (*models.SanctumBan, error) {
+	var ban models.SanctumBan
+	err := db.WithContext(ctx).
+		Where("sanctum_id = ? AND user_id = ?", sanctumID, userID).
+		First(&ban).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !ban.ActiveAt(time.Now()) {
+		return nil, nil
+	}
+	return &ban, nil
+}
+
+// sanctumBanError is the error returned to a banned or muted user.
+func sanctumBanError(ban *models.SanctumBan) error {
+	verb := "banned from"
+	if ban.Kind == models.SanctumBanKindMute {
+		verb = "muted in"
+	}
+	msg := "You are " + verb + " this sanctum"
+	if ban.ExpiresAt != nil {
+		msg += " until " + ban.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return models.NewForbiddenError(msg)
+}
+
+// CheckAccess returns a forbidden error when the user is banned or muted in
+// the sanctum. Post, comment and chat paths call it before writing.
+func (s *SanctumBanService) CheckAccess(ctx context.Context, sanctumID, userID uint) error {
+	ban, err := activeSanctumBan(ctx, s.db, sanctumID, userID)
+	if err != nil {
+		return err
+	}
+	if ban != nil {
+		return sanctumBanError(ban)
+	}
+	return nil
+}
+
+// IsBanned reports whether a full ban (not a mute) keeps the user out of the
+// sanctum.
+func (s *SanctumBanService) IsBanned(ctx context.Context, sanctumID, userID uint) (bool, error) {
+	ban, err := activeSanctumBan(ctx, s.db, sanctumID, userID)
+	if err != nil {
+		return false, err
+	}
+	return ban != nil && ban.Kind == models.SanctumBanKindBan, nil
+}
+
+func (s *SanctumBanService) requireManager(ctx context.Context, actorID, sanctumID uint) error {
+	if s.canManage == nil {
+		return models.NewForbiddenError("Sanctum owner access required")
+	}
+	ok, err := s.canManage(ctx, actorID, sanctumID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.NewForbiddenError("Sanctum owner access required")
+	}
+	return nil
+}
+
+// Ban bans or mutes a user in a sanctum, replacing any existing restriction.
+// Bans also drop the user's membership; owners and moderators must be
+// demoted first.
+func (s *SanctumBanService) Ban(ctx context.Context, in BanSanctumUserInput) (*models.SanctumBan, error) {
+	if err := s.requireManager(ctx, in.ActorID, in.SanctumID); err != nil {
+		return nil, err
+	}
+	if in.UserID == 0 {
+		return nil, models.NewValidationError("user_id is required")
+	}
+	if in.UserID == in.ActorID {
+		return nil, models.NewValidationError("You cannot ban yourself")
+	}
+	if in.Kind == "" {
+		in.Kind = models.SanctumBanKindBan
+	}
+	now := time.Now()
+	switch in.Kind {
+	case models.SanctumBanKindBan:
+		if in.ExpiresAt != nil && !in.ExpiresAt.After(now) {
+			return nil, models.NewValidationError("expires_at must be in the future")
+		}
+	case models.SanctumBanKindMute:
+		if in.ExpiresAt == nil || !in.ExpiresAt.After(now) {
+			return nil, models.NewValidationError("Mutes require a future expires_at")
+		}
+		if in.ExpiresAt.After(now.Add(maxSanctumMuteDuration)) {
+			return nil, models.NewValidationError("Mutes cannot last more than a year; use a ban")
+		}
+	default:
+		return nil, models.NewValidationError("kind must be 'ban' or 'mute'")
+	}
+	in.Reason = strings.TrimSpace(in.Reason)
+	if len(in.Reason) > 500 {
+		return nil, models.NewValidationError("Reason too long (max 500 characters)")
+	}
+
+	var user models.User
+	if err := s.db.WithContext(ctx).Select("id").First(&user, in.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("User", in.UserID)
+		}
+		return nil, err
+	}
+	var membership models.SanctumMembership
+	err := s.db.WithContext(ctx).
+		Where("sanctum_id = ? AND user_id = ?", in.SanctumID, in.UserID).
+		First(&membership).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err == nil && membership.Role != models.SanctumMembershipRoleMember {
+		return nil, models.NewValidationError("Demote sanctum owners and moderators before banning them")
+	}
+
+	ban := models.SanctumBan{
+		SanctumID:       in.SanctumID,
+		UserID:          in.UserID,
+		Kind:            in.Kind,
+		Reason:          in.Reason,
+		ExpiresAt:       in.ExpiresAt,
+		CreatedByUserID: in.ActorID,
+	}
+	action := models.ModActionBanUser
+	if in.Kind == models.SanctumBanKindMute {
+		action = models.ModActionMuteUser
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "sanctum_id"}, {Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"kind":               ban.Kind,
+				"reason":             ban.Reason,
+				"expires_at":         ban.ExpiresAt,
+				"created_by_user_id": ban.CreatedByUserID,
+				"updated_at":         now,
+			}),
+		}).Create(&ban).Error; err != nil {
+			return err
+		}
+		if in.Kind == models.SanctumBanKindBan {
+			if err := tx.Where("sanctum_id = ? AND user_id = ? AND role = ?",
+				in.SanctumID, in.UserID, models.SanctumMembershipRoleMember).
+				Delete(&models.SanctumMembership{}).Error; err != nil {
+				return err
+			}
+			if err := SyncSanctumChannelAccess(ctx, tx, in.SanctumID, in.UserID, false); err != nil {
+				return err
+			}
+		}
+		return RecordModAction(tx, in.SanctumID, in.ActorID, action, models.ModTargetUser, in.UserID, in.Reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var saved models.SanctumBan
+	if err := s.db.WithContext(ctx).Preload("User").
+		Where("sanctum_id = ? AND user_id = ?", in.SanctumID, in.UserID).
+		First(&saved).Error; err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// Unban lifts a user's ban or mute in a sanctum.
+func (s *SanctumBanService) Unban(ctx context.Context, actorID, sanctumID, userID uint) error {
+	if err := s.requireManager(ctx, actorID, sanctumID); err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("sanctum_id = ? AND user_id = ?", sanctumID, userID).Delete(&models.SanctumBan{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return models.NewNotFoundError("Sanctum ban", userID)
+		}
+		return RecordModAction(tx, sanctumID, actorID, models.ModActionUnbanUser, models.ModTargetUser, userID, "")
+	})
+}
+
+// ListBans returns the bans and mutes currently in force in a sanctum.
+func (s *SanctumBanService) ListBans(ctx context.Context, actorID, sanctumID uint) ([]models.SanctumBan, error) {
+	if err := s.requireManager(ctx, actorID, sanctumID); err != nil {
+		return nil, err
+	}
+	bans := []models.SanctumBan{}
+	if err := s.db.WithContext(ctx).
+		Preload("User").
+		Where("sanctum_id = ? AND (expires_at IS NULL OR expires_at > ?)", sanctumID, time.Now()).
+		Order("created_at DESC").
+		Find(&bans).Error; err != nil {
+		return nil, err
+	}
+	return bans, nil
+}
+
+// checkSanctumAccess runs an optional access check for a post or comment in
+// sanctumID. Posts outside a sanctum are never restricted.
+func checkSanctumAccess(ctx context.Context, check func(ctx context.Context, sanctumID, userID uint) error, sanctumID *uint, userID uint) error {
+	if check == nil || sanctumID == nil {
+		return nil
+	}
+	return check(ctx, *sanctumID, userID)
+}
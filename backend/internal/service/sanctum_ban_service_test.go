@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBanTestService(t *testing.T) (*SanctumBanService, *sanctumFixture) {
+	t.Helper()
+	f := newSanctumFixture(t,
+		&models.SanctumBan{},
+		&models.SanctumModLogEntry{},
+		&models.Conversation{},
+		&models.ConversationParticipant{},
+	)
+	return NewSanctumBanService(f.db, f.isOwner), f
+}
+
+func TestSanctumBanService_BanValidation(t *testing.T) {
+	bans, f := newBanTestService(t)
+	ctx := context.Background()
+	past, later := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	tooLong := time.Now().Add(2 * maxSanctumMuteDuration)
+
+	tests := []struct {
+		name string
+		in   BanSanctumUserInput
+		code string
+	}{
+		{"moderators cannot ban", BanSanctumUserInput{ActorID: f.mod.ID, UserID: f.member.ID}, "FORBIDDEN"},
+		{"moderators must be demoted first", BanSanctumUserInput{ActorID: f.owner.ID, UserID: f.mod.ID}, "VALIDATION_ERROR"},
+		{"no self bans", BanSanctumUserInput{ActorID: f.owner.ID, UserID: f.owner.ID}, "VALIDATION_ERROR"},
+		{"mutes need an expiry", BanSanctumUserInput{ActorID: f.owner.ID, UserID: f.member.ID, Kind: models.SanctumBanKindMute}, "VALIDATION_ERROR"},
+		{"mutes are capped", BanSanctumUserInput{ActorID: f.owner.ID, UserID: f.member.ID, Kind: models.SanctumBanKindMute, ExpiresAt: &tooLong}, "VALIDATION_ERROR"},
+		{"expiry in the past", BanSanctumUserInput{ActorID: f.owner.ID, UserID: f.member.ID, ExpiresAt: &past}, "VALIDATION_ERROR"},
+		{"unknown kind", BanSanctumUserInput{ActorID: f.owner.ID, UserID: f.member.ID, Kind: "kick", ExpiresAt: &later}, "VALIDATION_ERROR"},
+		{"unknown user", BanSanctumUserInput{ActorID: f.owner.ID, UserID: 9999}, "NOT_FOUND"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.SanctumID = f.sanctum.ID
+			_, err := bans.Ban(ctx, tt.in)
+			requireCode(t, err, tt.code)
+		})
+	}
+}
+
+func TestSanctumBanService_CheckAccess(t *testing.T) {
+	bans, f := newBanTestService(t)
+	ctx := context.Background()
+	past, later := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		ban       *models.SanctumBan
+		forbidden bool
+		banned    bool
+	}{
+		{"no restriction", nil, false, false},
+		{"active mute", &models.SanctumBan{Kind: models.SanctumBanKindMute, ExpiresAt: &later}, true, false},
+		{"expired mute", &models.SanctumBan{Kind: models.SanctumBanKindMute, ExpiresAt: &past}, false, false},
+		{"permanent ban", &models.SanctumBan{Kind: models.SanctumBanKindBan}, true, true},
+		{"expired ban", &models.SanctumBan{Kind: models.SanctumBanKindBan, ExpiresAt: &past}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, f.db.Where("1 = 1").Delete(&models.SanctumBan{}).Error)
+			if tt.ban != nil {
+				tt.ban.SanctumID, tt.ban.UserID, tt.ban.CreatedByUserID = f.sanctum.ID, f.member.ID, f.owner.ID
+				require.NoError(t, f.db.Create(tt.ban).Error)
+			}
+			err := bans.CheckAccess(ctx, f.sanctum.ID, f.member.ID)
+			if tt.forbidden {
+				requireCode(t, err, "FORBIDDEN")
+			} else {
+				assert.NoError(t, err)
+			}
+			banned, err := bans.IsBanned(ctx, f.sanctum.ID, f.member.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.banned, banned)
+		})
+	}
+}
+
+func TestSanctumBanService_BanReplacesMuteAndDropsMembership(t *testing.T) {
+	bans, f := newBanTestService(t)
+	ctx := context.Background()
+	later := time.Now().Add(time.Hour)
+
+	mute, err := bans.Ban(ctx, BanSanctumUserInput{
+		ActorID: f.owner.ID, SanctumID: f.sanctum.ID, UserID: f.member.ID,
+		Kind: models.SanctumBanKindMute, ExpiresAt: &later,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.SanctumBanKindMute, mute.Kind)
+	var memberships int64
+	f.db.Model(&models.SanctumMembership{}).Where("sanctum_id = ? AND user_id = ?", f.sanctum.ID, f.member.ID).Count(&memberships)
+	assert.Equal(t, int64(1), memberships, "a mute keeps the membership")
+
+	_, err = bans.Ban(ctx, BanSanctumUserInput{ActorID: f.owner.ID, SanctumID: f.sanctum.ID, UserID: f.member.ID, Reason: "spam"})
+	require.NoError(t, err)
+	f.db.Model(&models.SanctumMembership{}).Where("sanctum_id = ? AND user_id = ?", f.sanctum.ID, f.member.ID).Count(&memberships)
+	assert.Zero(t, memberships)
+	list, err := bans.ListBans(ctx, f.owner.ID, f.sanctum.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 1, "the ban replaces the mute")
+	assert.Equal(t, models.SanctumBanKindBan, list[0].Kind)
+	assert.Equal(t, "member", list[0].User.Username)
+
+	require.NoError(t, bans.Unban(ctx, f.owner.ID, f.sanctum.ID, f.member.ID))
+	assert.NoError(t, bans.CheckAccess(ctx, f.sanctum.ID, f.member.ID))
+	requireCode(t, bans.Unban(ctx, f.owner.ID, f.sanctum.ID, f.member.ID), "NOT_FOUND")
+
+	var actions []string
+	require.NoError(t, f.db.Model(&models.SanctumModLogEntry{}).Order("id").Pluck("action", &actions).Error)
+	assert.Equal(t, []string{models.ModActionMuteUser, models.ModActionBanUser, models.ModActionUnbanUser}, actions)
+}
+
+func TestSanctumBanService_CheckAccessFailsWithoutBanTable(t *testing.T) {
+	f := newSanctumFixture(t)
+	bans := NewSanctumBanService(f.db, f.isOwner)
+	assert.Error(t, bans.CheckAccess(context.Background(), f.sanctum.ID, f.member.ID),
+		"a missing table is an error, not \"not banned\"")
+}
+
+func TestChatService_SendMessage_SanctumMute(t *testing.T) {
+	f := newSanctumFixture(t, &models.SanctumBan{}, &models.SanctumAutomod{}, &models.ChatroomMute{})
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, f.db.Create(&models.SanctumBan{
+		SanctumID: f.sanctum.ID, UserID: f.member.ID, CreatedByUserID: f.owner.ID,
+		Kind: models.SanctumBanKindMute, ExpiresAt: &later,
+	}).Error)
+	repo := noopChatRepo()
+	repo.getConversationFn = func(context.Context, uint) (*models.Conversation, error) {
+		return &models.Conversation{ID: 1, IsGroup: true, SanctumID: &f.sanctum.ID,
+			Participants: []models.User{*f.member, *f.mod}}, nil
+	}
+	svc := NewChatService(repo, noopUserRepo(), f.db, nil, nil, nil)
+
+	_, _, err := svc.SendMessage(context.Background(), SendMessageInput{UserID: f.member.ID, ConversationID: 1, Content: "hi"})
+	requireCode(t, err, "FORBIDDEN")
+	_, _, err = svc.SendMessage(context.Background(), SendMessageInput{UserID: f.mod.ID, ConversationID: 1, Content: "hi"})
+	assert.NoError(t, err)
+}
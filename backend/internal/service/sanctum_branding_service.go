@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// SanctumImageSlot names a sanctum branding image.
+type SanctumImageSlot string
+
+const (
+	SanctumImageIcon   SanctumImageSlot = "icon"
+	SanctumImageBanner SanctumImageSlot = "banner"
+)
+
+func (slot SanctumImageSlot) column() (string, bool) {
+	switch slot {
+	case SanctumImageIcon:
+		return "icon_hash", true
+	case SanctumImageBanner:
+		return "banner_hash", true
+	}
+	return "", false
+}
+
+var accentColorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// SanctumBrandingService manages a sanctum's icon, banner and accent colours.
+// Images go through the ImageService upload pipeline.
+type SanctumBrandingService struct {
+	db     *gorm.DB
+	images *ImageService
+	// canManage reports whether a user owns the sanctum.
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error)
+}
+
+type UpdateSanctumThemeInput struct {
+	ActorID   uint
+	SanctumID uint
+	// Nil fields are left unchanged; an empty string clears the colour.
+	AccentColor     *string
+	AccentColorDark *string
+}
+
+func NewSanctumBrandingService(
+	db *gorm.DB,
+	images *ImageService,
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error),
+) *SanctumBrandingService {
+	return &SanctumBrandingService{db: db, images: images, canManage: canManage}
+}
+
+// NormalizeAccentColor lower-cases a #rrggbb colour. The empty string is
+// allowed and means the default theme colour.
+func NormalizeAccentColor(color string) (string, error) {
+	color = strings.ToLower(strings.TrimSpace(color))
+	if color != "" && !accentColorPattern.MatchString(color) {
+		return "", models.NewValidationError("Accent colours must be hex colours like #1a2b3c")
+	}
+	return color, nil
+}
+
+func (s *SanctumBrandingService) loadManaged(ctx context.Context, actorID, sanctumID uint) (*models.Sanctum, error) {
+	if s.canManage != nil {
+		ok, err := s.canManage(ctx, actorID, sanctumID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, models.NewForbiddenError("Only the sanctum owner can change its branding")
+		}
+	}
+	var sanctum models.Sanctum
+	if err := s.db.WithContext(ctx).First(&sanctum, sanctumID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("Sanctum", sanctumID)
+		}
+		return nil, err
+	}
+	return &sanctum, nil
+}
+
+// SetImage uploads a new icon or banner and releases the one it replaces.
+func (s *SanctumBrandingService) SetImage(ctx context.Context, sanctumID uint, slot SanctumImageSlot, in UploadImageInput) (*models.Sanctum, *models.Image, error) {
+	column, ok := slot.column()
+	if !ok {
+		return nil, nil, models.NewValidationError("Unknown sanctum image")
+	}
+	sanctum, err := s.loadManaged(ctx, in.UserID, sanctumID)
+	if err != nil {
+		return nil, nil, err
+	}
+	img, err := s.images.Upload(ctx, in)
+	if err != nil {
+		return nil, nil, err
+	}
+	previous := sanctum.IconHash
+	if slot == SanctumImageBanner {
+		previous = sanctum.BannerHash
+	}
+	if err := s.db.WithContext(ctx).Model(sanctum).Update(column, img.Hash).Error; err != nil {
+		return nil, nil, err
+	}
+	if previous != "" && previous != img.Hash {
+		s.images.ReleaseImages(ctx, []string{previous})
+	}
+	return sanctum, img, nil
+}
+
+// ClearImage removes a sanctum's icon or banner.
+func (s *SanctumBrandingService) ClearImage(ctx context.Context, actorID, sanctumID uint, slot SanctumImageSlot) (*models.Sanctum, error) {
+	column, ok := slot.column()
+	if !ok {
+		return nil, models.NewValidationError("Unknown sanctum image")
+	}
+	sanctum, err := s.loadManaged(ctx, actorID, sanctumID)
+	if err != nil {
+		return nil, err
+	}
+	previous := sanctum.IconHash
+	if slot == SanctumImageBanner {
+		previous = sanctum.BannerHash
+	}
+	if previous == "" {
+		return sanctum, nil
+	}
+	if err := s.db.WithContext(ctx).Model(sanctum).Update(column, "").Error; err != nil {
+		return nil, err
+	}
+	s.images.ReleaseImages(ctx, []string{previous})
+	return sanctum, nil
+}
+
+// UpdateTheme sets a sanctum's accent colours.
+func (s *SanctumBrandingService) UpdateTheme(ctx context.Context, in UpdateSanctumThemeInput) (*models.Sanctum, error) {
+	updates := map[string]any{}
+	if in.AccentColor != nil {
+		color, err := NormalizeAccentColor(*in.AccentColor)
+		if err != nil {
+			return nil, err
+		}
+		updates["accent_color"] = color
+	}
+	if in.AccentColorDark != nil {
+		color, err := NormalizeAccentColor(*in.AccentColorDark)
+		if err != nil {
+			return nil, err
+		}
+		updates["accent_color_dark"] = color
+	}
+	sanctum, err := s.loadManaged(ctx, in.ActorID, in.SanctumID)
+	if err != nil {
+		return nil, err
+	}
+	if len(updates) == 0 {
+		return sanctum, nil
+	}
+	if err := s.db.WithContext(ctx).Model(sanctum).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return sanctum, nil
+}
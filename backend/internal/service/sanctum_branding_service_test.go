@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"sanctum/internal/config"
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBrandingTestService returns a branding service whose image service
+// sweeps released images immediately.
+func newBrandingTestService(t *testing.T) (*SanctumBrandingService, *ImageService, *sanctumFixture) {
+	t.Helper()
+	f := newSanctumFixture(t, &models.ImageVariant{}, &models.PostImage{}, &models.Post{}, &models.Conversation{}, &models.Message{})
+	require.NoError(t, f.db.Exec(sqliteImagesDDL).Error)
+	images := NewImageService(repository.NewImageRepository(f.db), &config.Config{ImageUploadDir: t.TempDir()})
+	images.releaseGrace = 0
+	return NewSanctumBrandingService(f.db, images, f.isOwner), images, f
+}
+
+func brandingUpload(t *testing.T, userID uint, w int) UploadImageInput {
+	t.Helper()
+	return UploadImageInput{UserID: userID, Filename: "a.png", ContentType: "image/png", Content: tinyPNG(t, w, 40)}
+}
+
+func imageExists(t *testing.T, f *sanctumFixture, hash string) bool {
+	t.Helper()
+	var count int64
+	require.NoError(t, f.db.Model(&models.Image{}).Where("hash = ?", hash).Count(&count).Error)
+	return count > 0
+}
+
+func TestSanctumBrandingService_SetImage(t *testing.T) {
+	branding, images, f := newBrandingTestService(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		slot SanctumImageSlot
+		user uint
+		code string
+	}{
+		{"moderators cannot brand", SanctumImageIcon, f.mod.ID, "FORBIDDEN"},
+		{"unknown slot", "cover", f.owner.ID, "VALIDATION_ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := branding.SetImage(ctx, f.sanctum.ID, tt.slot, brandingUpload(t, tt.user, 50))
+			requireCode(t, err, tt.code)
+		})
+	}
+
+	_, first, err := branding.SetImage(ctx, f.sanctum.ID, SanctumImageIcon, brandingUpload(t, f.owner.ID, 50))
+	require.NoError(t, err)
+	updated, banner, err := branding.SetImage(ctx, f.sanctum.ID, SanctumImageBanner, brandingUpload(t, f.owner.ID, 120))
+	require.NoError(t, err)
+	assert.Equal(t, first.Hash, updated.IconHash)
+	assert.Equal(t, banner.Hash, updated.BannerHash)
+
+	// Replacing the icon releases the old upload.
+	updated, second, err := branding.SetImage(ctx, f.sanctum.ID, SanctumImageIcon, brandingUpload(t, f.owner.ID, 60))
+	require.NoError(t, err)
+	images.SweepReleasedImages(ctx)
+	assert.Equal(t, second.Hash, updated.IconHash)
+	assert.False(t, imageExists(t, f, first.Hash))
+	assert.True(t, imageExists(t, f, second.Hash))
+}
+
+func TestSanctumBrandingService_ClearImage(t *testing.T) {
+	branding, images, f := newBrandingTestService(t)
+	ctx := context.Background()
+	_, banner, err := branding.SetImage(ctx, f.sanctum.ID, SanctumImageBanner, brandingUpload(t, f.owner.ID, 120))
+	require.NoError(t, err)
+
+	// An image still used as a banner is kept when released elsewhere.
+	images.ReleaseImages(ctx, []string{banner.Hash})
+	assert.Zero(t, images.SweepReleasedImages(ctx))
+	assert.True(t, imageExists(t, f, banner.Hash))
+
+	_, err = branding.ClearImage(ctx, f.mod.ID, f.sanctum.ID, SanctumImageBanner)
+	requireCode(t, err, "FORBIDDEN")
+	updated, err := branding.ClearImage(ctx, f.owner.ID, f.sanctum.ID, SanctumImageBanner)
+	require.NoError(t, err)
+	images.SweepReleasedImages(ctx)
+	assert.Empty(t, updated.BannerHash)
+	assert.False(t, imageExists(t, f, banner.Hash))
+}
+
+func TestSanctumBrandingService_UpdateTheme(t *testing.T) {
+	branding, _, f := newBrandingTestService(t)
+	ctx := context.Background()
+	accent, upper, empty, bad := "#1a2b3c", "#1A2B3C", "", "red"
+
+	tests := []struct {
+		name   string
+		in     UpdateSanctumThemeInput
+		code   string
+		accent string
+		dark   string
+	}{
+		{"not a hex colour", UpdateSanctumThemeInput{ActorID: f.owner.ID, AccentColor: &bad}, "VALIDATION_ERROR", "", ""},
+		{"moderators cannot theme", UpdateSanctumThemeInput{ActorID: f.mod.ID, AccentColor: &accent}, "FORBIDDEN", "", ""},
+		{"lower-cases colours", UpdateSanctumThemeInput{ActorID: f.owner.ID, AccentColor: &upper, AccentColorDark: &upper}, "", accent, accent},
+		{"nil leaves a colour alone", UpdateSanctumThemeInput{ActorID: f.owner.ID, AccentColorDark: &empty}, "", accent, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.SanctumID = f.sanctum.ID
+			updated, err := branding.UpdateTheme(ctx, tt.in)
+			if tt.code != "" {
+				requireCode(t, err, tt.code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.accent, updated.AccentColor)
+			assert.Equal(t, tt.dark, updated.AccentColorDark)
+		})
+	}
+}
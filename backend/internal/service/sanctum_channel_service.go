@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"sanctum/internal/cache"
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxChannelNameLen     = 50
+	maxChannelTopicLen    = 300
+	maxChannelsPerSanctum = 50
+)
+
+// SanctumChannelService manages the chat channels a sanctum owns. Channel
+// access follows sanctum membership: members are participants of every
+// channel of their sanctums.
+type SanctumChannelService struct {
+	db *gorm.DB
+	// canManage reports whether a user moderates the sanctum.
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error)
+	// canRead hides private sanctums' channels from non-members.
+	canRead func(ctx context.Context, sanctumID, userID uint) (bool, error)
+}
+
+type CreateSanctumChannelInput struct {
+	ActorID        uint
+	SanctumID      uint
+	Name           string
+	Topic          string
+	PostPermission models.ChannelPostPermission
+}
+
+// UpdateSanctumChannelInput changes the non-nil fields of a channel.
+type UpdateSanctumChannelInput struct {
+	ActorID        uint
+	SanctumID      uint
+	ChannelID      uint
+	Name           *string
+	Topic          *string
+	PostPermission *models.ChannelPostPermission
+}
+
+func NewSanctumChannelService(
+	db *gorm.DB,
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error),
+	canRead func(ctx context.Context, sanctumID, userID uint) (bool, error),
+) *SanctumChannelService {
+	return &SanctumChannelService{db: db, canManage: canManage, canRead: canRead}
+}
+
+// ListChannels returns the sanctum's channels in display order.
+func (s *SanctumChannelService) ListChannels(ctx context.Context, sanctumID, viewerID uint) ([]models.Conversation, error) {
+	if s.canRead != nil {
+		ok, err := s.canRead(ctx, sanctumID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, models.NewForbiddenError("This sanctum is private")
+		}
+	}
+	var channels []models.Conversation
+	if err := s.db.WithContext(ctx).
+		Where("sanctum_id = ?", sanctumID).
+		Order("channel_position ASC, id ASC").
+		Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// CreateChannel adds a channel at the end of the sanctum's channel list and
+// gives every current member access to it.
+func (s *SanctumChannelService) CreateChannel(ctx context.Context, in CreateSanctumChannelInput) (*models.Conversation, error) {
+	if err := s.requireManager(ctx, in.ActorID, in.SanctumID); err != nil {
+		return nil, err
+	}
+	name, err := normalizeChannelName(in.Name)
+	if err != nil {
+		return nil, err
+	}
+	topic, err := normalizeChannelTopic(in.Topic)
+	if err != nil {
+		return nil, err
+	}
+	if in.PostPermission == "" {
+		in.PostPermission = models.ChannelPostMembers
+	}
+	if !in.PostPermission.Valid() {
+		return nil, models.NewValidationError("post_permission must be members or mods")
+	}
+
+	channel := models.Conversation{
+		Name:           name,
+		IsGroup:        true,
+		CreatedBy:      in.ActorID,
+		SanctumID:      &in.SanctumID,
+		Topic:          topic,
+		PostPermission: in.PostPermission,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Conversation{}).Where("sanctum_id = ?", in.SanctumID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= maxChannelsPerSanctum {
+			return models.NewValidationError(fmt.Sprintf("A sanctum can have at most %d channels", maxChannelsPerSanctum))
+		}
+		if err := ensureChannelNameFree(tx, in.SanctumID, 0, name); err != nil {
+			return err
+		}
+		var maxPosition *int
+		if err := tx.Model(&models.Conversation{}).
+			Where("sanctum_id = ?", in.SanctumID).
+			Select("MAX(channel_position)").
+			Scan(&maxPosition).Error; err != nil {
+			return err
+		}
+		if maxPosition != nil {
+			channel.Position = *maxPosition + 1
+		}
+		if err := tx.Create(&channel).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`INSERT INTO conversation_participants (conversation_id, user_id, joined_at, last_read_at, unread_count)
+SELECT ?, user_id, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 0 FROM sanctum_memberships WHERE sanctum_id = ?
+ON CONFLICT DO NOTHING`, channel.ID, in.SanctumID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	cache.InvalidateRoom(ctx, channel.ID)
+	return &channel, nil
+}
+
+// UpdateChannel renames a channel or changes its topic or post permission.
+func (s *SanctumChannelService) UpdateChannel(ctx context.Context, in UpdateSanctumChannelInput) (*models.Conversation, error) {
+	if err := s.requireManager(ctx, in.ActorID, in.SanctumID); err != nil {
+		return nil, err
+	}
+	channel, err := s.channel(ctx, in.SanctumID, in.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]any{}
+	if in.Name != nil {
+		name, err := normalizeChannelName(*in.Name)
+		if err != nil {
+			return nil, err
+		}
+		if err := ensureChannelNameFree(s.db.WithContext(ctx), in.SanctumID, channel.ID, name); err != nil {
+			return nil, err
+		}
+		updates["name"] = name
+	}
+	if in.Topic != nil {
+		topic, err := normalizeChannelTopic(*in.Topic)
+		if err != nil {
+			return nil, err
+		}
+		updates["topic"] = topic
+	}
+	if in.PostPermission != nil {
+		if !in.PostPermission.Valid() {
+			return nil, models.NewValidationError("post_permission must be members or mods")
+		}
+		updates["post_permission"] = *in.PostPermission
+	}
+	if len(updates) == 0 {
+		return channel, nil
+	}
+	if err := s.db.WithContext(ctx).Model(channel).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	cache.InvalidateRoom(ctx, channel.ID)
+	return s.channel(ctx, in.SanctumID, in.ChannelID)
+}
+
+// ReorderChannels sets the display order of the sanctum's channels.
+// channelIDs must list every channel exactly once.
+func (s *SanctumChannelService) ReorderChannels(ctx context.Context, actorID, sanctumID uint, channelIDs []uint) ([]models.Conversation, error) {
+	if err := s.requireManager(ctx, actorID, sanctumID); err != nil {
+		return nil, err
+	}
+	var existing []uint
+	if err := s.db.WithContext(ctx).Model(&models.Conversation{}).
+		Where("sanctum_id = ?", sanctumID).
+		Pluck("id", &existing).Error; err != nil {
+		return nil, err
+	}
+	known := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		known[id] = true
+	}
+	if len(channelIDs) != len(existing) {
+		return nil, models.NewValidationError("channel_ids must list every channel of the sanctum")
+	}
+	for _, id := range channelIDs {
+		if !known[id] {
+			return nil, models.NewValidationError("channel_ids must list every channel of the sanctum")
+		}
+		delete(known, id)
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for position, id := range channelIDs {
+			if err := tx.Model(&models.Conversation{}).
+				Where("id = ?", id).
+				UpdateColumn("channel_position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range channelIDs {
+		cache.InvalidateRoom(ctx, id)
+	}
+	return s.ListChannels(ctx, sanctumID, actorID)
+}
+
+// DeleteChannel removes a channel. The default channel cannot be deleted.
+func (s *SanctumChannelService) DeleteChannel(ctx context.Context, actorID, sanctumID, channelID uint) error {
+	if err := s.requireManager(ctx, actorID, sanctumID); err != nil {
+		return err
+	}
+	channel, err := s.channel(ctx, sanctumID, channelID)
+	if err != nil {
+		return err
+	}
+	if channel.IsDefault {
+		return models.NewValidationError("The default channel cannot be deleted")
+	}
+	if err := s.db.WithContext(ctx).Delete(channel).Error; err != nil {
+		return err
+	}
+	cache.InvalidateRoom(ctx, channel.ID)
+	return nil
+}
+
+// SyncSanctumChannelAccess mirrors a sanctum membership change onto the
+// participants of the sanctum's channels: joining adds the user to every
+// channel, leaving removes them. Call it in the membership's transaction.
+func SyncSanctumChannelAccess(ctx context.Context, tx *gorm.DB, sanctumID, userID uint, joined bool) error {
+	var channelIDs []uint
+	if err := tx.Model(&models.Conversation{}).
+		Where("sanctum_id = ?", sanctumID).
+		Pluck("id", &channelIDs).Error; err != nil {
+		return err
+	}
+	if len(channelIDs) == 0 {
+		return nil
+	}
+	if joined {
+		participants := make([]models.ConversationParticipant, 0, len(channelIDs))
+		for _, id := range channelIDs {
+			participants = append(participants, models.ConversationParticipant{ConversationID: id, UserID: userID})
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&participants).Error; err != nil {
+			return err
+		}
+	} else if err := tx.
+		Where("user_id = ? AND conversation_id IN ?", userID, channelIDs).
+		Delete(&models.ConversationParticipant{}).Error; err != nil {
+		return err
+	}
+	for _, id := range channelIDs {
+		cache.InvalidateRoom(ctx, id)
+	}
+	cache.InvalidateUser(ctx, userID)
+	return nil
+}
+
+func (s *SanctumChannelService) requireManager(ctx context.Context, userID, sanctumID uint) error {
+	ok, err := s.canManage(ctx, userID, sanctumID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.NewForbiddenError("Only sanctum moderators can manage channels")
+	}
+	return nil
+}
+
+func (s *SanctumChannelService) channel(ctx context.Context, sanctumID, channelID uint) (*models.Conversation, error) {
+	var channel models.Conversation
+	err := s.db.WithContext(ctx).
+		Where("id = ? AND sanctum_id = ?", channelID, sanctumID).
+		First(&channel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, models.NewNotFoundError("Channel", channelID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+func normalizeChannelName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxChannelNameLen {
+		return "", models.NewValidationError(fmt.Sprintf("Channel name must be 1-%d characters", maxChannelNameLen))
+	}
+	return name, nil
+}
+
+func normalizeChannelTopic(topic string) (string, error) {
+	topic = strings.TrimSpace(topic)
+	if utf8.RuneCountInString(topic) > maxChannelTopicLen {
+		return "", models.NewValidationError(fmt.Sprintf("Channel topic must be at most %d characters", maxChannelTopicLen))
+	}
+	return topic, nil
+}
+
+// ensureChannelNameFree rejects a name already used by another channel of
+// the sanctum, ignoring case.
+func ensureChannelNameFree(tx *gorm.DB, sanctumID, channelID uint, name string) error {
+	var count int64
+	if err := tx.Model(&models.Conversation{}).
+		Where("sanctum_id = ? AND id <> ? AND LOWER(name) = ?", sanctumID, channelID, strings.ToLower(name)).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return models.NewValidationError("A channel with this name already exists")
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newChannelTestService returns a channel service for the fixture sanctum
+// and its default channel.
+func newChannelTestService(t *testing.T) (*SanctumChannelService, *sanctumFixture, *models.Conversation) {
+	t.Helper()
+	f := newSanctumFixture(t, &models.Conversation{}, &models.ConversationParticipant{})
+	general := &models.Conversation{Name: "Books", IsGroup: true, SanctumID: &f.sanctum.ID, IsDefault: true}
+	require.NoError(t, f.db.Create(general).Error)
+	return NewSanctumChannelService(f.db, f.canManage, nil), f, general
+}
+
+func channelParticipants(t *testing.T, f *sanctumFixture, channelID uint) []uint {
+	t.Helper()
+	var ids []uint
+	require.NoError(t, f.db.Model(&models.ConversationParticipant{}).
+		Where("conversation_id = ?", channelID).Order("user_id").Pluck("user_id", &ids).Error)
+	return ids
+}
+
+func TestSanctumChannelService_CreateChannel(t *testing.T) {
+	channels, f, _ := newChannelTestService(t)
+	ctx := context.Background()
+
+	offTopic, err := channels.CreateChannel(ctx, CreateSanctumChannelInput{
+		ActorID: f.mod.ID, SanctumID: f.sanctum.ID, Name: "off-topic", Topic: "  Anything goes  ",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Anything goes", offTopic.Topic)
+	assert.Equal(t, models.ChannelPostMembers, offTopic.PostPermission)
+	assert.Equal(t, 1, offTopic.Position)
+	assert.Equal(t, []uint{f.owner.ID, f.mod.ID, f.member.ID}, channelParticipants(t, f, offTopic.ID),
+		"existing members get access")
+
+	tests := []struct {
+		name string
+		in   CreateSanctumChannelInput
+		code string
+	}{
+		{"members cannot", CreateSanctumChannelInput{ActorID: f.member.ID, Name: "news"}, "FORBIDDEN"},
+		{"name taken", CreateSanctumChannelInput{ActorID: f.mod.ID, Name: "Off-Topic"}, "VALIDATION_ERROR"},
+		{"name required", CreateSanctumChannelInput{ActorID: f.mod.ID, Name: "  "}, "VALIDATION_ERROR"},
+		{"unknown permission", CreateSanctumChannelInput{ActorID: f.mod.ID, Name: "news", PostPermission: "admins"}, "VALIDATION_ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.SanctumID = f.sanctum.ID
+			_, err := channels.CreateChannel(ctx, tt.in)
+			requireCode(t, err, tt.code)
+		})
+	}
+}
+
+func TestSyncSanctumChannelAccess(t *testing.T) {
+	channels, f, general := newChannelTestService(t)
+	ctx := context.Background()
+	news, err := channels.CreateChannel(ctx, CreateSanctumChannelInput{
+		ActorID: f.mod.ID, SanctumID: f.sanctum.ID, Name: "announcements", PostPermission: models.ChannelPostMods,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, SyncSanctumChannelAccess(ctx, f.db, f.sanctum.ID, f.outsider.ID, true))
+	require.NoError(t, SyncSanctumChannelAccess(ctx, f.db, f.sanctum.ID, f.outsider.ID, true), "joining twice is harmless")
+	for _, id := range []uint{general.ID, news.ID} {
+		assert.Contains(t, channelParticipants(t, f, id), f.outsider.ID)
+	}
+	require.NoError(t, SyncSanctumChannelAccess(ctx, f.db, f.sanctum.ID, f.outsider.ID, false))
+	for _, id := range []uint{general.ID, news.ID} {
+		assert.NotContains(t, channelParticipants(t, f, id), f.outsider.ID)
+	}
+}
+
+func TestSanctumChannelService_UpdateChannel(t *testing.T) {
+	channels, f, _ := newChannelTestService(t)
+	ctx := context.Background()
+	news, err := channels.CreateChannel(ctx, CreateSanctumChannelInput{ActorID: f.mod.ID, SanctumID: f.sanctum.ID, Name: "announcements"})
+	require.NoError(t, err)
+	topic, taken, mods, bad := "Patch notes only", "BOOKS", models.ChannelPostMods, models.ChannelPostPermission("admins")
+
+	tests := []struct {
+		name string
+		in   UpdateSanctumChannelInput
+		code string
+	}{
+		{"members cannot", UpdateSanctumChannelInput{ActorID: f.member.ID, Topic: &topic}, "FORBIDDEN"},
+		{"name taken", UpdateSanctumChannelInput{ActorID: f.mod.ID, Name: &taken}, "VALIDATION_ERROR"},
+		{"unknown permission", UpdateSanctumChannelInput{ActorID: f.mod.ID, PostPermission: &bad}, "VALIDATION_ERROR"},
+		{"topic and permission", UpdateSanctumChannelInput{ActorID: f.mod.ID, Topic: &topic, PostPermission: &mods}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.SanctumID, tt.in.ChannelID = f.sanctum.ID, news.ID
+			updated, err := channels.UpdateChannel(ctx, tt.in)
+			if tt.code != "" {
+				requireCode(t, err, tt.code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, topic, updated.Topic)
+			assert.Equal(t, models.ChannelPostMods, updated.PostPermission)
+			assert.Equal(t, "announcements", updated.Name)
+		})
+	}
+}
+
+func TestSanctumChannelService_ReorderAndDelete(t *testing.T) {
+	channels, f, general := newChannelTestService(t)
+	ctx := context.Background()
+	offTopic, err := channels.CreateChannel(ctx, CreateSanctumChannelInput{ActorID: f.mod.ID, SanctumID: f.sanctum.ID, Name: "off-topic"})
+	require.NoError(t, err)
+	news, err := channels.CreateChannel(ctx, CreateSanctumChannelInput{ActorID: f.mod.ID, SanctumID: f.sanctum.ID, Name: "news"})
+	require.NoError(t, err)
+
+	_, err = channels.ReorderChannels(ctx, f.mod.ID, f.sanctum.ID, []uint{news.ID, general.ID})
+	requireCode(t, err, "VALIDATION_ERROR")
+	ordered, err := channels.ReorderChannels(ctx, f.mod.ID, f.sanctum.ID, []uint{news.ID, general.ID, offTopic.ID})
+	require.NoError(t, err)
+	require.Len(t, ordered, 3)
+	assert.Equal(t, []uint{news.ID, general.ID, offTopic.ID}, []uint{ordered[0].ID, ordered[1].ID, ordered[2].ID})
+
+	requireCode(t, channels.DeleteChannel(ctx, f.mod.ID, f.sanctum.ID, general.ID), "VALIDATION_ERROR")
+	requireCode(t, channels.DeleteChannel(ctx, f.member.ID, f.sanctum.ID, offTopic.ID), "FORBIDDEN")
+	require.NoError(t, channels.DeleteChannel(ctx, f.mod.ID, f.sanctum.ID, offTopic.ID))
+	listed, err := channels.ListChannels(ctx, f.sanctum.ID, f.member.ID)
+	require.NoError(t, err)
+	assert.Len(t, listed, 2)
+}
+
+func TestChatService_SendMessage_ModsOnlyChannel(t *testing.T) {
+	const modID, memberID = 1, 2
+	repo := noopChatRepo()
+	repo.getConversationFn = func(context.Context, uint) (*models.Conversation, error) {
+		return &models.Conversation{
+			ID: 7, IsGroup: true, PostPermission: models.ChannelPostMods,
+			Participants: []models.User{{ID: modID}, {ID: memberID}},
+		}, nil
+	}
+	svc := NewChatService(repo, noopUserRepo(), nil, nil, func(_ context.Context, userID, _ uint) (bool, error) {
+		return userID == modID, nil
+	}, nil)
+
+	_, _, err := svc.SendMessage(context.Background(), SendMessageInput{UserID: memberID, ConversationID: 7, Content: "hi"})
+	requireCode(t, err, "FORBIDDEN")
+
+	_, _, err = svc.SendMessage(context.Background(), SendMessageInput{UserID: modID, ConversationID: 7, Content: "Patch 1.2 is out"})
+	assert.NoError(t, err)
+}
+
+func TestChatService_SanctumChannelsFollowMembership(t *testing.T) {
+	_, f, general := newChannelTestService(t)
+	require.NoError(t, f.db.AutoMigrate(&models.Message{}))
+	ctx := context.Background()
+	require.NoError(t, SyncSanctumChannelAccess(ctx, f.db, f.sanctum.ID, f.member.ID, true))
+	chat := NewChatService(repository.NewChatRepository(f.db), repository.NewUserRepository(f.db), f.db, nil, nil, nil)
+
+	_, err := chat.JoinChatroom(ctx, general.ID, f.outsider.ID)
+	requireCode(t, err, "FORBIDDEN")
+	requireCode(t, chat.AddParticipant(ctx, general.ID, f.member.ID, f.outsider.ID), "FORBIDDEN")
+	assert.NotContains(t, channelParticipants(t, f, general.ID), f.outsider.ID)
+
+	listed := func(userID uint) bool {
+		rooms, err := chat.GetAllChatrooms(ctx, userID)
+		require.NoError(t, err)
+		for _, room := range rooms {
+			if room.Conversation.ID == general.ID {
+				return true
+			}
+		}
+		return false
+	}
+	assert.True(t, listed(f.member.ID))
+	assert.False(t, listed(f.outsider.ID), "channels are hidden from non-participants")
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// sanctumConfigCache keeps values compiled from a sanctum's stored config,
+// such as AutoModerator rules or a title pattern, so hot paths do not
+// recompile them on every write. An entry is only reused while the config's
+// updated_at matches, which keeps instances that did not handle the save
+// correct; the saving instance also drops its entry straight away.
+type sanctumConfigCache[T any] struct {
+	mu      sync.Mutex
+	entries map[uint]sanctumConfigEntry[T]
+}
+
+type sanctumConfigEntry[T any] struct {
+	version time.Time
+	value   T
+}
+
+func (c *sanctumConfigCache[T]) get(sanctumID uint, version time.Time) (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[sanctumID]
+	if !ok || !entry.version.Equal(version) {
+		var zero T
+		return zero, false
+	}
+	return entry.value, true
+}
+
+func (c *sanctumConfigCache[T]) put(sanctumID uint, version time.Time, value T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[uint]sanctumConfigEntry[T])
+	}
+	c.entries[sanctumID] = sanctumConfigEntry[T]{version: version, value: value}
+}
+
+func (c *sanctumConfigCache[T]) drop(sanctumID uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, sanctumID)
+}
@@ -0,0 +1,563 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// directoryWindow is the period "this week" stats and trending cover.
+	directoryWindow = 7 * 24 * time.Hour
+	// maxRecommendationsPerUser bounds the precomputed recommendations kept
+	// per user.
+	maxRecommendationsPerUser = 20
+)
+
+// Directory sort orders.
+const (
+	DirectorySortName    = "name"
+	DirectorySortMembers = "members"
+	DirectorySortActive  = "active"
+)
+
+// SanctumDirectoryService serves the sanctum directory: categories, activity
+// stats, trending and per-user recommendations. Stats and recommendations
+// are precomputed by Refresh.
+type SanctumDirectoryService struct {
+	db *gorm.DB
+	// canManage reports whether a user owns the sanctum.
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error)
+}
+
+type DirectoryQuery struct {
+	// Category filters to one category when set.
+	Category models.SanctumCategory
+	// Sort is name (default), members or active.
+	Sort string
+	// Limit of 0 returns every match.
+	Limit  int
+	Offset int
+}
+
+// SanctumDirectoryEntry is a sanctum with its latest stats. Stats is zero
+// for sanctums created since the last refresh.
+type SanctumDirectoryEntry struct {
+	Sanctum models.Sanctum
+	Stats   models.SanctumStats
+}
+
+// SanctumCategoryCount is a directory category and how many active
+// sanctums it holds.
+type SanctumCategoryCount struct {
+	Category models.SanctumCategory `json:"category"`
+	Count    int64                  `json:"count"`
+}
+
+func NewSanctumDirectoryService(
+	db *gorm.DB,
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error),
+) *SanctumDirectoryService {
+	return &SanctumDirectoryService{db: db, canManage: canManage}
+}
+
+// withStats pairs sanctums with their stats, keeping the sanctums' order.
+func (s *SanctumDirectoryService) withStats(ctx context.Context, sanctums []models.Sanctum) ([]SanctumDirectoryEntry, error) {
+	entries := make([]SanctumDirectoryEntry, 0, len(sanctums))
+	if len(sanctums) == 0 {
+		return entries, nil
+	}
+	ids := make([]uint, 0, len(sanctums))
+	for _, sanctum := range sanctums {
+		ids = append(ids, sanctum.ID)
+	}
+	var stats []models.SanctumStats
+	if err := s.db.WithContext(ctx).Where("sanctum_id IN ?", ids).Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	bySanctum := make(map[uint]models.SanctumStats, len(stats))
+	for _, st := range stats {
+		bySanctum[st.SanctumID] = st
+	}
+	for _, sanctum := range sanctums {
+		st := bySanctum[sanctum.ID]
+		st.SanctumID = sanctum.ID
+		entries = append(entries, SanctumDirectoryEntry{Sanctum: sanctum, Stats: st})
+	}
+	return entries, nil
+}
+
+// List returns active sanctums with their stats.
+func (s *SanctumDirectoryService) List(ctx context.Context, q DirectoryQuery) ([]SanctumDirectoryEntry, error) {
+	query := s.db.WithContext(ctx).
+		Model(&models.Sanctum{}).
+		Joins("LEFT JOIN sanctum_stats ON sanctum_stats.sanctum_id = sanctums.id").
+		Where("sanctums.status = ?", models.SanctumStatusActive)
+	if q.Category != "" {
+		if !q.Category.Valid() {
+			return nil, models.NewValidationError("Unknown sanctum category")
+		}
+		query = query.Where("sanctums.category = ?", q.Category)
+	}
+	switch q.Sort {
+	case "", DirectorySortName:
+		query = query.Order("sanctums.name ASC")
+	case DirectorySortMembers:
+		query = query.Order("COALESCE(sanctum_stats.member_count, 0) DESC").Order("sanctums.name ASC")
+	case DirectorySortActive:
+		query = query.Order("COALESCE(sanctum_stats.posts_per_day, 0) DESC").Order("sanctums.name ASC")
+	default:
+		return nil, models.NewValidationError("sort must be name, members or active")
+	}
+	if q.Limit > 0 {
+		query = query.Limit(q.Limit).Offset(q.Offset)
+	}
+	var sanctums []models.Sanctum
+	if err := query.Select("sanctums.*").Find(&sanctums).Error; err != nil {
+		return nil, err
+	}
+	return s.withStats(ctx, sanctums)
+}
+
+// Categories returns every category with its number of active sanctums,
+// in display order.
+func (s *SanctumDirectoryService) Categories(ctx context.Context) ([]SanctumCategoryCount, error) {
+	var rows []SanctumCategoryCount
+	if err := s.db.WithContext(ctx).Model(&models.Sanctum{}).
+		Select("category, COUNT(*) AS count").
+		Where("status = ?", models.SanctumStatusActive).
+		Group("category").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[models.SanctumCategory]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Category] = row.Count
+	}
+	out := make([]SanctumCategoryCount, 0, len(models.SanctumCategories))
+	for _, category := range models.SanctumCategories {
+		out = append(out, SanctumCategoryCount{Category: category, Count: counts[category]})
+	}
+	return out, nil
+}
+
+// Trending returns the active sanctums with the most activity this week
+// relative to their size.
+func (s *SanctumDirectoryService) Trending(ctx context.Context, category models.SanctumCategory, limit int) ([]SanctumDirectoryEntry, error) {
+	query := s.db.WithContext(ctx).
+		Model(&models.Sanctum{}).
+		Joins("JOIN sanctum_stats ON sanctum_stats.sanctum_id = sanctums.id").
+		Where("sanctums.status = ? AND sanctum_stats.trending_score > 0", models.SanctumStatusActive)
+	if category != "" {
+		if !category.Valid() {
+			return nil, models.NewValidationError("Unknown sanctum category")
+		}
+		query = query.Where("sanctums.category = ?", category)
+	}
+	var sanctums []models.Sanctum
+	if err := query.Select("sanctums.*").
+		Order("sanctum_stats.trending_score DESC").
+		Order("sanctums.name ASC").
+		Limit(limit).
+		Find(&sanctums).Error; err != nil {
+		return nil, err
+	}
+	return s.withStats(ctx, sanctums)
+}
+
+// Recommended returns sanctums recommended for a user, best first. Sanctums
+// the user has joined or is banned from since the last refresh are skipped.
+// Users without recommendations get trending sanctums they have not joined.
+func (s *SanctumDirectoryService) Recommended(ctx context.Context, userID uint, limit int) ([]SanctumDirectoryEntry, error) {
+	notJoined := "NOT EXISTS (SELECT 1 FROM sanctum_memberships m WHERE m.sanctum_id = sanctums.id AND m.user_id = ?)"
+	var sanctums []models.Sanctum
+	if err := s.db.WithContext(ctx).
+		Model(&models.Sanctum{}).
+		Joins("JOIN sanctum_recommendations ON sanctum_recommendations.sanctum_id = sanctums.id").
+		Where("sanctum_recommendations.user_id = ? AND sanctums.status = ?", userID, models.SanctumStatusActive).
+		Where(notJoined, userID).
+		Select("sanctums.*").
+		Order("sanctum_recommendations.score DESC").
+		Order("sanctums.name ASC").
+		Limit(limit).
+		Find(&sanctums).Error; err != nil {
+		return nil, err
+	}
+	if len(sanctums) == 0 {
+		if err := s.db.WithContext(ctx).
+			Model(&models.Sanctum{}).
+			Joins("JOIN sanctum_stats ON sanctum_stats.sanctum_id = sanctums.id").
+			Where("sanctums.status = ? AND sanctum_stats.trending_score > 0", models.SanctumStatusActive).
+			Where(notJoined, userID).
+			Select("sanctums.*").
+			Order("sanctum_stats.trending_score DESC").
+			Order("sanctums.name ASC").
+			Limit(limit).
+			Find(&sanctums).Error; err != nil {
+			return nil, err
+		}
+	}
+	visible := sanctums[:0]
+	for _, sanctum := range sanctums {
+		ban, err := activeSanctumBan(ctx, s.db, sanctum.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if ban == nil {
+			visible = append(visible, sanctum)
+		}
+	}
+	return s.withStats(ctx, visible)
+}
+
+// SetCategory files a sanctum under a directory category. Owners only.
+func (s *SanctumDirectoryService) SetCategory(ctx context.Context, actorID, sanctumID uint, category models.SanctumCategory) (*models.Sanctum, error) {
+	if !category.Valid() {
+		return nil, models.NewValidationError("Unknown sanctum category")
+	}
+	if s.canManage != nil {
+		ok, err := s.canManage(ctx, actorID, sanctumID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, models.NewForbiddenError("Only the sanctum owner can change its category")
+		}
+	}
+	var sanctum models.Sanctum
+	if err := s.db.WithContext(ctx).First(&sanctum, sanctumID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("Sanctum", sanctumID)
+		}
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Model(&sanctum).Update("category", category).Error; err != nil {
+		return nil, err
+	}
+	return &sanctum, nil
+}
+
+// directoryRefreshLock is the Postgres advisory lock key that keeps two
+// instances from refreshing the directory at once.
+const directoryRefreshLock = 0x5a4c7d01
+
+// Refresh recomputes every sanctum's stats and every user's
+// recommendations. Rows are upserted in place and rows the refresh did not
+// touch are dropped, so readers never see empty tables. On Postgres only one
+// instance refreshes at a time; the others skip the round.
+func (s *SanctumDirectoryService) Refresh(ctx context.Context) error {
+	// Postgres keeps microseconds, so a finer timestamp would not compare
+	// equal to the one stored.
+	now := time.Now().Truncate(time.Microsecond)
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if tx.Name() == "postgres" {
+			var locked bool
+			if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", directoryRefreshLock).Scan(&locked).Error; err != nil {
+				return err
+			}
+			if !locked {
+				return nil
+			}
+		}
+		if err := s.refreshStats(tx, now); err != nil {
+			return err
+		}
+		return s.refreshRecommendations(tx, now)
+	})
+}
+
+type sanctumCount struct {
+	SanctumID uint
+	N         int64
+}
+
+func countBySanctum(query *gorm.DB) (map[uint]int64, error) {
+	var rows []sanctumCount
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.SanctumID] = row.N
+	}
+	return counts, nil
+}
+
+// trendingScore weighs a week's activity against the sanctum's size, so a
+// small sanctum that is suddenly busy can outrank a large, steady one.
+// refreshStatsSQL computes the same score on Postgres.
+func trendingScore(posts, comments, newMembers, members int64) float64 {
+	activity := float64(posts) + 0.5*float64(comments) + 2*float64(newMembers)
+	if activity == 0 {
+		return 0
+	}
+	return activity / math.Log2(float64(members)+2)
+}
+
+// refreshStatsSQL upserts every sanctum's stats in one statement. Its
+// arguments are since (four times), the published status, the window in
+// days and the refresh time.
+const refreshStatsSQL = `
+WITH members AS (
+	SELECT sanctum_id, COUNT(*) AS total, COUNT(*) FILTER (WHERE created_at >= ?) AS recent
+	FROM sanctum_memberships
+	GROUP BY sanctum_id
+), week_posts AS (
+	SELECT sanctum_id, COUNT(*) AS n
+	FROM posts
+	WHERE sanctum_id IS NOT NULL AND deleted_at IS NULL AND removed_at IS NULL
+	  AND created_at >= ? AND status = ?
+	GROUP BY sanctum_id
+), week_comments AS (
+	SELECT p.sanctum_id, COUNT(*) AS n
+	FROM comments c
+	JOIN posts p ON p.id = c.post_id AND p.deleted_at IS NULL
+	WHERE p.sanctum_id IS NOT NULL AND c.deleted_at IS NULL AND c.removed_at IS NULL
+	  AND c.created_at >= ?
+	GROUP BY p.sanctum_id
+), counts AS (
+	SELECT s.id AS sanctum_id,
+	       COALESCE(m.total, 0) AS members,
+	       COALESCE(m.recent, 0) AS new_members,
+	       COALESCE(wp.n, 0) AS posts,
+	       COALESCE(wc.n, 0) AS comments
+	FROM sanctums s
+	LEFT JOIN members m ON m.sanctum_id = s.id
+	LEFT JOIN week_posts wp ON wp.sanctum_id = s.id
+	LEFT JOIN week_comments wc ON wc.sanctum_id = s.id
+)
+INSERT INTO sanctum_stats (sanctum_id, member_count, new_members_this_week, posts_this_week,
+	comments_this_week, posts_per_day, trending_score, computed_at)
+SELECT sanctum_id, members, new_members, posts, comments,
+       ROUND(posts / ?::numeric, 2),
+       (posts + 0.5 * comments + 2 * new_members) / LOG(2, members + 2),
+       ?::timestamptz
+FROM counts
+ON CONFLICT (sanctum_id) DO UPDATE SET
+	member_count = EXCLUDED.member_count,
+	new_members_this_week = EXCLUDED.new_members_this_week,
+	posts_this_week = EXCLUDED.posts_this_week,
+	comments_this_week = EXCLUDED.comments_this_week,
+	posts_per_day = EXCLUDED.posts_per_day,
+	trending_score = EXCLUDED.trending_score,
+	computed_at = EXCLUDED.computed_at
+`
+
+func (s *SanctumDirectoryService) refreshStats(tx *gorm.DB, now time.Time) error {
+	since := now.Add(-directoryWindow)
+	days := directoryWindow.Hours() / 24
+	if tx.Name() == "postgres" {
+		if err := tx.Exec(refreshStatsSQL, since, since, models.PostStatusPublished, since, days, now).Error; err != nil {
+			return err
+		}
+	} else {
+		// SQLite/test fallback: it lacks the math functions, so the scores
+		// are computed here.
+		rows, err := s.computeStats(tx, since, days, now)
+		if err != nil {
+			return err
+		}
+		if len(rows) > 0 {
+			if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(rows, 500).Error; err != nil {
+				return err
+			}
+		}
+	}
+	// Rows the refresh did not touch belong to deleted sanctums.
+	return tx.Where("computed_at < ?", now).Delete(&models.SanctumStats{}).Error
+}
+
+func (s *SanctumDirectoryService) computeStats(tx *gorm.DB, since time.Time, days float64, now time.Time) ([]models.SanctumStats, error) {
+	var ids []uint
+	if err := tx.Model(&models.Sanctum{}).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	members, err := countBySanctum(tx.Model(&models.SanctumMembership{}).
+		Select("sanctum_id, COUNT(*) AS n").
+		Group("sanctum_id"))
+	if err != nil {
+		return nil, err
+	}
+	newMembers, err := countBySanctum(tx.Model(&models.SanctumMembership{}).
+		Select("sanctum_id, COUNT(*) AS n").
+		Where("created_at >= ?", since).
+		Group("sanctum_id"))
+	if err != nil {
+		return nil, err
+	}
+	posts, err := countBySanctum(tx.Model(&models.Post{}).
+		Select("sanctum_id, COUNT(*) AS n").
+		Where("sanctum_id IS NOT NULL AND status = ? AND removed_at IS NULL AND created_at >= ?",
+			models.PostStatusPublished, since).
+		Group("sanctum_id"))
+	if err != nil {
+		return nil, err
+	}
+	comments, err := countBySanctum(tx.Model(&models.Comment{}).
+		Select("posts.sanctum_id AS sanctum_id, COUNT(*) AS n").
+		Joins("JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL").
+		Where("posts.sanctum_id IS NOT NULL AND comments.removed_at IS NULL AND comments.created_at >= ?", since).
+		Group("posts.sanctum_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]models.SanctumStats, 0, len(ids))
+	for _, id := range ids {
+		rows = append(rows, models.SanctumStats{
+			SanctumID:          id,
+			MemberCount:        members[id],
+			NewMembersThisWeek: newMembers[id],
+			PostsThisWeek:      posts[id],
+			CommentsThisWeek:   comments[id],
+			PostsPerDay:        math.Round(float64(posts[id])/days*100) / 100,
+			TrendingScore:      trendingScore(posts[id], comments[id], newMembers[id], members[id]),
+			ComputedAt:         now,
+		})
+	}
+	return rows, nil
+}
+
+// refreshRecommendationsSQL scores, for each user, the sanctums they have
+// not joined by how much their members overlap with the user's own
+// sanctums: sanctum S scores sum(co(T, S) / sqrt(|T| * |S|)) over the
+// user's sanctums T, where co counts users who belong to both. Each user
+// keeps their top rows. Its arguments are the active status, the refresh
+// time and the per-user limit.
+const refreshRecommendationsSQL = `
+WITH active AS (
+	SELECT m.user_id, m.sanctum_id
+	FROM sanctum_memberships m
+	JOIN sanctums s ON s.id = m.sanctum_id
+	WHERE s.status = ?
+), size AS (
+	SELECT sanctum_id, COUNT(*) AS n FROM active GROUP BY sanctum_id
+), overlap AS (
+	SELECT a.sanctum_id AS joined, b.sanctum_id AS candidate, COUNT(*) AS shared
+	FROM active a
+	JOIN active b ON b.user_id = a.user_id AND b.sanctum_id <> a.sanctum_id
+	GROUP BY a.sanctum_id, b.sanctum_id
+), scored AS (
+	SELECT u.user_id, o.candidate AS sanctum_id,
+	       SUM(o.shared / SQRT(sj.n * sc.n)) AS score
+	FROM active u
+	JOIN overlap o ON o.joined = u.sanctum_id
+	JOIN size sj ON sj.sanctum_id = o.joined
+	JOIN size sc ON sc.sanctum_id = o.candidate
+	WHERE NOT EXISTS (
+		SELECT 1 FROM active j WHERE j.user_id = u.user_id AND j.sanctum_id = o.candidate
+	)
+	GROUP BY u.user_id, o.candidate
+), ranked AS (
+	SELECT user_id, sanctum_id, score,
+	       ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY score DESC, sanctum_id) AS row_rank
+	FROM scored
+)
+INSERT INTO sanctum_recommendations (user_id, sanctum_id, score, computed_at)
+SELECT user_id, sanctum_id, ROUND(score::numeric, 4), ?::timestamptz
+FROM ranked
+WHERE row_rank <= ?
+ON CONFLICT (user_id, sanctum_id) DO UPDATE SET
+	score = EXCLUDED.score,
+	computed_at = EXCLUDED.computed_at
+`
+
+func (s *SanctumDirectoryService) refreshRecommendations(tx *gorm.DB, now time.Time) error {
+	if tx.Name() == "postgres" {
+		if err := tx.Exec(refreshRecommendationsSQL, models.SanctumStatusActive, now, maxRecommendationsPerUser).Error; err != nil {
+			return err
+		}
+	} else {
+		rows, err := s.computeRecommendations(tx, now)
+		if err != nil {
+			return err
+		}
+		if len(rows) > 0 {
+			if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(rows, 500).Error; err != nil {
+				return err
+			}
+		}
+	}
+	// Rows the refresh did not touch are sanctums the user has since joined
+	// or that no longer rank.
+	return tx.Where("computed_at < ?", now).Delete(&models.SanctumRecommendation{}).Error
+}
+
+// computeRecommendations is the SQLite/test fallback for
+// refreshRecommendationsSQL.
+func (s *SanctumDirectoryService) computeRecommendations(tx *gorm.DB, now time.Time) ([]models.SanctumRecommendation, error) {
+	var memberships []models.SanctumMembership
+	if err := tx.Model(&models.SanctumMembership{}).
+		Select("sanctum_memberships.sanctum_id", "sanctum_memberships.user_id").
+		Joins("JOIN sanctums ON sanctums.id = sanctum_memberships.sanctum_id").
+		Where("sanctums.status = ?", models.SanctumStatusActive).
+		Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[uint][]uint)
+	size := make(map[uint]int)
+	for _, m := range memberships {
+		byUser[m.UserID] = append(byUser[m.UserID], m.SanctumID)
+		size[m.SanctumID]++
+	}
+	co := make(map[uint]map[uint]int)
+	for _, sanctums := range byUser {
+		for _, a := range sanctums {
+			for _, b := range sanctums {
+				if a == b {
+					continue
+				}
+				if co[a] == nil {
+					co[a] = make(map[uint]int)
+				}
+				co[a][b]++
+			}
+		}
+	}
+
+	var rows []models.SanctumRecommendation
+	for userID, joined := range byUser {
+		isMember := make(map[uint]bool, len(joined))
+		for _, id := range joined {
+			isMember[id] = true
+		}
+		scores := make(map[uint]float64)
+		for _, t := range joined {
+			for candidate, shared := range co[t] {
+				if isMember[candidate] {
+					continue
+				}
+				scores[candidate] += float64(shared) / math.Sqrt(float64(size[t]*size[candidate]))
+			}
+		}
+		ranked := make([]models.SanctumRecommendation, 0, len(scores))
+		for sanctumID, score := range scores {
+			ranked = append(ranked, models.SanctumRecommendation{
+				UserID:     userID,
+				SanctumID:  sanctumID,
+				Score:      math.Round(score*10000) / 10000,
+				ComputedAt: now,
+			})
+		}
+		sort.Slice(ranked, func(i, j int) bool {
+			if ranked[i].Score != ranked[j].Score {
+				return ranked[i].Score > ranked[j].Score
+			}
+			return ranked[i].SanctumID < ranked[j].SanctumID
+		})
+		if len(ranked) > maxRecommendationsPerUser {
+			ranked = ranked[:maxRecommendationsPerUser]
+		}
+		rows = append(rows, ranked...)
+	}
+
+	return rows, nil
+}
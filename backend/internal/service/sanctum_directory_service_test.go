@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// directoryFixture holds four sanctums: movie fans also read books, linux
+// users also like food. Linux had two posts this week; movies has been
+// quiet for a month.
+type directoryFixture struct {
+	db        *gorm.DB
+	directory *SanctumDirectoryService
+	users     map[string]*models.User
+	sanctums  map[string]*models.Sanctum
+}
+
+func newDirectoryFixture(t *testing.T) *directoryFixture {
+	t.Helper()
+	db := newTestDB(t,
+		&models.Sanctum{},
+		&models.SanctumMembership{},
+		&models.SanctumStats{},
+		&models.SanctumRecommendation{},
+		&models.SanctumBan{},
+		&models.Post{},
+		&models.Comment{},
+	)
+	f := &directoryFixture{db: db, users: map[string]*models.User{}, sanctums: map[string]*models.Sanctum{}}
+	for _, name := range []string{"ana", "ben", "cat", "dan", "eve"} {
+		f.users[name] = createTestUser(t, db, name)
+	}
+	for name, category := range map[string]models.SanctumCategory{
+		"movies": models.SanctumCategoryEntertainment,
+		"books":  models.SanctumCategoryEntertainment,
+		"linux":  models.SanctumCategoryTechnology,
+		"food":   models.SanctumCategoryLifestyle,
+	} {
+		sanctum := &models.Sanctum{Name: name, Slug: name, Status: models.SanctumStatusActive, Category: category}
+		require.NoError(t, db.Create(sanctum).Error)
+		f.sanctums[name] = sanctum
+	}
+	for user, sanctums := range map[string][]string{
+		"ana": {"movies", "books"},
+		"ben": {"movies", "books"},
+		"cat": {"movies"},
+		"dan": {"linux", "food"},
+		"eve": {"linux"},
+	} {
+		for _, sanctum := range sanctums {
+			f.join(t, user, sanctum)
+		}
+	}
+	monthAgo := time.Now().Add(-30 * 24 * time.Hour)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, db.Create(&models.Post{Title: "uptime", Content: "x", UserID: f.users["dan"].ID, SanctumID: &f.sanctums["linux"].ID}).Error)
+	}
+	old := &models.Post{Title: "classic", Content: "x", UserID: f.users["ana"].ID, SanctumID: &f.sanctums["movies"].ID}
+	require.NoError(t, db.Create(old).Error)
+	require.NoError(t, db.Model(old).UpdateColumn("created_at", monthAgo).Error)
+	require.NoError(t, db.Model(&models.SanctumMembership{}).
+		Where("sanctum_id = ?", f.sanctums["movies"].ID).
+		UpdateColumn("created_at", monthAgo).Error)
+
+	f.directory = NewSanctumDirectoryService(db, func(_ context.Context, userID, sanctumID uint) (bool, error) {
+		return userID == f.users["ana"].ID && sanctumID == f.sanctums["movies"].ID, nil
+	})
+	require.NoError(t, f.directory.Refresh(context.Background()))
+	return f
+}
+
+func (f *directoryFixture) join(t *testing.T, user, sanctum string) {
+	t.Helper()
+	require.NoError(t, f.db.Create(&models.SanctumMembership{
+		SanctumID: f.sanctums[sanctum].ID, UserID: f.users[user].ID, Role: models.SanctumMembershipRoleMember,
+	}).Error)
+}
+
+func directorySlugs(entries []SanctumDirectoryEntry) []string {
+	slugs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		slugs = append(slugs, entry.Sanctum.Slug)
+	}
+	return slugs
+}
+
+func TestSanctumDirectoryService_List(t *testing.T) {
+	f := newDirectoryFixture(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		query DirectoryQuery
+		want  []string
+	}{
+		{"by name", DirectoryQuery{}, []string{"books", "food", "linux", "movies"}},
+		{"by members", DirectoryQuery{Sort: DirectorySortMembers}, []string{"movies", "books", "linux", "food"}},
+		{"by activity", DirectoryQuery{Sort: DirectorySortActive, Limit: 1}, []string{"linux"}},
+		{"one category", DirectoryQuery{Category: models.SanctumCategoryEntertainment}, []string{"books", "movies"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := f.directory.List(ctx, tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, directorySlugs(entries))
+		})
+	}
+
+	_, err := f.directory.List(ctx, DirectoryQuery{Category: "sports"})
+	requireCode(t, err, "VALIDATION_ERROR")
+}
+
+func TestSanctumDirectoryService_Stats(t *testing.T) {
+	f := newDirectoryFixture(t)
+	entries, err := f.directory.List(context.Background(), DirectoryQuery{})
+	require.NoError(t, err)
+	stats := map[string]models.SanctumStats{}
+	for _, entry := range entries {
+		stats[entry.Sanctum.Slug] = entry.Stats
+	}
+
+	assert.Equal(t, int64(3), stats["movies"].MemberCount)
+	assert.Zero(t, stats["movies"].NewMembersThisWeek)
+	assert.Zero(t, stats["movies"].PostsThisWeek, "month-old posts are not this week's")
+	assert.Zero(t, stats["movies"].TrendingScore)
+	assert.Equal(t, int64(2), stats["linux"].PostsThisWeek)
+	assert.InDelta(t, 0.29, stats["linux"].PostsPerDay, 0.001)
+	assert.Positive(t, stats["linux"].TrendingScore)
+}
+
+func TestSanctumDirectoryService_Categories(t *testing.T) {
+	f := newDirectoryFixture(t)
+	categories, err := f.directory.Categories(context.Background())
+	require.NoError(t, err)
+	require.Len(t, categories, len(models.SanctumCategories))
+	counts := map[models.SanctumCategory]int64{}
+	for _, c := range categories {
+		counts[c.Category] = c.Count
+	}
+	assert.Equal(t, models.SanctumCategoryCommunity, categories[0].Category)
+	assert.Equal(t, int64(2), counts[models.SanctumCategoryEntertainment])
+	assert.Equal(t, int64(1), counts[models.SanctumCategoryTechnology])
+}
+
+func TestSanctumDirectoryService_Trending(t *testing.T) {
+	f := newDirectoryFixture(t)
+	trending, err := f.directory.Trending(context.Background(), "", 10)
+	require.NoError(t, err)
+	require.NotEmpty(t, trending)
+	assert.Equal(t, "linux", trending[0].Sanctum.Slug)
+	assert.NotContains(t, directorySlugs(trending), "movies", "quiet sanctums do not trend")
+
+	trending, err = f.directory.Trending(context.Background(), models.SanctumCategoryEntertainment, 10)
+	require.NoError(t, err)
+	assert.NotContains(t, directorySlugs(trending), "linux")
+}
+
+func TestSanctumDirectoryService_Recommended(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  string
+		setup func(t *testing.T, f *directoryFixture)
+		want  []string
+	}{
+		{"shared members", "cat", nil, []string{"books"}},
+		{"other cluster", "eve", nil, []string{"food"}},
+		{"joined since refresh", "cat", func(t *testing.T, f *directoryFixture) { f.join(t, "cat", "books") }, []string{"linux", "food"}},
+		{"banned since refresh", "eve", func(t *testing.T, f *directoryFixture) {
+			require.NoError(t, f.db.Create(&models.SanctumBan{
+				SanctumID: f.sanctums["food"].ID, UserID: f.users["eve"].ID,
+				CreatedByUserID: f.users["dan"].ID, Kind: models.SanctumBanKindBan,
+			}).Error)
+		}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newDirectoryFixture(t)
+			if tt.setup != nil {
+				tt.setup(t, f)
+			}
+			recommended, err := f.directory.Recommended(context.Background(), f.users[tt.user].ID, 10)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, directorySlugs(recommended))
+		})
+	}
+}
+
+func TestSanctumDirectoryService_SetCategory(t *testing.T) {
+	f := newDirectoryFixture(t)
+	movies := f.sanctums["movies"].ID
+
+	tests := []struct {
+		name     string
+		actor    string
+		category models.SanctumCategory
+		code     string
+	}{
+		{"members cannot", "ben", models.SanctumCategoryOther, "FORBIDDEN"},
+		{"unknown category", "ana", "sports", "VALIDATION_ERROR"},
+		{"owner", "ana", models.SanctumCategoryCommunity, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := f.directory.SetCategory(context.Background(), f.users[tt.actor].ID, movies, tt.category)
+			if tt.code != "" {
+				requireCode(t, err, tt.code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.category, updated.Category)
+		})
+	}
+}
+
+func TestSanctumDirectoryService_RefreshUpdatesInPlace(t *testing.T) {
+	f := newDirectoryFixture(t)
+	cat, books := f.users["cat"].ID, f.sanctums["books"].ID
+	f.join(t, "cat", "books")
+	require.NoError(t, f.directory.Refresh(context.Background()))
+
+	var stale int64
+	require.NoError(t, f.db.Model(&models.SanctumRecommendation{}).
+		Where("user_id = ? AND sanctum_id = ?", cat, books).Count(&stale).Error)
+	assert.Zero(t, stale, "recommendations the refresh no longer produces are dropped")
+	var stats models.SanctumStats
+	require.NoError(t, f.db.First(&stats, "sanctum_id = ?", books).Error)
+	assert.Equal(t, int64(3), stats.MemberCount)
+	var rows int64
+	require.NoError(t, f.db.Model(&models.SanctumStats{}).Count(&rows).Error)
+	assert.Equal(t, int64(len(f.sanctums)), rows)
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"sanctum/internal/cache"
+	"sanctum/internal/database"
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var flairColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// SanctumFlairService manages the post flairs a sanctum defines and checks
+// who may apply them.
+type SanctumFlairService struct {
+	db *gorm.DB
+	// canManage reports whether a user is a sanctum owner or moderator.
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error)
+}
+
+// SanctumFlairInput carries the flair fields to set; nil fields are left
+// unchanged.
+type SanctumFlairInput struct {
+	ActorID   uint
+	SanctumID uint
+	Name      *string
+	Color     *string
+	ModOnly   *bool
+	Position  *int
+}
+
+func NewSanctumFlairService(
+	db *gorm.DB,
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error),
+) *SanctumFlairService {
+	return &SanctumFlairService{db: db, canManage: canManage}
+}
+
+// List returns a sanctum's flairs in display order.
+func (s *SanctumFlairService) List(ctx context.Context, sanctumID uint) ([]models.SanctumFlair, error) {
+	flairs := []models.SanctumFlair{}
+	if err := s.db.WithContext(ctx).
+		Where("sanctum_id = ?", sanctumID).
+		Order("position ASC, id ASC").
+		Find(&flairs).Error; err != nil {
+		return nil, err
+	}
+	return flairs, nil
+}
+
+// Create defines a new flair. Without an explicit position it is appended
+// after the existing ones.
+func (s *SanctumFlairService) Create(ctx context.Context, in SanctumFlairInput) (*models.SanctumFlair, error) {
+	if err := s.requireManager(ctx, in.ActorID, in.SanctumID); err != nil {
+		return nil, err
+	}
+	flair := &models.SanctumFlair{SanctumID: in.SanctumID}
+	if err := in.apply(flair); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.SanctumFlair{}).
+		Where("sanctum_id = ?", in.SanctumID).
+		Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count >= models.MaxSanctumFlairs {
+		return nil, models.NewValidationError("Sanctum has too many flairs")
+	}
+	if in.Position == nil {
+		flair.Position = int(count)
+	}
+
+	if err := s.db.WithContext(ctx).Create(flair).Error; err != nil {
+		if database.IsUniqueConstraintError(err) {
+			return nil, models.NewValidationError("A flair with this name already exists")
+		}
+		return nil, err
+	}
+	return flair, nil
+}
+
+// Update renames, recolours or reorders a flair.
+func (s *SanctumFlairService) Update(ctx context.Context, flairID uint, in SanctumFlairInput) (*models.SanctumFlair, error) {
+	if err := s.requireManager(ctx, in.ActorID, in.SanctumID); err != nil {
+		return nil, err
+	}
+	flair, err := s.find(ctx, in.SanctumID, flairID)
+	if err != nil {
+		return nil, err
+	}
+	if err := in.apply(flair); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Save(flair).Error; err != nil {
+		if database.IsUniqueConstraintError(err) {
+			return nil, models.NewValidationError("A flair with this name already exists")
+		}
+		return nil, err
+	}
+	s.invalidateFlairPosts(ctx, flair.ID)
+	return flair, nil
+}
+
+// Delete removes a flair. Posts that carried it keep their content and lose
+// the label, and AutoModerator rules stop setting it.
+func (s *SanctumFlairService) Delete(ctx context.Context, actorID, sanctumID, flairID uint) error {
+	if err := s.requireManager(ctx, actorID, sanctumID); err != nil {
+		return err
+	}
+	flair, err := s.find(ctx, sanctumID, flairID)
+	if err != nil {
+		return err
+	}
+	var postIDs []uint
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Post{}).
+			Where("flair_id = ?", flair.ID).
+			Pluck("id", &postIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Post{}).
+			Where("flair_id = ?", flair.ID).
+			Update("flair_id", nil).Error; err != nil {
+			return err
+		}
+		if err := dropAutomodFlair(tx, sanctumID, flair.ID); err != nil {
+			return err
+		}
+		return tx.Delete(flair).Error
+	}); err != nil {
+		return err
+	}
+	// Only drop cached posts once the change is visible, or a concurrent
+	// read could cache the deleted flair again.
+	invalidatePosts(ctx, postIDs)
+	return nil
+}
+
+// CheckPostFlair verifies that userID may change a post's flair from current
+// (nil for a new or unlabelled post) to flairID, where zero clears it. The
+// flair must belong to the post's sanctum, and applying, replacing or
+// clearing a mod-only flair is reserved for sanctum moderators.
+func (s *SanctumFlairService) CheckPostFlair(ctx context.Context, userID uint, sanctumID, current *uint, flairID uint) error {
+	if current != nil && *current == flairID {
+		return nil
+	}
+	if flairID != 0 && sanctumID == nil {
+		return models.NewValidationError("Flairs can only be used on sanctum posts")
+	}
+	modOnly := false
+	if flairID != 0 {
+		flair, err := s.find(ctx, *sanctumID, flairID)
+		if err != nil {
+			var appErr *models.AppError
+			if errors.As(err, &appErr) && appErr.Code == "NOT_FOUND" {
+				return models.NewValidationError("Flair does not belong to this sanctum")
+			}
+			return err
+		}
+		modOnly = flair.ModOnly
+	}
+	if !modOnly && current != nil && sanctumID != nil {
+		previous, err := s.find(ctx, *sanctumID, *current)
+		var appErr *models.AppError
+		switch {
+		case err == nil:
+			modOnly = previous.ModOnly
+		case !errors.As(err, &appErr) || appErr.Code != "NOT_FOUND":
+			return err
+		}
+	}
+	if !modOnly {
+		return nil
+	}
+	allowed, err := s.isManager(ctx, userID, *sanctumID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return models.NewForbiddenError("This flair can only be changed by sanctum moderators")
+	}
+	return nil
+}
+
+func (s *SanctumFlairService) isManager(ctx context.Context, userID, sanctumID uint) (bool, error) {
+	if s.canManage == nil {
+		return false, nil
+	}
+	return s.canManage(ctx, userID, sanctumID)
+}
+
+func (s *SanctumFlairService) requireManager(ctx context.Context, userID, sanctumID uint) error {
+	ok, err := s.isManager(ctx, userID, sanctumID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.NewForbiddenError("Sanctum owner or moderator access required")
+	}
+	return nil
+}
+
+func (s *SanctumFlairService) find(ctx context.Context, sanctumID, flairID uint) (*models.SanctumFlair, error) {
+	var flair models.SanctumFlair
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND sanctum_id = ?", flairID, sanctumID).
+		First(&flair).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("Flair", flairID)
+		}
+		return nil, err
+	}
+	return &flair, nil
+}
+
+// invalidateFlairPosts drops cached copies of posts carrying flairID so they
+// pick up a renamed or removed flair.
+func (s *SanctumFlairService) invalidateFlairPosts(ctx context.Context, flairID uint) {
+	var postIDs []uint
+	if err := s.db.WithContext(ctx).Model(&models.Post{}).
+		Where("flair_id = ?", flairID).
+		Pluck("id", &postIDs).Error; err != nil {
+		return
+	}
+	invalidatePosts(ctx, postIDs)
+}
+
+func invalidatePosts(ctx context.Context, postIDs []uint) {
+	for _, id := range postIDs {
+		cache.Invalidate(ctx, cache.PostKey(id))
+	}
+	cache.InvalidatePostsList(ctx)
+}
+
+// apply copies the set fields onto flair and validates the result.
+func (in SanctumFlairInput) apply(flair *models.SanctumFlair) error {
+	if in.Name != nil {
+		flair.Name = strings.TrimSpace(*in.Name)
+	}
+	if in.Color != nil {
+		flair.Color = strings.ToLower(strings.TrimSpace(*in.Color))
+	}
+	if in.ModOnly != nil {
+		flair.ModOnly = *in.ModOnly
+	}
+	if in.Position != nil {
+		flair.Position = *in.Position
+	}
+	if flair.Name == "" || utf8.RuneCountInString(flair.Name) > 40 {
+		return models.NewValidationError("Flair name must be 1-40 characters")
+	}
+	if !flairColorPattern.MatchString(flair.Color) {
+		return models.NewValidationError("Flair color must be a #rrggbb hex value")
+	}
+	return nil
+}
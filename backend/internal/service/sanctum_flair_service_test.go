@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"sanctum/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	flairTestSanctum = uint(1)
+	flairTestMod     = uint(10)
+	flairTestMember  = uint(20)
+)
+
+func newFlairTestService(t *testing.T) (*SanctumFlairService, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.SanctumFlair{}, &models.Post{}, &models.SanctumAutomod{}))
+	svc := NewSanctumFlairService(db, func(_ context.Context, userID, sanctumID uint) (bool, error) {
+		return userID == flairTestMod && sanctumID == flairTestSanctum, nil
+	})
+	return svc, db
+}
+
+func flairInput(actorID uint, name, color string) SanctumFlairInput {
+	return SanctumFlairInput{ActorID: actorID, SanctumID: flairTestSanctum, Name: &name, Color: &color}
+}
+
+func TestSanctumFlairService_Create(t *testing.T) {
+	svc, _ := newFlairTestService(t)
+	ctx := context.Background()
+	_, err := svc.Create(ctx, flairInput(flairTestMod, "Existing", "#000000"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		in       SanctumFlairInput
+		wantCode string
+	}{
+		{"member cannot manage", flairInput(flairTestMember, "News", "#ff0000"), "FORBIDDEN"},
+		{"empty name", flairInput(flairTestMod, "  ", "#ff0000"), "VALIDATION_ERROR"},
+		{"named colour", flairInput(flairTestMod, "News", "red"), "VALIDATION_ERROR"},
+		{"duplicate name", flairInput(flairTestMod, "Existing", "#00ff00"), "VALIDATION_ERROR"},
+		{"valid", flairInput(flairTestMod, " News ", "#FF0000"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flair, err := svc.Create(ctx, tt.in)
+			if tt.wantCode != "" {
+				var appErr *models.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, tt.wantCode, appErr.Code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "News", flair.Name)
+			assert.Equal(t, "#ff0000", flair.Color)
+			assert.Equal(t, 1, flair.Position, "appended after existing flairs")
+		})
+	}
+}
+
+func TestSanctumFlairService_DeleteClearsPosts(t *testing.T) {
+	svc, db := newFlairTestService(t)
+	ctx := context.Background()
+	flair, err := svc.Create(ctx, flairInput(flairTestMod, "News", "#ff0000"))
+	require.NoError(t, err)
+	sanctumID := flairTestSanctum
+	post := &models.Post{Title: "Hello", Content: "x", UserID: flairTestMod, SanctumID: &sanctumID, FlairID: &flair.ID}
+	require.NoError(t, db.Create(post).Error)
+
+	assert.Error(t, svc.Delete(ctx, flairTestMember, flairTestSanctum, flair.ID))
+	require.NoError(t, svc.Delete(ctx, flairTestMod, flairTestSanctum, flair.ID))
+	require.NoError(t, db.First(post, post.ID).Error)
+	assert.Nil(t, post.FlairID)
+	flairs, err := svc.List(ctx, flairTestSanctum)
+	require.NoError(t, err)
+	assert.Empty(t, flairs)
+}
+
+func TestSanctumFlairService_CheckPostFlair(t *testing.T) {
+	svc, _ := newFlairTestService(t)
+	ctx := context.Background()
+	modOnly := true
+	in := flairInput(flairTestMod, "Announcement", "#ff0000")
+	in.ModOnly = &modOnly
+	announcement, err := svc.Create(ctx, in)
+	require.NoError(t, err)
+	open, err := svc.Create(ctx, flairInput(flairTestMod, "Question", "#00ff00"))
+	require.NoError(t, err)
+
+	sanctumID, otherSanctum := flairTestSanctum, flairTestSanctum+1
+	tests := []struct {
+		name      string
+		userID    uint
+		sanctumID *uint
+		current   *uint
+		flairID   uint
+		wantErr   bool
+	}{
+		{"clearing an unlabelled post", flairTestMember, nil, nil, 0, false},
+		{"not a sanctum post", flairTestMember, nil, nil, open.ID, true},
+		{"member applies open flair", flairTestMember, &sanctumID, nil, open.ID, false},
+		{"member applies mod-only flair", flairTestMember, &sanctumID, nil, announcement.ID, true},
+		{"mod applies mod-only flair", flairTestMod, &sanctumID, nil, announcement.ID, false},
+		{"flair from another sanctum", flairTestMod, &otherSanctum, nil, open.ID, true},
+		{"member keeps mod-only flair", flairTestMember, &sanctumID, &announcement.ID, announcement.ID, false},
+		{"member clears open flair", flairTestMember, &sanctumID, &open.ID, 0, false},
+		{"member clears mod-only flair", flairTestMember, &sanctumID, &announcement.ID, 0, true},
+		{"member replaces mod-only flair", flairTestMember, &sanctumID, &announcement.ID, open.ID, true},
+		{"mod clears mod-only flair", flairTestMod, &sanctumID, &announcement.ID, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.CheckPostFlair(ctx, tt.userID, tt.sanctumID, tt.current, tt.flairID)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SanctumJoinService enforces sanctum visibility and runs the join-request
+// queue for restricted and private sanctums.
+type SanctumJoinService struct {
+	db *gorm.DB
+	// canManage reports whether a user may review join requests in a sanctum.
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error)
+}
+
+func NewSanctumJoinService(
+	db *gorm.DB,
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error),
+) *SanctumJoinService {
+	return &SanctumJoinService{db: db, canManage: canManage}
+}
+
+// sanctumVisibility returns a sanctum's visibility, or a not-found error for
+// an unknown sanctum.
+func sanctumVisibility(ctx context.Context, db *gorm.DB, sanctumID uint) (models.SanctumVisibility, error) {
+	var sanctum models.Sanctum
+	err := db.WithContext(ctx).Select("id", "visibility").First(&sanctum, sanctumID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", models.NewNotFoundError("Sanctum", sanctumID)
+		}
+		return "", err
+	}
+	if sanctum.Visibility == "" {
+		return models.SanctumVisibilityPublic, nil
+	}
+	return sanctum.Visibility, nil
+}
+
+// approvedMember reports whether the user belongs to the sanctum, or may
+// manage it.
+func (s *SanctumJoinService) approvedMember(ctx context.Context, sanctumID, userID uint) (bool, error) {
+	if userID == 0 {
+		return false, nil
+	}
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.SanctumMembership{}).
+		Where("sanctum_id = ? AND user_id = ?", sanctumID, userID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+	if s.canManage == nil {
+		return false, nil
+	}
+	return s.canManage(ctx, userID, sanctumID)
+}
+
+// CanRead reports whether the user may read the sanctum's posts. Private
+// sanctums are readable by members only; userID 0 is an anonymous reader.
+func (s *SanctumJoinService) CanRead(ctx context.Context, sanctumID, userID uint) (bool, error) {
+	visibility, err := sanctumVisibility(ctx, s.db, sanctumID)
+	if err != nil {
+		return false, err
+	}
+	if visibility != models.SanctumVisibilityPrivate {
+		return true, nil
+	}
+	return s.approvedMember(ctx, sanctumID, userID)
+}
+
+// CheckPostAccess returns a forbidden error when the sanctum only accepts
+// posts from approved members and the user is not one.
+func (s *SanctumJoinService) CheckPostAccess(ctx context.Context, sanctumID, userID uint) error {
+	visibility, err := sanctumVisibility(ctx, s.db, sanctumID)
+	if err != nil {
+		return err
+	}
+	if visibility == models.SanctumVisibilityPublic {
+		return nil
+	}
+	ok, err := s.approvedMember(ctx, sanctumID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.NewForbiddenError("Only approved members can post in this sanctum")
+	}
+	return nil
+}
+
+// RequestJoin asks to join a restricted or private sanctum. Asking again
+// while a request is pending returns it unchanged; asking after a rejection
+// reopens it.
+func (s *SanctumJoinService) RequestJoin(ctx context.Context, sanctumID, userID uint, message string) (*models.SanctumJoinRequest, error) {
+	message = strings.TrimSpace(message)
+	if len(message) > 500 {
+		return nil, models.NewValidationError("Message too long (max 500 characters)")
+	}
+	visibility, err := sanctumVisibility(ctx, s.db, sanctumID)
+	if err != nil {
+		return nil, err
+	}
+	if visibility == models.SanctumVisibilityPublic {
+		return nil, models.NewValidationError("This sanctum is public; join it directly")
+	}
+	ban, err := activeSanctumBan(ctx, s.db, sanctumID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if ban != nil && ban.Kind == models.SanctumBanKindBan {
+		return nil, sanctumBanError(ban)
+	}
+	var members int64
+	if err := s.db.WithContext(ctx).Model(&models.SanctumMembership{}).
+		Where("sanctum_id = ? AND user_id = ?", sanctumID, userID).
+		Count(&members).Error; err != nil {
+		return nil, err
+	}
+	if members > 0 {
+		return nil, models.NewValidationError("You are already a member of this sanctum")
+	}
+
+	var existing models.SanctumJoinRequest
+	err = s.db.WithContext(ctx).
+		Where("sanctum_id = ? AND user_id = ?", sanctumID, userID).
+		First(&existing).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err == nil && existing.Status == models.SanctumRequestStatusPending {
+		return &existing, nil
+	}
+
+	req := models.SanctumJoinRequest{
+		SanctumID: sanctumID,
+		UserID:    userID,
+		Message:   message,
+		Status:    models.SanctumRequestStatusPending,
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "sanctum_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"message":             message,
+			"status":              models.SanctumRequestStatusPending,
+			"reviewed_by_user_id": nil,
+			"reviewed_at":         nil,
+			"updated_at":          time.Now(),
+		}),
+	}).Create(&req).Error; err != nil {
+		return nil, err
+	}
+	return s.loadRequest(ctx, sanctumID, userID)
+}
+
+func (s *SanctumJoinService) loadRequest(ctx context.Context, sanctumID, userID uint) (*models.SanctumJoinRequest, error) {
+	var req models.SanctumJoinRequest
+	if err := s.db.WithContext(ctx).
+		Where("sanctum_id = ? AND user_id = ?", sanctumID, userID).
+		First(&req).Error; err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (s *SanctumJoinService) requireReviewer(ctx context.Context, actorID, sanctumID uint) error {
+	if s.canManage == nil {
+		return models.NewForbiddenError("Sanctum moderator access required")
+	}
+	ok, err := s.canManage(ctx, actorID, sanctumID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.NewForbiddenError("Sanctum moderator access required")
+	}
+	return nil
+}
+
+// ListRequests returns a sanctum's join requests with the given status,
+// oldest first. Owners and moderators only.
+func (s *SanctumJoinService) ListRequests(ctx context.Context, actorID, sanctumID uint, status models.SanctumRequestStatus, limit, offset int) ([]models.SanctumJoinRequest, error) {
+	if err := s.requireReviewer(ctx, actorID, sanctumID); err != nil {
+		return nil, err
+	}
+	if status == "" {
+		status = models.SanctumRequestStatusPending
+	}
+	requests := []models.SanctumJoinRequest{}
+	if err := s.db.WithContext(ctx).
+		Preload("User").
+		Where("sanctum_id = ? AND status = ?", sanctumID, status).
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// ReviewRequest approves or rejects a pending join request. Approval makes
+// the requester a member; both outcomes are recorded in the mod log.
+func (s *SanctumJoinService) ReviewRequest(ctx context.Context, actorID, sanctumID, requestID uint, approve bool) (*models.SanctumJoinRequest, error) {
+	if err := s.requireReviewer(ctx, actorID, sanctumID); err != nil {
+		return nil, err
+	}
+	var req models.SanctumJoinRequest
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND sanctum_id = ?", requestID, sanctumID).
+		First(&req).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("Join request", requestID)
+		}
+		return nil, err
+	}
+	if req.Status != models.SanctumRequestStatusPending {
+		return nil, models.NewValidationError("Join request has already been reviewed")
+	}
+
+	status, action := models.SanctumRequestStatusRejected, models.ModActionRejectJoin
+	if approve {
+		status, action = models.SanctumRequestStatusApproved, models.ModActionApproveJoin
+	}
+	now := time.Now()
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only the first reviewer moves the request out of pending.
+		res := tx.Model(&models.SanctumJoinRequest{}).
+			Where("id = ? AND status = ?", req.ID, models.SanctumRequestStatusPending).
+			Updates(map[string]any{
+				"status":              status,
+				"reviewed_by_user_id": actorID,
+				"reviewed_at":         now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return models.NewValidationError("Join request has already been reviewed")
+		}
+		if approve {
+			ban, err := activeSanctumBan(ctx, tx, sanctumID, req.UserID)
+			if err != nil {
+				return err
+			}
+			if ban != nil && ban.Kind == models.SanctumBanKindBan {
+				return models.NewValidationError("This user is banned from the sanctum")
+			}
+			membership := models.SanctumMembership{
+				SanctumID: sanctumID,
+				UserID:    req.UserID,
+				Role:      models.SanctumMembershipRoleMember,
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&membership).Error; err != nil {
+				return err
+			}
+			if err := SyncSanctumChannelAccess(ctx, tx, sanctumID, req.UserID, true); err != nil {
+				return err
+			}
+		}
+		return RecordModAction(tx, sanctumID, actorID, action, models.ModTargetUser, req.UserID, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	req.Status = status
+	req.ReviewedByUserID = &actorID
+	req.ReviewedAt = &now
+	return &req, nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newJoinTestService(t *testing.T, visibility models.SanctumVisibility) (*SanctumJoinService, *sanctumFixture) {
+	t.Helper()
+	f := newSanctumFixture(t, append(postTables(),
+		&models.SanctumJoinRequest{},
+		&models.SanctumBan{},
+		&models.SanctumModLogEntry{},
+		&models.Conversation{},
+		&models.ConversationParticipant{},
+	)...)
+	require.NoError(t, f.db.Model(f.sanctum).Update("visibility", visibility).Error)
+	return NewSanctumJoinService(f.db, f.canManage), f
+}
+
+func TestSanctumJoinService_ReadAndPostAccess(t *testing.T) {
+	tests := []struct {
+		visibility models.SanctumVisibility
+		userID     func(f *sanctumFixture) uint
+		name       string
+		canRead    bool
+		canPost    bool
+	}{
+		{models.SanctumVisibilityPublic, func(f *sanctumFixture) uint { return f.outsider.ID }, "public/outsider", true, true},
+		{models.SanctumVisibilityPublic, func(*sanctumFixture) uint { return 0 }, "public/anonymous", true, true},
+		{models.SanctumVisibilityRestricted, func(f *sanctumFixture) uint { return f.outsider.ID }, "restricted/outsider", true, false},
+		{models.SanctumVisibilityRestricted, func(f *sanctumFixture) uint { return f.member.ID }, "restricted/member", true, true},
+		{models.SanctumVisibilityPrivate, func(f *sanctumFixture) uint { return f.outsider.ID }, "private/outsider", false, false},
+		{models.SanctumVisibilityPrivate, func(*sanctumFixture) uint { return 0 }, "private/anonymous", false, false},
+		{models.SanctumVisibilityPrivate, func(f *sanctumFixture) uint { return f.member.ID }, "private/member", true, true},
+		{models.SanctumVisibilityPrivate, func(f *sanctumFixture) uint { return f.mod.ID }, "private/mod", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			joins, f := newJoinTestService(t, tt.visibility)
+			ctx := context.Background()
+			userID := tt.userID(f)
+
+			canRead, err := joins.CanRead(ctx, f.sanctum.ID, userID)
+			require.NoError(t, err)
+			assert.Equal(t, tt.canRead, canRead)
+			err = joins.CheckPostAccess(ctx, f.sanctum.ID, userID)
+			if tt.canPost {
+				assert.NoError(t, err)
+			} else {
+				requireCode(t, err, "FORBIDDEN")
+			}
+		})
+	}
+}
+
+func TestPostService_PrivateSanctumPostsAreHidden(t *testing.T) {
+	joins, f := newJoinTestService(t, models.SanctumVisibilityPrivate)
+	ctx := context.Background()
+	posts := NewPostService(repository.NewPostRepository(f.db), repository.NewPollRepository(f.db), nil, nil, func(ctx context.Context, p *models.Post) error {
+		return joins.CheckPostAccess(ctx, *p.SanctumID, p.UserID)
+	}, joins.CanRead, nil, nil, nil)
+
+	secret, err := posts.CreatePost(ctx, CreatePostInput{UserID: f.member.ID, Title: "secret", Content: "x", SanctumID: &f.sanctum.ID})
+	require.NoError(t, err)
+	_, err = posts.CreatePost(ctx, CreatePostInput{UserID: f.outsider.ID, Title: "intrude", Content: "x", SanctumID: &f.sanctum.ID})
+	requireCode(t, err, "FORBIDDEN")
+
+	front, err := posts.ListPosts(ctx, ListPostsInput{Limit: 20, CurrentUserID: f.outsider.ID})
+	require.NoError(t, err)
+	assert.Empty(t, front)
+	_, err = posts.ListPosts(ctx, ListPostsInput{Limit: 20, SanctumID: &f.sanctum.ID, CurrentUserID: f.outsider.ID})
+	assert.Error(t, err)
+	_, err = posts.GetPost(ctx, secret.ID, f.outsider.ID)
+	assert.Error(t, err)
+	byAuthor, err := posts.GetUserPosts(ctx, f.member.ID, 20, 0, f.outsider.ID)
+	require.NoError(t, err)
+	assert.Empty(t, byAuthor)
+
+	feed, err := posts.ListPosts(ctx, ListPostsInput{Limit: 20, SanctumID: &f.sanctum.ID, CurrentUserID: f.mod.ID})
+	require.NoError(t, err)
+	require.Len(t, feed, 1)
+	assert.Equal(t, secret.ID, feed[0].ID)
+}
+
+func TestSanctumJoinService_RequestJoin(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility models.SanctumVisibility
+		userID     func(f *sanctumFixture) uint
+		code       string
+	}{
+		{"public sanctums are joined directly", models.SanctumVisibilityPublic, func(f *sanctumFixture) uint { return f.outsider.ID }, "VALIDATION_ERROR"},
+		{"members need not ask", models.SanctumVisibilityPrivate, func(f *sanctumFixture) uint { return f.member.ID }, "VALIDATION_ERROR"},
+		{"outsiders queue", models.SanctumVisibilityPrivate, func(f *sanctumFixture) uint { return f.outsider.ID }, ""},
+		{"restricted sanctums queue too", models.SanctumVisibilityRestricted, func(f *sanctumFixture) uint { return f.outsider.ID }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			joins, f := newJoinTestService(t, tt.visibility)
+			req, err := joins.RequestJoin(context.Background(), f.sanctum.ID, tt.userID(f), "let me in")
+			if tt.code != "" {
+				requireCode(t, err, tt.code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, models.SanctumRequestStatusPending, req.Status)
+			assert.Equal(t, "let me in", req.Message)
+		})
+	}
+}
+
+func TestSanctumJoinService_BannedUsersCannotRequest(t *testing.T) {
+	joins, f := newJoinTestService(t, models.SanctumVisibilityPrivate)
+	require.NoError(t, f.db.Create(&models.SanctumBan{
+		SanctumID: f.sanctum.ID, UserID: f.outsider.ID, CreatedByUserID: f.owner.ID, Kind: models.SanctumBanKindBan,
+	}).Error)
+	_, err := joins.RequestJoin(context.Background(), f.sanctum.ID, f.outsider.ID, "")
+	requireCode(t, err, "FORBIDDEN")
+}
+
+func TestSanctumJoinService_ReviewQueue(t *testing.T) {
+	joins, f := newJoinTestService(t, models.SanctumVisibilityPrivate)
+	ctx := context.Background()
+
+	req, err := joins.RequestJoin(ctx, f.sanctum.ID, f.outsider.ID, "let me in")
+	require.NoError(t, err)
+	_, err = joins.ListRequests(ctx, f.member.ID, f.sanctum.ID, "", 50, 0)
+	requireCode(t, err, "FORBIDDEN")
+	pending, err := joins.ListRequests(ctx, f.mod.ID, f.sanctum.ID, "", 50, 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "outsider", pending[0].User.Username)
+
+	rejected, err := joins.ReviewRequest(ctx, f.mod.ID, f.sanctum.ID, req.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, models.SanctumRequestStatusRejected, rejected.Status)
+	_, err = joins.ReviewRequest(ctx, f.mod.ID, f.sanctum.ID, req.ID, true)
+	requireCode(t, err, "VALIDATION_ERROR")
+
+	// Asking again after a rejection reopens the request.
+	req, err = joins.RequestJoin(ctx, f.sanctum.ID, f.outsider.ID, "please")
+	require.NoError(t, err)
+	assert.Equal(t, models.SanctumRequestStatusPending, req.Status)
+	assert.Equal(t, "please", req.Message)
+	_, err = joins.ReviewRequest(ctx, f.mod.ID, f.sanctum.ID, req.ID, true)
+	require.NoError(t, err)
+	assert.NoError(t, joins.CheckPostAccess(ctx, f.sanctum.ID, f.outsider.ID))
+
+	var actions []string
+	require.NoError(t, f.db.Model(&models.SanctumModLogEntry{}).Order("id").Pluck("action", &actions).Error)
+	assert.Equal(t, []string{models.ModActionRejectJoin, models.ModActionApproveJoin}, actions)
+}
+
+func TestSanctumJoinService_ApprovalRechecksBans(t *testing.T) {
+	joins, f := newJoinTestService(t, models.SanctumVisibilityPrivate)
+	ctx := context.Background()
+
+	req, err := joins.RequestJoin(ctx, f.sanctum.ID, f.outsider.ID, "")
+	require.NoError(t, err)
+	require.NoError(t, f.db.Create(&models.SanctumBan{
+		SanctumID: f.sanctum.ID, UserID: f.outsider.ID, CreatedByUserID: f.owner.ID, Kind: models.SanctumBanKindBan,
+	}).Error)
+
+	_, err = joins.ReviewRequest(ctx, f.mod.ID, f.sanctum.ID, req.ID, true)
+	requireCode(t, err, "VALIDATION_ERROR")
+	requireCode(t, joins.CheckPostAccess(ctx, f.sanctum.ID, f.outsider.ID), "FORBIDDEN")
+	var stored models.SanctumJoinRequest
+	require.NoError(t, f.db.First(&stored, req.ID).Error)
+	assert.Equal(t, models.SanctumRequestStatusPending, stored.Status, "a refused approval leaves the request pending")
+}
+
+func TestSanctumJoinService_UnknownSanctumIsNotFound(t *testing.T) {
+	joins, f := newJoinTestService(t, models.SanctumVisibilityPublic)
+	_, err := joins.CanRead(context.Background(), f.sanctum.ID+100, f.member.ID)
+	requireCode(t, err, "NOT_FOUND")
+}
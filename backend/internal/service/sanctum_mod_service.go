@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"sanctum/internal/cache"
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// maxModReasonLen bounds removal reasons and other mod log notes.
+const maxModReasonLen = 1000
+
+// SanctumModService implements sanctum moderator actions on posts and
+// comments and records each one in the sanctum's mod log.
+type SanctumModService struct {
+	db        *gorm.DB
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error)
+}
+
+func NewSanctumModService(
+	db *gorm.DB,
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error),
+) *SanctumModService {
+	return &SanctumModService{db: db, canManage: canManage}
+}
+
+// RecordModAction appends an entry to a sanctum's mod log using tx.
+func RecordModAction(tx *gorm.DB, sanctumID, moderatorID uint, action, targetType string, targetID uint, reason string) error {
+	return tx.Create(&models.SanctumModLogEntry{
+		SanctumID:   sanctumID,
+		ModeratorID: moderatorID,
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Reason:      reason,
+	}).Error
+}
+
+// CanModerate reports whether userID moderates the sanctum.
+func (s *SanctumModService) CanModerate(ctx context.Context, userID, sanctumID uint) (bool, error) {
+	if s.canManage == nil {
+		return false, nil
+	}
+	return s.canManage(ctx, userID, sanctumID)
+}
+
+func (s *SanctumModService) requireModerator(ctx context.Context, userID uint, sanctumID *uint) error {
+	if sanctumID == nil {
+		return models.NewValidationError("Only sanctum posts can be moderated")
+	}
+	ok, err := s.CanModerate(ctx, userID, *sanctumID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.NewForbiddenError("Sanctum moderator access required")
+	}
+	return nil
+}
+
+func (s *SanctumModService) loadPost(ctx context.Context, postID uint) (*models.Post, error) {
+	var post models.Post
+	if err := s.db.WithContext(ctx).
+		Select("id", "sanctum_id", "status", "removed_at", "pinned_at", "locked", "needs_review").
+		First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("Post", postID)
+		}
+		return nil, err
+	}
+	return &post, nil
+}
+
+// loadComment returns a comment together with the sanctum of its post.
+func (s *SanctumModService) loadComment(ctx context.Context, commentID uint) (*models.Comment, *uint, error) {
+	var comment models.Comment
+	if err := s.db.WithContext(ctx).First(&comment, commentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, models.NewNotFoundError("Comment", commentID)
+		}
+		return nil, nil, err
+	}
+	post, err := s.loadPost(ctx, comment.PostID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &comment, post.SanctumID, nil
+}
+
+func normalizeModReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if len(reason) > maxModReasonLen {
+		return "", models.NewValidationError(fmt.Sprintf("Reason too long (max %d characters)", maxModReasonLen))
+	}
+	return reason, nil
+}
+
+// updatePost applies updates to a post and logs action in one transaction.
+func (s *SanctumModService) updatePost(ctx context.Context, modID uint, post *models.Post, updates map[string]any, action, reason string) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Post{}).Where("id = ?", post.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		return RecordModAction(tx, *post.SanctumID, modID, action, models.ModTargetPost, post.ID, reason)
+	})
+	if err != nil {
+		return err
+	}
+	cache.Invalidate(ctx, cache.PostKey(post.ID))
+	cache.InvalidatePostsList(ctx)
+	return nil
+}
+
+// RemovePost hides a post from feeds. The author and moderators can still
+// open it and see the reason. Removing a post AutoModerator filtered
+// confirms the filter and clears it from the mod queue.
+func (s *SanctumModService) RemovePost(ctx context.Context, modID, postID uint, reason string) error {
+	post, err := s.loadPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if err := s.requireModerator(ctx, modID, post.SanctumID); err != nil {
+		return err
+	}
+	if post.RemovedAt != nil && !post.NeedsReview {
+		return models.NewValidationError("Post is already removed")
+	}
+	if reason, err = normalizeModReason(reason); err != nil {
+		return err
+	}
+	return s.updatePost(ctx, modID, post, map[string]any{
+		"removed_at":         time.Now(),
+		"removed_by_user_id": modID,
+		"removal_reason":     reason,
+		"pinned_at":          nil,
+		"needs_review":       false,
+	}, models.ModActionRemovePost, reason)
+}
+
+// RestorePost undoes RemovePost, and approves posts AutoModerator
+// filtered.
+func (s *SanctumModService) RestorePost(ctx context.Context, modID, postID uint) error {
+	post, err := s.loadPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if err := s.requireModerator(ctx, modID, post.SanctumID); err != nil {
+		return err
+	}
+	if post.RemovedAt == nil {
+		return models.NewValidationError("Post is not removed")
+	}
+	return s.updatePost(ctx, modID, post, map[string]any{
+		"removed_at":         nil,
+		"removed_by_user_id": nil,
+		"removal_reason":     "",
+		"needs_review":       false,
+	}, models.ModActionRestorePost, "")
+}
+
+// SetPostPinned pins or unpins a post in its sanctum feed.
+func (s *SanctumModService) SetPostPinned(ctx context.Context, modID, postID uint, pinned bool) error {
+	post, err := s.loadPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if err := s.requireModerator(ctx, modID, post.SanctumID); err != nil {
+		return err
+	}
+	if !pinned {
+		if post.PinnedAt == nil {
+			return nil
+		}
+		return s.updatePost(ctx, modID, post, map[string]any{"pinned_at": nil}, models.ModActionUnpinPost, "")
+	}
+	if post.PinnedAt != nil {
+		return nil
+	}
+	if post.RemovedAt != nil || post.Status != models.PostStatusPublished {
+		return models.NewValidationError("Only published posts can be pinned")
+	}
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.Post{}).
+		Where("sanctum_id = ? AND pinned_at IS NOT NULL", *post.SanctumID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count >= models.MaxSanctumPinnedPosts {
+		return models.NewValidationError(fmt.Sprintf("A sanctum can pin at most %d posts", models.MaxSanctumPinnedPosts))
+	}
+	return s.updatePost(ctx, modID, post, map[string]any{"pinned_at": time.Now()}, models.ModActionPinPost, "")
+}
+
+// SetPostLocked locks or unlocks comments on a post.
+func (s *SanctumModService) SetPostLocked(ctx context.Context, modID, postID uint, locked bool) error {
+	post, err := s.loadPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if err := s.requireModerator(ctx, modID, post.SanctumID); err != nil {
+		return err
+	}
+	if post.Locked == locked {
+		return nil
+	}
+	action := models.ModActionLockPost
+	if !locked {
+		action = models.ModActionUnlockPost
+	}
+	return s.updatePost(ctx, modID, post, map[string]any{"locked": locked}, action, "")
+}
+
+func (s *SanctumModService) updateComment(ctx context.Context, modID, sanctumID uint, comment *models.Comment, updates map[string]any, action, reason string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Comment{}).Where("id = ?", comment.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		return RecordModAction(tx, sanctumID, modID, action, models.ModTargetComment, comment.ID, reason)
+	})
+}
+
+// RemoveComment hides a comment's content from everyone but moderators.
+// Like RemovePost, it also confirms an AutoModerator filter.
+func (s *SanctumModService) RemoveComment(ctx context.Context, modID, commentID uint, reason string) error {
+	comment, sanctumID, err := s.loadComment(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	if err := s.requireModerator(ctx, modID, sanctumID); err != nil {
+		return err
+	}
+	if comment.RemovedAt != nil && !comment.NeedsReview {
+		return models.NewValidationError("Comment is already removed")
+	}
+	if reason, err = normalizeModReason(reason); err != nil {
+		return err
+	}
+	return s.updateComment(ctx, modID, *sanctumID, comment, map[string]any{
+		"removed_at":         time.Now(),
+		"removed_by_user_id": modID,
+		"removal_reason":     reason,
+		"needs_review":       false,
+	}, models.ModActionRemoveComment, reason)
+}
+
+// RestoreComment undoes RemoveComment.
+func (s *SanctumModService) RestoreComment(ctx context.Context, modID, commentID uint) error {
+	comment, sanctumID, err := s.loadComment(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	if err := s.requireModerator(ctx, modID, sanctumID); err != nil {
+		return err
+	}
+	if comment.RemovedAt == nil {
+		return models.NewValidationError("Comment is not removed")
+	}
+	return s.updateComment(ctx, modID, *sanctumID, comment, map[string]any{
+		"removed_at":         nil,
+		"removed_by_user_id": nil,
+		"removal_reason":     "",
+		"needs_review":       false,
+	}, models.ModActionRestoreComment, "")
+}
+
+// SetCommentDistinguished marks a moderator's own comment as an official
+// moderator statement.
+func (s *SanctumModService) SetCommentDistinguished(ctx context.Context, modID, commentID uint, distinguished bool) error {
+	comment, sanctumID, err := s.loadComment(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	if err := s.requireModerator(ctx, modID, sanctumID); err != nil {
+		return err
+	}
+	if comment.UserID != modID {
+		return models.NewForbiddenError("You can only distinguish your own comments")
+	}
+	if comment.Distinguished == distinguished {
+		return nil
+	}
+	action := models.ModActionDistinguishComment
+	if !distinguished {
+		action = models.ModActionUndistinguishComment
+	}
+	return s.updateComment(ctx, modID, *sanctumID, comment, map[string]any{"distinguished": distinguished}, action, "")
+}
+
+// ListModLog returns a sanctum's mod log, newest first. Moderators only.
+func (s *SanctumModService) ListModLog(ctx context.Context, userID, sanctumID uint, limit, offset int) ([]models.SanctumModLogEntry, error) {
+	if err := s.requireModerator(ctx, userID, &sanctumID); err != nil {
+		return nil, err
+	}
+	entries := []models.SanctumModLogEntry{}
+	if err := s.db.WithContext(ctx).
+		Preload("Moderator").
+		Where("sanctum_id = ?", sanctumID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"sanctum/internal/models"
+	"sanctum/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanctumModService_PostAndCommentActions(t *testing.T) {
+	posts, _, db, _ := newGalleryTestService(t)
+	require.NoError(t, db.AutoMigrate(&models.Sanctum{}, &models.SanctumModLogEntry{}))
+	ctx := context.Background()
+
+	mod := &models.User{Username: "mod", Email: "mod@e.com"}
+	author := &models.User{Username: "author", Email: "author@e.com"}
+	require.NoError(t, db.Create(mod).Error)
+	require.NoError(t, db.Create(author).Error)
+	sanctum := &models.Sanctum{Name: "Books", Slug: "books"}
+	require.NoError(t, db.Create(sanctum).Error)
+
+	mods := NewSanctumModService(db, func(_ context.Context, userID, sanctumID uint) (bool, error) {
+		return userID == mod.ID && sanctumID == sanctum.ID, nil
+	})
+	comments := NewCommentService(repository.NewCommentRepository(db), repository.NewPostRepository(db), nil, nil, nil, nil)
+
+	newPost := func(title string) *models.Post {
+		p, err := posts.CreatePost(ctx, CreatePostInput{UserID: author.ID, Title: title, Content: "x", SanctumID: &sanctum.ID})
+		require.NoError(t, err)
+		return p
+	}
+	feed := func() []string {
+		got, err := posts.ListPosts(ctx, ListPostsInput{Limit: 20, SanctumID: &sanctum.ID})
+		require.NoError(t, err)
+		titles := make([]string, 0, len(got))
+		for _, p := range got {
+			titles = append(titles, p.Title)
+		}
+		return titles
+	}
+
+	first, second, third, fourth := newPost("first"), newPost("second"), newPost("third"), newPost("fourth")
+
+	assert.Error(t, mods.RemovePost(ctx, author.ID, first.ID, "spam"), "only moderators may remove")
+
+	require.NoError(t, mods.RemovePost(ctx, mod.ID, first.ID, "off topic"))
+	assert.NotContains(t, feed(), "first")
+	removed, err := posts.GetPost(ctx, first.ID, author.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "off topic", removed.RemovalReason)
+	_, err = comments.CreateComment(ctx, CreateCommentInput{UserID: author.ID, PostID: first.ID, Content: "hi"})
+	assert.Error(t, err, "removed posts take no comments")
+	require.NoError(t, mods.RestorePost(ctx, mod.ID, first.ID))
+	assert.Contains(t, feed(), "first")
+
+	// Pins lead the feed and are capped.
+	require.NoError(t, mods.SetPostPinned(ctx, mod.ID, first.ID, true))
+	require.NoError(t, mods.SetPostPinned(ctx, mod.ID, second.ID, true))
+	require.NoError(t, mods.SetPostPinned(ctx, mod.ID, third.ID, true))
+	assert.Error(t, mods.SetPostPinned(ctx, mod.ID, fourth.ID, true))
+	assert.Equal(t, []string{"third", "second", "first", "fourth"}, feed())
+	require.NoError(t, mods.SetPostPinned(ctx, mod.ID, third.ID, false))
+	assert.Equal(t, "second", feed()[0])
+
+	require.NoError(t, mods.SetPostLocked(ctx, mod.ID, fourth.ID, true))
+	_, err = comments.CreateComment(ctx, CreateCommentInput{UserID: author.ID, PostID: fourth.ID, Content: "hi"})
+	assert.Error(t, err, "locked posts take no comments")
+	require.NoError(t, mods.SetPostLocked(ctx, mod.ID, fourth.ID, false))
+
+	userComment, err := comments.CreateComment(ctx, CreateCommentInput{UserID: author.ID, PostID: fourth.ID, Content: "rude"})
+	require.NoError(t, err)
+	modComment, err := comments.CreateComment(ctx, CreateCommentInput{UserID: mod.ID, PostID: fourth.ID, Content: "be nice"})
+	require.NoError(t, err)
+
+	require.NoError(t, mods.RemoveComment(ctx, mod.ID, userComment.ID, "incivility"))
+	_, err = comments.UpdateComment(ctx, UpdateCommentInput{UserID: author.ID, CommentID: userComment.ID, Content: "edit"})
+	assert.Error(t, err, "removed comments cannot be edited")
+	assert.Error(t, mods.SetCommentDistinguished(ctx, mod.ID, userComment.ID, true), "only own comments")
+	require.NoError(t, mods.SetCommentDistinguished(ctx, mod.ID, modComment.ID, true))
+	var stored models.Comment
+	require.NoError(t, db.First(&stored, modComment.ID).Error)
+	assert.True(t, stored.Distinguished)
+
+	_, err = mods.ListModLog(ctx, author.ID, sanctum.ID, 50, 0)
+	assert.Error(t, err)
+	log, err := mods.ListModLog(ctx, mod.ID, sanctum.ID, 50, 0)
+	require.NoError(t, err)
+	actions := make([]string, 0, len(log))
+	for _, entry := range log {
+		actions = append(actions, entry.Action)
+	}
+	assert.Equal(t, []string{
+		models.ModActionDistinguishComment,
+		models.ModActionRemoveComment,
+		models.ModActionUnlockPost,
+		models.ModActionLockPost,
+		models.ModActionUnpinPost,
+		models.ModActionPinPost,
+		models.ModActionPinPost,
+		models.ModActionPinPost,
+		models.ModActionRestorePost,
+		models.ModActionRemovePost,
+	}, actions)
+	assert.Equal(t, "incivility", log[1].Reason)
+	require.NotNil(t, log[0].Moderator)
+	assert.Equal(t, "mod", log[0].Moderator.Username)
+}
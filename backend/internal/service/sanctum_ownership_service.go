@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SanctumOwnershipService hands sanctums to new owners, either by an
+// owner's offer the recipient accepts or by a site admin reassigning an
+// orphaned sanctum. Both paths are recorded in the sanctum's mod log.
+type SanctumOwnershipService struct {
+	db *gorm.DB
+}
+
+// OrphanedSanctum is a sanctum without a live owner.
+type OrphanedSanctum struct {
+	Sanctum    models.Sanctum `json:"sanctum"`
+	Moderators int64          `json:"moderators"`
+}
+
+func NewSanctumOwnershipService(db *gorm.DB) *SanctumOwnershipService {
+	return &SanctumOwnershipService{db: db}
+}
+
+func (s *SanctumOwnershipService) isOwner(ctx context.Context, db *gorm.DB, sanctumID, userID uint) (bool, error) {
+	var count int64
+	err := db.WithContext(ctx).Model(&models.SanctumMembership{}).
+		Where("sanctum_id = ? AND user_id = ? AND role = ?", sanctumID, userID, models.SanctumMembershipRoleOwner).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// OfferTransfer offers the sanctum to another user. Any earlier open offer
+// is cancelled. Only the current owner may offer.
+func (s *SanctumOwnershipService) OfferTransfer(ctx context.Context, actorID, sanctumID, toUserID uint) (*models.SanctumOwnershipTransfer, error) {
+	owner, err := s.isOwner(ctx, s.db, sanctumID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !owner {
+		return nil, models.NewForbiddenError("Only the sanctum owner can transfer ownership")
+	}
+	if toUserID == actorID {
+		return nil, models.NewValidationError("You already own this sanctum")
+	}
+	var recipient models.User
+	if err := s.db.WithContext(ctx).Select("id", "is_banned").First(&recipient, toUserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("User", toUserID)
+		}
+		return nil, err
+	}
+	if recipient.IsBanned {
+		return nil, models.NewValidationError("Banned users cannot own a sanctum")
+	}
+	ban, err := activeSanctumBan(ctx, s.db, sanctumID, toUserID)
+	if err != nil {
+		return nil, err
+	}
+	if ban != nil && ban.Kind == models.SanctumBanKindBan {
+		return nil, models.NewValidationError("User is banned from this sanctum")
+	}
+
+	transfer := models.SanctumOwnershipTransfer{
+		SanctumID:  sanctumID,
+		FromUserID: actorID,
+		ToUserID:   toUserID,
+		Status:     models.SanctumTransferStatusPending,
+	}
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := cancelPendingTransfers(tx, sanctumID); err != nil {
+			return err
+		}
+		if err := tx.Create(&transfer).Error; err != nil {
+			return err
+		}
+		return RecordModAction(tx, sanctumID, actorID, models.ModActionOfferOwnership, models.ModTargetUser, toUserID, "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func cancelPendingTransfers(tx *gorm.DB, sanctumID uint) error {
+	return tx.Model(&models.SanctumOwnershipTransfer{}).
+		Where("sanctum_id = ? AND status = ?", sanctumID, models.SanctumTransferStatusPending).
+		Updates(map[string]any{
+			"status":       models.SanctumTransferStatusCancelled,
+			"responded_at": time.Now(),
+		}).Error
+}
+
+// CancelTransfer withdraws the sanctum's open offer.
+func (s *SanctumOwnershipService) CancelTransfer(ctx context.Context, actorID, sanctumID uint) error {
+	owner, err := s.isOwner(ctx, s.db, sanctumID, actorID)
+	if err != nil {
+		return err
+	}
+	if !owner {
+		return models.NewForbiddenError("Only the sanctum owner can transfer ownership")
+	}
+	res := s.db.WithContext(ctx).Model(&models.SanctumOwnershipTransfer{}).
+		Where("sanctum_id = ? AND status = ?", sanctumID, models.SanctumTransferStatusPending).
+		Updates(map[string]any{
+			"status":       models.SanctumTransferStatusCancelled,
+			"responded_at": time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return models.NewNotFoundError("Ownership transfer", sanctumID)
+	}
+	return nil
+}
+
+// ListIncoming returns the open offers made to a user.
+func (s *SanctumOwnershipService) ListIncoming(ctx context.Context, userID uint) ([]models.SanctumOwnershipTransfer, error) {
+	transfers := []models.SanctumOwnershipTransfer{}
+	if err := s.db.WithContext(ctx).
+		Preload("Sanctum").
+		Preload("FromUser").
+		Where("to_user_id = ? AND status = ?", userID, models.SanctumTransferStatusPending).
+		Order("created_at DESC, id DESC").
+		Find(&transfers).Error; err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+// setOwner makes userID the sole owner of the sanctum. Previous owners stay
+// on as moderators.
+func setOwner(tx *gorm.DB, sanctumID, userID uint) error {
+	now := time.Now()
+	if err := tx.Model(&models.SanctumMembership{}).
+		Where("sanctum_id = ? AND role = ? AND user_id <> ?", sanctumID, models.SanctumMembershipRoleOwner, userID).
+		Updates(map[string]any{"role": models.SanctumMembershipRoleMod, "updated_at": now}).Error; err != nil {
+		return err
+	}
+	membership := models.SanctumMembership{
+		SanctumID: sanctumID,
+		UserID:    userID,
+		Role:      models.SanctumMembershipRoleOwner,
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "sanctum_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"role":       models.SanctumMembershipRoleOwner,
+			"updated_at": now,
+		}),
+	}).Create(&membership).Error; err != nil {
+		return err
+	}
+	if err := SyncSanctumChannelAccess(tx.Statement.Context, tx, sanctumID, userID, true); err != nil {
+		return err
+	}
+	return tx.Model(&models.Sanctum{}).Where("id = ?", sanctumID).Update("created_by_user_id", userID).Error
+}
+
+// RespondTransfer accepts or declines an offer made to userID. Accepting
+// fails if the offering user is no longer the owner or if userID was banned
+// from the sanctum after the offer was made.
+func (s *SanctumOwnershipService) RespondTransfer(ctx context.Context, userID, transferID uint, accept bool) (*models.SanctumOwnershipTransfer, error) {
+	var transfer models.SanctumOwnershipTransfer
+	if err := s.db.WithContext(ctx).Preload("Sanctum").First(&transfer, transferID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("Ownership transfer", transferID)
+		}
+		return nil, err
+	}
+	if transfer.ToUserID != userID {
+		return nil, models.NewNotFoundError("Ownership transfer", transferID)
+	}
+	if transfer.Status != models.SanctumTransferStatusPending {
+		return nil, models.NewValidationError("Ownership transfer is no longer open")
+	}
+
+	now := time.Now()
+	status := models.SanctumTransferStatusDeclined
+	if accept {
+		status = models.SanctumTransferStatusAccepted
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if accept {
+			owner, err := s.isOwner(ctx, tx, transfer.SanctumID, transfer.FromUserID)
+			if err != nil {
+				return err
+			}
+			if !owner {
+				return models.NewValidationError("The offering user no longer owns this sanctum")
+			}
+			ban, err := activeSanctumBan(ctx, tx, transfer.SanctumID, transfer.ToUserID)
+			if err != nil {
+				return err
+			}
+			if ban != nil && ban.Kind == models.SanctumBanKindBan {
+				return models.NewValidationError("User is banned from this sanctum")
+			}
+			if err := setOwner(tx, transfer.SanctumID, transfer.ToUserID); err != nil {
+				return err
+			}
+			if err := RecordModAction(tx, transfer.SanctumID, transfer.FromUserID, models.ModActionTransferOwnership,
+				models.ModTargetUser, transfer.ToUserID, ""); err != nil {
+				return err
+			}
+		}
+		res := tx.Model(&models.SanctumOwnershipTransfer{}).
+			Where("id = ? AND status = ?", transfer.ID, models.SanctumTransferStatusPending).
+			Updates(map[string]any{"status": status, "responded_at": now})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return models.NewValidationError("Ownership transfer is no longer open")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	transfer.Status = status
+	transfer.RespondedAt = &now
+	return &transfer, nil
+}
+
+// ListOrphaned reports sanctums whose owners are all gone, either because
+// no owner membership exists or because the owner's account was deleted.
+func (s *SanctumOwnershipService) ListOrphaned(ctx context.Context, limit, offset int) ([]OrphanedSanctum, error) {
+	var sanctums []models.Sanctum
+	if err := s.db.WithContext(ctx).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM sanctum_memberships m
+			JOIN users u ON u.id = m.user_id AND u.deleted_at IS NULL
+			WHERE m.sanctum_id = sanctums.id AND m.role = ?
+		)`, models.SanctumMembershipRoleOwner).
+		Order("id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&sanctums).Error; err != nil {
+		return nil, err
+	}
+	orphaned := make([]OrphanedSanctum, 0, len(sanctums))
+	for _, sanctum := range sanctums {
+		var mods int64
+		if err := s.db.WithContext(ctx).Model(&models.SanctumMembership{}).
+			Joins("JOIN users ON users.id = sanctum_memberships.user_id AND users.deleted_at IS NULL").
+			Where("sanctum_memberships.sanctum_id = ? AND sanctum_memberships.role = ?", sanctum.ID, models.SanctumMembershipRoleMod).
+			Count(&mods).Error; err != nil {
+			return nil, err
+		}
+		orphaned = append(orphaned, OrphanedSanctum{Sanctum: sanctum, Moderators: mods})
+	}
+	return orphaned, nil
+}
+
+// Reassign makes userID the owner of a sanctum on a site admin's authority
+// and cancels any open offer. Callers must have checked admin access.
+func (s *SanctumOwnershipService) Reassign(ctx context.Context, adminID, sanctumID, userID uint, reason string) error {
+	reason, err := normalizeModReason(reason)
+	if err != nil {
+		return err
+	}
+	var sanctum models.Sanctum
+	if err := s.db.WithContext(ctx).Select("id").First(&sanctum, sanctumID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NewNotFoundError("Sanctum", sanctumID)
+		}
+		return err
+	}
+	var user models.User
+	if err := s.db.WithContext(ctx).Select("id", "is_banned").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NewNotFoundError("User", userID)
+		}
+		return err
+	}
+	if user.IsBanned {
+		return models.NewValidationError("Banned users cannot own a sanctum")
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := cancelPendingTransfers(tx, sanctumID); err != nil {
+			return err
+		}
+		// A sanctum ban would lock the new owner out of their own sanctum.
+		if err := tx.Where("sanctum_id = ? AND user_id = ?", sanctumID, userID).Delete(&models.SanctumBan{}).Error; err != nil {
+			return err
+		}
+		if err := setOwner(tx, sanctumID, userID); err != nil {
+			return err
+		}
+		return RecordModAction(tx, sanctumID, adminID, models.ModActionReassignOwnership, models.ModTargetUser, userID, reason)
+	})
+}
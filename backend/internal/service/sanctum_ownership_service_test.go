@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"sanctum/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOwnershipTestService(t *testing.T) (*SanctumOwnershipService, *sanctumFixture) {
+	t.Helper()
+	f := newSanctumFixture(t,
+		&models.SanctumOwnershipTransfer{},
+		&models.SanctumBan{},
+		&models.SanctumModLogEntry{},
+		&models.Conversation{},
+		&models.ConversationParticipant{},
+	)
+	return NewSanctumOwnershipService(f.db), f
+}
+
+func TestSanctumOwnershipService_OfferValidation(t *testing.T) {
+	ownership, f := newOwnershipTestService(t)
+	ctx := context.Background()
+	banned := createTestUser(t, f.db, "banned")
+	require.NoError(t, f.db.Create(&models.SanctumBan{
+		SanctumID: f.sanctum.ID, UserID: banned.ID, CreatedByUserID: f.owner.ID, Kind: models.SanctumBanKindBan,
+	}).Error)
+
+	tests := []struct {
+		name    string
+		actorID uint
+		toID    uint
+		code    string
+	}{
+		{"only the owner offers", f.mod.ID, f.member.ID, "FORBIDDEN"},
+		{"not to yourself", f.owner.ID, f.owner.ID, "VALIDATION_ERROR"},
+		{"unknown user", f.owner.ID, 9999, "NOT_FOUND"},
+		{"banned from the sanctum", f.owner.ID, banned.ID, "VALIDATION_ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ownership.OfferTransfer(ctx, tt.actorID, f.sanctum.ID, tt.toID)
+			requireCode(t, err, tt.code)
+		})
+	}
+}
+
+func TestSanctumOwnershipService_Respond(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept bool
+		status models.SanctumTransferStatus
+		owner  func(f *sanctumFixture) uint
+	}{
+		{"accept", true, models.SanctumTransferStatusAccepted, func(f *sanctumFixture) uint { return f.member.ID }},
+		{"decline", false, models.SanctumTransferStatusDeclined, func(f *sanctumFixture) uint { return f.owner.ID }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ownership, f := newOwnershipTestService(t)
+			ctx := context.Background()
+			offer, err := ownership.OfferTransfer(ctx, f.owner.ID, f.sanctum.ID, f.member.ID)
+			require.NoError(t, err)
+			incoming, err := ownership.ListIncoming(ctx, f.member.ID)
+			require.NoError(t, err)
+			require.Len(t, incoming, 1)
+			assert.Equal(t, "books", incoming[0].Sanctum.Slug)
+
+			_, err = ownership.RespondTransfer(ctx, f.mod.ID, offer.ID, tt.accept)
+			requireCode(t, err, "NOT_FOUND")
+			transfer, err := ownership.RespondTransfer(ctx, f.member.ID, offer.ID, tt.accept)
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, transfer.Status)
+			_, err = ownership.RespondTransfer(ctx, f.member.ID, offer.ID, tt.accept)
+			requireCode(t, err, "VALIDATION_ERROR")
+
+			owner := tt.owner(f)
+			assert.Equal(t, models.SanctumMembershipRoleOwner, f.role(owner))
+			require.NoError(t, f.db.First(f.sanctum, f.sanctum.ID).Error)
+			assert.Equal(t, owner, *f.sanctum.CreatedByUserID)
+			if tt.accept {
+				assert.Equal(t, models.SanctumMembershipRoleMod, f.role(f.owner.ID), "the old owner stays on as a moderator")
+			}
+		})
+	}
+}
+
+func TestSanctumOwnershipService_NewOfferSupersedesOpenOne(t *testing.T) {
+	ownership, f := newOwnershipTestService(t)
+	ctx := context.Background()
+
+	first, err := ownership.OfferTransfer(ctx, f.owner.ID, f.sanctum.ID, f.outsider.ID)
+	require.NoError(t, err)
+	_, err = ownership.OfferTransfer(ctx, f.owner.ID, f.sanctum.ID, f.member.ID)
+	require.NoError(t, err)
+	_, err = ownership.RespondTransfer(ctx, f.outsider.ID, first.ID, true)
+	requireCode(t, err, "VALIDATION_ERROR")
+	assert.Equal(t, models.SanctumMembershipRoleOwner, f.role(f.owner.ID))
+
+	require.NoError(t, ownership.CancelTransfer(ctx, f.owner.ID, f.sanctum.ID))
+	requireCode(t, ownership.CancelTransfer(ctx, f.owner.ID, f.sanctum.ID), "NOT_FOUND")
+	incoming, err := ownership.ListIncoming(ctx, f.member.ID)
+	require.NoError(t, err)
+	assert.Empty(t, incoming)
+}
+
+func TestSanctumOwnershipService_AcceptFailsAfterOwnerChanges(t *testing.T) {
+	ownership, f := newOwnershipTestService(t)
+	ctx := context.Background()
+	offer, err := ownership.OfferTransfer(ctx, f.owner.ID, f.sanctum.ID, f.member.ID)
+	require.NoError(t, err)
+	require.NoError(t, f.db.Model(&models.SanctumMembership{}).
+		Where("sanctum_id = ? AND user_id = ?", f.sanctum.ID, f.owner.ID).
+		Update("role", models.SanctumMembershipRoleMod).Error)
+
+	_, err = ownership.RespondTransfer(ctx, f.member.ID, offer.ID, true)
+	requireCode(t, err, "VALIDATION_ERROR")
+	assert.Equal(t, models.SanctumMembershipRoleMember, f.role(f.member.ID))
+}
+
+func TestSanctumOwnershipService_OrphanedAndReassign(t *testing.T) {
+	ownership, f := newOwnershipTestService(t)
+	ctx := context.Background()
+	admin := createTestUser(t, f.db, "admin")
+
+	orphaned, err := ownership.ListOrphaned(ctx, 50, 0)
+	require.NoError(t, err)
+	assert.Empty(t, orphaned)
+
+	require.NoError(t, f.db.Delete(f.owner).Error)
+	orphaned, err = ownership.ListOrphaned(ctx, 50, 0)
+	require.NoError(t, err)
+	require.Len(t, orphaned, 1)
+	assert.Equal(t, f.sanctum.ID, orphaned[0].Sanctum.ID)
+	assert.Equal(t, int64(1), orphaned[0].Moderators)
+
+	requireCode(t, ownership.Reassign(ctx, admin.ID, 9999, f.mod.ID, ""), "NOT_FOUND")
+	require.NoError(t, ownership.Reassign(ctx, admin.ID, f.sanctum.ID, f.mod.ID, "previous owner left"))
+	assert.Equal(t, models.SanctumMembershipRoleOwner, f.role(f.mod.ID))
+	orphaned, err = ownership.ListOrphaned(ctx, 50, 0)
+	require.NoError(t, err)
+	assert.Empty(t, orphaned)
+
+	var entry models.SanctumModLogEntry
+	require.NoError(t, f.db.Last(&entry).Error)
+	assert.Equal(t, models.ModActionReassignOwnership, entry.Action)
+	assert.Equal(t, admin.ID, entry.ModeratorID)
+}
+
+func TestSanctumOwnershipService_AcceptFailsAfterBan(t *testing.T) {
+	ownership, f := newOwnershipTestService(t)
+	ctx := context.Background()
+	offer, err := ownership.OfferTransfer(ctx, f.owner.ID, f.sanctum.ID, f.member.ID)
+	require.NoError(t, err)
+	require.NoError(t, f.db.Create(&models.SanctumBan{
+		SanctumID: f.sanctum.ID, UserID: f.member.ID, CreatedByUserID: f.owner.ID, Kind: models.SanctumBanKindBan,
+	}).Error)
+
+	_, err = ownership.RespondTransfer(ctx, f.member.ID, offer.ID, true)
+	requireCode(t, err, "VALIDATION_ERROR")
+	assert.Equal(t, models.SanctumMembershipRoleOwner, f.role(f.owner.ID))
+	var stored models.SanctumOwnershipTransfer
+	require.NoError(t, f.db.First(&stored, offer.ID).Error)
+	assert.Equal(t, models.SanctumTransferStatusPending, stored.Status)
+}
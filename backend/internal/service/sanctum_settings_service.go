@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"sanctum/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SanctumSettingsService stores sanctum rules and enforces their posting
+// policy.
+type SanctumSettingsService struct {
+	db *gorm.DB
+	// canManage reports whether a user may edit a sanctum's settings.
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error)
+	// titlePatterns holds each sanctum's compiled title pattern.
+	titlePatterns sanctumConfigCache[*regexp.Regexp]
+}
+
+type UpdateSanctumSettingsInput struct {
+	ActorID           uint
+	SanctumID         uint
+	Rules             []models.SanctumRule
+	AllowedPostTypes  []string
+	MinAccountAgeDays int
+	MinKarma          int
+	RequireImage      bool
+	TitlePattern      string
+}
+
+func NewSanctumSettingsService(
+	db *gorm.DB,
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error),
+) *SanctumSettingsService {
+	return &SanctumSettingsService{db: db, canManage: canManage}
+}
+
+// Get returns a sanctum's settings, or permissive defaults when none are
+// stored.
+func (s *SanctumSettingsService) Get(ctx context.Context, sanctumID uint) (*models.SanctumSettings, error) {
+	settings := models.SanctumSettings{SanctumID: sanctumID}
+	err := s.db.WithContext(ctx).First(&settings, "sanctum_id = ?", sanctumID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if settings.Rules == nil {
+		settings.Rules = []models.SanctumRule{}
+	}
+	if settings.AllowedPostTypes == nil {
+		settings.AllowedPostTypes = []string{}
+	}
+	return &settings, nil
+}
+
+// Update replaces a sanctum's settings. Rules are renumbered in the order
+// given.
+func (s *SanctumSettingsService) Update(ctx context.Context, in UpdateSanctumSettingsInput) (*models.SanctumSettings, error) {
+	if s.canManage == nil {
+		return nil, models.NewForbiddenError("Sanctum owner access required")
+	}
+	ok, err := s.canManage(ctx, in.ActorID, in.SanctumID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, models.NewForbiddenError("Sanctum owner access required")
+	}
+
+	if len(in.Rules) > models.MaxSanctumRules {
+		return nil, models.NewValidationError(fmt.Sprintf("A sanctum can list at most %d rules", models.MaxSanctumRules))
+	}
+	rules := make([]models.SanctumRule, 0, len(in.Rules))
+	for i, rule := range in.Rules {
+		title := strings.TrimSpace(rule.Title)
+		description := strings.TrimSpace(rule.Description)
+		if title == "" || utf8.RuneCountInString(title) > 100 {
+			return nil, models.NewValidationError(fmt.Sprintf("Rule %d title must be 1-100 characters", i+1))
+		}
+		if utf8.RuneCountInString(description) > 500 {
+			return nil, models.NewValidationError(fmt.Sprintf("Rule %d description too long (max 500 characters)", i+1))
+		}
+		rules = append(rules, models.SanctumRule{Number: i + 1, Title: title, Description: description})
+	}
+
+	postTypes := make([]string, 0, len(in.AllowedPostTypes))
+	seen := make(map[string]struct{}, len(in.AllowedPostTypes))
+	for _, postType := range in.AllowedPostTypes {
+		switch postType {
+		case models.PostTypeText, models.PostTypeMedia, models.PostTypeVideo, models.PostTypeLink, models.PostTypePoll, models.PostTypeGallery:
+		default:
+			return nil, models.NewValidationError("Invalid post type in allowed_post_types: " + postType)
+		}
+		if _, dup := seen[postType]; dup {
+			continue
+		}
+		seen[postType] = struct{}{}
+		postTypes = append(postTypes, postType)
+	}
+
+	if in.MinAccountAgeDays < 0 || in.MinAccountAgeDays > 3650 {
+		return nil, models.NewValidationError("min_account_age_days must be between 0 and 3650")
+	}
+	if in.MinKarma < 0 {
+		return nil, models.NewValidationError("min_karma cannot be negative")
+	}
+	pattern := strings.TrimSpace(in.TitlePattern)
+	if len(pattern) > models.MaxSanctumTitlePatternLen {
+		return nil, models.NewValidationError(fmt.Sprintf("title_pattern too long (max %d characters)", models.MaxSanctumTitlePatternLen))
+	}
+	if pattern != "" {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, models.NewValidationError("title_pattern is not a valid regular expression")
+		}
+	}
+
+	settings := models.SanctumSettings{
+		SanctumID:         in.SanctumID,
+		Rules:             rules,
+		AllowedPostTypes:  postTypes,
+		MinAccountAgeDays: in.MinAccountAgeDays,
+		MinKarma:          in.MinKarma,
+		RequireImage:      in.RequireImage,
+		TitlePattern:      pattern,
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "sanctum_id"}},
+		UpdateAll: true,
+	}).Create(&settings).Error; err != nil {
+		return nil, err
+	}
+	s.titlePatterns.drop(in.SanctumID)
+	return &settings, nil
+}
+
+// CheckPost validates a new post against its sanctum's posting policy.
+func (s *SanctumSettingsService) CheckPost(ctx context.Context, post *models.Post) error {
+	if post.SanctumID == nil {
+		return nil
+	}
+	settings, err := s.Get(ctx, *post.SanctumID)
+	if err != nil {
+		return err
+	}
+
+	if len(settings.AllowedPostTypes) > 0 {
+		allowed := false
+		for _, postType := range settings.AllowedPostTypes {
+			if postType == post.PostType {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return models.NewValidationError("This sanctum does not accept " + post.PostType + " posts")
+		}
+	}
+	if settings.RequireImage && strings.TrimSpace(post.ImageURL) == "" && len(post.Images) == 0 {
+		return models.NewValidationError("Posts in this sanctum must include an image")
+	}
+	if settings.TitlePattern != "" {
+		re, err := s.titlePattern(settings)
+		if err != nil {
+			return err
+		}
+		if !re.MatchString(post.Title) {
+			return models.NewValidationError("Title does not match this sanctum's required format")
+		}
+	}
+
+	if settings.MinAccountAgeDays > 0 {
+		var author models.User
+		if err := s.db.WithContext(ctx).Select("id", "created_at").First(&author, post.UserID).Error; err != nil {
+			return err
+		}
+		minAge := time.Duration(settings.MinAccountAgeDays) * 24 * time.Hour
+		if time.Since(author.CreatedAt) < minAge {
+			return models.NewForbiddenError(fmt.Sprintf("Your account must be at least %d days old to post here", settings.MinAccountAgeDays))
+		}
+	}
+	if settings.MinKarma > 0 {
+		karma, err := userKarma(ctx, s.db, post.UserID)
+		if err != nil {
+			return err
+		}
+		if karma < int64(settings.MinKarma) {
+			return models.NewForbiddenError(fmt.Sprintf("You need at least %d karma to post here", settings.MinKarma))
+		}
+	}
+	return nil
+}
+
+// titlePattern returns the compiled title pattern of settings, reusing it
+// until the settings change.
+func (s *SanctumSettingsService) titlePattern(settings *models.SanctumSettings) (*regexp.Regexp, error) {
+	if re, ok := s.titlePatterns.get(settings.SanctumID, settings.UpdatedAt); ok && re.String() == settings.TitlePattern {
+		return re, nil
+	}
+	re, err := regexp.Compile(settings.TitlePattern)
+	if err != nil {
+		return nil, err
+	}
+	s.titlePatterns.put(settings.SanctumID, settings.UpdatedAt, re)
+	return re, nil
+}
+
+// userKarma counts the likes a user's posts have received.
+func userKarma(ctx context.Context, db *gorm.DB, userID uint) (int64, error) {
+	var karma int64
+	err := db.WithContext(ctx).Table("likes").
+		Joins("JOIN posts ON posts.id = likes.post_id").
+		Where("posts.user_id = ? AND posts.deleted_at IS NULL", userID).
+		Count(&karma).Error
+	return karma, err
+}
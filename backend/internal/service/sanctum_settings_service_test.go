@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSettingsTestService(t *testing.T) (*SanctumSettingsService, *sanctumFixture) {
+	t.Helper()
+	f := newSanctumFixture(t, &models.SanctumSettings{}, &models.Post{}, &models.Like{})
+	return NewSanctumSettingsService(f.db, f.isOwner), f
+}
+
+func TestSanctumSettingsService_GetDefaults(t *testing.T) {
+	settings, f := newSettingsTestService(t)
+	defaults, err := settings.Get(context.Background(), f.sanctum.ID)
+	require.NoError(t, err)
+	assert.Empty(t, defaults.Rules)
+	assert.Empty(t, defaults.AllowedPostTypes)
+	assert.Zero(t, defaults.MinKarma)
+}
+
+func TestSanctumSettingsService_UpdateValidation(t *testing.T) {
+	settings, f := newSettingsTestService(t)
+	tests := []struct {
+		name string
+		in   UpdateSanctumSettingsInput
+		code string
+	}{
+		{"moderators cannot edit", UpdateSanctumSettingsInput{ActorID: f.mod.ID}, "FORBIDDEN"},
+		{"invalid regex", UpdateSanctumSettingsInput{ActorID: f.owner.ID, TitlePattern: "(["}, "VALIDATION_ERROR"},
+		{"unknown post type", UpdateSanctumSettingsInput{ActorID: f.owner.ID, AllowedPostTypes: []string{"meme"}}, "VALIDATION_ERROR"},
+		{"negative karma", UpdateSanctumSettingsInput{ActorID: f.owner.ID, MinKarma: -1}, "VALIDATION_ERROR"},
+		{"account age out of range", UpdateSanctumSettingsInput{ActorID: f.owner.ID, MinAccountAgeDays: 4000}, "VALIDATION_ERROR"},
+		{"untitled rule", UpdateSanctumSettingsInput{ActorID: f.owner.ID, Rules: []models.SanctumRule{{Title: " "}}}, "VALIDATION_ERROR"},
+		{"rule description too long", UpdateSanctumSettingsInput{ActorID: f.owner.ID,
+			Rules: []models.SanctumRule{{Title: "ok", Description: strings.Repeat("x", 501)}}}, "VALIDATION_ERROR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.in.SanctumID = f.sanctum.ID
+			_, err := settings.Update(context.Background(), tt.in)
+			requireCode(t, err, tt.code)
+		})
+	}
+}
+
+func TestSanctumSettingsService_UpdateRenumbersRules(t *testing.T) {
+	settings, f := newSettingsTestService(t)
+	ctx := context.Background()
+	saved, err := settings.Update(ctx, UpdateSanctumSettingsInput{
+		ActorID:   f.owner.ID,
+		SanctumID: f.sanctum.ID,
+		Rules: []models.SanctumRule{
+			{Title: " Original content only "},
+			{Number: 9, Title: "Be kind", Description: "No personal attacks"},
+		},
+		AllowedPostTypes: []string{models.PostTypeText, models.PostTypeText},
+	})
+	require.NoError(t, err)
+	require.Len(t, saved.Rules, 2)
+	assert.Equal(t, "Original content only", saved.Rules[0].Title)
+	assert.Equal(t, 2, saved.Rules[1].Number)
+	assert.Equal(t, []string{models.PostTypeText}, saved.AllowedPostTypes)
+
+	stored, err := settings.Get(ctx, f.sanctum.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.Rule(2))
+	assert.Equal(t, "Be kind", stored.Rule(2).Title)
+	assert.Nil(t, stored.Rule(3))
+}
+
+func TestSanctumSettingsService_CheckPost(t *testing.T) {
+	const image = "https://example.com/a.png"
+	tests := []struct {
+		name     string
+		settings UpdateSanctumSettingsInput
+		post     models.Post
+		newbie   bool
+		code     string
+	}{
+		{"no policy", UpdateSanctumSettingsInput{}, models.Post{PostType: models.PostTypeLink, Title: "anything"}, true, ""},
+		{"post type not allowed", UpdateSanctumSettingsInput{AllowedPostTypes: []string{models.PostTypeMedia}},
+			models.Post{PostType: models.PostTypeLink, Title: "x"}, false, "VALIDATION_ERROR"},
+		{"image required", UpdateSanctumSettingsInput{RequireImage: true},
+			models.Post{PostType: models.PostTypeText, Title: "x"}, false, "VALIDATION_ERROR"},
+		{"image supplied", UpdateSanctumSettingsInput{RequireImage: true},
+			models.Post{PostType: models.PostTypeMedia, Title: "x", ImageURL: image}, false, ""},
+		{"title must match", UpdateSanctumSettingsInput{TitlePattern: `^\[OC\] `},
+			models.Post{PostType: models.PostTypeText, Title: "my photo"}, false, "VALIDATION_ERROR"},
+		{"title matches", UpdateSanctumSettingsInput{TitlePattern: `^\[OC\] `},
+			models.Post{PostType: models.PostTypeText, Title: "[OC] photo"}, false, ""},
+		{"account too new", UpdateSanctumSettingsInput{MinAccountAgeDays: 30},
+			models.Post{PostType: models.PostTypeText, Title: "x"}, true, "FORBIDDEN"},
+		{"account old enough", UpdateSanctumSettingsInput{MinAccountAgeDays: 30},
+			models.Post{PostType: models.PostTypeText, Title: "x"}, false, ""},
+		{"not enough karma", UpdateSanctumSettingsInput{MinKarma: 1},
+			models.Post{PostType: models.PostTypeText, Title: "x"}, true, "FORBIDDEN"},
+		{"enough karma", UpdateSanctumSettingsInput{MinKarma: 1},
+			models.Post{PostType: models.PostTypeText, Title: "x"}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, f := newSettingsTestService(t)
+			ctx := context.Background()
+			// The member is a year old with one liked post; the outsider is new.
+			require.NoError(t, f.db.Model(f.member).Update("created_at", time.Now().AddDate(-1, 0, 0)).Error)
+			liked := &models.Post{Title: "old", Content: "x", UserID: f.member.ID}
+			require.NoError(t, f.db.Create(liked).Error)
+			require.NoError(t, f.db.Create(&models.Like{UserID: f.owner.ID, PostID: liked.ID}).Error)
+
+			tt.settings.ActorID, tt.settings.SanctumID = f.owner.ID, f.sanctum.ID
+			_, err := settings.Update(ctx, tt.settings)
+			require.NoError(t, err)
+
+			tt.post.SanctumID, tt.post.UserID = &f.sanctum.ID, f.member.ID
+			if tt.newbie {
+				tt.post.UserID = f.outsider.ID
+			}
+			err = settings.CheckPost(ctx, &tt.post)
+			if tt.code == "" {
+				assert.NoError(t, err)
+				return
+			}
+			requireCode(t, err, tt.code)
+		})
+	}
+}
+
+func TestSanctumSettingsService_TitlePatternFollowsUpdates(t *testing.T) {
+	settings, f := newSettingsTestService(t)
+	ctx := context.Background()
+	post := &models.Post{PostType: models.PostTypeText, Title: "[OC] photo", SanctumID: &f.sanctum.ID, UserID: f.member.ID}
+
+	_, err := settings.Update(ctx, UpdateSanctumSettingsInput{ActorID: f.owner.ID, SanctumID: f.sanctum.ID, TitlePattern: `^\[OC\] `})
+	require.NoError(t, err)
+	require.NoError(t, settings.CheckPost(ctx, post))
+
+	_, err = settings.Update(ctx, UpdateSanctumSettingsInput{ActorID: f.owner.ID, SanctumID: f.sanctum.ID, TitlePattern: `^\[Art\] `})
+	require.NoError(t, err)
+	requireCode(t, settings.CheckPost(ctx, post), "VALIDATION_ERROR")
+}
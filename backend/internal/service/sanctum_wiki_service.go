@@ -0,0 +1,546 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"sanctum/internal/cache"
+	"sanctum/internal/database"
+	"sanctum/internal/models"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxWikiSlugLen    = 200
+	maxWikiSlugDepth  = 5
+	maxWikiContentLen = 100000
+	// maxWikiDiffLines bounds each side of a diff; difflib's cost grows with
+	// the product of the two line counts.
+	maxWikiDiffLines = 2000
+)
+
+var wikiSlugSegment = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// SanctumWikiService stores sanctum wiki pages and their revision history.
+type SanctumWikiService struct {
+	db *gorm.DB
+	// canManage reports whether a user moderates the sanctum.
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error)
+	// canRead hides private sanctums' wikis from non-members.
+	canRead func(ctx context.Context, sanctumID, userID uint) (bool, error)
+}
+
+type SaveWikiPageInput struct {
+	ActorID   uint
+	SanctumID uint
+	Slug      string
+	Title     string
+	Content   string
+	Reason    string
+	// BaseRevision is the revision the edit started from. When set, the save
+	// is rejected if someone else has saved since.
+	BaseRevision int
+}
+
+// WikiPageSummary is a page as listed in a sanctum's wiki index.
+type WikiPageSummary struct {
+	ID             uint                      `json:"id"`
+	Slug           string                    `json:"slug"`
+	Title          string                    `json:"title"`
+	EditPermission models.WikiEditPermission `json:"edit_permission"`
+	Revision       int                       `json:"revision"`
+	UpdatedAt      time.Time                 `json:"updated_at"`
+}
+
+// WikiDiff is a unified diff between two revisions of a page.
+type WikiDiff struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Diff string `json:"diff"`
+}
+
+func NewSanctumWikiService(
+	db *gorm.DB,
+	canManage func(ctx context.Context, userID, sanctumID uint) (bool, error),
+	canRead func(ctx context.Context, sanctumID, userID uint) (bool, error),
+) *SanctumWikiService {
+	return &SanctumWikiService{db: db, canManage: canManage, canRead: canRead}
+}
+
+// NormalizeWikiSlug lower-cases a page slug and checks that it is a path of
+// 1-5 segments of letters, digits, dashes and underscores.
+func NormalizeWikiSlug(slug string) (string, error) {
+	slug = strings.Trim(strings.ToLower(strings.TrimSpace(slug)), "/")
+	if slug == "" || len(slug) > maxWikiSlugLen {
+		return "", models.NewValidationError(fmt.Sprintf("Page slug must be 1-%d characters", maxWikiSlugLen))
+	}
+	segments := strings.Split(slug, "/")
+	if len(segments) > maxWikiSlugDepth {
+		return "", models.NewValidationError(fmt.Sprintf("Page slugs can be at most %d levels deep", maxWikiSlugDepth))
+	}
+	for _, segment := range segments {
+		if !wikiSlugSegment.MatchString(segment) {
+			return "", models.NewValidationError("Page slug segments may only contain letters, digits, dashes and underscores")
+		}
+	}
+	return slug, nil
+}
+
+func (s *SanctumWikiService) isMod(ctx context.Context, userID, sanctumID uint) (bool, error) {
+	if s.canManage == nil || userID == 0 {
+		return false, nil
+	}
+	return s.canManage(ctx, userID, sanctumID)
+}
+
+func (s *SanctumWikiService) requireReader(ctx context.Context, viewerID, sanctumID uint) error {
+	if s.canRead == nil {
+		return nil
+	}
+	ok, err := s.canRead(ctx, sanctumID, viewerID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.NewNotFoundError("Sanctum", sanctumID)
+	}
+	return nil
+}
+
+func (s *SanctumWikiService) requireMod(ctx context.Context, actorID, sanctumID uint) error {
+	ok, err := s.isMod(ctx, actorID, sanctumID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.NewForbiddenError("Sanctum moderator access required")
+	}
+	return nil
+}
+
+// canEdit reports whether a user may edit a page under its permission.
+// Users banned or muted in the sanctum cannot edit at all.
+func (s *SanctumWikiService) canEdit(ctx context.Context, userID uint, page *models.SanctumWikiPage) (bool, error) {
+	mod, err := s.isMod(ctx, userID, page.SanctumID)
+	if err != nil || mod {
+		return mod, err
+	}
+	ban, err := activeSanctumBan(ctx, s.db, page.SanctumID, userID)
+	if err != nil || ban != nil {
+		return false, err
+	}
+	var count int64
+	switch page.EditPermission {
+	case models.WikiEditContributors:
+		err = s.db.WithContext(ctx).Model(&models.SanctumWikiContributor{}).
+			Where("sanctum_id = ? AND user_id = ?", page.SanctumID, userID).
+			Count(&count).Error
+	case models.WikiEditMembers:
+		err = s.db.WithContext(ctx).Model(&models.SanctumMembership{}).
+			Where("sanctum_id = ? AND user_id = ?", page.SanctumID, userID).
+			Count(&count).Error
+	}
+	return count > 0, err
+}
+
+func (s *SanctumWikiService) findPage(ctx context.Context, sanctumID uint, slug string) (*models.SanctumWikiPage, error) {
+	slug, err := NormalizeWikiSlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	var page models.SanctumWikiPage
+	if err := s.db.WithContext(ctx).
+		Where("sanctum_id = ? AND slug = ?", sanctumID, slug).
+		First(&page).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("Wiki page", slug)
+		}
+		return nil, err
+	}
+	return &page, nil
+}
+
+// pageByID loads a page for a viewer who may read its sanctum.
+func (s *SanctumWikiService) pageByID(ctx context.Context, viewerID, pageID uint) (*models.SanctumWikiPage, error) {
+	var page models.SanctumWikiPage
+	if err := s.db.WithContext(ctx).First(&page, pageID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("Wiki page", pageID)
+		}
+		return nil, err
+	}
+	if err := s.requireReader(ctx, viewerID, page.SanctumID); err != nil {
+		return nil, models.NewNotFoundError("Wiki page", pageID)
+	}
+	return &page, nil
+}
+
+// ListPages returns a sanctum's pages ordered by slug, so children follow
+// their parents.
+func (s *SanctumWikiService) ListPages(ctx context.Context, viewerID, sanctumID uint) ([]WikiPageSummary, error) {
+	if err := s.requireReader(ctx, viewerID, sanctumID); err != nil {
+		return nil, err
+	}
+	pages := []WikiPageSummary{}
+	if err := s.db.WithContext(ctx).Model(&models.SanctumWikiPage{}).
+		Select("id", "slug", "title", "edit_permission", "revision", "updated_at").
+		Where("sanctum_id = ?", sanctumID).
+		Order("slug ASC").
+		Scan(&pages).Error; err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// GetPage returns a page's current version.
+func (s *SanctumWikiService) GetPage(ctx context.Context, viewerID, sanctumID uint, slug string) (*models.SanctumWikiPage, error) {
+	if err := s.requireReader(ctx, viewerID, sanctumID); err != nil {
+		return nil, err
+	}
+	page, err := s.findPage(ctx, sanctumID, slug)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Preload("UpdatedBy").First(page, page.ID).Error; err != nil {
+		return nil, err
+	}
+	RenderWikiPageHTML(page)
+	return page, nil
+}
+
+func normalizeWikiEdit(title, content, reason string) (string, string, string, error) {
+	title = strings.TrimSpace(title)
+	reason = strings.TrimSpace(reason)
+	if title == "" || utf8.RuneCountInString(title) > 200 {
+		return "", "", "", models.NewValidationError("Title must be 1-200 characters")
+	}
+	if len(content) > maxWikiContentLen {
+		return "", "", "", models.NewValidationError(fmt.Sprintf("Page too long (max %d characters)", maxWikiContentLen))
+	}
+	if utf8.RuneCountInString(reason) > 300 {
+		return "", "", "", models.NewValidationError("Edit reason too long (max 300 characters)")
+	}
+	return title, content, reason, nil
+}
+
+// appendRevision writes a page's new current version and its revision.
+func appendRevision(tx *gorm.DB, page *models.SanctumWikiPage, actorID uint, title, content, reason string, revertedFrom *int) error {
+	next := page.Revision + 1
+	res := tx.Model(&models.SanctumWikiPage{}).
+		Where("id = ? AND revision = ?", page.ID, page.Revision).
+		Updates(map[string]any{
+			"title":              title,
+			"content":            content,
+			"revision":           next,
+			"updated_by_user_id": actorID,
+			"updated_at":         time.Now(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return models.NewValidationError("Page was edited by someone else; reload and try again")
+	}
+	page.Title, page.Content, page.Revision, page.UpdatedByUserID = title, content, next, &actorID
+	return tx.Create(&models.SanctumWikiRevision{
+		PageID:       page.ID,
+		Revision:     next,
+		Title:        title,
+		Content:      content,
+		Reason:       reason,
+		RevertedFrom: revertedFrom,
+		AuthorID:     actorID,
+	}).Error
+}
+
+// SavePage creates a page or saves a new revision of it. Only moderators
+// create pages; edits follow the page's edit permission.
+func (s *SanctumWikiService) SavePage(ctx context.Context, in SaveWikiPageInput) (*models.SanctumWikiPage, error) {
+	slug, err := NormalizeWikiSlug(in.Slug)
+	if err != nil {
+		return nil, err
+	}
+	title, content, reason, err := normalizeWikiEdit(in.Title, in.Content, in.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.findPage(ctx, in.SanctumID, slug)
+	var appErr *models.AppError
+	switch {
+	case errors.As(err, &appErr) && appErr.Code == "NOT_FOUND":
+		if err := s.requireMod(ctx, in.ActorID, in.SanctumID); err != nil {
+			return nil, err
+		}
+		page = &models.SanctumWikiPage{
+			SanctumID:       in.SanctumID,
+			Slug:            slug,
+			Title:           title,
+			Content:         content,
+			EditPermission:  models.WikiEditMods,
+			Revision:        1,
+			UpdatedByUserID: &in.ActorID,
+		}
+		err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(page).Error; err != nil {
+				if database.IsUniqueConstraintError(err) {
+					return models.NewValidationError("Page was created by someone else; reload and try again")
+				}
+				return err
+			}
+			return tx.Create(&models.SanctumWikiRevision{
+				PageID:   page.ID,
+				Revision: 1,
+				Title:    title,
+				Content:  content,
+				Reason:   reason,
+				AuthorID: in.ActorID,
+			}).Error
+		})
+		if err != nil {
+			return nil, err
+		}
+		RenderWikiPageHTML(page)
+		return page, nil
+	case err != nil:
+		return nil, err
+	}
+
+	ok, err := s.canEdit(ctx, in.ActorID, page)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, models.NewForbiddenError("You cannot edit this page")
+	}
+	if in.BaseRevision != 0 && in.BaseRevision != page.Revision {
+		return nil, models.NewValidationError("Page was edited by someone else; reload and try again")
+	}
+	if page.Title == title && page.Content == content {
+		RenderWikiPageHTML(page)
+		return page, nil
+	}
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return appendRevision(tx, page, in.ActorID, title, content, reason, nil)
+	}); err != nil {
+		return nil, err
+	}
+	RenderWikiPageHTML(page)
+	return page, nil
+}
+
+// DeletePage removes a page and its history. Moderators only.
+func (s *SanctumWikiService) DeletePage(ctx context.Context, actorID, sanctumID uint, slug string) error {
+	if err := s.requireMod(ctx, actorID, sanctumID); err != nil {
+		return err
+	}
+	page, err := s.findPage(ctx, sanctumID, slug)
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("page_id = ?", page.ID).Delete(&models.SanctumWikiRevision{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(page).Error
+	})
+}
+
+// SetPermission changes who may edit a page. Moderators only.
+func (s *SanctumWikiService) SetPermission(ctx context.Context, actorID, pageID uint, permission models.WikiEditPermission) (*models.SanctumWikiPage, error) {
+	if !permission.Valid() {
+		return nil, models.NewValidationError("edit_permission must be mods, contributors or members")
+	}
+	page, err := s.pageByID(ctx, actorID, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireMod(ctx, actorID, page.SanctumID); err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Model(page).Update("edit_permission", permission).Error; err != nil {
+		return nil, err
+	}
+	page.EditPermission = permission
+	RenderWikiPageHTML(page)
+	return page, nil
+}
+
+// ListRevisions returns a page's revisions, newest first, without their
+// content.
+func (s *SanctumWikiService) ListRevisions(ctx context.Context, viewerID, pageID uint, limit, offset int) ([]models.SanctumWikiRevision, error) {
+	if _, err := s.pageByID(ctx, viewerID, pageID); err != nil {
+		return nil, err
+	}
+	revisions := []models.SanctumWikiRevision{}
+	if err := s.db.WithContext(ctx).
+		Omit("content").
+		Preload("Author").
+		Where("page_id = ?", pageID).
+		Order("revision DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (s *SanctumWikiService) loadRevision(ctx context.Context, pageID uint, revision int) (*models.SanctumWikiRevision, error) {
+	var rev models.SanctumWikiRevision
+	if err := s.db.WithContext(ctx).
+		Preload("Author").
+		Where("page_id = ? AND revision = ?", pageID, revision).
+		First(&rev).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.NewNotFoundError("Wiki revision", revision)
+		}
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// GetRevision returns one revision of a page, including its content.
+func (s *SanctumWikiService) GetRevision(ctx context.Context, viewerID, pageID uint, revision int) (*models.SanctumWikiRevision, error) {
+	if _, err := s.pageByID(ctx, viewerID, pageID); err != nil {
+		return nil, err
+	}
+	return s.loadRevision(ctx, pageID, revision)
+}
+
+// Diff returns a unified diff of a page's content between two revisions.
+// Revisions are immutable, so each pair is diffed once and then cached.
+func (s *SanctumWikiService) Diff(ctx context.Context, viewerID, pageID uint, from, to int) (*WikiDiff, error) {
+	if _, err := s.pageByID(ctx, viewerID, pageID); err != nil {
+		return nil, err
+	}
+	var out WikiDiff
+	err := cache.Aside(ctx, cache.WikiDiffKey(pageID, from, to), &out, cache.WikiDiffTTL, func() error {
+		a, err := s.loadRevision(ctx, pageID, from)
+		if err != nil {
+			return err
+		}
+		b, err := s.loadRevision(ctx, pageID, to)
+		if err != nil {
+			return err
+		}
+		linesA, linesB := difflib.SplitLines(a.Content), difflib.SplitLines(b.Content)
+		if len(linesA) > maxWikiDiffLines || len(linesB) > maxWikiDiffLines {
+			return models.NewValidationError(fmt.Sprintf("Revisions over %d lines are too long to diff", maxWikiDiffLines))
+		}
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        linesA,
+			B:        linesB,
+			FromFile: fmt.Sprintf("revision %d", from),
+			ToFile:   fmt.Sprintf("revision %d", to),
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		out = WikiDiff{From: from, To: to, Diff: diff}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Revert restores an earlier revision by saving it as a new one, so the
+// history keeps every version.
+func (s *SanctumWikiService) Revert(ctx context.Context, actorID, pageID uint, revision int, reason string) (*models.SanctumWikiPage, error) {
+	page, err := s.pageByID(ctx, actorID, pageID)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.canEdit(ctx, actorID, page)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, models.NewForbiddenError("You cannot edit this page")
+	}
+	target, err := s.loadRevision(ctx, pageID, revision)
+	if err != nil {
+		return nil, err
+	}
+	if target.Revision == page.Revision {
+		return nil, models.NewValidationError("That is already the current revision")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		reason = fmt.Sprintf("Revert to revision %d", revision)
+	}
+	if utf8.RuneCountInString(reason) > 300 {
+		return nil, models.NewValidationError("Edit reason too long (max 300 characters)")
+	}
+	if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return appendRevision(tx, page, actorID, target.Title, target.Content, reason, &target.Revision)
+	}); err != nil {
+		return nil, err
+	}
+	RenderWikiPageHTML(page)
+	return page, nil
+}
+
+// ListContributors returns a sanctum's approved wiki contributors.
+// Moderators only.
+func (s *SanctumWikiService) ListContributors(ctx context.Context, actorID, sanctumID uint) ([]models.SanctumWikiContributor, error) {
+	if err := s.requireMod(ctx, actorID, sanctumID); err != nil {
+		return nil, err
+	}
+	contributors := []models.SanctumWikiContributor{}
+	if err := s.db.WithContext(ctx).
+		Preload("User").
+		Where("sanctum_id = ?", sanctumID).
+		Order("created_at ASC").
+		Find(&contributors).Error; err != nil {
+		return nil, err
+	}
+	return contributors, nil
+}
+
+// AddContributor approves a user to edit contributor-editable pages.
+func (s *SanctumWikiService) AddContributor(ctx context.Context, actorID, sanctumID, userID uint) error {
+	if err := s.requireMod(ctx, actorID, sanctumID); err != nil {
+		return err
+	}
+	var user models.User
+	if err := s.db.WithContext(ctx).Select("id").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.NewNotFoundError("User", userID)
+		}
+		return err
+	}
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.SanctumWikiContributor{
+		SanctumID:     sanctumID,
+		UserID:        userID,
+		AddedByUserID: actorID,
+	}).Error
+}
+
+// RemoveContributor revokes a user's contributor approval.
+func (s *SanctumWikiService) RemoveContributor(ctx context.Context, actorID, sanctumID, userID uint) error {
+	if err := s.requireMod(ctx, actorID, sanctumID); err != nil {
+		return err
+	}
+	res := s.db.WithContext(ctx).
+		Where("sanctum_id = ? AND user_id = ?", sanctumID, userID).
+		Delete(&models.SanctumWikiContributor{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return models.NewNotFoundError("Wiki contributor", userID)
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"sanctum/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeWikiSlug(t *testing.T) {
+	slug, err := NormalizeWikiSlug(" /Guides/Getting-Started/ ")
+	require.NoError(t, err)
+	assert.Equal(t, "guides/getting-started", slug)
+
+	for _, bad := range []string{"", "/", "a//b", "a/../b", "has space", "a/b/c/d/e/f", "-lead"} {
+		_, err := NormalizeWikiSlug(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+// wikiFixture is a sanctum with a moderator-created page, an approved
+// contributor and a muted member.
+type wikiFixture struct {
+	*sanctumFixture
+	wiki   *SanctumWikiService
+	page   *models.SanctumWikiPage
+	writer *models.User
+	muted  *models.User
+}
+
+func newWikiFixture(t *testing.T) *wikiFixture {
+	t.Helper()
+	sf := newSanctumFixture(t,
+		&models.SanctumWikiPage{},
+		&models.SanctumWikiRevision{},
+		&models.SanctumWikiContributor{},
+		&models.SanctumBan{},
+	)
+	f := &wikiFixture{
+		sanctumFixture: sf,
+		wiki:           NewSanctumWikiService(sf.db, sf.canManage, nil),
+		writer:         createTestUser(t, sf.db, "writer"),
+		muted:          createTestUser(t, sf.db, "muted"),
+	}
+	for _, u := range []*models.User{f.writer, f.muted} {
+		require.NoError(t, f.db.Create(&models.SanctumMembership{SanctumID: f.sanctum.ID, UserID: u.ID, Role: models.SanctumMembershipRoleMember}).Error)
+	}
+	mutedUntil := time.Now().Add(time.Hour)
+	require.NoError(t, f.db.Create(&models.SanctumBan{
+		SanctumID: f.sanctum.ID, UserID: f.muted.ID, CreatedByUserID: f.mod.ID,
+		Kind: models.SanctumBanKindMute, ExpiresAt: &mutedUntil,
+	}).Error)
+	require.NoError(t, f.wiki.AddContributor(context.Background(), f.mod.ID, f.sanctum.ID, f.writer.ID))
+
+	page, err := f.save(f.mod.ID, "line one\nline two\n", 0)
+	require.NoError(t, err)
+	f.page = page
+	return f
+}
+
+func (f *wikiFixture) save(actorID uint, content string, base int) (*models.SanctumWikiPage, error) {
+	return f.wiki.SavePage(context.Background(), SaveWikiPageInput{
+		ActorID: actorID, SanctumID: f.sanctum.ID, Slug: "faq/posting",
+		Title: "Posting FAQ", Content: content, BaseRevision: base,
+	})
+}
+
+func TestSanctumWikiService_CreatePage(t *testing.T) {
+	f := newWikiFixture(t)
+	assert.Equal(t, 1, f.page.Revision)
+	assert.Equal(t, models.WikiEditMods, f.page.EditPermission)
+
+	_, err := f.wiki.SavePage(context.Background(), SaveWikiPageInput{
+		ActorID: f.member.ID, SanctumID: f.sanctum.ID, Slug: "new", Title: "New", Content: "x",
+	})
+	requireCode(t, err, "FORBIDDEN")
+}
+
+func TestSanctumWikiService_EditPermissions(t *testing.T) {
+	tests := []struct {
+		permission models.WikiEditPermission
+		allowed    map[string]bool
+	}{
+		{models.WikiEditMods, map[string]bool{"mod": true, "writer": false, "member": false, "muted": false}},
+		{models.WikiEditContributors, map[string]bool{"mod": true, "writer": true, "member": false, "muted": false}},
+		{models.WikiEditMembers, map[string]bool{"mod": true, "writer": true, "member": true, "muted": false, "outsider": false}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.permission), func(t *testing.T) {
+			f := newWikiFixture(t)
+			_, err := f.wiki.SetPermission(context.Background(), f.mod.ID, f.page.ID, tt.permission)
+			require.NoError(t, err)
+			users := map[string]uint{
+				"mod": f.mod.ID, "writer": f.writer.ID, "member": f.member.ID,
+				"muted": f.muted.ID, "outsider": f.outsider.ID,
+			}
+			for name, allowed := range tt.allowed {
+				_, err := f.save(users[name], "edit by "+name, 0)
+				if allowed {
+					assert.NoError(t, err, name)
+				} else {
+					requireCode(t, err, "FORBIDDEN")
+				}
+			}
+		})
+	}
+}
+
+func TestSanctumWikiService_ModeratorOnlyActions(t *testing.T) {
+	f := newWikiFixture(t)
+	ctx := context.Background()
+
+	_, err := f.wiki.SetPermission(ctx, f.member.ID, f.page.ID, models.WikiEditMembers)
+	requireCode(t, err, "FORBIDDEN")
+	requireCode(t, f.wiki.AddContributor(ctx, f.member.ID, f.sanctum.ID, f.member.ID), "FORBIDDEN")
+	requireCode(t, f.wiki.DeletePage(ctx, f.member.ID, f.sanctum.ID, "faq/posting"), "FORBIDDEN")
+
+	require.NoError(t, f.wiki.RemoveContributor(ctx, f.mod.ID, f.sanctum.ID, f.writer.ID))
+	contributors, err := f.wiki.ListContributors(ctx, f.mod.ID, f.sanctum.ID)
+	require.NoError(t, err)
+	assert.Empty(t, contributors)
+	require.NoError(t, f.wiki.DeletePage(ctx, f.mod.ID, f.sanctum.ID, "faq/posting"))
+	_, err = f.wiki.GetPage(ctx, 0, f.sanctum.ID, "faq/posting")
+	requireCode(t, err, "NOT_FOUND")
+}
+
+func TestSanctumWikiService_StaleBaseRevision(t *testing.T) {
+	f := newWikiFixture(t)
+	page, err := f.save(f.mod.ID, "line one\nline 2\n", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, page.Revision)
+
+	_, err = f.save(f.mod.ID, "stale", 1)
+	requireCode(t, err, "VALIDATION_ERROR")
+
+	// Saving unchanged content does not add a revision.
+	page, err = f.save(f.mod.ID, "line one\nline 2\n", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, page.Revision)
+}
+
+func TestSanctumWikiService_HistoryDiffAndRevert(t *testing.T) {
+	f := newWikiFixture(t)
+	ctx := context.Background()
+	_, err := f.save(f.mod.ID, "line one\nline 2\n", 1)
+	require.NoError(t, err)
+	page, err := f.save(f.mod.ID, "line one\nline 2\nline three\n", 2)
+	require.NoError(t, err)
+
+	diff, err := f.wiki.Diff(ctx, 0, page.ID, 1, 3)
+	require.NoError(t, err)
+	assert.Contains(t, diff.Diff, "-line two")
+	assert.Contains(t, diff.Diff, "+line three")
+
+	reverted, err := f.wiki.Revert(ctx, f.mod.ID, page.ID, 1, "")
+	require.NoError(t, err)
+	assert.Equal(t, 4, reverted.Revision)
+	assert.Equal(t, "line one\nline two\n", reverted.Content)
+	current, err := f.wiki.GetPage(ctx, 0, f.sanctum.ID, "FAQ/Posting")
+	require.NoError(t, err)
+	assert.Equal(t, "line one\nline two\n", current.Content)
+	assert.Contains(t, current.ContentHTML, "line two")
+
+	revisions, err := f.wiki.ListRevisions(ctx, 0, page.ID, 50, 0)
+	require.NoError(t, err)
+	require.Len(t, revisions, 4)
+	assert.Equal(t, 4, revisions[0].Revision)
+	require.NotNil(t, revisions[0].RevertedFrom)
+	assert.Equal(t, 1, *revisions[0].RevertedFrom)
+	assert.Equal(t, "Revert to revision 1", revisions[0].Reason)
+
+	pages, err := f.wiki.ListPages(ctx, 0, f.sanctum.ID)
+	require.NoError(t, err)
+	require.Len(t, pages, 1)
+	assert.Equal(t, "faq/posting", pages[0].Slug)
+}
+
+func TestSanctumWikiService_DiffSizeCap(t *testing.T) {
+	f := newWikiFixture(t)
+	page, err := f.save(f.mod.ID, strings.Repeat("line\n", maxWikiDiffLines+1), 0)
+	require.NoError(t, err)
+
+	_, err = f.wiki.Diff(context.Background(), 0, page.ID, 1, page.Revision)
+	requireCode(t, err, "VALIDATION_ERROR")
+}
@@ -62,6 +62,21 @@ func (s *UserService) UpdateProfile(ctx context.Context, in UpdateProfileInput)
 	return user, nil
 }
 
+// SetReadReceipts turns sending read receipts on or off for the user.
+func (s *UserService) SetReadReceipts(ctx context.Context, userID uint, enabled bool) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.HideReadReceipts = !enabled
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
 func (s *UserService) SetAdmin(ctx context.Context, targetID uint, isAdmin bool) (*models.User, error) {
 	user, err := s.userRepo.GetByID(ctx, targetID)
 	if err != nil {
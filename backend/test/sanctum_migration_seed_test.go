@@ -146,20 +146,26 @@ func TestMigrationsApplyFreshDB(t *testing.T) {
 		t.Error("expected foreign key constraint 'fk_conversations_sanctum' to exist")
 	}
 
-	// Verify Unique Index: idx_conversations_sanctum_id_unique
+	// Sanctums may own several channels, but only one default channel.
 	var indexExists bool
 	indexQuery := `
 		SELECT EXISTS (
 			SELECT 1 
 			FROM pg_indexes 
-			WHERE indexname = 'idx_conversations_sanctum_id_unique' 
+			WHERE indexname = ? 
 			AND tablename = 'conversations'
 		)`
-	if err := db.Raw(indexQuery).Scan(&indexExists).Error; err != nil {
-		t.Fatalf("check unique index idx_conversations_sanctum_id_unique: %v", err)
+	if err := db.Raw(indexQuery, "uq_conversations_sanctum_default").Scan(&indexExists).Error; err != nil {
+		t.Fatalf("check unique index uq_conversations_sanctum_default: %v", err)
 	}
 	if !indexExists {
-		t.Error("expected unique index 'idx_conversations_sanctum_id_unique' to exist")
+		t.Error("expected unique index 'uq_conversations_sanctum_default' to exist")
+	}
+	if err := db.Raw(indexQuery, "idx_conversations_sanctum_id_unique").Scan(&indexExists).Error; err != nil {
+		t.Fatalf("check legacy index idx_conversations_sanctum_id_unique: %v", err)
+	}
+	if indexExists {
+		t.Error("legacy unique index 'idx_conversations_sanctum_id_unique' should be dropped")
 	}
 
 	var legacyTableExists bool